@@ -296,6 +296,12 @@ refresh-goldens:
 
 update-golden: refresh-goldens
 
+# Runs the Gateway API conformance scenarios through the translation offline and writes the report of which features
+# pass to pilot/pkg/config/kube/gateway/testdata/conformance-report.yaml.
+gateway-conformance-report:
+	@REFRESH_GOLDEN=true go test ${GOBUILDFLAGS} ./pilot/pkg/config/kube/gateway -run TestConformanceReport -count=1
+	@echo "Wrote pilot/pkg/config/kube/gateway/testdata/conformance-report.yaml"
+
 # Keep dummy target since some build pipelines depend on this
 gen-charts:
 	@echo "This target is no longer required and will be removed in the future"
@@ -105,6 +105,7 @@ var rootCmd = &cobra.Command{
 					DNSCapture:        cfg.InstallConfig.AmbientDNSCapture,
 					EnableIPv6:        cfg.InstallConfig.AmbientIPv6,
 					TPROXYRedirection: cfg.InstallConfig.AmbientTPROXYRedirection,
+					NativeNftables:    cfg.InstallConfig.NativeNftables,
 				})
 			if err != nil {
 				return fmt.Errorf("failed to create ambient nodeagent service: %v", err)
@@ -268,6 +269,7 @@ func constructConfig() (*config.Config, error) {
 		AmbientDNSCapture:        viper.GetBool(constants.AmbientDNSCapture),
 		AmbientIPv6:              viper.GetBool(constants.AmbientIPv6),
 		AmbientTPROXYRedirection: viper.GetBool(constants.AmbientTPROXYRedirection),
+		NativeNftables:           viper.GetBool(constants.NativeNftables),
 	}
 
 	if len(installCfg.K8sNodeName) == 0 {
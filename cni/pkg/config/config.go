@@ -82,6 +82,10 @@ type InstallConfig struct {
 
 	// Feature flag to determined whether TPROXY is used for redirection.
 	AmbientTPROXYRedirection bool
+
+	// Feature flag to determine whether pod traffic redirection, both sidecar and ambient, and the ambient host rules
+	// are programmed with native nftables instead of iptables.
+	NativeNftables bool
 }
 
 // RepairConfig struct defines the Istio CNI race repair configuration
@@ -145,6 +149,7 @@ func (c InstallConfig) String() string {
 	b.WriteString("AmbientDNSCapture: " + fmt.Sprint(c.AmbientDNSCapture) + "\n")
 	b.WriteString("AmbientIPv6: " + fmt.Sprint(c.AmbientIPv6) + "\n")
 	b.WriteString("AmbientRedirectTPROXY: " + fmt.Sprint(c.AmbientTPROXYRedirection) + "\n")
+	b.WriteString("NativeNftables: " + fmt.Sprint(c.NativeNftables) + "\n")
 
 	return b.String()
 }
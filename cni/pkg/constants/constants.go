@@ -36,6 +36,7 @@ const (
 	AmbientDNSCapture        = "ambient-dns-capture"
 	AmbientIPv6              = "ambient-ipv6"
 	AmbientTPROXYRedirection = "ambient-tproxy-redirection"
+	NativeNftables           = "native-nftables"
 
 	// Repair
 	RepairEnabled            = "repair-enabled"
@@ -37,6 +37,7 @@ func createCNIConfigFile(ctx context.Context, cfg *config.InstallConfig) (string
 		CNIAgentRunDir:    cfg.CNIAgentRunDir,
 		AmbientEnabled:    cfg.AmbientEnabled,
 		ExcludeNamespaces: strings.Split(cfg.ExcludeNamespaces, ","),
+		NativeNftables:    cfg.NativeNftables,
 	}
 
 	pluginConfig.Name = "istio-cni"
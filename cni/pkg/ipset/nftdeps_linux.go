@@ -0,0 +1,155 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ipset
+
+import (
+	"errors"
+	"fmt"
+	"net/netip"
+
+	"github.com/google/nftables"
+
+	"istio.io/istio/pkg/util/sets"
+	iptablesconstants "istio.io/istio/tools/istio-iptables/pkg/constants"
+	istionft "istio.io/istio/tools/istio-iptables/pkg/nftables"
+)
+
+// RealNftablesDeps manages the sets as nftables sets of the Istio nat table instead of ipsets, so that rules
+// programmed natively with nftables can match on them. The sets are recreated empty whenever the host rules are
+// applied, so they must be populated after.
+func RealNftablesDeps() NetlinkIpsetDeps {
+	return &nftDeps{v6Sets: sets.New[string]()}
+}
+
+type nftDeps struct {
+	// v6Sets are the names of the sets of IPv6 addresses, which live in the ip6 table.
+	v6Sets sets.String
+}
+
+func (m *nftDeps) set(name string) *nftables.Set {
+	family := istionft.IPv4
+	tableFamily := nftables.TableFamilyIPv4
+	if m.v6Sets.Contains(name) {
+		family = istionft.IPv6
+		tableFamily = nftables.TableFamilyIPv6
+	}
+	return &nftables.Set{
+		Table:   &nftables.Table{Family: tableFamily, Name: istionft.TablePrefix + iptablesconstants.NAT},
+		Name:    name,
+		KeyType: istionft.SetKeyType(family),
+	}
+}
+
+// apply runs the operations added by f to a connection in a single transaction.
+func apply(f func(conn *nftables.Conn) error) error {
+	conn, err := nftables.New()
+	if err != nil {
+		return fmt.Errorf("failed to open netlink connection: %w", err)
+	}
+	if err := f(conn); err != nil {
+		return err
+	}
+	return conn.Flush()
+}
+
+func (m *nftDeps) ipsetIPHashCreate(name string, v6 bool) error {
+	if v6 {
+		m.v6Sets.Insert(name)
+	}
+	set := m.set(name)
+	err := apply(func(conn *nftables.Conn) error {
+		// Adding a table or set which already exists is not an error.
+		conn.AddTable(set.Table)
+		return conn.AddSet(set, nil)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create nftables set %s: %w", name, err)
+	}
+	return nil
+}
+
+func (m *nftDeps) destroySet(name string) error {
+	return apply(func(conn *nftables.Conn) error {
+		conn.DelSet(m.set(name))
+		return nil
+	})
+}
+
+// addIP adds the IP to the set. nftables set elements have no comment, nor protocol, so these are ignored.
+func (m *nftDeps) addIP(name string, ip netip.Addr, ipProto uint8, comment string, replace bool) error {
+	err := apply(func(conn *nftables.Conn) error {
+		return conn.SetAddElements(m.set(name), []nftables.SetElement{{Key: ip.AsSlice()}})
+	})
+	if err != nil {
+		return fmt.Errorf("failed to add IP %s to nftables set %s: %w", ip, name, err)
+	}
+	return nil
+}
+
+func (m *nftDeps) deleteIP(name string, ip netip.Addr, ipProto uint8) error {
+	err := apply(func(conn *nftables.Conn) error {
+		return conn.SetDeleteElements(m.set(name), []nftables.SetElement{{Key: ip.AsSlice()}})
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete IP %s from nftables set %s: %w", ip, name, err)
+	}
+	return nil
+}
+
+func (m *nftDeps) flush(name string) error {
+	err := apply(func(conn *nftables.Conn) error {
+		conn.FlushSet(m.set(name))
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to flush nftables set %s: %w", name, err)
+	}
+	return nil
+}
+
+func (m *nftDeps) clearEntriesWithComment(name, comment string) error {
+	return errors.New("nftables set elements have no comment")
+}
+
+func (m *nftDeps) clearEntriesWithIP(name string, ip netip.Addr) error {
+	entries, err := m.listEntriesByIP(name)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if entry == ip {
+			return m.deleteIP(name, ip, 0)
+		}
+	}
+	return nil
+}
+
+func (m *nftDeps) listEntriesByIP(name string) ([]netip.Addr, error) {
+	var ipList []netip.Addr
+
+	conn, err := nftables.New()
+	if err != nil {
+		return ipList, fmt.Errorf("failed to open netlink connection: %w", err)
+	}
+	elements, err := conn.GetSetElements(m.set(name))
+	if err != nil {
+		return ipList, fmt.Errorf("failed to list nftables set %s: %w", name, err)
+	}
+	for _, element := range elements {
+		addr, _ := netip.AddrFromSlice(element.Key)
+		ipList = append(ipList, addr)
+	}
+	return ipList, nil
+}
@@ -60,6 +60,9 @@ type Config struct {
 	// If true, TPROXY will be used for redirection. Else, REDIRECT will be used.
 	// Currently, this is treated as a feature flag, but may be promoted to a permanent feature if there is a need.
 	TPROXYRedirection bool `json:"TPROXY_REDIRECTION"`
+	// If true, the in-pod and host rules are programmed as native nftables tables over netlink, instead of with
+	// iptables. The host rules then match on nftables sets of probe IPs rather than ipsets.
+	NativeNftables bool `json:"NATIVE_NFTABLES"`
 }
 
 type IptablesConfigurator struct {
@@ -72,10 +75,11 @@ type IptablesConfigurator struct {
 
 func ipbuildConfig(c *Config) *iptablesconfig.Config {
 	return &iptablesconfig.Config{
-		RestoreFormat: c.RestoreFormat,
-		TraceLogging:  c.TraceLogging,
-		EnableIPv6:    c.EnableIPv6,
-		RedirectDNS:   c.RedirectDNS,
+		RestoreFormat:  c.RestoreFormat,
+		TraceLogging:   c.TraceLogging,
+		EnableIPv6:     c.EnableIPv6,
+		RedirectDNS:    c.RedirectDNS,
+		NativeNftables: c.NativeNftables,
 	}
 }
 
@@ -97,6 +101,13 @@ func NewIptablesConfigurator(
 		cfg:    cfg,
 	}
 
+	if cfg.NativeNftables {
+		// No iptables binary is used at all, so there is nothing to detect.
+		inPodConfigurator := ptr.Of(*configurator)
+		inPodConfigurator.ext = podDeps
+		return configurator, inPodConfigurator, nil
+	}
+
 	// By detecting iptables versions *here* once-for-all we are
 	// committing to using the same binary/variant (legacy or nft)
 	// within all pods as we do on the host.
@@ -145,6 +156,15 @@ func (cfg *IptablesConfigurator) DeleteInpodRules() error {
 }
 
 func (cfg *IptablesConfigurator) executeDeleteCommands() error {
+	if cfg.cfg.NativeNftables {
+		// Only the families of the ruleset matter here: deleting it removes all of our tables.
+		rs, err := builder.NewIptablesRuleBuilder(ipbuildConfig(cfg.cfg)).BuildNftables()
+		if err != nil {
+			return err
+		}
+		return cfg.ext.DeleteNftables(rs)
+	}
+
 	deleteCmds := [][]string{
 		{"-t", iptablesconstants.MANGLE, "-D", iptablesconstants.PREROUTING, "-j", ChainInpodPrerouting},
 		{"-t", iptablesconstants.MANGLE, "-D", iptablesconstants.OUTPUT, "-j", ChainInpodOutput},
@@ -209,6 +229,19 @@ func (cfg *IptablesConfigurator) CreateInpodRules(log *istiolog.Scope, hostProbe
 		return err
 	}
 
+	if cfg.cfg.NativeNftables {
+		log.Debug("Adding nftables rules")
+		rs, err := builder.BuildNftables()
+		if err != nil {
+			return err
+		}
+		if err := cfg.ext.ApplyNftables(rs); err != nil {
+			log.Errorf("failed to apply nftables rules: %v", err)
+			return err
+		}
+		return nil
+	}
+
 	log.Debug("Adding iptables rules")
 	if err := cfg.executeCommands(log, builder); err != nil {
 		log.Errorf("failed to restore iptables rules: %v", err)
@@ -544,6 +577,19 @@ func (cfg *IptablesConfigurator) CreateHostRulesForHealthChecks(hostSNATIP, host
 	// Append our rules here
 	builder := cfg.appendHostRules(hostSNATIP, hostSNATIPV6)
 
+	if cfg.cfg.NativeNftables {
+		log.Info("Adding host netnamespace nftables rules")
+		rs, err := builder.BuildNftables()
+		if err != nil {
+			return err
+		}
+		if err := cfg.ext.ApplyNftables(rs); err != nil {
+			log.Errorf("failed to add host netnamespace nftables rules: %v", err)
+			return err
+		}
+		return nil
+	}
+
 	log.Info("Adding host netnamespace iptables rules")
 
 	if err := cfg.executeCommands(log.WithLabels("component", "host"), builder); err != nil {
@@ -560,6 +606,18 @@ func (cfg *IptablesConfigurator) DeleteHostRules() {
 }
 
 func (cfg *IptablesConfigurator) executeHostDeleteCommands() {
+	if cfg.cfg.NativeNftables {
+		rs, err := builder.NewIptablesRuleBuilder(ipbuildConfig(cfg.cfg)).BuildNftables()
+		if err == nil {
+			err = cfg.ext.DeleteNftables(rs)
+		}
+		if err != nil {
+			// Ignore errors, as it is expected to fail in cases where the node is already cleaned up.
+			log.Debugf("ignoring error deleting host nftables rules: %v", err)
+		}
+		return
+	}
+
 	optionalDeleteCmds := [][]string{
 		// delete our main jump in the host ruleset. If it's not there, NBD.
 		{"-t", iptablesconstants.NAT, "-D", iptablesconstants.POSTROUTING, "-j", ChainHostPostrouting},
@@ -15,6 +15,7 @@
 package iptables
 
 import (
+	"errors"
 	"net/netip"
 	"path/filepath"
 	"strings"
@@ -66,6 +67,48 @@ func TestIptables(t *testing.T) {
 	}
 }
 
+func TestNftables(t *testing.T) {
+	cases := []struct {
+		name   string
+		config func(cfg *Config)
+	}{
+		{
+			"default",
+			func(cfg *Config) {
+				cfg.RedirectDNS = true
+			},
+		},
+		{
+			"tproxy",
+			func(cfg *Config) {
+				cfg.TPROXYRedirection = true
+				cfg.RedirectDNS = true
+			},
+		},
+	}
+	probeSNATipv4 := netip.MustParseAddr("169.254.7.127")
+	probeSNATipv6 := netip.MustParseAddr("e9ac:1e77:90ca:399f:4d6d:ece2:2f9b:3164")
+
+	for _, tt := range cases {
+		for _, ipv6 := range []bool{false, true} {
+			t.Run(tt.name+"_"+ipstr(ipv6), func(t *testing.T) {
+				cfg := constructTestConfig()
+				cfg.EnableIPv6 = ipv6
+				cfg.NativeNftables = true
+				tt.config(cfg)
+				ext := &dep.DependenciesStub{}
+				iptConfigurator, _, _ := NewIptablesConfigurator(cfg, ext, ext, EmptyNlDeps())
+				err := iptConfigurator.CreateInpodRules(scopes.CNIAgent, &probeSNATipv4, &probeSNATipv6)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				compareToGolden(t, ipv6, filepath.Join("nft", tt.name), ext.ExecutedAll)
+			})
+		}
+	}
+}
+
 func TestIptablesHostRules(t *testing.T) {
 	cases := []struct {
 		name   string
@@ -99,6 +142,39 @@ func TestIptablesHostRules(t *testing.T) {
 	}
 }
 
+// noIptablesDeps fails to detect iptables, as on a host without any iptables binary.
+type noIptablesDeps struct {
+	*dep.DependenciesStub
+}
+
+func (noIptablesDeps) DetectIptablesVersion(bool) (dep.IptablesVersion, error) {
+	return dep.IptablesVersion{}, errors.New("iptables not found")
+}
+
+func TestNftablesHostRules(t *testing.T) {
+	probeSNATipv4 := netip.MustParseAddr("169.254.7.127")
+	probeSNATipv6 := netip.MustParseAddr("fd16:9254:7127:1337:ffff:ffff:ffff:ffff")
+
+	for _, ipv6 := range []bool{false, true} {
+		t.Run("hostprobe_"+ipstr(ipv6), func(t *testing.T) {
+			cfg := constructTestConfig()
+			cfg.EnableIPv6 = ipv6
+			cfg.NativeNftables = true
+			ext := &dep.DependenciesStub{}
+			iptConfigurator, _, err := NewIptablesConfigurator(cfg, noIptablesDeps{ext}, ext, EmptyNlDeps())
+			if err != nil {
+				t.Fatal(err)
+			}
+			iptConfigurator.DeleteHostRules()
+			if err := iptConfigurator.CreateHostRulesForHealthChecks(&probeSNATipv4, &probeSNATipv6); err != nil {
+				t.Fatal(err)
+			}
+
+			compareToGolden(t, ipv6, filepath.Join("nft", "hostprobe"), ext.ExecutedAll)
+		})
+	}
+}
+
 func TestInvokedTwiceIsIdempotent(t *testing.T) {
 	tt := struct {
 		name   string
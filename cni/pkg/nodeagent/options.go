@@ -51,4 +51,5 @@ type AmbientArgs struct {
 	DNSCapture        bool
 	EnableIPv6        bool
 	TPROXYRedirection bool
+	NativeNftables    bool
 }
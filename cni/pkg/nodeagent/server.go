@@ -74,10 +74,11 @@ func NewServer(ctx context.Context, ready *atomic.Value, pluginSocket string, ar
 		RedirectDNS:       args.DNSCapture,
 		EnableIPv6:        args.EnableIPv6,
 		TPROXYRedirection: args.TPROXYRedirection,
+		NativeNftables:    args.NativeNftables,
 	}
 
 	log.Debug("creating ipsets in the node netns")
-	set, err := createHostsideProbeIpset(cfg.EnableIPv6)
+	set, err := createHostsideProbeIpset(cfg.EnableIPv6, cfg.NativeNftables)
 	if err != nil {
 		return nil, fmt.Errorf("error initializing hostside probe ipset: %w", err)
 	}
@@ -157,10 +158,14 @@ func buildKubeClient(kubeConfig string) (kube.Client, error) {
 
 // createHostsideProbeIpset creates an ipset. This is designed to be called from the host netns.
 // Note that if the ipset already exist by name, Create will not return an error.
+// With native nftables, the set is an nftables set matched by the host rules instead.
 //
 // We will unconditionally flush our set before use here, so it shouldn't matter.
-func createHostsideProbeIpset(isV6 bool) (ipset.IPSet, error) {
+func createHostsideProbeIpset(isV6, nativeNftables bool) (ipset.IPSet, error) {
 	linDeps := ipset.RealNlDeps()
+	if nativeNftables {
+		linDeps = ipset.RealNftablesDeps()
+	}
 	probeSet, err := ipset.NewIPSet(iptables.ProbeIPSet, isV6, linDeps)
 	if err != nil {
 		return probeSet, err
@@ -66,6 +66,7 @@ type Config struct {
 	CNIAgentRunDir    string   `json:"cni_agent_run_dir"`
 	AmbientEnabled    bool     `json:"ambient_enabled"`
 	ExcludeNamespaces []string `json:"exclude_namespaces"`
+	NativeNftables    bool     `json:"native_nftables,omitempty"`
 }
 
 // K8sArgs is the valid CNI_ARGS used for Kubernetes
@@ -296,6 +297,7 @@ func doAddRun(args *skel.CmdArgs, conf *Config, kClient kubernetes.Interface, ru
 		log.Errorf("redirect failed due to bad params: %v", err)
 		return err
 	}
+	redirect.nativeNftables = conf.NativeNftables
 
 	if err := rulesMgr.Program(podName, args.Netns, redirect); err != nil {
 		return err
@@ -53,6 +53,7 @@ func (ipt *iptables) Program(podName, netns string, rdrct *Redirect) error {
 	cfg.CaptureAllDNS = rdrct.dnsRedirect
 	cfg.DropInvalid = rdrct.invalidDrop
 	cfg.DualStack = rdrct.dualStack
+	cfg.NativeNftables = rdrct.nativeNftables
 
 	netNs, err := getNs(netns)
 	if err != nil {
@@ -90,6 +90,7 @@ type Redirect struct {
 	dnsRedirect          bool
 	dualStack            bool
 	invalidDrop          bool
+	nativeNftables       bool
 }
 
 type annotationValidationFunc func(value string) error
@@ -16,19 +16,29 @@ package authz
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"io"
+	"net/http"
 	"os"
+	"strings"
 
+	listener "github.com/envoyproxy/go-control-plane/envoy/config/listener/v3"
 	"github.com/spf13/cobra"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 
 	"istio.io/istio/istioctl/pkg/cli"
 	"istio.io/istio/istioctl/pkg/completion"
 	"istio.io/istio/istioctl/pkg/util"
 	"istio.io/istio/istioctl/pkg/util/configdump"
+	"istio.io/istio/pilot/pkg/config/kube/crd"
+	v3 "istio.io/istio/pilot/pkg/xds/v3"
+	"istio.io/istio/pkg/config"
+	"istio.io/istio/pkg/config/constants"
 	"istio.io/istio/pkg/kube"
 	"istio.io/istio/pkg/log"
+	"istio.io/istio/pkg/spiffe"
 )
 
 var configDumpFile string
@@ -96,6 +106,217 @@ The command also supports reading from a standalone config dump file with flag -
 	return cmd
 }
 
+type evalOptions struct {
+	configDumpFile string
+	policyFiles    []string
+	labels         string
+
+	sourcePrincipal string
+	sourceNamespace string
+	sourceIP        string
+	destinationIP   string
+	port            uint32
+	sni             string
+	tcp             bool
+	method          string
+	path            string
+	host            string
+	headers         []string
+	claims          string
+}
+
+func evalCmd(ctx cli.Context) *cobra.Command {
+	o := &evalOptions{}
+	cmd := &cobra.Command{
+		Use:   "eval [<type>/]<name>[.<namespace>]",
+		Short: "Evaluate a request against the AuthorizationPolicy applied in the pod.",
+		Long: `Eval runs a described request against the authorization (RBAC) configuration of a pod and reports
+whether it would be allowed, denied, or sent to an external authorizer by a CUSTOM policy, together with the
+policy and rule that decided it.
+
+The configuration is read from the Envoy configuration of the pod, from a config dump file with -f, or
+generated offline from AuthorizationPolicy files with --policies for a workload with the given labels.`,
+		Example: `  # Check if a GET request from the frontend service account would be allowed by pod httpbin-88ddbcfdd-nt5jb:
+  istioctl x authz eval httpbin-88ddbcfdd-nt5jb --port 8000 --method GET --path /headers \
+    --source-principal cluster.local/ns/default/sa/frontend
+
+  # Evaluate a request with JWT claims against a config dump file:
+  istioctl x authz eval -f httpbin_config_dump.json --port 8000 --path /admin \
+    --claims '{"iss":"https://example.com","sub":"alice","groups":["admin"]}'
+
+  # Evaluate a TCP connection against local policy files before applying them:
+  istioctl x authz eval --policies policies.yaml --labels app=mysql -n db --port 3306 --tcp --source-namespace web`,
+		Args: func(cmd *cobra.Command, args []string) error {
+			sources := len(args)
+			if o.configDumpFile != "" {
+				sources++
+			}
+			if len(o.policyFiles) > 0 {
+				sources++
+			}
+			if sources != 1 {
+				cmd.Println(cmd.UsageString())
+				return fmt.Errorf("expecting exactly one of <pod-name>[.<pod-namespace>], --file or --policies")
+			}
+			if o.port == 0 {
+				return fmt.Errorf("--port is required")
+			}
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			req, err := o.request()
+			if err != nil {
+				return err
+			}
+			forTCP := o.tcp
+			var fc *filterChain
+			if len(o.policyFiles) > 0 {
+				fc, err = o.filterChainFromPolicyFiles(ctx.NamespaceOrDefault(ctx.Namespace()))
+				if err != nil {
+					return err
+				}
+			} else {
+				var configDump *configdump.Wrapper
+				if o.configDumpFile != "" {
+					configDump, err = getConfigDumpFromFile(o.configDumpFile)
+					if err != nil {
+						return fmt.Errorf("failed to get config dump from file %s: %s", o.configDumpFile, err)
+					}
+				} else {
+					kubeClient, err := ctx.CLIClient()
+					if err != nil {
+						return fmt.Errorf("failed to create k8s client: %w", err)
+					}
+					podName, podNamespace, err := ctx.InferPodInfoFromTypedResource(args[0], ctx.Namespace())
+					if err != nil {
+						return err
+					}
+					configDump, err = getConfigDumpFromPod(kubeClient, podName, podNamespace)
+					if err != nil {
+						return fmt.Errorf("failed to get config dump from pod %s in %s", podName, podNamespace)
+					}
+				}
+				listeners, err := getListeners(configDump)
+				if err != nil {
+					return err
+				}
+				fc, forTCP, err = inboundFilterChain(listeners, o.port, o.tcp)
+				if err != nil {
+					return err
+				}
+				if forTCP != o.tcp {
+					if forTCP {
+						cmd.PrintErrf("port %d is not configured for HTTP, evaluating as a TCP connection\n", o.port)
+					} else {
+						cmd.PrintErrf("port %d is configured for HTTP, evaluating as an HTTP request\n", o.port)
+					}
+				}
+			}
+			res, err := fc.Evaluate(req, forTCP)
+			if err != nil {
+				return err
+			}
+			PrintEvaluation(cmd.OutOrStdout(), res)
+			return nil
+		},
+		ValidArgsFunction: completion.ValidPodsNameArgs(ctx),
+	}
+	flags := cmd.Flags()
+	flags.StringVarP(&o.configDumpFile, "file", "f", "", "The json file with Envoy config dump to evaluate against")
+	flags.StringArrayVar(&o.policyFiles, "policies", nil, "AuthorizationPolicy YAML files to evaluate against, instead of a pod")
+	flags.StringVarP(&o.labels, "labels", "l", "", "Labels of the workload the --policies are evaluated for")
+	flags.StringVar(&o.sourcePrincipal, "source-principal", "",
+		"Peer identity of the client, e.g. cluster.local/ns/foo/sa/bar. Leave empty for plaintext traffic")
+	flags.StringVar(&o.sourceNamespace, "source-namespace", "",
+		"Namespace of the client, used to derive the source principal from its default service account if not set")
+	flags.StringVar(&o.sourceIP, "source-ip", "", "IP address of the client")
+	flags.StringVar(&o.destinationIP, "destination-ip", "", "IP address of the workload receiving the request")
+	flags.Uint32Var(&o.port, "port", 0, "Port of the workload receiving the request")
+	flags.StringVar(&o.sni, "sni", "", "SNI of the connection")
+	flags.BoolVar(&o.tcp, "tcp", false, "Evaluate a TCP connection instead of an HTTP request")
+	flags.StringVar(&o.method, "method", "GET", "HTTP method of the request")
+	flags.StringVar(&o.path, "path", "/", "HTTP path of the request")
+	flags.StringVar(&o.host, "host", "", "HTTP host of the request")
+	flags.StringArrayVarP(&o.headers, "header", "H", nil, "HTTP header of the request in the form <name>: <value>; may be repeated")
+	flags.StringVar(&o.claims, "claims", "", "JSON object with the claims of a validated JWT sent with the request")
+	return cmd
+}
+
+func (o *evalOptions) request() (*Request, error) {
+	req := &Request{
+		SourcePrincipal: o.sourcePrincipal,
+		SourceIP:        o.sourceIP,
+		DestinationIP:   o.destinationIP,
+		DestinationPort: o.port,
+		SNI:             o.sni,
+		Method:          o.method,
+		Path:            o.path,
+		Host:            o.host,
+		Headers:         http.Header{},
+	}
+	if req.SourcePrincipal == "" && o.sourceNamespace != "" {
+		req.SourcePrincipal = fmt.Sprintf("%s/ns/%s/sa/default", constants.DefaultClusterLocalDomain, o.sourceNamespace)
+	}
+	if req.SourcePrincipal != "" && !strings.HasPrefix(req.SourcePrincipal, spiffe.URIPrefix) {
+		req.SourcePrincipal = spiffe.URIPrefix + req.SourcePrincipal
+	}
+	for _, h := range o.headers {
+		k, v, ok := strings.Cut(h, ":")
+		if !ok {
+			return nil, fmt.Errorf("invalid header %q, expected <name>: <value>", h)
+		}
+		req.Headers.Add(strings.TrimSpace(k), strings.TrimSpace(v))
+	}
+	if o.claims != "" {
+		if err := json.Unmarshal([]byte(o.claims), &req.Claims); err != nil {
+			return nil, fmt.Errorf("invalid claims %q: %v", o.claims, err)
+		}
+	}
+	return req, nil
+}
+
+func (o *evalOptions) filterChainFromPolicyFiles(namespace string) (*filterChain, error) {
+	var policies []config.Config
+	for _, f := range o.policyFiles {
+		b, err := os.ReadFile(f)
+		if err != nil {
+			return nil, err
+		}
+		configs, _, err := crd.ParseInputs(string(b))
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %v", f, err)
+		}
+		for _, c := range configs {
+			if c.Namespace == "" {
+				c.Namespace = namespace
+			}
+			policies = append(policies, c)
+		}
+	}
+	l, err := labels.ConvertSelectorToLabelsMap(o.labels)
+	if err != nil {
+		return nil, fmt.Errorf("invalid labels %q: %v", o.labels, err)
+	}
+	return filterChainFromPolicies(policies, namespace, l, o.tcp), nil
+}
+
+func getListeners(envoyConfig *configdump.Wrapper) ([]*listener.Listener, error) {
+	dump, err := envoyConfig.GetDynamicListenerDump(true)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get dynamic listener dump: %s", err)
+	}
+	listeners := make([]*listener.Listener, 0, len(dump.DynamicListeners))
+	for _, l := range dump.DynamicListeners {
+		l.ActiveState.Listener.TypeUrl = v3.ListenerType
+		listenerTyped := &listener.Listener{}
+		if err := l.ActiveState.Listener.UnmarshalTo(listenerTyped); err != nil {
+			return nil, err
+		}
+		listeners = append(listeners, listenerTyped)
+	}
+	return listeners, nil
+}
+
 func getConfigDumpFromFile(filename string) (*configdump.Wrapper, error) {
 	file, err := os.Open(filename)
 	if err != nil {
@@ -149,6 +370,7 @@ func AuthZ(ctx cli.Context) *cobra.Command {
 	}
 
 	cmd.AddCommand(checkCmd(ctx))
+	cmd.AddCommand(evalCmd(ctx))
 	cmd.Long += "\n\n" + util.ExperimentalMsg
 	return cmd
 }
@@ -46,3 +46,31 @@ ALLOW    httpbin.default             1
 		})
 	}
 }
+
+func TestAuthzEval(t *testing.T) {
+	cases := []testutil.TestCase{
+		{
+			Args: []string{
+				"-f", "testdata/configdump.yaml", "--port", "80", "--path", "/info",
+				"--source-principal", "cluster.local/ns/default/sa/sleep", "--claims", `{"iss":"https://accounts.google.com"}`,
+			},
+			ExpectedOutput: `DECISION: ALLOW
+POLICY:   httpbin.default
+RULE:     0
+REASON:   matched ALLOW policy
+`,
+		},
+		{
+			Args: []string{"-f", "testdata/configdump.yaml", "--port", "80", "--path", "/info"},
+			ExpectedOutput: `DECISION: DENY
+REASON:   no ALLOW policy matched
+`,
+		},
+	}
+
+	for i, c := range cases {
+		t.Run(fmt.Sprintf("case %d %s", i, strings.Join(c.Args, " ")), func(t *testing.T) {
+			testutil.VerifyOutput(t, evalCmd(cli.NewFakeContext(&cli.NewFakeContextOption{})), c)
+		})
+	}
+}
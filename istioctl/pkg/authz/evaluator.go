@@ -0,0 +1,364 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package authz
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	listener "github.com/envoyproxy/go-control-plane/envoy/config/listener/v3"
+	rbacpb "github.com/envoyproxy/go-control-plane/envoy/config/rbac/v3"
+
+	authpb "istio.io/api/security/v1beta1"
+	"istio.io/istio/pilot/pkg/model"
+	"istio.io/istio/pilot/pkg/networking"
+	"istio.io/istio/pilot/pkg/networking/plugin/authz"
+	authzmodel "istio.io/istio/pilot/pkg/security/authz/model"
+	"istio.io/istio/pilot/pkg/xds/filters"
+	"istio.io/istio/pkg/config"
+	"istio.io/istio/pkg/config/mesh"
+	"istio.io/istio/pkg/config/schema/gvk"
+	"istio.io/istio/pkg/wellknown"
+)
+
+// Decision is the result of evaluating a request against the authorization configuration.
+type Decision string
+
+const (
+	Allow Decision = "ALLOW"
+	Deny  Decision = "DENY"
+	// Custom means the request is delegated to an external authorizer configured by a CUSTOM policy.
+	Custom Decision = "CUSTOM"
+)
+
+// Request describes the attributes of a request used for the authorization evaluation.
+type Request struct {
+	// SourcePrincipal is the peer identity from mTLS, e.g. spiffe://cluster.local/ns/foo/sa/bar. Empty for plaintext.
+	SourcePrincipal string
+	SourceIP        string
+	DestinationIP   string
+	DestinationPort uint32
+	SNI             string
+
+	// HTTP attributes, ignored when evaluating a TCP connection.
+	Method  string
+	Path    string
+	Host    string
+	Headers http.Header
+
+	// Claims are the claims of the validated JWT, if any.
+	Claims map[string]any
+}
+
+// Evaluation is the result of evaluating a request.
+type Evaluation struct {
+	Decision Decision
+	// Policy and Rule identify the policy that decided the request, empty if no policy matched.
+	Policy string
+	Rule   string
+	Reason string
+	// DryRun lists the dry-run policies that would have matched the request.
+	DryRun []string
+	// Audit lists the AUDIT policies that matched the request.
+	Audit []string
+}
+
+// rbacConfig is implemented by both the HTTP and network RBAC filter configs.
+type rbacConfig interface {
+	GetRules() *rbacpb.RBAC
+	GetShadowRules() *rbacpb.RBAC
+	GetShadowRulesStatPrefix() string
+}
+
+// Evaluate runs the request through the RBAC filters of the filter chain in order, as Envoy would.
+func (fc *filterChain) Evaluate(req *Request, forTCP bool) (*Evaluation, error) {
+	var configs []rbacConfig
+	if forTCP {
+		for _, c := range fc.rbacTCP {
+			configs = append(configs, c)
+		}
+	} else {
+		for _, c := range fc.rbacHTTP {
+			configs = append(configs, c)
+		}
+	}
+
+	res := &Evaluation{}
+	var allowed *Evaluation
+	for _, c := range configs {
+		if shadow := c.GetShadowRules(); shadow != nil {
+			matched, err := req.matchedPolicies(shadow)
+			if err != nil {
+				return nil, err
+			}
+			if c.GetShadowRulesStatPrefix() == authzmodel.RBACExtAuthzShadowRulesStatPrefix {
+				if len(matched) > 0 {
+					res.Decision = Custom
+					res.Policy, res.Rule = extractName(matched[0])
+					res.Reason = "matched CUSTOM policy, the request is sent to the external authorizer"
+					return res, nil
+				}
+			} else {
+				for _, m := range matched {
+					name, rule := extractName(m)
+					res.DryRun = append(res.DryRun, fmt.Sprintf("%s %s rule %s", shadow.GetAction(), name, rule))
+				}
+			}
+		}
+
+		rules := c.GetRules()
+		if rules == nil {
+			continue
+		}
+		matched, err := req.matchedPolicies(rules)
+		if err != nil {
+			return nil, err
+		}
+		switch rules.GetAction() {
+		case rbacpb.RBAC_LOG:
+			for _, m := range matched {
+				name, rule := extractName(m)
+				res.Audit = append(res.Audit, fmt.Sprintf("%s rule %s", name, rule))
+			}
+		case rbacpb.RBAC_DENY:
+			if len(matched) > 0 {
+				res.Decision = Deny
+				res.Policy, res.Rule = extractName(matched[0])
+				res.Reason = "matched DENY policy"
+				return res, nil
+			}
+		case rbacpb.RBAC_ALLOW:
+			if len(matched) == 0 {
+				res.Decision = Deny
+				res.Reason = "no ALLOW policy matched"
+				return res, nil
+			}
+			if allowed == nil {
+				allowed = &Evaluation{}
+				allowed.Policy, allowed.Rule = extractName(matched[0])
+			}
+		}
+	}
+
+	res.Decision = Allow
+	if allowed != nil {
+		res.Policy, res.Rule = allowed.Policy, allowed.Rule
+		res.Reason = "matched ALLOW policy"
+	} else {
+		res.Reason = "no policy applies to the request"
+	}
+	return res, nil
+}
+
+// matchedPolicies returns the sorted names of the policies matching the request.
+func (r *Request) matchedPolicies(rules *rbacpb.RBAC) ([]string, error) {
+	var matched []string
+	for name, policy := range rules.GetPolicies() {
+		m, err := r.matchPolicy(policy)
+		if err != nil {
+			return nil, fmt.Errorf("failed to evaluate policy %s: %v", name, err)
+		}
+		if m {
+			matched = append(matched, name)
+		}
+	}
+	sort.Strings(matched)
+	return matched, nil
+}
+
+func (r *Request) matchPolicy(policy *rbacpb.Policy) (bool, error) {
+	if policy.GetCondition() != nil || policy.GetCheckedCondition() != nil {
+		return false, fmt.Errorf("CEL conditions are not supported")
+	}
+	permission, err := r.matchPermission(&rbacpb.Permission{
+		Rule: &rbacpb.Permission_OrRules{OrRules: &rbacpb.Permission_Set{Rules: policy.GetPermissions()}},
+	})
+	if err != nil || !permission {
+		return false, err
+	}
+	return r.matchPrincipal(&rbacpb.Principal{
+		Identifier: &rbacpb.Principal_OrIds{OrIds: &rbacpb.Principal_Set{Ids: policy.GetPrincipals()}},
+	})
+}
+
+// metadata returns the dynamic metadata the authentication filters would populate for the request.
+func (r *Request) metadata() map[string]any {
+	md := map[string]any{}
+	if len(r.Claims) == 0 {
+		return md
+	}
+	md[filters.EnvoyJwtFilterName] = map[string]any{filters.EnvoyJwtFilterPayload: r.Claims}
+
+	authn := map[string]any{}
+	iss, _ := r.Claims["iss"].(string)
+	sub, _ := r.Claims["sub"].(string)
+	if iss != "" && sub != "" {
+		authn["request.auth.principal"] = iss + "/" + sub
+	}
+	switch aud := r.Claims["aud"].(type) {
+	case string:
+		authn["request.auth.audiences"] = aud
+	case []any:
+		if len(aud) > 0 {
+			authn["request.auth.audiences"] = aud[0]
+		}
+	}
+	if azp, ok := r.Claims["azp"].(string); ok {
+		authn["request.auth.presenter"] = azp
+	}
+	authn["request.auth.claims"] = claimsToLists(r.Claims)
+	md[filters.AuthnFilterName] = authn
+	return md
+}
+
+// claimsToLists converts the leaf string claims to lists, matching the format of the Istio authn filter metadata.
+func claimsToLists(claims map[string]any) map[string]any {
+	out := make(map[string]any, len(claims))
+	for k, v := range claims {
+		switch v := v.(type) {
+		case map[string]any:
+			out[k] = claimsToLists(v)
+		case string:
+			out[k] = []any{v}
+		default:
+			out[k] = v
+		}
+	}
+	return out
+}
+
+// inboundFilterChain finds the filter chain of the listeners that would handle the request on the given port.
+// It returns whether the chain handles TCP traffic, which may differ from the requested protocol if the port
+// was configured with another protocol.
+func inboundFilterChain(listeners []*listener.Listener, port uint32, forTCP bool) (*filterChain, bool, error) {
+	var chains []*listener.FilterChain
+	for _, l := range listeners {
+		if l.Name == model.VirtualInboundListenerName {
+			chains = l.FilterChains
+			break
+		}
+	}
+	if chains == nil {
+		// Gateways do not have a virtual inbound listener, they listen on each port directly.
+		for _, l := range listeners {
+			if l.GetAddress().GetSocketAddress().GetPortValue() == port {
+				chains = append(chains, l.FilterChains...)
+			}
+		}
+	}
+
+	var portMatched, portOtherProtocol, catchAll *listener.FilterChain
+	for _, fc := range chains {
+		chainTCP := !hasHTTPConnectionManager(fc)
+		p := fc.GetFilterChainMatch().GetDestinationPort()
+		switch {
+		case p != nil && p.GetValue() == port && chainTCP == forTCP:
+			if portMatched == nil {
+				portMatched = fc
+			}
+		case p != nil && p.GetValue() == port:
+			if portOtherProtocol == nil {
+				portOtherProtocol = fc
+			}
+		case p == nil && chainTCP == forTCP:
+			if catchAll == nil {
+				catchAll = fc
+			}
+		}
+	}
+	switch {
+	case portMatched != nil:
+		return parseFilterChain(portMatched), forTCP, nil
+	case portOtherProtocol != nil:
+		return parseFilterChain(portOtherProtocol), !forTCP, nil
+	case catchAll != nil:
+		return parseFilterChain(catchAll), forTCP, nil
+	}
+	return nil, false, fmt.Errorf("no inbound filter chain found for port %d", port)
+}
+
+func hasHTTPConnectionManager(fc *listener.FilterChain) bool {
+	for _, f := range fc.Filters {
+		if f.Name == wellknown.HTTPConnectionManager || f.Name == "envoy.http_connection_manager" {
+			return true
+		}
+	}
+	return false
+}
+
+// filterChainFromPolicies builds the RBAC filters the sidecar of a workload would receive for the given
+// AuthorizationPolicy configs, using the same builder as istiod.
+func filterChainFromPolicies(policies []config.Config, namespace string, labels map[string]string, forTCP bool) *filterChain {
+	meshConfig := mesh.DefaultMeshConfig()
+	authzPolicies := &model.AuthorizationPolicies{
+		NamespaceToPolicies: map[string][]model.AuthorizationPolicy{},
+		RootNamespace:       meshConfig.GetRootNamespace(),
+	}
+	for _, cfg := range policies {
+		if cfg.GroupVersionKind != gvk.AuthorizationPolicy {
+			continue
+		}
+		authzPolicies.NamespaceToPolicies[cfg.Namespace] = append(authzPolicies.NamespaceToPolicies[cfg.Namespace], model.AuthorizationPolicy{
+			Name:        cfg.Name,
+			Namespace:   cfg.Namespace,
+			Annotations: cfg.Annotations,
+			Spec:        cfg.Spec.(*authpb.AuthorizationPolicy),
+		})
+	}
+	push := model.NewPushContext()
+	push.Mesh = meshConfig
+	push.AuthzPolicies = authzPolicies
+	proxy := &model.Proxy{
+		Type:            model.SidecarProxy,
+		ConfigNamespace: namespace,
+		Labels:          labels,
+		Metadata:        &model.NodeMetadata{Namespace: namespace, Labels: labels},
+	}
+
+	fc := &filterChain{}
+	for _, action := range []authz.ActionType{authz.Custom, authz.Local} {
+		b := authz.NewBuilder(action, push, proxy, false)
+		if forTCP {
+			for _, f := range b.BuildTCP() {
+				fc.addNetworkFilter(f)
+			}
+		} else {
+			fc.addHTTPFilters(b.BuildHTTP(networking.ListenerClassSidecarInbound))
+		}
+	}
+	return fc
+}
+
+// PrintEvaluation prints the result of an evaluation.
+func PrintEvaluation(writer io.Writer, e *Evaluation) {
+	w := new(tabwriter.Writer).Init(writer, 0, 8, 1, ' ', 0)
+	_, _ = fmt.Fprintf(w, "DECISION:\t%s\n", e.Decision)
+	if e.Policy != "" {
+		_, _ = fmt.Fprintf(w, "POLICY:\t%s\n", e.Policy)
+		_, _ = fmt.Fprintf(w, "RULE:\t%s\n", e.Rule)
+	}
+	_, _ = fmt.Fprintf(w, "REASON:\t%s\n", e.Reason)
+	if len(e.DryRun) > 0 {
+		_, _ = fmt.Fprintf(w, "DRY-RUN MATCHES:\t%s\n", strings.Join(e.DryRun, ", "))
+	}
+	if len(e.Audit) > 0 {
+		_, _ = fmt.Fprintf(w, "AUDIT MATCHES:\t%s\n", strings.Join(e.Audit, ", "))
+	}
+	_ = w.Flush()
+}
@@ -0,0 +1,207 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package authz
+
+import (
+	"net/http"
+	"testing"
+
+	"istio.io/istio/pilot/pkg/config/kube/crd"
+	"istio.io/istio/pkg/test/util/assert"
+)
+
+const policies = `
+apiVersion: security.istio.io/v1
+kind: AuthorizationPolicy
+metadata:
+  name: allow-mysql
+  namespace: default
+spec:
+  selector:
+    matchLabels:
+      app: mysql
+  action: ALLOW
+  rules:
+  - from:
+    - source:
+        namespaces: ["trusted"]
+  - from:
+    - source:
+        principals: ["cluster.local/ns/default/sa/sleep"]
+    to:
+    - operation:
+        methods: ["GET"]
+---
+apiVersion: security.istio.io/v1
+kind: AuthorizationPolicy
+metadata:
+  name: deny-admin
+  namespace: default
+spec:
+  selector:
+    matchLabels:
+      app: httpbin
+  action: DENY
+  rules:
+  - to:
+    - operation:
+        paths: ["/admin*"]
+    when:
+    - key: request.auth.claims[groups]
+      notValues: ["admin"]
+---
+apiVersion: security.istio.io/v1
+kind: AuthorizationPolicy
+metadata:
+  name: allow-sleep
+  namespace: default
+spec:
+  selector:
+    matchLabels:
+      app: httpbin
+  action: ALLOW
+  rules:
+  - from:
+    - source:
+        principals: ["cluster.local/ns/default/sa/sleep"]
+    to:
+    - operation:
+        methods: ["GET"]
+  - from:
+    - source:
+        namespaces: ["trusted"]
+    to:
+    - operation:
+        ports: ["8000"]
+---
+apiVersion: security.istio.io/v1
+kind: AuthorizationPolicy
+metadata:
+  name: allow-header
+  namespace: default
+  annotations:
+    istio.io/dry-run: "true"
+spec:
+  selector:
+    matchLabels:
+      app: httpbin
+  action: ALLOW
+  rules:
+  - when:
+    - key: request.headers[x-token]
+      values: ["secret"]
+`
+
+func TestEvaluate(t *testing.T) {
+	configs, _, err := crd.ParseInputs(policies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sleep := "spiffe://cluster.local/ns/default/sa/sleep"
+	cases := []struct {
+		name   string
+		labels map[string]string
+		tcp    bool
+		req    Request
+		want   Evaluation
+	}{
+		{
+			name:   "no policy selects the workload",
+			labels: map[string]string{"app": "other"},
+			req:    Request{Method: "POST", Path: "/"},
+			want:   Evaluation{Decision: Allow, Reason: "no policy applies to the request"},
+		},
+		{
+			name: "allowed by principal",
+			req:  Request{SourcePrincipal: sleep, Method: "GET", Path: "/headers"},
+			want: Evaluation{Decision: Allow, Policy: "allow-sleep.default", Rule: "0", Reason: "matched ALLOW policy"},
+		},
+		{
+			name: "allowed by namespace and port",
+			req: Request{
+				SourcePrincipal: "spiffe://cluster.local/ns/trusted/sa/client",
+				DestinationPort: 8000, Method: "POST", Path: "/post",
+			},
+			want: Evaluation{Decision: Allow, Policy: "allow-sleep.default", Rule: "1", Reason: "matched ALLOW policy"},
+		},
+		{
+			name: "no allow policy matched",
+			req:  Request{SourcePrincipal: sleep, Method: "POST", Path: "/post"},
+			want: Evaluation{Decision: Deny, Reason: "no ALLOW policy matched"},
+		},
+		{
+			name: "plaintext is denied",
+			req:  Request{Method: "GET", Path: "/headers"},
+			want: Evaluation{Decision: Deny, Reason: "no ALLOW policy matched"},
+		},
+		{
+			name: "denied without admin claim",
+			req:  Request{SourcePrincipal: sleep, Method: "GET", Path: "/admin/users"},
+			want: Evaluation{Decision: Deny, Policy: "deny-admin.default", Rule: "0", Reason: "matched DENY policy"},
+		},
+		{
+			name: "admin claim skips deny",
+			req: Request{
+				SourcePrincipal: sleep, Method: "GET", Path: "/admin/users",
+				Claims: map[string]any{"iss": "https://example.com", "sub": "alice", "groups": []any{"dev", "admin"}},
+			},
+			want: Evaluation{Decision: Allow, Policy: "allow-sleep.default", Rule: "0", Reason: "matched ALLOW policy"},
+		},
+		{
+			name: "dry-run match is reported",
+			req: Request{
+				SourcePrincipal: sleep, Method: "GET", Path: "/",
+				Headers: http.Header{"X-Token": []string{"secret"}},
+			},
+			want: Evaluation{
+				Decision: Allow, Policy: "allow-sleep.default", Rule: "0", Reason: "matched ALLOW policy",
+				DryRun: []string{"ALLOW allow-header.default rule 0"},
+			},
+		},
+		{
+			name:   "tcp ignores HTTP only fields in ALLOW rules",
+			labels: map[string]string{"app": "httpbin", "version": "v1"},
+			tcp:    true,
+			req:    Request{SourcePrincipal: "spiffe://cluster.local/ns/trusted/sa/client", DestinationPort: 8000},
+			want:   Evaluation{Decision: Deny, Policy: "deny-admin.default", Rule: "0", Reason: "matched DENY policy"},
+		},
+		{
+			name:   "tcp without DENY policy",
+			labels: map[string]string{"app": "mysql"},
+			tcp:    true,
+			req:    Request{SourcePrincipal: "spiffe://cluster.local/ns/trusted/sa/client", DestinationPort: 3306},
+			want:   Evaluation{Decision: Allow, Policy: "allow-mysql.default", Rule: "0", Reason: "matched ALLOW policy"},
+		},
+		{
+			name:   "tcp ALLOW rule with HTTP only fields never matches",
+			labels: map[string]string{"app": "mysql"},
+			tcp:    true,
+			req:    Request{SourcePrincipal: sleep, DestinationPort: 3306},
+			want:   Evaluation{Decision: Deny, Reason: "no ALLOW policy matched"},
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			labels := tt.labels
+			if labels == nil {
+				labels = map[string]string{"app": "httpbin"}
+			}
+			fc := filterChainFromPolicies(configs, "default", labels, tt.tcp)
+			got, err := fc.Evaluate(&tt.req, tt.tcp)
+			assert.NoError(t, err)
+			assert.Equal(t, got, &tt.want)
+		})
+	}
+}
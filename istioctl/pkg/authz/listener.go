@@ -83,40 +83,54 @@ func parse(listeners []*listener.Listener) []*parsedListener {
 	for _, l := range listeners {
 		parsed := &parsedListener{}
 		for _, fc := range l.FilterChains {
-			parsedFC := &filterChain{}
-			for _, filter := range fc.Filters {
-				switch filter.Name {
-				case wellknown.HTTPConnectionManager, "envoy.http_connection_manager":
-					if cm := getHTTPConnectionManager(filter); cm != nil {
-						for _, httpFilter := range cm.GetHttpFilters() {
-							switch httpFilter.GetName() {
-							case wellknown.HTTPRoleBasedAccessControl:
-								rbacHTTP := &rbachttp.RBAC{}
-								if err := getHTTPFilterConfig(httpFilter, rbacHTTP); err != nil {
-									log.Errorf("found RBAC HTTP filter but failed to parse: %s", err)
-								} else {
-									parsedFC.rbacHTTP = append(parsedFC.rbacHTTP, rbacHTTP)
-								}
-							}
-						}
-					}
-				case wellknown.RoleBasedAccessControl:
-					rbacTCP := &rbactcp.RBAC{}
-					if err := getFilterConfig(filter, rbacTCP); err != nil {
-						log.Errorf("found RBAC network filter but failed to parse: %s", err)
-					} else {
-						parsedFC.rbacTCP = append(parsedFC.rbacTCP, rbacTCP)
-					}
-				}
-			}
-
-			parsed.filterChains = append(parsed.filterChains, parsedFC)
+			parsed.filterChains = append(parsed.filterChains, parseFilterChain(fc))
 		}
 		parsedListeners = append(parsedListeners, parsed)
 	}
 	return parsedListeners
 }
 
+func parseFilterChain(fc *listener.FilterChain) *filterChain {
+	parsedFC := &filterChain{}
+	for _, filter := range fc.Filters {
+		switch filter.Name {
+		case wellknown.HTTPConnectionManager, "envoy.http_connection_manager":
+			if cm := getHTTPConnectionManager(filter); cm != nil {
+				parsedFC.addHTTPFilters(cm.GetHttpFilters())
+			}
+		default:
+			parsedFC.addNetworkFilter(filter)
+		}
+	}
+	return parsedFC
+}
+
+func (fc *filterChain) addHTTPFilters(filters []*hcm.HttpFilter) {
+	for _, httpFilter := range filters {
+		switch httpFilter.GetName() {
+		case wellknown.HTTPRoleBasedAccessControl:
+			rbacHTTP := &rbachttp.RBAC{}
+			if err := getHTTPFilterConfig(httpFilter, rbacHTTP); err != nil {
+				log.Errorf("found RBAC HTTP filter but failed to parse: %s", err)
+			} else {
+				fc.rbacHTTP = append(fc.rbacHTTP, rbacHTTP)
+			}
+		}
+	}
+}
+
+func (fc *filterChain) addNetworkFilter(filter *listener.Filter) {
+	switch filter.Name {
+	case wellknown.RoleBasedAccessControl:
+		rbacTCP := &rbactcp.RBAC{}
+		if err := getFilterConfig(filter, rbacTCP); err != nil {
+			log.Errorf("found RBAC network filter but failed to parse: %s", err)
+		} else {
+			fc.rbacTCP = append(fc.rbacTCP, rbacTCP)
+		}
+	}
+}
+
 func extractName(name string) (string, string) {
 	// parts[1] is the namespace, parts[2] is the policy name, parts[3] is the rule index.
 	parts := re.FindStringSubmatch(name)
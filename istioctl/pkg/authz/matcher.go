@@ -0,0 +1,346 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package authz
+
+import (
+	"fmt"
+	"net/netip"
+	"regexp"
+	"strings"
+
+	core "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	rbacpb "github.com/envoyproxy/go-control-plane/envoy/config/rbac/v3"
+	routepb "github.com/envoyproxy/go-control-plane/envoy/config/route/v3"
+	uri_template "github.com/envoyproxy/go-control-plane/envoy/extensions/path/match/uri_template/v3"
+	matcherpb "github.com/envoyproxy/go-control-plane/envoy/type/matcher/v3"
+)
+
+// peerPrincipalFilterStateKey is the filter state key holding the peer principal when filter state is used.
+const peerPrincipalFilterStateKey = "io.istio.peer_principal"
+
+// matchPrincipal evaluates an Envoy RBAC principal against the request.
+func (r *Request) matchPrincipal(p *rbacpb.Principal) (bool, error) {
+	switch id := p.GetIdentifier().(type) {
+	case *rbacpb.Principal_Any:
+		return id.Any, nil
+	case *rbacpb.Principal_AndIds:
+		for _, sub := range id.AndIds.GetIds() {
+			m, err := r.matchPrincipal(sub)
+			if err != nil || !m {
+				return false, err
+			}
+		}
+		return true, nil
+	case *rbacpb.Principal_OrIds:
+		for _, sub := range id.OrIds.GetIds() {
+			m, err := r.matchPrincipal(sub)
+			if err != nil {
+				return false, err
+			}
+			if m {
+				return true, nil
+			}
+		}
+		return false, nil
+	case *rbacpb.Principal_NotId:
+		m, err := r.matchPrincipal(id.NotId)
+		return !m, err
+	case *rbacpb.Principal_Authenticated_:
+		if r.SourcePrincipal == "" {
+			return false, nil
+		}
+		if id.Authenticated.GetPrincipalName() == nil {
+			return true, nil
+		}
+		return matchString(id.Authenticated.GetPrincipalName(), r.SourcePrincipal)
+	case *rbacpb.Principal_FilterState:
+		if id.FilterState.GetKey() != peerPrincipalFilterStateKey {
+			return false, fmt.Errorf("unsupported filter state key %q", id.FilterState.GetKey())
+		}
+		if r.SourcePrincipal == "" {
+			return false, nil
+		}
+		return matchString(id.FilterState.GetStringMatch(), r.SourcePrincipal)
+	case *rbacpb.Principal_DirectRemoteIp:
+		return matchCIDR(id.DirectRemoteIp, r.SourceIP)
+	case *rbacpb.Principal_RemoteIp:
+		return matchCIDR(id.RemoteIp, r.remoteIP())
+	case *rbacpb.Principal_SourceIp:
+		return matchCIDR(id.SourceIp, r.SourceIP)
+	case *rbacpb.Principal_Header:
+		return r.matchHeader(id.Header)
+	case *rbacpb.Principal_UrlPath:
+		return r.matchPath(id.UrlPath)
+	case *rbacpb.Principal_Metadata:
+		return r.matchMetadata(id.Metadata)
+	default:
+		return false, fmt.Errorf("unsupported principal %T", id)
+	}
+}
+
+// matchPermission evaluates an Envoy RBAC permission against the request.
+func (r *Request) matchPermission(p *rbacpb.Permission) (bool, error) {
+	switch rule := p.GetRule().(type) {
+	case *rbacpb.Permission_Any:
+		return rule.Any, nil
+	case *rbacpb.Permission_AndRules:
+		for _, sub := range rule.AndRules.GetRules() {
+			m, err := r.matchPermission(sub)
+			if err != nil || !m {
+				return false, err
+			}
+		}
+		return true, nil
+	case *rbacpb.Permission_OrRules:
+		for _, sub := range rule.OrRules.GetRules() {
+			m, err := r.matchPermission(sub)
+			if err != nil {
+				return false, err
+			}
+			if m {
+				return true, nil
+			}
+		}
+		return false, nil
+	case *rbacpb.Permission_NotRule:
+		m, err := r.matchPermission(rule.NotRule)
+		return !m, err
+	case *rbacpb.Permission_DestinationIp:
+		return matchCIDR(rule.DestinationIp, r.DestinationIP)
+	case *rbacpb.Permission_DestinationPort:
+		return rule.DestinationPort == r.DestinationPort, nil
+	case *rbacpb.Permission_DestinationPortRange:
+		port := int32(r.DestinationPort)
+		return port >= rule.DestinationPortRange.GetStart() && port < rule.DestinationPortRange.GetEnd(), nil
+	case *rbacpb.Permission_RequestedServerName:
+		return matchString(rule.RequestedServerName, r.SNI)
+	case *rbacpb.Permission_Header:
+		return r.matchHeader(rule.Header)
+	case *rbacpb.Permission_UrlPath:
+		return r.matchPath(rule.UrlPath)
+	case *rbacpb.Permission_UriTemplate:
+		return r.matchURITemplate(rule.UriTemplate)
+	case *rbacpb.Permission_Metadata:
+		return r.matchMetadata(rule.Metadata)
+	default:
+		return false, fmt.Errorf("unsupported permission %T", rule)
+	}
+}
+
+func (r *Request) remoteIP() string {
+	if xff := r.header("x-forwarded-for"); xff != "" {
+		// Istio configures trusted hops to 0 by default, so the last address is the client.
+		parts := strings.Split(xff, ",")
+		return strings.TrimSpace(parts[len(parts)-1])
+	}
+	return r.SourceIP
+}
+
+// header returns the value of a request header, including the HTTP/2 pseudo headers.
+func (r *Request) header(name string) string {
+	switch strings.ToLower(name) {
+	case ":method":
+		return r.Method
+	case ":path":
+		return r.Path
+	case ":authority", "host":
+		return r.Host
+	}
+	return strings.Join(r.Headers.Values(name), ",")
+}
+
+func (r *Request) matchHeader(h *routepb.HeaderMatcher) (bool, error) {
+	value := r.header(h.GetName())
+	present := value != ""
+	var matched bool
+	var err error
+	switch m := h.GetHeaderMatchSpecifier().(type) {
+	case *routepb.HeaderMatcher_PresentMatch:
+		matched = present == m.PresentMatch
+	case *routepb.HeaderMatcher_StringMatch:
+		matched = present || h.GetTreatMissingHeaderAsEmpty()
+		if matched {
+			matched, err = matchString(m.StringMatch, value)
+		}
+	case *routepb.HeaderMatcher_ExactMatch:
+		matched = present && value == m.ExactMatch
+	case *routepb.HeaderMatcher_PrefixMatch:
+		matched = present && strings.HasPrefix(value, m.PrefixMatch)
+	case *routepb.HeaderMatcher_SuffixMatch:
+		matched = present && strings.HasSuffix(value, m.SuffixMatch)
+	case *routepb.HeaderMatcher_ContainsMatch:
+		matched = present && strings.Contains(value, m.ContainsMatch)
+	case *routepb.HeaderMatcher_SafeRegexMatch:
+		if present {
+			matched, err = matchRegex(m.SafeRegexMatch.GetRegex(), value)
+		}
+	case nil:
+		matched = present
+	default:
+		return false, fmt.Errorf("unsupported header matcher %T", m)
+	}
+	if err != nil {
+		return false, err
+	}
+	return matched != h.GetInvertMatch(), nil
+}
+
+func (r *Request) matchPath(p *matcherpb.PathMatcher) (bool, error) {
+	path, _, _ := strings.Cut(r.Path, "?")
+	return matchString(p.GetPath(), path)
+}
+
+// matchURITemplate matches the request path against a path template such as /foo/{*}/bar/{**}.
+func (r *Request) matchURITemplate(ext *core.TypedExtensionConfig) (bool, error) {
+	cfg := &uri_template.UriTemplateMatchConfig{}
+	if err := ext.GetTypedConfig().UnmarshalTo(cfg); err != nil {
+		return false, fmt.Errorf("unsupported uri template %v: %v", ext.GetName(), err)
+	}
+	path, _, _ := strings.Cut(r.Path, "?")
+	segments := strings.Split(cfg.GetPathTemplate(), "/")
+	for i, segment := range segments {
+		switch segment {
+		case "{*}":
+			segments[i] = "[^/]+"
+		case "{**}":
+			segments[i] = ".*"
+		default:
+			segments[i] = regexp.QuoteMeta(segment)
+		}
+	}
+	return matchRegex(strings.Join(segments, "/"), path)
+}
+
+func (r *Request) matchMetadata(m *matcherpb.MetadataMatcher) (bool, error) {
+	var value any = r.metadata()[m.GetFilter()]
+	for _, segment := range m.GetPath() {
+		obj, ok := value.(map[string]any)
+		if !ok {
+			value = nil
+			break
+		}
+		value = obj[segment.GetKey()]
+	}
+	matched, err := matchValue(m.GetValue(), value)
+	if err != nil {
+		return false, err
+	}
+	return matched != m.GetInvert(), nil
+}
+
+func matchValue(m *matcherpb.ValueMatcher, value any) (bool, error) {
+	switch p := m.GetMatchPattern().(type) {
+	case *matcherpb.ValueMatcher_NullMatch_:
+		return value == nil, nil
+	case *matcherpb.ValueMatcher_PresentMatch:
+		return (value != nil) == p.PresentMatch, nil
+	case *matcherpb.ValueMatcher_BoolMatch:
+		b, ok := value.(bool)
+		return ok && b == p.BoolMatch, nil
+	case *matcherpb.ValueMatcher_DoubleMatch:
+		f, ok := value.(float64)
+		if !ok {
+			return false, nil
+		}
+		switch d := p.DoubleMatch.GetMatchPattern().(type) {
+		case *matcherpb.DoubleMatcher_Exact:
+			return f == d.Exact, nil
+		case *matcherpb.DoubleMatcher_Range:
+			return f >= d.Range.GetStart() && f < d.Range.GetEnd(), nil
+		}
+		return false, nil
+	case *matcherpb.ValueMatcher_StringMatch:
+		s, ok := value.(string)
+		if !ok {
+			return false, nil
+		}
+		return matchString(p.StringMatch, s)
+	case *matcherpb.ValueMatcher_ListMatch:
+		list, ok := value.([]any)
+		if !ok {
+			return false, nil
+		}
+		for _, v := range list {
+			m, err := matchValue(p.ListMatch.GetOneOf(), v)
+			if err != nil {
+				return false, err
+			}
+			if m {
+				return true, nil
+			}
+		}
+		return false, nil
+	case *matcherpb.ValueMatcher_OrMatch:
+		for _, sub := range p.OrMatch.GetValueMatchers() {
+			m, err := matchValue(sub, value)
+			if err != nil {
+				return false, err
+			}
+			if m {
+				return true, nil
+			}
+		}
+		return false, nil
+	default:
+		return false, fmt.Errorf("unsupported value matcher %T", p)
+	}
+}
+
+func matchString(m *matcherpb.StringMatcher, value string) (bool, error) {
+	fold := func(s string) string {
+		if m.GetIgnoreCase() {
+			return strings.ToLower(s)
+		}
+		return s
+	}
+	switch p := m.GetMatchPattern().(type) {
+	case *matcherpb.StringMatcher_Exact:
+		return fold(value) == fold(p.Exact), nil
+	case *matcherpb.StringMatcher_Prefix:
+		return strings.HasPrefix(fold(value), fold(p.Prefix)), nil
+	case *matcherpb.StringMatcher_Suffix:
+		return strings.HasSuffix(fold(value), fold(p.Suffix)), nil
+	case *matcherpb.StringMatcher_Contains:
+		return strings.Contains(fold(value), fold(p.Contains)), nil
+	case *matcherpb.StringMatcher_SafeRegex:
+		return matchRegex(p.SafeRegex.GetRegex(), value)
+	default:
+		return false, fmt.Errorf("unsupported string matcher %T", p)
+	}
+}
+
+// matchRegex performs a full match, as Envoy does for RE2 matchers.
+func matchRegex(expr, value string) (bool, error) {
+	re, err := regexp.Compile("^(?:" + expr + ")$")
+	if err != nil {
+		return false, fmt.Errorf("invalid regex %q: %v", expr, err)
+	}
+	return re.MatchString(value), nil
+}
+
+func matchCIDR(cidr *core.CidrRange, ip string) (bool, error) {
+	if ip == "" {
+		return false, nil
+	}
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return false, fmt.Errorf("invalid IP %q: %v", ip, err)
+	}
+	prefix, err := netip.ParsePrefix(fmt.Sprintf("%s/%d", cidr.GetAddressPrefix(), cidr.GetPrefixLen().GetValue()))
+	if err != nil {
+		return false, fmt.Errorf("invalid CIDR %v/%v: %v", cidr.GetAddressPrefix(), cidr.GetPrefixLen().GetValue(), err)
+	}
+	return prefix.Contains(addr), nil
+}
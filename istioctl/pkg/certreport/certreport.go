@@ -0,0 +1,496 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package certreport
+
+import (
+	"bytes"
+	"context"
+	"crypto/x509"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"istio.io/istio/istioctl/pkg/cli"
+	"istio.io/istio/istioctl/pkg/util"
+	"istio.io/istio/istioctl/pkg/util/configdump"
+	sdscompare "istio.io/istio/istioctl/pkg/writer/compare/sds"
+	"istio.io/istio/pilot/pkg/serviceregistry/kube/controller"
+	"istio.io/istio/pkg/config/constants"
+	"istio.io/istio/pkg/kube"
+	"istio.io/istio/pkg/kube/inject"
+	"istio.io/istio/pkg/log"
+	"istio.io/istio/pkg/slices"
+	"istio.io/istio/security/pkg/pki/ca"
+)
+
+const (
+	// istiodSource is the source of certs read from the Istiod CA, rather than a proxy.
+	istiodSource = "istiod"
+
+	leafType         = "leaf"
+	intermediateType = "intermediate"
+	rootType         = "root"
+)
+
+// CertInfo describes a certificate in use in the mesh.
+type CertInfo struct {
+	// Source is the proxy, as <pod>.<namespace>, or istiod for the certs of the CA.
+	Source string `json:"source"`
+	// Resource is the SDS resource, or Kubernetes object, the cert was read from.
+	Resource string `json:"resource"`
+	// Type is one of leaf, intermediate or root.
+	Type     string   `json:"type"`
+	Identity []string `json:"identity,omitempty"`
+	Subject  string   `json:"subject"`
+	// IssuerChain is the subject of each issuer of the cert, ending at the root.
+	IssuerChain []string  `json:"issuerChain,omitempty"`
+	Serial      string    `json:"serial"`
+	NotBefore   time.Time `json:"notBefore"`
+	NotAfter    time.Time `json:"notAfter"`
+	ExpiresIn   string    `json:"expiresIn"`
+	// Trusted is set if the cert chains to a root in the mesh trust bundle.
+	Trusted bool `json:"trusted"`
+
+	expiresIn time.Duration
+}
+
+type filterOptions struct {
+	expiresWithin time.Duration
+	untrustedOnly bool
+	types         []string
+}
+
+func Cmd(ctx cli.Context) *cobra.Command {
+	var (
+		allNamespaces   bool
+		selector        string
+		trustBundleFile string
+		output          string
+		filter          filterOptions
+	)
+	cmd := &cobra.Command{
+		Use:   "cert-report",
+		Short: "Report the certificates in use by proxies in the mesh and by the Istiod CA",
+		Long: `Report the certificates in use in the mesh, along with their identity, issuer chain, serial and time to expiry.
+
+Certificates are collected from the SDS config dump of each proxy, and from the signing certificates of the Istiod CA.
+Certificates that do not chain to a root in the mesh trust bundle, read from the istio-ca-root-cert ConfigMap in the
+Istio namespace, are reported as untrusted.`,
+		Example: `  # Report all certificates used by proxies in the default namespace
+  istioctl x cert-report
+
+  # Report certificates expiring in the next week across the mesh
+  istioctl x cert-report -A --expires-within 168h
+
+  # Report leaf certificates that do not chain to the mesh trust bundle, as CSV
+  istioctl x cert-report -A --untrusted-only --type leaf -o csv`,
+		Args: cobra.NoArgs,
+		RunE: func(c *cobra.Command, args []string) error {
+			if !slices.Contains([]string{"short", "json", "csv"}, output) {
+				return fmt.Errorf("unknown output format %q, expected one of short|json|csv", output)
+			}
+			for _, t := range filter.types {
+				if !slices.Contains([]string{leafType, intermediateType, rootType}, t) {
+					return fmt.Errorf("unknown certificate type %q, expected one of leaf|intermediate|root", t)
+				}
+			}
+			kubeClient, err := ctx.CLIClient()
+			if err != nil {
+				return err
+			}
+			namespace := ctx.NamespaceOrDefault(ctx.Namespace())
+			if allNamespaces {
+				namespace = metav1.NamespaceAll
+			}
+
+			var bundle trustBundle
+			if trustBundleFile != "" {
+				b, err := os.ReadFile(trustBundleFile)
+				if err != nil {
+					return err
+				}
+				if bundle, err = parseTrustBundle(b); err != nil {
+					return fmt.Errorf("failed to parse trust bundle %s: %v", trustBundleFile, err)
+				}
+			}
+			certs, bundle, err := collectIstiodCerts(kubeClient, ctx.IstioNamespace(), bundle)
+			if err != nil {
+				return err
+			}
+			proxyCerts, err := collectProxyCerts(kubeClient, namespace, selector, bundle, c.ErrOrStderr())
+			if err != nil {
+				return err
+			}
+			certs = append(certs, proxyCerts...)
+			return printReport(c.OutOrStdout(), filterCerts(certs, filter), output)
+		},
+	}
+	cmd.Long += "\n\n" + util.ExperimentalMsg
+	cmd.Flags().BoolVarP(&allNamespaces, "all-namespaces", "A", false, "Report certificates of proxies in all namespaces")
+	cmd.Flags().StringVarP(&selector, "selector", "l", "", "Label selector for the pods to report")
+	cmd.Flags().StringVar(&trustBundleFile, "trust-bundle", "",
+		"File containing the mesh trust bundle. If unset, it is read from the istio-ca-root-cert ConfigMap")
+	cmd.Flags().DurationVar(&filter.expiresWithin, "expires-within", 0, "Only report certificates expiring within this duration")
+	cmd.Flags().BoolVar(&filter.untrustedOnly, "untrusted-only", false,
+		"Only report certificates that do not chain to a root in the mesh trust bundle")
+	cmd.Flags().StringSliceVar(&filter.types, "type", nil, "Only report certificates of these types: leaf, intermediate or root")
+	cmd.Flags().StringVarP(&output, "output", "o", "short", "Output format: one of short|json|csv")
+	return cmd
+}
+
+// collectIstiodCerts returns the signing certs of the Istiod CA, along with the mesh trust bundle. If bundle is set,
+// it is used as the trust bundle instead of the one distributed by Istiod.
+func collectIstiodCerts(kubeClient kube.CLIClient, istioNamespace string, bundle trustBundle) ([]CertInfo, trustBundle, error) {
+	var certs []CertInfo
+	cm, err := kubeClient.Kube().CoreV1().ConfigMaps(istioNamespace).Get(context.TODO(), controller.CACertNamespaceConfigMap, metav1.GetOptions{})
+	if err != nil && !kerrors.IsNotFound(err) {
+		return nil, nil, err
+	}
+	if cm != nil && cm.Data[constants.CACertNamespaceConfigMapDataName] != "" {
+		roots := []byte(cm.Data[constants.CACertNamespaceConfigMapDataName])
+		if bundle == nil {
+			if bundle, err = parseTrustBundle(roots); err != nil {
+				return nil, nil, fmt.Errorf("failed to parse mesh trust bundle: %v", err)
+			}
+		}
+		rootCerts, err := parseBundle(istiodSource, "configmap/"+controller.CACertNamespaceConfigMap, roots, bundle, time.Now())
+		if err != nil {
+			return nil, nil, err
+		}
+		certs = append(certs, rootCerts...)
+	}
+	if bundle == nil {
+		log.Warnf("mesh trust bundle not found in ConfigMap %s/%s, all certificates will be reported as untrusted",
+			istioNamespace, controller.CACertNamespaceConfigMap)
+	}
+
+	// The plugged in CA certs take precedence over the self-signed CA, as in Istiod.
+	for _, name := range []string{ca.CACertsSecret, ca.CASecret} {
+		secret, err := kubeClient.Kube().CoreV1().Secrets(istioNamespace).Get(context.TODO(), name, metav1.GetOptions{})
+		if kerrors.IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			// Reading secrets is commonly forbidden, which should not prevent reporting on the proxies.
+			log.Warnf("failed to read CA secret %s/%s: %v", istioNamespace, name, err)
+			break
+		}
+		chain := caSecretChain(secret)
+		if len(chain) == 0 {
+			continue
+		}
+		caCerts, err := parseChain(istiodSource, "secret/"+name, chain, bundle, time.Now())
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to parse CA secret %s/%s: %v", istioNamespace, name, err)
+		}
+		certs = append(certs, caCerts...)
+		break
+	}
+	return certs, bundle, nil
+}
+
+// caSecretChain returns the signing cert chain from a CA secret.
+func caSecretChain(secret *corev1.Secret) []byte {
+	if chain := secret.Data[ca.CertChainFile]; len(chain) > 0 {
+		return chain
+	}
+	if chain := secret.Data[ca.CACertFile]; len(chain) > 0 {
+		return chain
+	}
+	return secret.Data[ca.TLSSecretCACertFile]
+}
+
+// collectProxyCerts returns the certs in the SDS config dump of each proxy matching the namespace and selector.
+// Proxies whose config dump cannot be read are skipped with a warning.
+func collectProxyCerts(kubeClient kube.CLIClient, namespace, selector string, bundle trustBundle, errOut io.Writer) ([]CertInfo, error) {
+	pods, err := kubeClient.PodsForSelector(context.TODO(), namespace, selector)
+	if err != nil {
+		return nil, err
+	}
+	var certs []CertInfo
+	for _, pod := range pods.Items {
+		if pod.Status.Phase != corev1.PodRunning || !hasProxy(pod) {
+			continue
+		}
+		source := pod.Name + "." + pod.Namespace
+		dump, err := kubeClient.EnvoyDoWithPort(context.TODO(), pod.Name, pod.Namespace, "GET", "config_dump", util.DefaultProxyAdminPort)
+		if err != nil {
+			_, _ = fmt.Fprintf(errOut, "Skipping %s: failed to get config dump: %v\n", source, err)
+			continue
+		}
+		podCerts, err := proxyCerts(source, dump, bundle, time.Now())
+		if err != nil {
+			_, _ = fmt.Fprintf(errOut, "Skipping %s: %v\n", source, err)
+			continue
+		}
+		certs = append(certs, podCerts...)
+	}
+	return certs, nil
+}
+
+func hasProxy(pod corev1.Pod) bool {
+	return slices.FindFunc(pod.Spec.Containers, func(c corev1.Container) bool {
+		return c.Name == inject.ProxyContainerName
+	}) != nil || slices.FindFunc(pod.Spec.InitContainers, func(c corev1.Container) bool {
+		return c.Name == inject.ProxyContainerName
+	}) != nil
+}
+
+// proxyCerts returns the certs in the SDS config dump of a proxy.
+func proxyCerts(source string, dump []byte, bundle trustBundle, now time.Time) ([]CertInfo, error) {
+	w := &configdump.Wrapper{}
+	if err := w.UnmarshalJSON(dump); err != nil {
+		return nil, fmt.Errorf("failed to parse config dump: %v", err)
+	}
+	secrets, err := sdscompare.GetEnvoySecrets(w)
+	if err != nil {
+		return nil, err
+	}
+	var certs []CertInfo
+	for _, s := range secrets {
+		if s.Data == "" {
+			continue
+		}
+		var parsed []CertInfo
+		// Validation contexts hold a bundle of roots, while TLS certificates hold a cert chain.
+		if s.Type == "CA" {
+			parsed, err = parseBundle(source, s.Name, []byte(s.Data), bundle, now)
+		} else {
+			parsed, err = parseChain(source, s.Name, []byte(s.Data), bundle, now)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse secret %s: %v", s.Name, err)
+		}
+		certs = append(certs, parsed...)
+	}
+	return certs, nil
+}
+
+// trustBundle is the set of roots trusted by the mesh.
+type trustBundle []*x509.Certificate
+
+func parseTrustBundle(b []byte) (trustBundle, error) {
+	return parsePEM(b)
+}
+
+// contains returns whether the cert is one of the roots in the bundle.
+func (t trustBundle) contains(cert *x509.Certificate) bool {
+	return slices.FindFunc(t, func(root *x509.Certificate) bool {
+		return bytes.Equal(root.Raw, cert.Raw)
+	}) != nil
+}
+
+// issued returns whether the cert is one of the roots in the bundle, or is signed by one of them.
+func (t trustBundle) issued(cert *x509.Certificate) bool {
+	if t.contains(cert) {
+		return true
+	}
+	return slices.FindFunc(t, func(root *x509.Certificate) bool {
+		return bytes.Equal(root.RawSubject, cert.RawIssuer) && cert.CheckSignatureFrom(root) == nil
+	}) != nil
+}
+
+// verifies returns whether the cert chains to a root in the bundle, using the given intermediates.
+func (t trustBundle) verifies(cert *x509.Certificate, intermediates []*x509.Certificate, now time.Time) bool {
+	roots := x509.NewCertPool()
+	for _, root := range t {
+		roots.AddCert(root)
+	}
+	pool := x509.NewCertPool()
+	for _, c := range intermediates {
+		pool.AddCert(c)
+	}
+	_, err := cert.Verify(x509.VerifyOptions{
+		Roots:         roots,
+		Intermediates: pool,
+		CurrentTime:   now,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+	})
+	return err == nil
+}
+
+// parseChain parses a cert chain, ordered from the leaf to the root. Each cert in the chain is trusted if it
+// verifies against the trust bundle, using the certs after it in the chain as intermediates.
+func parseChain(source, resource string, b []byte, bundle trustBundle, now time.Time) ([]CertInfo, error) {
+	chain, err := parsePEM(b)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]CertInfo, 0, len(chain))
+	for i, cert := range chain {
+		info := newCertInfo(source, resource, cert, now)
+		for _, issuer := range chain[i+1:] {
+			info.IssuerChain = append(info.IssuerChain, issuer.Subject.String())
+		}
+		if len(info.IssuerChain) == 0 && info.Type != rootType {
+			info.IssuerChain = []string{cert.Issuer.String()}
+		}
+		info.Trusted = bundle.verifies(cert, chain[i+1:], now)
+		out = append(out, info)
+	}
+	return out, nil
+}
+
+// parseBundle parses a bundle of independent roots. Each root is trusted if it is in the trust bundle.
+func parseBundle(source, resource string, b []byte, bundle trustBundle, now time.Time) ([]CertInfo, error) {
+	roots, err := parsePEM(b)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]CertInfo, 0, len(roots))
+	for _, cert := range roots {
+		info := newCertInfo(source, resource, cert, now)
+		info.Trusted = bundle.issued(cert)
+		out = append(out, info)
+	}
+	return out, nil
+}
+
+func newCertInfo(source, resource string, cert *x509.Certificate, now time.Time) CertInfo {
+	certType := leafType
+	if cert.IsCA {
+		certType = intermediateType
+		if bytes.Equal(cert.RawSubject, cert.RawIssuer) {
+			certType = rootType
+		}
+	}
+	expiresIn := cert.NotAfter.Sub(now)
+	return CertInfo{
+		Source:    source,
+		Resource:  resource,
+		Type:      certType,
+		Identity:  slices.Map(cert.URIs, func(u *url.URL) string { return u.String() }),
+		Subject:   cert.Subject.String(),
+		Serial:    fmt.Sprintf("%x", cert.SerialNumber),
+		NotBefore: cert.NotBefore,
+		NotAfter:  cert.NotAfter,
+		ExpiresIn: formatExpiry(expiresIn),
+		expiresIn: expiresIn,
+	}
+}
+
+func formatExpiry(d time.Duration) string {
+	if d <= 0 {
+		return "expired"
+	}
+	days := int(d.Hours()) / 24
+	if days > 0 {
+		return strconv.Itoa(days) + "d" + strconv.Itoa(int(d.Hours())%24) + "h"
+	}
+	return d.Truncate(time.Minute).String()
+}
+
+func parsePEM(b []byte) ([]*x509.Certificate, error) {
+	var certs []*x509.Certificate
+	for {
+		var block *pem.Block
+		block, b = pem.Decode(b)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		certs = append(certs, cert)
+	}
+	if len(certs) == 0 {
+		return nil, fmt.Errorf("no PEM encoded certificates found")
+	}
+	return certs, nil
+}
+
+func filterCerts(certs []CertInfo, opts filterOptions) []CertInfo {
+	return slices.Filter(certs, func(c CertInfo) bool {
+		if opts.expiresWithin > 0 && c.expiresIn > opts.expiresWithin {
+			return false
+		}
+		if opts.untrustedOnly && c.Trusted {
+			return false
+		}
+		if len(opts.types) > 0 && !slices.Contains(opts.types, c.Type) {
+			return false
+		}
+		return true
+	})
+}
+
+var reportColumns = []string{"SOURCE", "RESOURCE", "TYPE", "IDENTITY", "SERIAL", "NOT AFTER", "EXPIRES IN", "TRUSTED", "ISSUER CHAIN"}
+
+func printReport(w io.Writer, certs []CertInfo, format string) error {
+	switch format {
+	case "json":
+		b, err := json.MarshalIndent(certs, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(w, string(b))
+		return err
+	case "csv":
+		cw := csv.NewWriter(w)
+		if err := cw.Write(reportColumns); err != nil {
+			return err
+		}
+		for _, c := range certs {
+			if err := cw.Write(row(c)); err != nil {
+				return err
+			}
+		}
+		cw.Flush()
+		return cw.Error()
+	default:
+		if len(certs) == 0 {
+			_, err := fmt.Fprintln(w, "No certificates found.")
+			return err
+		}
+		tw := new(tabwriter.Writer).Init(w, 0, 8, 3, ' ', 0)
+		_, _ = fmt.Fprintln(tw, strings.Join(reportColumns, "\t"))
+		for _, c := range certs {
+			_, _ = fmt.Fprintln(tw, strings.Join(row(c), "\t"))
+		}
+		return tw.Flush()
+	}
+}
+
+func row(c CertInfo) []string {
+	identity := strings.Join(c.Identity, ",")
+	if identity == "" {
+		identity = "-"
+	}
+	issuers := strings.Join(c.IssuerChain, " <- ")
+	if issuers == "" {
+		issuers = "-"
+	}
+	return []string{
+		c.Source, c.Resource, c.Type, identity, c.Serial, c.NotAfter.UTC().Format(time.RFC3339), c.ExpiresIn,
+		strconv.FormatBool(c.Trusted), issuers,
+	}
+}
@@ -0,0 +1,218 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package certreport
+
+import (
+	"bytes"
+	"crypto/x509"
+	"strings"
+	"testing"
+	"time"
+
+	admin "github.com/envoyproxy/go-control-plane/envoy/admin/v3"
+	core "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	tls "github.com/envoyproxy/go-control-plane/envoy/extensions/transport_sockets/tls/v3"
+	"google.golang.org/protobuf/types/known/anypb"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"istio.io/istio/pilot/pkg/util/protoconv"
+	"istio.io/istio/pkg/kube"
+	"istio.io/istio/pkg/slices"
+	"istio.io/istio/pkg/test/util/assert"
+	"istio.io/istio/pkg/util/protomarshal"
+	"istio.io/istio/security/pkg/pki/util"
+)
+
+type testCA struct {
+	certPem []byte
+	cert    *x509.Certificate
+	key     any
+}
+
+func newTestCA(t *testing.T, org string, parent *testCA) testCA {
+	t.Helper()
+	opts := util.CertOptions{Org: org, TTL: 365 * 24 * time.Hour, IsCA: true, IsSelfSigned: parent == nil, ECSigAlg: util.EcdsaSigAlg}
+	if parent != nil {
+		opts.SignerCert, opts.SignerPriv = parent.cert, parent.key
+	}
+	certPem, keyPem, err := util.GenCertKeyFromOptions(opts)
+	assert.NoError(t, err)
+	cert, err := util.ParsePemEncodedCertificate(certPem)
+	assert.NoError(t, err)
+	key, err := util.ParsePemEncodedKey(keyPem)
+	assert.NoError(t, err)
+	return testCA{certPem: certPem, cert: cert, key: key}
+}
+
+func (c testCA) issue(t *testing.T, identity string, ttl time.Duration) []byte {
+	t.Helper()
+	certPem, _, err := util.GenCertKeyFromOptions(util.CertOptions{
+		Host: identity, TTL: ttl, SignerCert: c.cert, SignerPriv: c.key, ECSigAlg: util.EcdsaSigAlg,
+	})
+	assert.NoError(t, err)
+	return certPem
+}
+
+func configDump(t *testing.T, certChain, rootCA []byte) []byte {
+	t.Helper()
+	secrets := &admin.SecretsConfigDump{}
+	if certChain != nil {
+		secrets.DynamicActiveSecrets = append(secrets.DynamicActiveSecrets, &admin.SecretsConfigDump_DynamicSecret{
+			Name: "default",
+			Secret: protoconv.MessageToAny(&tls.Secret{Name: "default", Type: &tls.Secret_TlsCertificate{
+				TlsCertificate: &tls.TlsCertificate{
+					CertificateChain: &core.DataSource{Specifier: &core.DataSource_InlineBytes{InlineBytes: certChain}},
+				},
+			}}),
+		})
+	}
+	if rootCA != nil {
+		secrets.DynamicActiveSecrets = append(secrets.DynamicActiveSecrets, &admin.SecretsConfigDump_DynamicSecret{
+			Name: "ROOTCA",
+			Secret: protoconv.MessageToAny(&tls.Secret{Name: "ROOTCA", Type: &tls.Secret_ValidationContext{
+				ValidationContext: &tls.CertificateValidationContext{
+					TrustedCa: &core.DataSource{Specifier: &core.DataSource_InlineBytes{InlineBytes: rootCA}},
+				},
+			}}),
+		})
+	}
+	b, err := protomarshal.ToJSON(&admin.ConfigDump{Configs: []*anypb.Any{protoconv.MessageToAny(secrets)}})
+	assert.NoError(t, err)
+	return []byte(b)
+}
+
+func TestProxyCerts(t *testing.T) {
+	root := newTestCA(t, "root", nil)
+	intermediate := newTestCA(t, "intermediate", &root)
+	foreignRoot := newTestCA(t, "foreign", nil)
+	bundle, err := parseTrustBundle(root.certPem)
+	assert.NoError(t, err)
+	now := time.Now()
+
+	leaf := intermediate.issue(t, "spiffe://cluster.local/ns/default/sa/productpage", 24*time.Hour)
+	chain := append(append([]byte{}, leaf...), intermediate.certPem...)
+	roots := append(append([]byte{}, root.certPem...), foreignRoot.certPem...)
+	certs, err := proxyCerts("productpage.default", configDump(t, chain, roots), bundle, now)
+	assert.NoError(t, err)
+	assert.Equal(t, len(certs), 4)
+
+	assert.Equal(t, certs[0].Resource, "default")
+	assert.Equal(t, certs[0].Type, leafType)
+	assert.Equal(t, certs[0].Identity, []string{"spiffe://cluster.local/ns/default/sa/productpage"})
+	assert.Equal(t, certs[0].IssuerChain, []string{"O=intermediate"})
+	assert.Equal(t, certs[0].Trusted, true)
+	assert.Equal(t, certs[0].ExpiresIn, "23h59m0s")
+	assert.Equal(t, certs[1].Type, intermediateType)
+	assert.Equal(t, certs[1].IssuerChain, []string{"O=root"})
+	assert.Equal(t, certs[1].Trusted, true)
+
+	assert.Equal(t, certs[2].Resource, "ROOTCA")
+	assert.Equal(t, certs[2].Type, rootType)
+	assert.Equal(t, certs[2].Trusted, true)
+	assert.Equal(t, certs[3].Subject, "O=foreign")
+	assert.Equal(t, certs[3].Trusted, false)
+
+	// A cert issued by a root that is not in the trust bundle.
+	certs, err = proxyCerts("reviews.default", configDump(t, foreignRoot.issue(t, "spiffe://other/ns/default/sa/reviews", time.Hour), nil), bundle, now)
+	assert.NoError(t, err)
+	assert.Equal(t, len(certs), 1)
+	assert.Equal(t, certs[0].Trusted, false)
+	assert.Equal(t, certs[0].IssuerChain, []string{"O=foreign"})
+
+	// A chain ending in a trusted intermediate, with a leaf that was not issued by it.
+	forged := append(foreignRoot.issue(t, "spiffe://cluster.local/ns/default/sa/forged", time.Hour), intermediate.certPem...)
+	certs, err = proxyCerts("forged.default", configDump(t, forged, nil), bundle, now)
+	assert.NoError(t, err)
+	assert.Equal(t, len(certs), 2)
+	assert.Equal(t, certs[0].Trusted, false)
+	assert.Equal(t, certs[1].Trusted, true)
+
+	_, err = proxyCerts("broken.default", []byte("{}}"), bundle, now)
+	assert.Error(t, err)
+}
+
+func TestCollectIstiodCerts(t *testing.T) {
+	root := newTestCA(t, "root", nil)
+	intermediate := newTestCA(t, "intermediate", &root)
+	client := kube.NewFakeClient(
+		&corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "istio-ca-root-cert", Namespace: "istio-system"},
+			Data:       map[string]string{"root-cert.pem": string(root.certPem)},
+		},
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "cacerts", Namespace: "istio-system"},
+			Data: map[string][]byte{
+				"ca-cert.pem":    intermediate.certPem,
+				"cert-chain.pem": append(append([]byte{}, intermediate.certPem...), root.certPem...),
+			},
+		},
+	)
+	certs, bundle, err := collectIstiodCerts(client, "istio-system", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, len(bundle), 1)
+	assert.Equal(t, slices.Map(certs, func(c CertInfo) string {
+		return c.Resource + "/" + c.Type
+	}), []string{"configmap/istio-ca-root-cert/root", "secret/cacerts/intermediate", "secret/cacerts/root"})
+	for _, c := range certs {
+		assert.Equal(t, c.Source, istiodSource)
+		assert.Equal(t, c.Trusted, true)
+	}
+
+	// An explicit trust bundle takes precedence over the one distributed by Istiod.
+	other := newTestCA(t, "other", nil)
+	otherBundle, err := parseTrustBundle(other.certPem)
+	assert.NoError(t, err)
+	certs, _, err = collectIstiodCerts(client, "istio-system", otherBundle)
+	assert.NoError(t, err)
+	assert.Equal(t, certs[0].Trusted, false)
+}
+
+func TestFilterAndPrint(t *testing.T) {
+	notAfter := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	certs := []CertInfo{
+		{
+			Source: "productpage.default", Resource: "default", Type: leafType, Serial: "1",
+			Identity: []string{"spiffe://cluster.local/ns/default/sa/productpage"}, IssuerChain: []string{"O=intermediate", "O=root"},
+			NotAfter: notAfter, ExpiresIn: "1d0h", expiresIn: 24 * time.Hour, Trusted: true,
+		},
+		{
+			Source: "productpage.default", Resource: "ROOTCA", Type: rootType, Serial: "2",
+			NotAfter: notAfter, ExpiresIn: "365d0h", expiresIn: 365 * 24 * time.Hour,
+		},
+	}
+	assert.Equal(t, len(filterCerts(certs, filterOptions{})), 2)
+	assert.Equal(t, filterCerts(certs, filterOptions{expiresWithin: 48 * time.Hour})[0].Serial, "1")
+	assert.Equal(t, filterCerts(certs, filterOptions{untrustedOnly: true})[0].Serial, "2")
+	assert.Equal(t, filterCerts(certs, filterOptions{types: []string{rootType}})[0].Serial, "2")
+	assert.Equal(t, len(filterCerts(certs, filterOptions{types: []string{intermediateType}})), 0)
+
+	var out bytes.Buffer
+	assert.NoError(t, printReport(&out, certs, "csv"))
+	assert.Equal(t, out.String(), `SOURCE,RESOURCE,TYPE,IDENTITY,SERIAL,NOT AFTER,EXPIRES IN,TRUSTED,ISSUER CHAIN
+productpage.default,default,leaf,spiffe://cluster.local/ns/default/sa/productpage,1,2030-01-01T00:00:00Z,1d0h,true,O=intermediate <- O=root
+productpage.default,ROOTCA,root,-,2,2030-01-01T00:00:00Z,365d0h,false,-
+`)
+
+	out.Reset()
+	assert.NoError(t, printReport(&out, certs, "short"))
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	assert.Equal(t, len(lines), 3)
+	assert.Equal(t, strings.Fields(lines[2]), []string{"productpage.default", "ROOTCA", "root", "-", "2", "2030-01-01T00:00:00Z", "365d0h", "false", "-"})
+
+	out.Reset()
+	assert.NoError(t, printReport(&out, nil, "short"))
+	assert.Equal(t, out.String(), "No certificates found.\n")
+}
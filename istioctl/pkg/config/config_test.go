@@ -21,6 +21,7 @@ import (
 
 	"github.com/spf13/viper"
 
+	"istio.io/istio/istioctl/pkg/cli"
 	"istio.io/istio/istioctl/pkg/util/testutil"
 	"istio.io/istio/pkg/config/constants"
 )
@@ -50,7 +51,7 @@ xds-port                15012            default
 
 	for i, c := range cases {
 		t.Run(fmt.Sprintf("case %d %s", i, strings.Join(c.Args, " ")), func(t *testing.T) {
-			testutil.VerifyOutput(t, Cmd(), c)
+			testutil.VerifyOutput(t, Cmd(cli.NewFakeContext(nil)), c)
 		})
 	}
 }
@@ -0,0 +1,255 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	core "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	discovery "github.com/envoyproxy/go-control-plane/envoy/service/discovery/v3"
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/yaml"
+
+	"istio.io/istio/istioctl/pkg/cli"
+	"istio.io/istio/istioctl/pkg/clioptions"
+	"istio.io/istio/istioctl/pkg/multixds"
+	"istio.io/istio/istioctl/pkg/util"
+	"istio.io/istio/pilot/pkg/config/kube/crd"
+	"istio.io/istio/pilot/pkg/xds"
+	v3 "istio.io/istio/pilot/pkg/xds/v3"
+	"istio.io/istio/pkg/maps"
+	"istio.io/istio/pkg/slices"
+)
+
+type historyOptions struct {
+	kind   string
+	name   string
+	revert string
+	output string
+}
+
+// historyEntry is a config change seen by a single Istiod replica. Each replica keeps its own history and assigns
+// its own IDs, so a change is identified by the replica and the ID together.
+type historyEntry struct {
+	Istiod string `json:"istiod"`
+	xds.ConfigHistoryEntry
+}
+
+// ref returns the reference to the change accepted by --revert, in the form <istiod>/<id>.
+func (e historyEntry) ref() string {
+	return fmt.Sprintf("%s/%d", e.Istiod, e.ID)
+}
+
+func historyCommand(ctx cli.Context) *cobra.Command {
+	var opts clioptions.ControlPlaneOptions
+	var centralOpts clioptions.CentralControlPlaneOptions
+	var o historyOptions
+
+	cmd := &cobra.Command{
+		Use:   "history",
+		Short: "Show recent config changes seen by Istiod",
+		Long: `Show the most recent Istio config changes seen by Istiod, along with the push that first included them.
+Istiod keeps a bounded history in memory, configured by PILOT_CONFIG_HISTORY_SIZE, so older changes and changes
+from before Istiod started are not shown. Each Istiod replica keeps its own history, so changes are listed per
+replica and identified as <istiod>/<id>.`,
+		Example: `  # List recent config changes
+  istioctl x config history
+
+  # List recent changes to VirtualServices in the default namespace
+  istioctl x config history --kind VirtualService -n default
+
+  # Print the YAML needed to revert change 42 seen by istiod-5b9f7c7d8-abcde, and apply it
+  istioctl x config history --revert istiod-5b9f7c7d8-abcde/42 | kubectl apply -f -`,
+		Args: cobra.NoArgs,
+		RunE: func(c *cobra.Command, _ []string) error {
+			kubeClient, err := ctx.CLIClientWithRevision(opts.Revision)
+			if err != nil {
+				return err
+			}
+			xdsRequest := discovery.DiscoveryRequest{
+				ResourceNames: []string{"confighistory"},
+				Node: &core.Node{
+					Id: "debug~0.0.0.0~istioctl~cluster.local",
+				},
+				TypeUrl: v3.DebugType,
+			}
+			responses, err := multixds.MultiRequestAndProcessXds(false, &xdsRequest, centralOpts, ctx.IstioNamespace(),
+				"", "", kubeClient, multixds.DefaultOptions)
+			if err != nil {
+				return err
+			}
+			entries, err := parseHistory(responses)
+			if err != nil {
+				return err
+			}
+			if c.Flags().Changed("revert") {
+				return printRevert(c.OutOrStdout(), entries, o.revert)
+			}
+			entries = slices.Filter(entries, func(e historyEntry) bool {
+				return (o.kind == "" || e.Kind == o.kind) &&
+					(o.name == "" || e.Name == o.name) &&
+					(ctx.Namespace() == "" || e.Namespace == ctx.Namespace())
+			})
+			return printHistory(c.OutOrStdout(), entries, o.output)
+		},
+	}
+	cmd.Long += "\n\n" + util.ExperimentalMsg
+	opts.AttachControlPlaneFlags(cmd)
+	centralOpts.AttachControlPlaneFlags(cmd)
+	cmd.Flags().StringVar(&o.kind, "kind", "", "Only show changes to config of this kind, such as VirtualService")
+	cmd.Flags().StringVar(&o.name, "name", "", "Only show changes to config with this name")
+	cmd.Flags().StringVar(&o.revert, "revert", "",
+		"Print the YAML needed to revert the change with this ID, in the form <istiod>/<id>, instead of the history. "+
+			"The Istiod replica may be omitted if only one replica has a change with the ID")
+	cmd.Flags().StringVarP(&o.output, "output", "o", "short", "Output format: one of json|yaml|short")
+	return cmd
+}
+
+// parseHistory merges the histories of all Istiod replicas, ordered by time.
+func parseHistory(responses map[string]*discovery.DiscoveryResponse) ([]historyEntry, error) {
+	if len(responses) == 0 {
+		return nil, fmt.Errorf("no config history returned from Istiod")
+	}
+	var entries []historyEntry
+	for _, istiod := range slices.Sort(maps.Keys(responses)) {
+		for _, resource := range responses[istiod].Resources {
+			var history []xds.ConfigHistoryEntry
+			if err := json.Unmarshal(resource.Value, &history); err != nil {
+				return nil, fmt.Errorf("failed to parse config history from Istiod %s, it may not support it: %v", istiod, err)
+			}
+			for _, e := range history {
+				entries = append(entries, historyEntry{Istiod: istiod, ConfigHistoryEntry: e})
+			}
+		}
+	}
+	// The sort is stable, so changes seen by one replica at the same time stay in ID order.
+	slices.SortStableFunc(entries, func(a, b historyEntry) int {
+		return a.Time.Compare(b.Time)
+	})
+	return entries, nil
+}
+
+func printHistory(w io.Writer, entries []historyEntry, format string) error {
+	switch format {
+	case "json":
+		b, err := json.MarshalIndent(entries, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, _ = fmt.Fprintln(w, string(b))
+	case "yaml":
+		b, err := yaml.Marshal(entries)
+		if err != nil {
+			return err
+		}
+		_, _ = fmt.Fprint(w, string(b))
+	case "short":
+		tw := new(tabwriter.Writer).Init(w, 0, 8, 3, ' ', 0)
+		_, _ = fmt.Fprintln(tw, "ID\tTIME\tEVENT\tKIND\tNAME\tNAMESPACE\tPUSH VERSION")
+		for _, e := range entries {
+			pushVersion := e.PushVersion
+			if pushVersion == "" {
+				pushVersion = "<pending>"
+			}
+			_, _ = fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+				e.ref(), e.Time.Format(time.RFC3339), e.Event, e.Kind, e.Name, e.Namespace, pushVersion)
+		}
+		return tw.Flush()
+	default:
+		return fmt.Errorf("unknown output format %q, expected one of json|yaml|short", format)
+	}
+	return nil
+}
+
+// printRevert writes the YAML that undoes the change with the given reference. Additions are undone by deleting the
+// config, while updates and deletions are undone by applying the config from before the change.
+func printRevert(w io.Writer, entries []historyEntry, ref string) error {
+	idx, err := findChange(entries, ref)
+	if err != nil {
+		return err
+	}
+	e := entries[idx]
+	target := fmt.Sprintf("%s %s/%s", e.Kind, e.Namespace, e.Name)
+
+	obj, header := e.Before, fmt.Sprintf("# Change %s (%s) of %s. To revert it, apply the config:\n#   kubectl apply -f <this file>\n", e.ref(), e.Event, target)
+	if e.Event == "add" {
+		obj, header = e.After, fmt.Sprintf("# Change %s added %s. To revert it, delete the config:\n#   kubectl delete -f <this file>\n", e.ref(), target)
+	}
+	if obj == nil {
+		return fmt.Errorf("change %s has no config to revert to", e.ref())
+	}
+	_, _ = fmt.Fprint(w, header)
+	later := 0
+	for _, l := range entries[idx+1:] {
+		if l.Istiod == e.Istiod && l.Kind == e.Kind && l.Namespace == e.Namespace && l.Name == e.Name {
+			later++
+		}
+	}
+	if later > 0 {
+		_, _ = fmt.Fprintf(w, "# Warning: %s has %d later change(s), which will also be undone.\n", target, later)
+	}
+	b, err := yaml.Marshal(revertObject(*obj))
+	if err != nil {
+		return err
+	}
+	_, _ = fmt.Fprint(w, string(b))
+	return nil
+}
+
+// findChange returns the index of the change referenced by ref, either <istiod>/<id> or a bare ID that only one
+// replica has.
+func findChange(entries []historyEntry, ref string) (int, error) {
+	istiod, rawID := "", ref
+	if i := strings.LastIndex(ref, "/"); i >= 0 {
+		istiod, rawID = ref[:i], ref[i+1:]
+	}
+	id, err := strconv.ParseUint(rawID, 10, 64)
+	if err != nil {
+		return -1, fmt.Errorf("invalid change %q, expected <istiod>/<id>", ref)
+	}
+	idx := -1
+	for i, e := range entries {
+		if e.ID != id || (istiod != "" && e.Istiod != istiod) {
+			continue
+		}
+		if idx >= 0 && entries[idx].Istiod != e.Istiod {
+			return -1, fmt.Errorf("change %d was seen by more than one Istiod, specify one of %s or %s", id, entries[idx].ref(), e.ref())
+		}
+		idx = i
+	}
+	if idx < 0 {
+		return -1, fmt.Errorf("change %s not found, it may have been evicted from the history", ref)
+	}
+	return idx, nil
+}
+
+// revertObject strips the fields set by the API server, so the object can be applied.
+func revertObject(obj crd.IstioKind) crd.IstioKind {
+	obj.ObjectMeta = metav1.ObjectMeta{
+		Name:        obj.Name,
+		Namespace:   obj.Namespace,
+		Labels:      obj.Labels,
+		Annotations: obj.Annotations,
+	}
+	obj.Status = nil
+	return obj
+}
@@ -0,0 +1,205 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	discovery "github.com/envoyproxy/go-control-plane/envoy/service/discovery/v3"
+	"google.golang.org/protobuf/types/known/anypb"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"istio.io/istio/pilot/pkg/config/kube/crd"
+	"istio.io/istio/pilot/pkg/xds"
+	"istio.io/istio/pkg/slices"
+	"istio.io/istio/pkg/test/util/assert"
+)
+
+func historyObject(hosts string) *crd.IstioKind {
+	return &crd.IstioKind{
+		TypeMeta: metav1.TypeMeta{Kind: "VirtualService", APIVersion: "networking.istio.io/v1"},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              "reviews",
+			Namespace:         "default",
+			ResourceVersion:   "123",
+			CreationTimestamp: metav1.NewTime(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)),
+		},
+		Spec: []byte(`{"hosts":["` + hosts + `"]}`),
+	}
+}
+
+var istiodAHistory = []xds.ConfigHistoryEntry{
+	{
+		ID:          1,
+		Time:        time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		Event:       "add",
+		Kind:        "VirtualService",
+		Name:        "reviews",
+		Namespace:   "default",
+		After:       historyObject("v1"),
+		PushVersion: "2024-01-01T00:00:00Z/1",
+	},
+	{
+		ID:        2,
+		Time:      time.Date(2024, 1, 1, 0, 1, 0, 0, time.UTC),
+		Event:     "update",
+		Kind:      "VirtualService",
+		Name:      "reviews",
+		Namespace: "default",
+		Before:    historyObject("v1"),
+		After:     historyObject("v2"),
+	},
+}
+
+var istiodBHistory = []xds.ConfigHistoryEntry{
+	{
+		ID:          1,
+		Time:        time.Date(2024, 1, 1, 0, 0, 30, 0, time.UTC),
+		Event:       "add",
+		Kind:        "VirtualService",
+		Name:        "reviews",
+		Namespace:   "default",
+		After:       historyObject("v1"),
+		PushVersion: "2024-01-01T00:00:30Z/1",
+	},
+}
+
+func historyResponse(t *testing.T, history []xds.ConfigHistoryEntry) *discovery.DiscoveryResponse {
+	b, err := json.Marshal(history)
+	assert.NoError(t, err)
+	return &discovery.DiscoveryResponse{Resources: []*anypb.Any{{Value: b}}}
+}
+
+func testHistory(t *testing.T) []historyEntry {
+	entries, err := parseHistory(map[string]*discovery.DiscoveryResponse{
+		"istiod-a": historyResponse(t, istiodAHistory),
+		"istiod-b": historyResponse(t, istiodBHistory),
+	})
+	assert.NoError(t, err)
+	return entries
+}
+
+func TestParseHistory(t *testing.T) {
+	entries := testHistory(t)
+	assert.Equal(t, slices.Map(entries, historyEntry.ref), []string{"istiod-a/1", "istiod-b/1", "istiod-a/2"})
+
+	_, err := parseHistory(nil)
+	assert.Error(t, err)
+	_, err = parseHistory(map[string]*discovery.DiscoveryResponse{
+		"istiod-a": {Resources: []*anypb.Any{{Value: []byte("not json")}}},
+	})
+	assert.Error(t, err)
+}
+
+func TestPrintHistory(t *testing.T) {
+	var out bytes.Buffer
+	assert.NoError(t, printHistory(&out, testHistory(t), "short"))
+	assert.Equal(t, out.String(),
+		`ID           TIME                   EVENT    KIND             NAME      NAMESPACE   PUSH VERSION
+istiod-a/1   2024-01-01T00:00:00Z   add      VirtualService   reviews   default     2024-01-01T00:00:00Z/1
+istiod-b/1   2024-01-01T00:00:30Z   add      VirtualService   reviews   default     2024-01-01T00:00:30Z/1
+istiod-a/2   2024-01-01T00:01:00Z   update   VirtualService   reviews   default     <pending>
+`)
+	assert.Error(t, printHistory(&out, testHistory(t), "table"))
+}
+
+func TestPrintRevert(t *testing.T) {
+	cases := []struct {
+		name     string
+		ref      string
+		expected string
+		err      bool
+	}{
+		{
+			name: "add",
+			ref:  "istiod-a/1",
+			expected: `# Change istiod-a/1 added VirtualService default/reviews. To revert it, delete the config:
+#   kubectl delete -f <this file>
+# Warning: VirtualService default/reviews has 1 later change(s), which will also be undone.
+apiVersion: networking.istio.io/v1
+kind: VirtualService
+metadata:
+  creationTimestamp: null
+  name: reviews
+  namespace: default
+spec:
+  hosts:
+  - v1
+`,
+		},
+		{
+			name: "update bare ID",
+			ref:  "2",
+			expected: `# Change istiod-a/2 (update) of VirtualService default/reviews. To revert it, apply the config:
+#   kubectl apply -f <this file>
+apiVersion: networking.istio.io/v1
+kind: VirtualService
+metadata:
+  creationTimestamp: null
+  name: reviews
+  namespace: default
+spec:
+  hosts:
+  - v1
+`,
+		},
+		{
+			name: "other istiod",
+			ref:  "istiod-b/1",
+			expected: `# Change istiod-b/1 added VirtualService default/reviews. To revert it, delete the config:
+#   kubectl delete -f <this file>
+apiVersion: networking.istio.io/v1
+kind: VirtualService
+metadata:
+  creationTimestamp: null
+  name: reviews
+  namespace: default
+spec:
+  hosts:
+  - v1
+`,
+		},
+		{
+			name: "ambiguous bare ID",
+			ref:  "1",
+			err:  true,
+		},
+		{
+			name: "invalid",
+			ref:  "istiod-a/latest",
+			err:  true,
+		},
+		{
+			name: "evicted",
+			ref:  "istiod-a/3",
+			err:  true,
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			err := printRevert(&out, testHistory(t), tt.ref)
+			if tt.err {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, out.String(), tt.expected)
+		})
+	}
+}
@@ -32,6 +32,7 @@ import (
 	"istio.io/istio/istioctl/pkg/completion"
 	"istio.io/istio/istioctl/pkg/kubeinject"
 	istioctlutil "istio.io/istio/istioctl/pkg/util"
+	"istio.io/istio/istioctl/pkg/writer/compare"
 	sdscompare "istio.io/istio/istioctl/pkg/writer/compare/sds"
 	"istio.io/istio/istioctl/pkg/writer/envoy/clusters"
 	"istio.io/istio/istioctl/pkg/writer/envoy/configdump"
@@ -1267,6 +1268,104 @@ func rootCACompareConfigCmd(ctx cli.Context) *cobra.Command {
 	return rootCACompareConfigCmd
 }
 
+func diffConfigCmd(ctx cli.Context) *cobra.Command {
+	var beforeFile string
+
+	diffConfigCmd := &cobra.Command{
+		Use:   "diff [<type>/]<name-1>[.<namespace-1>] [[<type>/]<name-2>[.<namespace-2>]]",
+		Short: "Compare the configuration of two proxies, or of a proxy at two points in time",
+		Long: `Compare the clusters, listeners, routes and endpoints of two Envoy instances, or of the same Envoy instance
+at two points in time. Version information and the ordering of resources are ignored, so only differences
+that affect traffic are reported.`,
+		Example: `  # Compare the configuration of two replicas.
+  istioctl proxy-config diff <pod-name-1[.namespace]> <pod-name-2[.namespace]>
+
+  # Compare the configuration of a pod with a config dump taken earlier.
+  istioctl proxy-config all <pod-name[.namespace]> -o json > before.json
+  istioctl proxy-config diff <pod-name[.namespace]> --before before.json
+
+  # Compare two config dumps without using Kubernetes API
+  istioctl proxy-config diff --before before.json --file after.json`,
+		Args: func(cmd *cobra.Command, args []string) error {
+			if len(diffSources(beforeFile, args)) != 2 {
+				cmd.Println(cmd.UsageString())
+				return fmt.Errorf("diff requires two pods, or a pod or --file and a --before config dump")
+			}
+			return nil
+		},
+		RunE: func(c *cobra.Command, args []string) error {
+			var names []string
+			var dumps [][]byte
+			for _, source := range diffSources(beforeFile, args) {
+				var name string
+				var dump []byte
+				var err error
+				if source.file != "" {
+					name = source.file
+					dump, err = readFile(source.file)
+				} else {
+					// The client is only created for pods, so that config dump files can be compared offline.
+					var kubeClient kube.CLIClient
+					if kubeClient, err = ctx.CLIClient(); err != nil {
+						return err
+					}
+					var podName, podNamespace string
+					if podName, podNamespace, err = getPodName(ctx, source.pod); err != nil {
+						return err
+					}
+					name = podName + "." + podNamespace
+					dump, err = extractConfigDump(kubeClient, podName, podNamespace, true)
+				}
+				if err != nil {
+					return err
+				}
+				names = append(names, name)
+				dumps = append(dumps, dump)
+			}
+
+			comparator, err := compare.NewProxyComparator(c.OutOrStdout(), names[0], dumps[0], names[1], dumps[1])
+			if err != nil {
+				return err
+			}
+			differs, err := comparator.Diff()
+			if err != nil {
+				return err
+			}
+			if differs {
+				return fmt.Errorf("configuration of %s and %s differs", names[0], names[1])
+			}
+			return nil
+		},
+		ValidArgsFunction: completion.ValidPodsNameArgs(ctx),
+	}
+
+	diffConfigCmd.PersistentFlags().StringVar(&beforeFile, "before", "",
+		"Envoy config dump JSON file to compare against, as returned by 'istioctl proxy-config all -o json'")
+	diffConfigCmd.PersistentFlags().StringVarP(&configDumpFile, "file", "f", "",
+		"Envoy config dump JSON file to use instead of a pod")
+	return diffConfigCmd
+}
+
+// diffSource is one side of a proxy-config diff: either a pod or a config dump file.
+type diffSource struct {
+	pod, file string
+}
+
+// diffSources returns the sources to compare, in order: the --before file, the pods, then the --file.
+func diffSources(beforeFile string, args []string) []diffSource {
+	var sources []diffSource
+	if beforeFile != "" {
+		sources = append(sources, diffSource{file: beforeFile})
+	}
+	for _, arg := range args {
+		sources = append(sources, diffSource{pod: arg})
+	}
+	if configDumpFile != "" {
+		sources = append(sources, diffSource{file: configDumpFile})
+	}
+	return sources
+}
+
 func extractRootCA(client kube.CLIClient, podName, podNamespace string, out io.Writer) (string, error) {
 	configWriter, err := setupPodConfigdumpWriter(client, podName, podNamespace, false, out)
 	if err != nil {
@@ -1298,6 +1397,7 @@ func ProxyConfig(ctx cli.Context) *cobra.Command {
 	configCmd.AddCommand(edsConfigCmd(ctx))
 	configCmd.AddCommand(secretConfigCmd(ctx))
 	configCmd.AddCommand(rootCACompareConfigCmd(ctx))
+	configCmd.AddCommand(diffConfigCmd(ctx))
 	configCmd.AddCommand(ecdsConfigCmd(ctx))
 
 	return configCmd
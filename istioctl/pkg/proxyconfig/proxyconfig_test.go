@@ -16,6 +16,7 @@ package proxyconfig
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
 	"net/http"
 	"strings"
@@ -49,6 +50,10 @@ func TestProxyConfig(t *testing.T) {
 		"details-v1-5b7f94f9bc-wp5tb": util.ReadFile(t, "../writer/envoy/logging/testdata/logging.txt"),
 		"httpbin-794b576b6c-qx6pf":    []byte("{}"),
 	}
+	diffConfig := map[string][]byte{
+		"reviews-v1": util.ReadFile(t, "../writer/compare/testdata/configdump.json"),
+		"reviews-v2": util.ReadFile(t, "../writer/compare/testdata/configdump_diff.json"),
+	}
 	cases := []execTestCase{
 		{
 			args:           []string{},
@@ -163,6 +168,22 @@ func TestProxyConfig(t *testing.T) {
 			expectedString:   `config dump has no configuration type`,
 			wantException:    true,
 		},
+		{ // diff requires two sources
+			args:           strings.Split("diff reviews-v1", " "),
+			expectedString: "diff requires two pods, or a pod or --file and a --before config dump",
+			wantException:  true,
+		},
+		{ // diff of two pods with different config
+			execClientConfig: diffConfig,
+			args:             strings.Split("diff reviews-v1 reviews-v2", " "),
+			expectedString:   "Only in reviews-v2.default: inbound-vip|9999|http|ratings.default.svc.cluster.local",
+			wantException:    true,
+		},
+		{ // diff of a pod against an earlier config dump
+			execClientConfig: diffConfig,
+			args:             strings.Split("diff reviews-v1 --before ../writer/compare/testdata/configdump.json", " "),
+			expectedString:   "Clusters Match\nListeners Match\nRoutes Match\nEndpoints Match\n",
+		},
 	}
 
 	for i, c := range cases {
@@ -175,6 +196,37 @@ func TestProxyConfig(t *testing.T) {
 	}
 }
 
+// offlineContext is a context without access to Kubernetes.
+type offlineContext struct {
+	cli.Context
+}
+
+func (offlineContext) CLIClient() (kube.CLIClient, error) {
+	return nil, errors.New("no Kubernetes access")
+}
+
+func TestDiffConfigOffline(t *testing.T) {
+	cases := []execTestCase{
+		{ // two config dumps are compared without Kubernetes
+			args: strings.Split("diff --before ../writer/compare/testdata/configdump.json "+
+				"--file ../writer/compare/testdata/configdump_diff.json", " "),
+			expectedString: "Only in ../writer/compare/testdata/configdump_diff.json: inbound-vip|9999|http|ratings.default.svc.cluster.local",
+			wantException:  true,
+		},
+		{ // a pod still requires Kubernetes
+			args:           strings.Split("diff reviews-v1 --before ../writer/compare/testdata/configdump.json", " "),
+			expectedString: "no Kubernetes access",
+			wantException:  true,
+		},
+	}
+	for i, c := range cases {
+		t.Run(fmt.Sprintf("case %d %s", i, strings.Join(c.args, " ")), func(t *testing.T) {
+			ctx := offlineContext{cli.NewFakeContext(&cli.NewFakeContextOption{Namespace: "default"})}
+			verifyExecTestOutput(t, ProxyConfig(ctx), c)
+		})
+	}
+}
+
 func verifyExecTestOutput(t *testing.T, cmd *cobra.Command, c execTestCase) {
 	t.Helper()
 
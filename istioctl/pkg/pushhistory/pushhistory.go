@@ -0,0 +1,175 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pushhistory
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	core "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	discovery "github.com/envoyproxy/go-control-plane/envoy/service/discovery/v3"
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/yaml"
+
+	"istio.io/istio/istioctl/pkg/cli"
+	"istio.io/istio/istioctl/pkg/clioptions"
+	"istio.io/istio/istioctl/pkg/completion"
+	"istio.io/istio/istioctl/pkg/multixds"
+	"istio.io/istio/istioctl/pkg/util"
+	networkutil "istio.io/istio/pilot/pkg/networking/util"
+	"istio.io/istio/pilot/pkg/xds"
+	v3 "istio.io/istio/pilot/pkg/xds/v3"
+	"istio.io/istio/pkg/maps"
+	"istio.io/istio/pkg/slices"
+)
+
+func Cmd(ctx cli.Context) *cobra.Command {
+	var opts clioptions.ControlPlaneOptions
+	var centralOpts clioptions.CentralControlPlaneOptions
+	var output string
+
+	cmd := &cobra.Command{
+		Use:   "push-history <pod-name[.namespace]>",
+		Short: "Show recent pushes from Istiod to a proxy, and why they happened",
+		Long: `Show the most recent pushes from Istiod to a proxy. For each push, this shows the reasons and configs that
+triggered it, along with the xDS types sent, their size, and how long they took to generate and send.
+Istiod keeps a bounded history for each connection, configured by PILOT_PUSH_HISTORY_SIZE, so only recent pushes
+over the current connection are shown.`,
+		Example: `  # Show recent pushes to a pod
+  istioctl x push-history productpage-v1-bb495f7d7-2xv5f.default
+
+  # Show recent pushes to a pod of a deployment, as JSON
+  istioctl x push-history deployment/productpage-v1 -o json`,
+		Args: func(cmd *cobra.Command, args []string) error {
+			if len(args) != 1 {
+				cmd.Println(cmd.UsageString())
+				return fmt.Errorf("push-history requires pod name")
+			}
+			return nil
+		},
+		RunE: func(c *cobra.Command, args []string) error {
+			kubeClient, err := ctx.CLIClientWithRevision(opts.Revision)
+			if err != nil {
+				return err
+			}
+			podName, ns, err := ctx.InferPodInfoFromTypedResource(args[0], ctx.Namespace())
+			if err != nil {
+				return err
+			}
+			proxyID := podName + "." + ns
+			xdsRequest := discovery.DiscoveryRequest{
+				ResourceNames: []string{"connections?proxyID=" + proxyID},
+				Node: &core.Node{
+					Id: "debug~0.0.0.0~istioctl~cluster.local",
+				},
+				TypeUrl: v3.DebugType,
+			}
+			// The proxy is only connected to one Istiod, so ask all of them.
+			responses, err := multixds.MultiRequestAndProcessXds(true, &xdsRequest, centralOpts, ctx.IstioNamespace(),
+				"", "", kubeClient, multixds.DefaultOptions)
+			if err != nil {
+				return err
+			}
+			client, err := findClient(responses)
+			if err != nil {
+				return fmt.Errorf("%s: %v", proxyID, err)
+			}
+			return printHistory(c.OutOrStdout(), client, output)
+		},
+		ValidArgsFunction: completion.ValidPodsNameArgs(ctx),
+	}
+	cmd.Long += "\n\n" + util.ExperimentalMsg
+	opts.AttachControlPlaneFlags(cmd)
+	centralOpts.AttachControlPlaneFlags(cmd)
+	cmd.Flags().StringVarP(&output, "output", "o", "short", "Output format: one of json|yaml|short")
+	return cmd
+}
+
+// findClient returns the connection from the Istiod the proxy is connected to. Other Istiods respond with an error.
+func findClient(responses map[string]*discovery.DiscoveryResponse) (xds.AdsClient, error) {
+	for _, response := range responses {
+		for _, resource := range response.Resources {
+			var clients xds.AdsClients
+			if err := json.Unmarshal(resource.Value, &clients); err != nil {
+				continue
+			}
+			if len(clients.Connected) > 0 {
+				return clients.Connected[0], nil
+			}
+		}
+	}
+	return xds.AdsClient{}, fmt.Errorf("proxy is not connected to any Istiod")
+}
+
+func printHistory(w io.Writer, client xds.AdsClient, format string) error {
+	switch format {
+	case "json":
+		b, err := json.MarshalIndent(client.PushHistory, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, _ = fmt.Fprintln(w, string(b))
+	case "yaml":
+		b, err := yaml.Marshal(client.PushHistory)
+		if err != nil {
+			return err
+		}
+		_, _ = fmt.Fprint(w, string(b))
+	case "short":
+		_, _ = fmt.Fprintf(w, "Connection %s, connected at %s\n", client.ConnectionID, client.ConnectedAt.Format(time.RFC3339))
+		tw := new(tabwriter.Writer).Init(w, 0, 8, 3, ' ', 0)
+		_, _ = fmt.Fprintln(tw, "TIME\tVERSION\tFULL\tDURATION\tREASONS\tCONFIGS\tTYPES")
+		for _, p := range client.PushHistory {
+			_, _ = fmt.Fprintf(tw, "%s\t%s\t%v\t%s\t%s\t%s\t%s\n",
+				p.Time.Format(time.RFC3339), p.Version, p.Full, p.Duration, reasons(p), configs(p), types(p))
+		}
+		return tw.Flush()
+	default:
+		return fmt.Errorf("unknown output format %q, expected one of json|yaml|short", format)
+	}
+	return nil
+}
+
+func reasons(p xds.PushHistoryEntry) string {
+	if len(p.Reasons) == 0 {
+		return "<none>"
+	}
+	out := make([]string, 0, len(p.Reasons))
+	for _, r := range slices.Sort(maps.Keys(p.Reasons)) {
+		out = append(out, fmt.Sprintf("%s:%d", r, p.Reasons[r]))
+	}
+	return strings.Join(out, ",")
+}
+
+func configs(p xds.PushHistoryEntry) string {
+	if len(p.ConfigsUpdated) == 0 {
+		return "<all>"
+	}
+	out := strings.Join(p.ConfigsUpdated, ",")
+	if p.OmittedConfigs > 0 {
+		out += fmt.Sprintf(" and %d more", p.OmittedConfigs)
+	}
+	return out
+}
+
+func types(p xds.PushHistoryEntry) string {
+	return strings.Join(slices.Map(p.Types, func(t xds.PushedType) string {
+		return fmt.Sprintf("%s(%d,%s)", v3.GetShortType(t.TypeURL), t.Resources, networkutil.ByteCount(t.Bytes))
+	}), " ")
+}
@@ -0,0 +1,83 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pushhistory
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	discovery "github.com/envoyproxy/go-control-plane/envoy/service/discovery/v3"
+	"google.golang.org/protobuf/types/known/anypb"
+
+	"istio.io/istio/pilot/pkg/model"
+	"istio.io/istio/pilot/pkg/xds"
+	v3 "istio.io/istio/pilot/pkg/xds/v3"
+	"istio.io/istio/pkg/test/util/assert"
+)
+
+func TestFindClient(t *testing.T) {
+	responses := map[string]*discovery.DiscoveryResponse{
+		"istiod-1": {Resources: []*anypb.Any{{Value: []byte(`{"statusCode":"404"}Proxy not connected to this Pilot instance.`)}}},
+		"istiod-2": {Resources: []*anypb.Any{{Value: []byte(`{"totalClients":1,"clients":[{"connectionId":"productpage.default-1"}]}`)}}},
+	}
+	client, err := findClient(responses)
+	assert.NoError(t, err)
+	assert.Equal(t, client.ConnectionID, "productpage.default-1")
+
+	delete(responses, "istiod-2")
+	_, err = findClient(responses)
+	assert.Error(t, err)
+}
+
+func TestPrintHistory(t *testing.T) {
+	client := xds.AdsClient{
+		ConnectionID: "productpage.default-1",
+		ConnectedAt:  time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		PushHistory: []xds.PushHistoryEntry{
+			{
+				Time:     time.Date(2024, 1, 1, 0, 1, 0, 0, time.UTC),
+				Version:  "2024-01-01T00:01:00Z/5",
+				Full:     true,
+				Reasons:  model.NewReasonStats(model.ConfigUpdate, model.ConfigUpdate, model.EndpointUpdate),
+				Duration: "3ms",
+				ConfigsUpdated: []string{
+					"VirtualService/default/reviews",
+					"DestinationRule/default/reviews",
+				},
+				OmittedConfigs: 3,
+				Types: []xds.PushedType{
+					{TypeURL: v3.ClusterType, Resources: 3, Bytes: 2048},
+					{TypeURL: v3.ListenerType, Resources: 2, Bytes: 512},
+				},
+			},
+			{
+				Time:     time.Date(2024, 1, 1, 0, 2, 0, 0, time.UTC),
+				Version:  "2024-01-01T00:02:00Z/6",
+				Duration: "1ms",
+				Types: []xds.PushedType{
+					{TypeURL: v3.EndpointType, Resources: 1, Bytes: 100},
+				},
+			},
+		},
+	}
+	var out bytes.Buffer
+	assert.NoError(t, printHistory(&out, client, "short"))
+	assert.Equal(t, out.String(), `Connection productpage.default-1, connected at 2024-01-01T00:00:00Z
+TIME                   VERSION                  FULL    DURATION   REASONS               CONFIGS                                                                     TYPES
+2024-01-01T00:01:00Z   2024-01-01T00:01:00Z/5   true    3ms        config:2,endpoint:1   VirtualService/default/reviews,DestinationRule/default/reviews and 3 more   CDS(3,2.0kB) LDS(2,512B)
+2024-01-01T00:02:00Z   2024-01-01T00:02:00Z/6   false   1ms        <none>                <all>                                                                       EDS(1,100B)
+`)
+}
@@ -0,0 +1,499 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package simulate
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+
+	cluster "github.com/envoyproxy/go-control-plane/envoy/config/cluster/v3"
+	listener "github.com/envoyproxy/go-control-plane/envoy/config/listener/v3"
+	route "github.com/envoyproxy/go-control-plane/envoy/config/route/v3"
+	tlsv3 "github.com/envoyproxy/go-control-plane/envoy/extensions/transport_sockets/tls/v3"
+	"github.com/spf13/cobra"
+	"google.golang.org/protobuf/types/known/anypb"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	klabels "k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/yaml"
+
+	"istio.io/istio/istioctl/pkg/cli"
+	"istio.io/istio/istioctl/pkg/completion"
+	"istio.io/istio/istioctl/pkg/util"
+	"istio.io/istio/istioctl/pkg/util/configdump"
+	"istio.io/istio/pilot/pkg/model"
+	"istio.io/istio/pilot/pkg/simulation"
+	"istio.io/istio/pkg/config/schema/collections"
+	"istio.io/istio/pkg/config/schema/resource"
+	"istio.io/istio/pkg/test/util/yml"
+)
+
+type options struct {
+	files          []string
+	configDumpFile string
+
+	workload  string
+	labels    string
+	ip        string
+	proxyType string
+
+	address  string
+	port     int
+	host     string
+	path     string
+	headers  []string
+	protocol string
+	tls      string
+	sni      string
+	alpn     string
+	mode     string
+
+	output string
+}
+
+// Output describes where a simulated request ended up.
+type Output struct {
+	Listener     string `json:"listener,omitempty"`
+	FilterChain  string `json:"filterChain,omitempty"`
+	RouteConfig  string `json:"routeConfig,omitempty"`
+	VirtualHost  string `json:"virtualHost,omitempty"`
+	Route        string `json:"route,omitempty"`
+	Cluster      string `json:"cluster,omitempty"`
+	MTLSRequired bool   `json:"mtlsRequired"`
+	UpstreamTLS  string `json:"upstreamTLS,omitempty"`
+	Error        string `json:"error,omitempty"`
+}
+
+// Cmd returns the `simulate` command, which traces a synthetic request through proxy configuration offline.
+func Cmd(ctx cli.Context) *cobra.Command {
+	o := &options{}
+	cmd := &cobra.Command{
+		Use:   "simulate [<type>/]<name>[.<namespace>]",
+		Short: "Simulate a request against proxy configuration",
+		Long: `Simulate traces a synthetic request through the listeners, filter chains, routes and clusters of a proxy
+and reports which of them the request would hit.
+
+The proxy configuration is either generated offline from a set of Istio and Kubernetes YAML files with -f,
+read from an Envoy config dump file with --config-dump, or fetched from a running pod.`,
+		Example: `  # Simulate an outbound HTTP request from a workload defined in local files
+  istioctl x simulate -f services.yaml -f routing.yaml --workload productpage-v1 \
+    --port 9080 --host reviews:9080 --path /reviews/1
+
+  # Simulate an inbound mTLS request against a live pod
+  istioctl x simulate reviews-v1-5b8d5d9f4d-xk2fv --mode inbound --port 9080 --tls mtls
+
+  # Simulate a TLS request through a gateway from a saved config dump
+  istioctl x simulate --config-dump gateway.json --mode gateway --port 443 --tls tls --host example.com`,
+		Args: func(cmd *cobra.Command, args []string) error {
+			if len(args) > 1 {
+				return fmt.Errorf("simulate accepts at most one pod, found %d", len(args))
+			}
+			sources := 0
+			if len(args) == 1 {
+				sources++
+			}
+			if len(o.files) > 0 {
+				sources++
+			}
+			if o.configDumpFile != "" {
+				sources++
+			}
+			if sources != 1 {
+				return fmt.Errorf("exactly one of a pod, --file or --config-dump must be provided")
+			}
+			if o.port == 0 {
+				return fmt.Errorf("--port is required")
+			}
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			call, err := o.call()
+			if err != nil {
+				return err
+			}
+			var out Output
+			switch {
+			case len(o.files) > 0:
+				proxy, kubeObjects, istioConfig, err := o.loadFiles(ctx.NamespaceOrDefault(ctx.Namespace()))
+				if err != nil {
+					return err
+				}
+				out, err = simulateFromConfig(proxy, kubeObjects, istioConfig, call)
+				if err != nil {
+					return err
+				}
+			default:
+				var dump *configdump.Wrapper
+				if o.configDumpFile != "" {
+					dump, err = configDumpFromFile(o.configDumpFile)
+				} else {
+					dump, err = configDumpFromPod(ctx, args[0])
+				}
+				if err != nil {
+					return err
+				}
+				out, err = simulateFromConfigDump(dump, call)
+				if err != nil {
+					return err
+				}
+			}
+			return printOutput(cmd.OutOrStdout(), out, o.output)
+		},
+		ValidArgsFunction: completion.ValidPodsNameArgs(ctx),
+	}
+	cmd.Long += "\n\n" + util.ExperimentalMsg
+
+	flags := cmd.Flags()
+	flags.StringArrayVarP(&o.files, "file", "f", nil, "Istio and Kubernetes YAML files to generate proxy configuration from")
+	flags.StringVar(&o.configDumpFile, "config-dump", "", "Envoy config dump JSON file to simulate against")
+	flags.StringVar(&o.workload, "workload", "",
+		"Name of a Pod in the input files, as <name>[.<namespace>], to generate configuration for")
+	flags.StringVarP(&o.labels, "labels", "l", "", "Labels of the workload to generate configuration for, when --workload is not set")
+	flags.StringVar(&o.ip, "ip", "", "IP address of the workload to generate configuration for, when --workload is not set")
+	flags.StringVar(&o.proxyType, "proxy-type", string(model.SidecarProxy),
+		"Type of proxy to generate configuration for, one of sidecar or router")
+
+	flags.StringVar(&o.address, "address", "", "Destination IP address of the request")
+	flags.IntVar(&o.port, "port", 0, "Destination port of the request")
+	flags.StringVar(&o.host, "host", "", "Host header (and, for TLS requests, SNI) of the request")
+	flags.StringVar(&o.path, "path", "/", "Path of the request")
+	flags.StringArrayVarP(&o.headers, "header", "H", nil, "Request header in the form <name>: <value>; may be repeated")
+	flags.StringVar(&o.protocol, "protocol", string(simulation.HTTP), "Protocol of the request, one of http, http2 or tcp")
+	flags.StringVar(&o.tls, "tls", string(simulation.Plaintext), "TLS mode of the request, one of plaintext, tls or mtls")
+	flags.StringVar(&o.sni, "sni", "", "SNI of the request, defaults to the host for TLS requests")
+	flags.StringVar(&o.alpn, "alpn", "", "ALPN of the request, defaults based on protocol and TLS mode")
+	flags.StringVar(&o.mode, "mode", string(simulation.CallModeOutbound),
+		"How the request reaches the proxy, one of outbound, inbound or gateway")
+	flags.StringVarP(&o.output, "output", "o", "short", "Output format: one of json|yaml|short")
+	return cmd
+}
+
+func (o *options) call() (simulation.Call, error) {
+	headers := http.Header{}
+	for _, h := range o.headers {
+		k, v, ok := strings.Cut(h, ":")
+		if !ok {
+			return simulation.Call{}, fmt.Errorf("invalid header %q, expected <name>: <value>", h)
+		}
+		headers.Add(strings.TrimSpace(k), strings.TrimSpace(v))
+	}
+	protocol := simulation.Protocol(o.protocol)
+	switch protocol {
+	case simulation.HTTP, simulation.HTTP2, simulation.TCP:
+	default:
+		return simulation.Call{}, fmt.Errorf("unknown protocol %q", o.protocol)
+	}
+	tls := simulation.TLSMode(o.tls)
+	switch tls {
+	case simulation.Plaintext, simulation.TLS, simulation.MTLS:
+	default:
+		return simulation.Call{}, fmt.Errorf("unknown TLS mode %q", o.tls)
+	}
+	mode := simulation.CallMode(o.mode)
+	switch mode {
+	case simulation.CallModeOutbound, simulation.CallModeInbound, simulation.CallModeGateway:
+	default:
+		return simulation.Call{}, fmt.Errorf("unknown mode %q", o.mode)
+	}
+	return simulation.Call{
+		Address:    o.address,
+		Port:       o.port,
+		Path:       o.path,
+		Protocol:   protocol,
+		TLS:        tls,
+		Alpn:       o.alpn,
+		HostHeader: o.host,
+		Headers:    headers,
+		Sni:        o.sni,
+		CallMode:   mode,
+	}, nil
+}
+
+// loadFiles splits the input files into Istio configuration and Kubernetes objects, and builds the proxy
+// the configuration will be generated for.
+func (o *options) loadFiles(namespace string) (*model.Proxy, string, string, error) {
+	var kubeObjects, istioConfig []string
+	var pods []*corev1.Pod
+	for _, f := range o.files {
+		b, err := os.ReadFile(f)
+		if err != nil {
+			return nil, "", "", err
+		}
+		for _, doc := range yml.SplitString(string(b)) {
+			var tm metav1.TypeMeta
+			if err := yaml.Unmarshal([]byte(doc), &tm); err != nil || tm.Kind == "" {
+				continue
+			}
+			gvk := tm.GroupVersionKind()
+			if _, f := collections.PilotGatewayAPI().FindByGroupVersionAliasesKind(resource.FromKubernetesGVK(&gvk)); f {
+				istioConfig = append(istioConfig, doc)
+				continue
+			}
+			kubeObjects = append(kubeObjects, doc)
+			if tm.Kind == "Pod" {
+				pod := &corev1.Pod{}
+				if err := yaml.Unmarshal([]byte(doc), pod); err != nil {
+					return nil, "", "", fmt.Errorf("invalid pod in %v: %v", f, err)
+				}
+				if pod.Namespace == "" {
+					pod.Namespace = "default"
+				}
+				pods = append(pods, pod)
+			}
+		}
+	}
+	proxy, err := o.proxy(namespace, pods)
+	if err != nil {
+		return nil, "", "", err
+	}
+	return proxy, yml.JoinString(kubeObjects...), yml.JoinString(istioConfig...), nil
+}
+
+func (o *options) proxy(namespace string, pods []*corev1.Pod) (*model.Proxy, error) {
+	proxyType := model.NodeType(o.proxyType)
+	if proxyType != model.SidecarProxy && proxyType != model.Router {
+		return nil, fmt.Errorf("unknown proxy type %q", o.proxyType)
+	}
+	p := &model.Proxy{
+		Type:     proxyType,
+		Metadata: &model.NodeMetadata{},
+	}
+	if o.workload != "" {
+		name, ns, _ := strings.Cut(o.workload, ".")
+		if ns == "" {
+			ns = namespace
+		}
+		var pod *corev1.Pod
+		for _, candidate := range pods {
+			if candidate.Name == name && candidate.Namespace == ns {
+				pod = candidate
+				break
+			}
+		}
+		if pod == nil {
+			return nil, fmt.Errorf("pod %s.%s not found in the input files", name, ns)
+		}
+		p.Labels = pod.Labels
+		p.ConfigNamespace = pod.Namespace
+		p.ID = pod.Name + "." + pod.Namespace
+		if pod.Status.PodIP != "" {
+			p.IPAddresses = []string{pod.Status.PodIP}
+		}
+	} else {
+		l, err := klabels.ConvertSelectorToLabelsMap(o.labels)
+		if err != nil {
+			return nil, fmt.Errorf("invalid labels %q: %v", o.labels, err)
+		}
+		p.Labels = l
+		p.ConfigNamespace = namespace
+		if o.ip != "" {
+			p.IPAddresses = []string{o.ip}
+		}
+	}
+	p.Metadata.Labels = p.Labels
+	p.Metadata.Namespace = p.ConfigNamespace
+	return p, nil
+}
+
+// simulateFromConfig generates the proxy configuration in an in-memory control plane and runs the call against it.
+func simulateFromConfig(proxy *model.Proxy, kubeObjects, istioConfig string, call simulation.Call) (Output, error) {
+	var out Output
+	err := simulation.RunOffline(istioConfig, kubeObjects, proxy, func(sim *simulation.Simulation) {
+		out = run(sim, call)
+	})
+	return out, err
+}
+
+func simulateFromConfigDump(dump *configdump.Wrapper, call simulation.Call) (Output, error) {
+	listeners, clusters, routes, err := resourcesFromConfigDump(dump)
+	if err != nil {
+		return Output{}, err
+	}
+	var out Output
+	err = simulation.RunFromResources(listeners, clusters, routes, func(sim *simulation.Simulation) {
+		out = run(sim, call)
+	})
+	return out, err
+}
+
+func run(sim *simulation.Simulation, call simulation.Call) Output {
+	res := sim.Run(call)
+	out := Output{
+		Listener:    res.ListenerMatched,
+		FilterChain: res.FilterChainMatched,
+		RouteConfig: res.RouteConfigMatched,
+		VirtualHost: res.VirtualHostMatched,
+		Route:       res.RouteMatched,
+		Cluster:     res.ClusterMatched,
+	}
+	if res.Error != nil {
+		out.Error = res.Error.Error()
+	}
+	out.MTLSRequired = sim.MTLSRequired(res)
+	for _, c := range sim.Clusters {
+		if c.Name == res.ClusterMatched {
+			out.UpstreamTLS = upstreamTLS(c)
+			break
+		}
+	}
+	return out
+}
+
+// upstreamTLS summarizes how the proxy secures traffic sent to a cluster.
+func upstreamTLS(c *cluster.Cluster) string {
+	if ts := c.GetTransportSocket(); ts != nil {
+		return tlsContextMode(ts.GetTypedConfig())
+	}
+	for _, m := range c.GetTransportSocketMatches() {
+		if m.Name == "tlsMode-"+model.IstioMutualTLSModeLabel {
+			return "ISTIO_MUTUAL (when the endpoint supports it)"
+		}
+	}
+	return "DISABLE"
+}
+
+func tlsContextMode(config *anypb.Any) string {
+	tc := &tlsv3.UpstreamTlsContext{}
+	if !config.MessageIs(tc) {
+		return "DISABLE"
+	}
+	if err := config.UnmarshalTo(tc); err != nil {
+		return "UNKNOWN"
+	}
+	for _, sds := range tc.GetCommonTlsContext().GetTlsCertificateSdsSecretConfigs() {
+		if sds.GetName() == "default" {
+			return "ISTIO_MUTUAL"
+		}
+	}
+	if len(tc.GetCommonTlsContext().GetTlsCertificateSdsSecretConfigs()) > 0 ||
+		len(tc.GetCommonTlsContext().GetTlsCertificates()) > 0 {
+		return "MUTUAL"
+	}
+	return "SIMPLE"
+}
+
+func resourcesFromConfigDump(dump *configdump.Wrapper) ([]*listener.Listener, []*cluster.Cluster, []*route.RouteConfiguration, error) {
+	ld, err := dump.GetDynamicListenerDump(true)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to read listeners from config dump: %v", err)
+	}
+	listeners := make([]*listener.Listener, 0, len(ld.DynamicListeners))
+	for _, l := range ld.DynamicListeners {
+		res := &listener.Listener{}
+		if err := l.ActiveState.Listener.UnmarshalTo(res); err != nil {
+			return nil, nil, nil, err
+		}
+		listeners = append(listeners, res)
+	}
+	cd, err := dump.GetDynamicClusterDump(true)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to read clusters from config dump: %v", err)
+	}
+	clusters := make([]*cluster.Cluster, 0, len(cd.DynamicActiveClusters))
+	for _, c := range cd.DynamicActiveClusters {
+		res := &cluster.Cluster{}
+		if err := c.Cluster.UnmarshalTo(res); err != nil {
+			return nil, nil, nil, err
+		}
+		clusters = append(clusters, res)
+	}
+	rd, err := dump.GetDynamicRouteDump(true)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to read routes from config dump: %v", err)
+	}
+	routes := make([]*route.RouteConfiguration, 0, len(rd.DynamicRouteConfigs))
+	for _, r := range rd.DynamicRouteConfigs {
+		res := &route.RouteConfiguration{}
+		if err := r.RouteConfig.UnmarshalTo(res); err != nil {
+			return nil, nil, nil, err
+		}
+		routes = append(routes, res)
+	}
+	return listeners, clusters, routes, nil
+}
+
+func configDumpFromFile(filename string) (*configdump.Wrapper, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	dump := &configdump.Wrapper{}
+	if err := dump.UnmarshalJSON(data); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal config dump %s: %v", filename, err)
+	}
+	return dump, nil
+}
+
+func configDumpFromPod(ctx cli.Context, name string) (*configdump.Wrapper, error) {
+	kubeClient, err := ctx.CLIClient()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create k8s client: %w", err)
+	}
+	podName, podNamespace, err := ctx.InferPodInfoFromTypedResource(name, ctx.Namespace())
+	if err != nil {
+		return nil, err
+	}
+	data, err := kubeClient.EnvoyDo(context.TODO(), podName, podNamespace, "GET", "config_dump")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get proxy config for %s.%s: %v", podName, podNamespace, err)
+	}
+	dump := &configdump.Wrapper{}
+	if err := dump.UnmarshalJSON(data); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal proxy config: %v", err)
+	}
+	return dump, nil
+}
+
+func printOutput(w io.Writer, out Output, format string) error {
+	switch format {
+	case "json":
+		b, err := json.MarshalIndent(out, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, _ = fmt.Fprintln(w, string(b))
+	case "yaml":
+		b, err := yaml.Marshal(out)
+		if err != nil {
+			return err
+		}
+		_, _ = fmt.Fprint(w, string(b))
+	case "short":
+		field := func(name, value string) {
+			if value != "" {
+				_, _ = fmt.Fprintf(w, "%-14s%s\n", name+":", value)
+			}
+		}
+		field("Listener", out.Listener)
+		field("Filter chain", out.FilterChain)
+		field("Route config", out.RouteConfig)
+		field("Virtual host", out.VirtualHost)
+		field("Route", out.Route)
+		field("Cluster", out.Cluster)
+		if out.Listener != "" {
+			field("mTLS required", fmt.Sprint(out.MTLSRequired))
+		}
+		field("Upstream TLS", out.UpstreamTLS)
+		field("Error", out.Error)
+	default:
+		return fmt.Errorf("unknown output format %q, expected one of json|yaml|short", format)
+	}
+	return nil
+}
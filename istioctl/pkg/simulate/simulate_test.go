@@ -0,0 +1,219 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package simulate
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	admin "github.com/envoyproxy/go-control-plane/envoy/admin/v3"
+	listener "github.com/envoyproxy/go-control-plane/envoy/config/listener/v3"
+	"google.golang.org/protobuf/types/known/anypb"
+
+	"istio.io/istio/istioctl/pkg/cli"
+	"istio.io/istio/istioctl/pkg/util/configdump"
+	"istio.io/istio/pilot/pkg/simulation"
+	"istio.io/istio/pilot/pkg/util/protoconv"
+	"istio.io/istio/pkg/slices"
+	"istio.io/istio/pkg/test/util/assert"
+	"istio.io/istio/pkg/util/protomarshal"
+	"istio.io/istio/pkg/util/sets"
+)
+
+var simulateCases = []struct {
+	name string
+	args []string
+	want Output
+}{
+	{
+		name: "default route",
+		args: []string{"--port", "9080", "--host", "reviews.example.com"},
+		want: Output{
+			Listener:    "0.0.0.0_9080",
+			RouteConfig: "9080",
+			VirtualHost: "reviews.example.com:9080",
+			Route:       "default",
+			Cluster:     "outbound|9080|v1|reviews.example.com",
+			UpstreamTLS: "ISTIO_MUTUAL",
+		},
+	},
+	{
+		name: "header match",
+		args: []string{"--port", "9080", "--host", "reviews.example.com", "-H", "end-user: jason"},
+		want: Output{
+			Listener:    "0.0.0.0_9080",
+			RouteConfig: "9080",
+			VirtualHost: "reviews.example.com:9080",
+			Route:       "canary",
+			Cluster:     "outbound|9080|v2|reviews.example.com",
+			UpstreamTLS: "ISTIO_MUTUAL",
+		},
+	},
+	{
+		name: "unknown host",
+		args: []string{"--port", "9080", "--host", "details.example.com"},
+		want: Output{
+			Listener:    "0.0.0.0_9080",
+			RouteConfig: "9080",
+			VirtualHost: "allow_any",
+			Route:       "allow_any",
+			Cluster:     "PassthroughCluster",
+			UpstreamTLS: "DISABLE",
+		},
+	},
+}
+
+func TestSimulate(t *testing.T) {
+	for _, tt := range simulateCases {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd := Cmd(cli.NewFakeContext(&cli.NewFakeContextOption{Namespace: "default"}))
+			out := &bytes.Buffer{}
+			cmd.SetOut(out)
+			cmd.SetArgs(append([]string{"-f", "testdata/config.yaml", "--workload", "productpage", "-o", "json"}, tt.args...))
+			assert.NoError(t, cmd.Execute())
+			var got Output
+			assert.NoError(t, json.Unmarshal(out.Bytes(), &got))
+			assert.Equal(t, got, tt.want)
+		})
+	}
+}
+
+func TestSimulateArgs(t *testing.T) {
+	cases := []struct {
+		name string
+		args []string
+		err  string
+	}{
+		{
+			name: "no source",
+			args: []string{"--port", "80"},
+			err:  "exactly one of a pod, --file or --config-dump must be provided",
+		},
+		{
+			name: "no port",
+			args: []string{"-f", "testdata/config.yaml"},
+			err:  "--port is required",
+		},
+		{
+			name: "invalid header",
+			args: []string{"-f", "testdata/config.yaml", "--port", "80", "-H", "foo"},
+			err:  "invalid header",
+		},
+		{
+			name: "unknown workload",
+			args: []string{"-f", "testdata/config.yaml", "--port", "80", "--workload", "ratings"},
+			err:  "pod ratings.default not found",
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd := Cmd(cli.NewFakeContext(&cli.NewFakeContextOption{Namespace: "default"}))
+			cmd.SetOut(&bytes.Buffer{})
+			cmd.SetErr(&bytes.Buffer{})
+			cmd.SetArgs(tt.args)
+			err := cmd.Execute()
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Fatalf("expected error containing %q, got %v", tt.err, err)
+			}
+		})
+	}
+}
+
+// configDumpFromFiles generates the productpage proxy configuration from testdata, in the shape Envoy reports it.
+func configDumpFromFiles(t *testing.T) (*configdump.Wrapper, *simulation.Simulation) {
+	o := &options{files: []string{"testdata/config.yaml"}, workload: "productpage", proxyType: "sidecar"}
+	proxy, kubeObjects, istioConfig, err := o.loadFiles("default")
+	assert.NoError(t, err)
+	var sim *simulation.Simulation
+	assert.NoError(t, simulation.RunOffline(istioConfig, kubeObjects, proxy, func(s *simulation.Simulation) {
+		sim = s
+	}))
+
+	ld := &admin.ListenersConfigDump{}
+	for _, l := range sim.Listeners {
+		ld.DynamicListeners = append(ld.DynamicListeners, &admin.ListenersConfigDump_DynamicListener{
+			Name:        l.Name,
+			ActiveState: &admin.ListenersConfigDump_DynamicListenerState{Listener: protoconv.MessageToAny(l)},
+		})
+	}
+	// Listeners that are only draining are not simulated.
+	ld.DynamicListeners = append(ld.DynamicListeners, &admin.ListenersConfigDump_DynamicListener{
+		Name:          "draining",
+		DrainingState: &admin.ListenersConfigDump_DynamicListenerState{Listener: protoconv.MessageToAny(&listener.Listener{Name: "draining"})},
+	})
+	cd := &admin.ClustersConfigDump{}
+	for _, c := range sim.Clusters {
+		cd.DynamicActiveClusters = append(cd.DynamicActiveClusters, &admin.ClustersConfigDump_DynamicCluster{Cluster: protoconv.MessageToAny(c)})
+	}
+	rd := &admin.RoutesConfigDump{}
+	for _, r := range sim.Routes {
+		rd.DynamicRouteConfigs = append(rd.DynamicRouteConfigs, &admin.RoutesConfigDump_DynamicRouteConfig{RouteConfig: protoconv.MessageToAny(r)})
+	}
+	return &configdump.Wrapper{ConfigDump: &admin.ConfigDump{Configs: []*anypb.Any{
+		protoconv.MessageToAny(ld),
+		protoconv.MessageToAny(cd),
+		protoconv.MessageToAny(rd),
+	}}}, sim
+}
+
+func TestSimulateFromConfigDump(t *testing.T) {
+	dump, _ := configDumpFromFiles(t)
+	js, err := protomarshal.ToJSON(dump.ConfigDump)
+	assert.NoError(t, err)
+	file := filepath.Join(t.TempDir(), "config_dump.json")
+	assert.NoError(t, os.WriteFile(file, []byte(js), 0o644))
+
+	for _, tt := range simulateCases {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd := Cmd(cli.NewFakeContext(&cli.NewFakeContextOption{Namespace: "default"}))
+			out := &bytes.Buffer{}
+			cmd.SetOut(out)
+			cmd.SetArgs(append([]string{"--config-dump", file, "-o", "json"}, tt.args...))
+			assert.NoError(t, cmd.Execute())
+			var got Output
+			assert.NoError(t, json.Unmarshal(out.Bytes(), &got))
+			assert.Equal(t, got, tt.want)
+		})
+	}
+	t.Run("unknown port", func(t *testing.T) {
+		call, err := (&options{port: 1234, path: "/", protocol: "http", tls: "plaintext", mode: "inbound"}).call()
+		assert.NoError(t, err)
+		got, err := simulateFromConfigDump(dump, call)
+		assert.NoError(t, err)
+		assert.Equal(t, got.Listener, "virtualInbound")
+		assert.Equal(t, got.Cluster, "InboundPassthroughCluster")
+	})
+}
+
+func TestResourcesFromConfigDump(t *testing.T) {
+	dump, want := configDumpFromFiles(t)
+	listeners, clusters, routes, err := resourcesFromConfigDump(dump)
+	assert.NoError(t, err)
+	// The draining listener is skipped.
+	assert.Equal(t, names(listeners), names(want.Listeners))
+	assert.Equal(t, names(clusters), names(want.Clusters))
+	assert.Equal(t, names(routes), names(want.Routes))
+
+	_, _, _, err = resourcesFromConfigDump(&configdump.Wrapper{ConfigDump: &admin.ConfigDump{}})
+	assert.Error(t, err)
+}
+
+func names[T interface{ GetName() string }](resources []T) sets.String {
+	return sets.New(slices.Map(resources, T.GetName)...)
+}
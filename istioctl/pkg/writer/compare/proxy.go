@@ -0,0 +1,252 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package compare
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	cluster "github.com/envoyproxy/go-control-plane/envoy/config/cluster/v3"
+	core "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	endpoint "github.com/envoyproxy/go-control-plane/envoy/config/endpoint/v3"
+	listener "github.com/envoyproxy/go-control-plane/envoy/config/listener/v3"
+	route "github.com/envoyproxy/go-control-plane/envoy/config/route/v3"
+	"github.com/pmezard/go-difflib/difflib"
+	"google.golang.org/protobuf/proto"
+
+	"istio.io/istio/istioctl/pkg/util/configdump"
+	"istio.io/istio/pkg/maps"
+	"istio.io/istio/pkg/slices"
+	"istio.io/istio/pkg/util/protomarshal"
+	"istio.io/istio/pkg/util/sets"
+)
+
+// ProxyComparator diffs the config dumps of two proxies, or of the same proxy at two points in time.
+// Unlike Comparator, resources are matched by name, and version information and the order of
+// unordered fields are ignored, so only semantic differences are reported.
+type ProxyComparator struct {
+	from, to         *configdump.Wrapper
+	fromName, toName string
+	w                io.Writer
+	context          int
+}
+
+// NewProxyComparator is a ProxyComparator constructor. The names identify the two config dumps in the output.
+func NewProxyComparator(w io.Writer, fromName string, from []byte, toName string, to []byte) (*ProxyComparator, error) {
+	c := &ProxyComparator{
+		from:     &configdump.Wrapper{},
+		to:       &configdump.Wrapper{},
+		fromName: fromName,
+		toName:   toName,
+		w:        w,
+		context:  7,
+	}
+	if err := json.Unmarshal(from, c.from); err != nil {
+		return nil, fmt.Errorf("failed to parse config dump of %s: %v", fromName, err)
+	}
+	if err := json.Unmarshal(to, c.to); err != nil {
+		return nil, fmt.Errorf("failed to parse config dump of %s: %v", toName, err)
+	}
+	return c, nil
+}
+
+// Diff prints the differences in clusters, listeners, routes and endpoints to the passed writer,
+// and returns whether any were found.
+func (c *ProxyComparator) Diff() (bool, error) {
+	sections := []struct {
+		name      string
+		resources func(*configdump.Wrapper) (map[string]proto.Message, error)
+	}{
+		{"Clusters", clusterResources},
+		{"Listeners", listenerResources},
+		{"Routes", routeResources},
+		{"Endpoints", endpointResources},
+	}
+	differs := false
+	for _, s := range sections {
+		from, err := s.resources(c.from)
+		if err != nil {
+			return false, fmt.Errorf("failed to read %s of %s: %v", s.name, c.fromName, err)
+		}
+		to, err := s.resources(c.to)
+		if err != nil {
+			return false, fmt.Errorf("failed to read %s of %s: %v", s.name, c.toName, err)
+		}
+		d, err := c.diffResources(s.name, from, to)
+		if err != nil {
+			return false, err
+		}
+		differs = differs || d
+	}
+	return differs, nil
+}
+
+func (c *ProxyComparator) diffResources(section string, from, to map[string]proto.Message) (bool, error) {
+	var onlyFrom, onlyTo, changed []string
+	for _, name := range sets.SortedList(sets.New(maps.Keys(from)...).Merge(sets.New(maps.Keys(to)...))) {
+		a, inFrom := from[name]
+		b, inTo := to[name]
+		switch {
+		case !inTo:
+			onlyFrom = append(onlyFrom, name)
+		case !inFrom:
+			onlyTo = append(onlyTo, name)
+		case !proto.Equal(a, b):
+			changed = append(changed, name)
+		}
+	}
+	if len(onlyFrom)+len(onlyTo)+len(changed) == 0 {
+		fmt.Fprintf(c.w, "%s Match\n", section)
+		return false, nil
+	}
+
+	fmt.Fprintf(c.w, "%s Don't Match\n", section)
+	for _, name := range onlyFrom {
+		fmt.Fprintf(c.w, "Only in %s: %s\n", c.fromName, name)
+	}
+	for _, name := range onlyTo {
+		fmt.Fprintf(c.w, "Only in %s: %s\n", c.toName, name)
+	}
+	for _, name := range changed {
+		a, err := protomarshal.ToJSONWithAnyResolver(from[name], "    ", &envoyResolver)
+		if err != nil {
+			return false, err
+		}
+		b, err := protomarshal.ToJSONWithAnyResolver(to[name], "    ", &envoyResolver)
+		if err != nil {
+			return false, err
+		}
+		text, err := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+			FromFile: c.fromName + " " + name,
+			A:        difflib.SplitLines(a),
+			ToFile:   c.toName + " " + name,
+			B:        difflib.SplitLines(b),
+			Context:  c.context,
+		})
+		if err != nil {
+			return false, err
+		}
+		fmt.Fprintf(c.w, "Changed: %s\n", name)
+		fmt.Fprintln(c.w, text)
+	}
+	return true, nil
+}
+
+func clusterResources(w *configdump.Wrapper) (map[string]proto.Message, error) {
+	dump, err := w.GetDynamicClusterDump(true)
+	if err != nil {
+		return nil, err
+	}
+	res := map[string]proto.Message{}
+	for _, dc := range dump.DynamicActiveClusters {
+		c := &cluster.Cluster{}
+		if err := dc.Cluster.UnmarshalTo(c); err != nil {
+			return nil, err
+		}
+		if c.LoadAssignment != nil {
+			normalizeLoadAssignment(c.LoadAssignment)
+		}
+		res[c.Name] = c
+	}
+	return res, nil
+}
+
+func listenerResources(w *configdump.Wrapper) (map[string]proto.Message, error) {
+	dump, err := w.GetDynamicListenerDump(true)
+	if err != nil {
+		return nil, err
+	}
+	res := map[string]proto.Message{}
+	for _, dl := range dump.DynamicListeners {
+		l := &listener.Listener{}
+		if err := dl.ActiveState.Listener.UnmarshalTo(l); err != nil {
+			return nil, err
+		}
+		// Filter chains are selected by their match, not their position.
+		slices.SortStableFunc(l.FilterChains, func(a, b *listener.FilterChain) int {
+			return cmp.Compare(a.Name, b.Name)
+		})
+		res[l.Name] = l
+	}
+	return res, nil
+}
+
+func routeResources(w *configdump.Wrapper) (map[string]proto.Message, error) {
+	// Virtual hosts are already sorted by the dump.
+	dump, err := w.GetDynamicRouteDump(true)
+	if err != nil {
+		return nil, err
+	}
+	res := map[string]proto.Message{}
+	for _, drc := range dump.DynamicRouteConfigs {
+		r := &route.RouteConfiguration{}
+		if err := drc.RouteConfig.UnmarshalTo(r); err != nil {
+			return nil, err
+		}
+		res[r.Name] = r
+	}
+	return res, nil
+}
+
+func endpointResources(w *configdump.Wrapper) (map[string]proto.Message, error) {
+	dump, err := w.GetEndpointsConfigDump()
+	if err != nil {
+		return nil, err
+	}
+	res := map[string]proto.Message{}
+	// Endpoints are only present if the dump was taken with include_eds.
+	for _, dec := range dump.GetDynamicEndpointConfigs() {
+		cla := &endpoint.ClusterLoadAssignment{}
+		if err := dec.EndpointConfig.UnmarshalTo(cla); err != nil {
+			return nil, err
+		}
+		normalizeLoadAssignment(cla)
+		res[cla.ClusterName] = cla
+	}
+	return res, nil
+}
+
+// normalizeLoadAssignment sorts the localities and endpoints of a load assignment, as their order has no meaning.
+func normalizeLoadAssignment(cla *endpoint.ClusterLoadAssignment) {
+	slices.SortStableFunc(cla.Endpoints, func(a, b *endpoint.LocalityLbEndpoints) int {
+		if r := cmp.Compare(a.Priority, b.Priority); r != 0 {
+			return r
+		}
+		return cmp.Compare(localityString(a.Locality), localityString(b.Locality))
+	})
+	for _, llb := range cla.Endpoints {
+		slices.SortStableFunc(llb.LbEndpoints, func(a, b *endpoint.LbEndpoint) int {
+			return cmp.Compare(endpointAddress(a), endpointAddress(b))
+		})
+	}
+}
+
+func endpointAddress(ep *endpoint.LbEndpoint) string {
+	addr := ep.GetEndpoint().GetAddress()
+	if pipe := addr.GetPipe(); pipe != nil {
+		return pipe.Path
+	}
+	if internal := addr.GetEnvoyInternalAddress(); internal != nil {
+		return internal.GetServerListenerName() + "/" + internal.GetEndpointId()
+	}
+	sa := addr.GetSocketAddress()
+	return fmt.Sprintf("%s:%d", sa.GetAddress(), sa.GetPortValue())
+}
+
+func localityString(l *core.Locality) string {
+	return l.GetRegion() + "/" + l.GetZone() + "/" + l.GetSubZone()
+}
@@ -0,0 +1,148 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package compare
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+)
+
+func endpointsConfig(version string, addresses ...string) map[string]any {
+	var lbEndpoints []any
+	for _, a := range addresses {
+		lbEndpoints = append(lbEndpoints, map[string]any{
+			"endpoint": map[string]any{
+				"address": map[string]any{"socket_address": map[string]any{"address": a, "port_value": 8080}},
+			},
+		})
+	}
+	return map[string]any{
+		"@type": "type.googleapis.com/envoy.admin.v3.EndpointsConfigDump",
+		"dynamic_endpoint_configs": []any{map[string]any{
+			"version_info": version,
+			"endpoint_config": map[string]any{
+				"@type":        "type.googleapis.com/envoy.config.endpoint.v3.ClusterLoadAssignment",
+				"cluster_name": "outbound|8080||reviews.default.svc.cluster.local",
+				"endpoints":    []any{map[string]any{"lb_endpoints": lbEndpoints}},
+			},
+		}},
+	}
+}
+
+// mutateConfigDump applies f to the parsed config dump in file.
+func mutateConfigDump(t *testing.T, file string, f func(configs []any) []any) []byte {
+	t.Helper()
+	b, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatalf("Failed to read test data: %v", err)
+	}
+	dump := map[string]any{}
+	if err := json.Unmarshal(b, &dump); err != nil {
+		t.Fatal(err)
+	}
+	dump["configs"] = f(dump["configs"].([]any))
+	out, err := json.Marshal(dump)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return out
+}
+
+func TestProxyComparator(t *testing.T) {
+	cfg := mutateConfigDump(t, "testdata/configdump.json", func(configs []any) []any {
+		return append(configs, endpointsConfig("1", "10.0.0.1", "10.0.0.2"))
+	})
+	// The same config at a different version, with clusters and endpoints in a different order.
+	reordered := mutateConfigDump(t, "testdata/configdump.json", func(configs []any) []any {
+		clusters := configs[0].(map[string]any)["dynamic_active_clusters"].([]any)
+		for i, j := 0, len(clusters)-1; i < j; i, j = i+1, j-1 {
+			clusters[i], clusters[j] = clusters[j], clusters[i]
+		}
+		for _, c := range clusters {
+			c.(map[string]any)["version_info"] = "2024-01-01T00:00:00Z/2"
+		}
+		return append(configs, endpointsConfig("2", "10.0.0.2", "10.0.0.1"))
+	})
+	diffCfg := mutateConfigDump(t, "testdata/configdump_diff.json", func(configs []any) []any {
+		return append(configs, endpointsConfig("1", "10.0.0.1", "10.0.0.3"))
+	})
+
+	cases := []struct {
+		name      string
+		from, to  []byte
+		differs   bool
+		expected  []string
+		forbidden []string
+	}{
+		{
+			name:     "identical",
+			from:     cfg,
+			to:       cfg,
+			expected: []string{"Clusters Match", "Listeners Match", "Routes Match", "Endpoints Match"},
+		},
+		{
+			name:     "ordering and versions ignored",
+			from:     cfg,
+			to:       reordered,
+			expected: []string{"Clusters Match", "Listeners Match", "Routes Match", "Endpoints Match"},
+		},
+		{
+			name:    "different",
+			from:    cfg,
+			to:      diffCfg,
+			differs: true,
+			expected: []string{
+				"Clusters Don't Match",
+				"Only in before: inbound-vip|9080|http|ratings.default.svc.cluster.local",
+				"Only in after: inbound-vip|9999|http|ratings.default.svc.cluster.local",
+				"Listeners Don't Match",
+				"Routes Don't Match",
+				"Endpoints Don't Match",
+				"Changed: outbound|8080||reviews.default.svc.cluster.local",
+				`+                                "address": "10.0.0.3",`,
+			},
+			forbidden: []string{"version_info", "last_updated"},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			var out bytes.Buffer
+			comparator, err := NewProxyComparator(&out, "before", c.from, "after", c.to)
+			if err != nil {
+				t.Fatalf("Failed to create ProxyComparator: %v", err)
+			}
+			differs, err := comparator.Diff()
+			if err != nil {
+				t.Fatalf("Unexpected error during diff: %v", err)
+			}
+			if differs != c.differs {
+				t.Errorf("Expected differs=%v, got %v:\n%s", c.differs, differs, out.String())
+			}
+			for _, exp := range c.expected {
+				if !strings.Contains(out.String(), exp) {
+					t.Errorf("Expected %q, but it was not found in:\n%s", exp, out.String())
+				}
+			}
+			for _, f := range c.forbidden {
+				if strings.Contains(out.String(), f) {
+					t.Errorf("Did not expect %q in:\n%s", f, out.String())
+				}
+			}
+		})
+	}
+}
@@ -48,6 +48,9 @@ func NewAgentOptions(proxy *ProxyArgs, cfg *meshconfig.ProxyConfig, sds istioage
 			PurgeInterval:         wasmPurgeInterval,
 			HTTPRequestTimeout:    wasmHTTPRequestTimeout,
 			HTTPRequestMaxRetries: wasmHTTPRequestMaxRetries,
+			VerificationKeysPath:  wasmVerificationKeys,
+			SharedDir:             wasmSharedCacheDir,
+			MaxCacheSize:          int64(wasmCacheMaxSize),
 		},
 		ProxyIPAddresses:            proxy.IPAddresses,
 		ServiceNode:                 proxy.ServiceNode(),
@@ -62,6 +65,8 @@ func NewAgentOptions(proxy *ProxyArgs, cfg *meshconfig.ProxyConfig, sds istioage
 		ProxyXDSDebugViaAgentPort:   proxyXDSDebugViaAgentPort,
 		DNSCapture:                  DNSCaptureByAgent.Get(),
 		DNSForwardParallel:          DNSForwardParallel.Get(),
+		DNSCacheSize:                DNSCacheSize.Get(),
+		DNSCacheMaxTTL:              DNSCacheMaxTTL.Get(),
 		DNSAddr:                     DNSCaptureAddr.Get(),
 		ProxyNamespace:              PodNamespaceVar.Get(),
 		ProxyDomain:                 proxy.DNSDomain,
@@ -21,6 +21,7 @@ import (
 
 	"istio.io/istio/pilot/cmd/pilot-agent/status"
 	"istio.io/istio/pkg/config/constants"
+	dnsClient "istio.io/istio/pkg/dns/client"
 	"istio.io/istio/pkg/env"
 	"istio.io/istio/pkg/jwt"
 	"istio.io/istio/pkg/security"
@@ -110,6 +111,12 @@ var (
 	DNSForwardParallel = env.Register("DNS_FORWARD_PARALLEL", false,
 		"If set to true, agent will send parallel DNS queries to all upstream nameservers")
 
+	DNSCacheSize = env.Register("DNS_CACHE_SIZE", 0,
+		"Maximum number of upstream DNS answers cached by the agent, honoring their TTL. If set to 0, the cache is disabled")
+
+	DNSCacheMaxTTL = env.Register("DNS_CACHE_MAX_TTL", dnsClient.DefaultCacheMaxTTL,
+		"Maximum duration an upstream DNS answer is cached by the agent, regardless of its TTL")
+
 	// Ability of istio-agent to retrieve proxyConfig via XDS for dynamic configuration updates
 	enableProxyConfigXdsEnv = env.Register("PROXY_CONFIG_XDS_AGENT", false,
 		"If set to true, agent retrieves dynamic proxy-config updates via xds channel").Get()
@@ -129,6 +136,18 @@ var (
 	wasmHTTPRequestMaxRetries = env.Register("WASM_HTTP_REQUEST_MAX_RETRIES", wasm.DefaultHTTPRequestMaxRetries,
 		"maximum number of HTTP/HTTPS request retries for pulling a Wasm module via http/https").Get()
 
+	wasmVerificationKeys = env.Register("WASM_VERIFICATION_KEYS", "",
+		"path to a PEM file, or a directory of PEM files, with the public keys trusted to sign Wasm modules. "+
+			"If set, Wasm modules without a valid signature by one of these keys are rejected").Get()
+
+	wasmSharedCacheDir = env.Register("WASM_SHARED_CACHE_DIR", "",
+		"directory, for example a hostPath volume, in which Wasm modules are cached and shared with the other proxies "+
+			"on the node. If unset, modules are cached in a directory private to the proxy").Get()
+
+	wasmCacheMaxSize = env.Register("WASM_CACHE_MAX_SIZE", 0,
+		"maximum total size in bytes of the cached Wasm modules. When exceeded, the least recently used modules are evicted. "+
+			"If the cache is shared, the limit applies to the modules of all proxies on the node. 0 means no limit").Get()
+
 	enableWDSEnv = env.Register("PEER_METADATA_DISCOVERY", false,
 		"If set to true, enable the peer metadata discovery extension in Envoy").Get()
 
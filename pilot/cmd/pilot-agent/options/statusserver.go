@@ -33,6 +33,7 @@ func NewStatusServerOptions(ipv6 bool, t model.NodeType, proxyConfig *meshconfig
 		Probes:         []ready.Prober{agent},
 		NoEnvoy:        agent.EnvoyDisabled(),
 		FetchDNS:       agent.GetDNSTable,
+		FetchDNSCache:  agent.GetDNSCache,
 		GRPCBootstrap:  agent.GRPCBootstrapPath(),
 		TriggerDrain: func() {
 			agent.DrainNow()
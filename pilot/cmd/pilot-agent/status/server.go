@@ -48,6 +48,7 @@ import (
 	"istio.io/istio/pilot/cmd/pilot-agent/metrics"
 	"istio.io/istio/pilot/cmd/pilot-agent/status/grpcready"
 	"istio.io/istio/pilot/cmd/pilot-agent/status/ready"
+	dnsClient "istio.io/istio/pkg/dns/client"
 	dnsProto "istio.io/istio/pkg/dns/proto"
 	"istio.io/istio/pkg/env"
 	commonFeatures "istio.io/istio/pkg/features"
@@ -129,6 +130,7 @@ type Options struct {
 	EnvoyPrometheusPort int
 	Context             context.Context
 	FetchDNS            func() *dnsProto.NameTable
+	FetchDNSCache       func() []dnsClient.CacheEntry
 	NoEnvoy             bool
 	GRPCBootstrap       string
 	EnableProfiling     bool
@@ -150,6 +152,7 @@ type Server struct {
 	lastProbeSuccessful   bool
 	envoyStatsPort        int
 	fetchDNS              func() *dnsProto.NameTable
+	fetchDNSCache         func() []dnsClient.CacheEntry
 	upstreamLocalAddress  *net.TCPAddr
 	config                Options
 	http                  *http.Client
@@ -218,6 +221,7 @@ func NewServer(config Options) (*Server, error) {
 		appProbersDestination: config.PodIP,
 		envoyStatsPort:        config.EnvoyPrometheusPort,
 		fetchDNS:              config.FetchDNS,
+		fetchDNSCache:         config.FetchDNSCache,
 		upstreamLocalAddress:  upstreamLocalAddress,
 		config:                config,
 		enableProfiling:       config.EnableProfiling,
@@ -384,6 +388,7 @@ func (s *Server) Run(ctx context.Context) {
 		mux.HandleFunc("/debug/pprof/trace", s.handlePprofTrace)
 	}
 	mux.HandleFunc("/debug/ndsz", s.handleNdsz)
+	mux.HandleFunc("/debug/dnscachez", s.handleDNSCachez)
 
 	l, err := net.Listen("tcp", fmt.Sprintf(":%d", s.statusPort))
 	if err != nil {
@@ -904,6 +909,29 @@ func (s *Server) handleNdsz(w http.ResponseWriter, r *http.Request) {
 	writeJSONProto(w, nametable)
 }
 
+func (s *Server) handleDNSCachez(w http.ResponseWriter, r *http.Request) {
+	if !istioNetUtil.IsRequestFromLocalhost(r) {
+		http.Error(w, "Only requests from localhost are allowed", http.StatusForbidden)
+		return
+	}
+	var entries []dnsClient.CacheEntry
+	if s.fetchDNSCache != nil {
+		entries = s.fetchDNSCache()
+	}
+	if entries == nil {
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`[]`))
+		return
+	}
+	b, err := json.Marshal(entries)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(b)
+}
+
 // writeJSONProto writes a protobuf to a json payload, handling content type, marshaling, and errors
 func writeJSONProto(w http.ResponseWriter, obj proto.Message) {
 	w.Header().Set("Content-Type", "application/json")
@@ -29,6 +29,7 @@ import (
 	ingress "istio.io/istio/pilot/pkg/config/kube/ingress"
 	"istio.io/istio/pilot/pkg/config/memory"
 	configmonitor "istio.io/istio/pilot/pkg/config/monitor"
+	"istio.io/istio/pilot/pkg/controllers/trafficshift"
 	"istio.io/istio/pilot/pkg/features"
 	"istio.io/istio/pilot/pkg/leaderelection"
 	"istio.io/istio/pilot/pkg/model"
@@ -199,6 +200,9 @@ func (s *Server) initK8SConfigStore(args *PilotArgs) error {
 		return err
 	}
 	s.XDSServer.WorkloadEntryController = autoregistration.NewController(configController, args.PodName, args.KeepaliveOptions.MaxServerConnectionAge)
+	if features.EnableTrafficShiftController {
+		s.initTrafficShiftController(args)
+	}
 	return nil
 }
 
@@ -304,6 +308,21 @@ func (s *Server) initInprocessAnalysisController(args *PilotArgs) error {
 	return nil
 }
 
+// initTrafficShiftController starts the controller progressively shifting VirtualService traffic to canaries.
+func (s *Server) initTrafficShiftController(args *PilotArgs) {
+	// The status manager may already be registered for Gateway API status, which only sets it once started.
+	s.initStatusManager(args)
+	s.addStartFunc("traffic shift controller", func(stop <-chan struct{}) error {
+		go leaderelection.
+			NewLeaderElection(args.Namespace, args.PodName, leaderelection.TrafficShiftController, args.Revision, s.kubeClient).
+			AddRunFunction(func(leaderStop <-chan struct{}) {
+				metrics := trafficshift.NewPrometheus(features.TrafficShiftPrometheusAddress)
+				trafficshift.NewController(s.RWConfigStore, metrics, s.statusManager).Run(leaderStop)
+			}).Run(stop)
+		return nil
+	})
+}
+
 func (s *Server) makeKubeConfigController(args *PilotArgs) *crdclient.Client {
 	opts := crdclient.Option{
 		Revision:     args.Revision,
@@ -29,8 +29,10 @@ import (
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
+	meshconfig "istio.io/api/mesh/v1alpha1"
 	"istio.io/api/security/v1beta1"
 	"istio.io/istio/pilot/pkg/features"
+	istiogrpc "istio.io/istio/pilot/pkg/grpc"
 	securityModel "istio.io/istio/pilot/pkg/security/model"
 	"istio.io/istio/pkg/config/constants"
 	"istio.io/istio/pkg/env"
@@ -129,7 +131,29 @@ var (
 
 	// TODO: Likely to be removed and added to mesh config
 	externalCaType = env.Register("EXTERNAL_CA", "",
-		"External CA Integration Type. Permitted value is ISTIOD_RA_KUBERNETES_API.").Get()
+		"External CA Integration Type. Permitted values are ISTIOD_RA_KUBERNETES_API and ISTIOD_RA_GRPC.").Get()
+
+	externalCaAddr = env.Register("EXTERNAL_CA_ADDR", "",
+		"Address of the external signer, when EXTERNAL_CA is ISTIOD_RA_GRPC.").Get()
+
+	externalCaPlaintext = env.Register("EXTERNAL_CA_PLAINTEXT", false,
+		"If enabled, the connection to the external signer does not use TLS. "+
+			"This should only be used when the signer is local to Istiod.").Get()
+
+	externalCaTLSRootCert = env.Register("EXTERNAL_CA_TLS_ROOT_CERT", "",
+		"File containing the root cert used to verify the external signer. If unset, the system roots are used.").Get()
+
+	externalCaTLSCert = env.Register("EXTERNAL_CA_TLS_CLIENT_CERT", "",
+		"File containing the client cert used to authenticate to the external signer.").Get()
+
+	externalCaTLSKey = env.Register("EXTERNAL_CA_TLS_CLIENT_KEY", "",
+		"File containing the client key used to authenticate to the external signer.").Get()
+
+	externalCaTLSSAN = env.Register("EXTERNAL_CA_TLS_SAN", "",
+		"Expected SAN of the external signer. If unset, the host of EXTERNAL_CA_ADDR is used.").Get()
+
+	externalCaTimeout = env.Register("EXTERNAL_CA_TIMEOUT", 10*time.Second,
+		"Timeout for requests to the external signer.").Get()
 
 	// TODO: Likely to be removed and added to mesh config
 	k8sSigner = env.Register("K8S_SIGNER", "",
@@ -514,6 +538,21 @@ func (s *Server) createSelfSignedCACertificateOptions(fileBundle *ca.SigningCAFi
 	return caOpts, nil
 }
 
+// meshConfigRootCerts returns the PEM roots from mesh config that are not scoped to a cert signer.
+func meshConfigRootCerts(meshConfig *meshconfig.MeshConfig) []byte {
+	var roots []byte
+	for _, c := range meshConfig.GetCaCertificates() {
+		if len(c.CertSigners) > 0 || c.GetPem() == "" {
+			continue
+		}
+		roots = append(roots, c.GetPem()...)
+		if !strings.HasSuffix(c.GetPem(), "\n") {
+			roots = append(roots, '\n')
+		}
+	}
+	return roots
+}
+
 // createIstioRA initializes the Istio RA signing functionality.
 // the caOptions defines the external provider
 // ca cert can come from three sources, order matters:
@@ -532,8 +571,8 @@ func (s *Server) createIstioRA(opts *caOptions) (ra.RegistrationAuthority, error
 			return nil, fmt.Errorf("failed to get file info: %v", err)
 		}
 
-		// File does not exist.
-		if certSignerDomain == "" {
+		// File does not exist. The gRPC RA falls back to the roots from mesh config instead.
+		if certSignerDomain == "" && opts.ExternalCAType == ra.ExtCAK8s {
 			log.Infof("CA cert file %q not found, using %q.", caCertFile, defaultCACertPath)
 			caCertFile = defaultCACertPath
 		} else {
@@ -542,9 +581,6 @@ func (s *Server) createIstioRA(opts *caOptions) (ra.RegistrationAuthority, error
 		}
 	}
 
-	if s.kubeClient == nil {
-		return nil, fmt.Errorf("kubeClient is nil")
-	}
 	raOpts := &ra.IstioRAOptions{
 		ExternalCAType:   opts.ExternalCAType,
 		DefaultCertTTL:   workloadCertTTL.Get(),
@@ -552,10 +588,36 @@ func (s *Server) createIstioRA(opts *caOptions) (ra.RegistrationAuthority, error
 		CaSigner:         opts.ExternalCASigner,
 		CaCertFile:       caCertFile,
 		VerifyAppendCA:   true,
-		K8sClient:        s.kubeClient.Kube(),
 		TrustDomain:      opts.TrustDomain,
 		CertSignerDomain: opts.CertSignerDomain,
 	}
+	switch opts.ExternalCAType {
+	case ra.ExtCAK8s:
+		if s.kubeClient == nil {
+			return nil, fmt.Errorf("kubeClient is nil")
+		}
+		raOpts.K8sClient = s.kubeClient.Kube()
+	case ra.ExtCAGRPC:
+		if caCertFile == "" {
+			// The root bundle returned by the signer is not trusted, so a root must be configured.
+			raOpts.CaCertPEM = meshConfigRootCerts(s.environment.Mesh())
+			if len(raOpts.CaCertPEM) == 0 {
+				return nil, fmt.Errorf("%s requires the root cert of the external signer, in %s or in meshConfig.caCertificates",
+					ra.ExtCAGRPC, path.Join(ra.DefaultExtCACertDir, constants.CACertNamespaceConfigMapDataName))
+			}
+		}
+		raOpts.ExternalCAAddr = externalCaAddr
+		raOpts.ExternalCATimeout = externalCaTimeout
+		if !externalCaPlaintext {
+			raOpts.ExternalCATLS = &istiogrpc.TLSOptions{
+				RootCert:      externalCaTLSRootCert,
+				Cert:          externalCaTLSCert,
+				Key:           externalCaTLSKey,
+				ServerAddress: externalCaAddr,
+				SAN:           externalCaTLSSAN,
+			}
+		}
+	}
 	raServer, err := ra.NewIstioRA(raOpts)
 	if err != nil {
 		return nil, err
@@ -23,10 +23,12 @@ import (
 	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
+	meshconfig "istio.io/api/mesh/v1alpha1"
 	"istio.io/istio/pkg/kube"
 	"istio.io/istio/pkg/kube/kclient/clienttest"
 	"istio.io/istio/pkg/test"
 	"istio.io/istio/pkg/test/env"
+	"istio.io/istio/pkg/test/util/assert"
 	"istio.io/istio/security/pkg/pki/ca"
 )
 
@@ -167,3 +169,18 @@ func createCASecret(t test.Failer, client kube.Client) {
 func readSampleCertFromFile(f string) ([]byte, error) {
 	return os.ReadFile(path.Join(env.IstioSrc, "samples/certs", f))
 }
+
+func TestMeshConfigRootCerts(t *testing.T) {
+	pem := func(p string) *meshconfig.MeshConfig_CertificateData_Pem {
+		return &meshconfig.MeshConfig_CertificateData_Pem{Pem: p}
+	}
+	assert.Equal(t, len(meshConfigRootCerts(&meshconfig.MeshConfig{})), 0)
+	assert.Equal(t, string(meshConfigRootCerts(&meshconfig.MeshConfig{
+		CaCertificates: []*meshconfig.MeshConfig_CertificateData{
+			{CertificateData: pem("root-a")},
+			{CertificateData: pem("signer-root\n"), CertSigners: []string{"example.com/custom"}},
+			{CertificateData: &meshconfig.MeshConfig_CertificateData_SpiffeBundleUrl{SpiffeBundleUrl: "https://example.com"}},
+			{CertificateData: pem("root-b\n")},
+		},
+	})), "root-a\nroot-b\n")
+}
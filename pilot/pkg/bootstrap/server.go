@@ -175,6 +175,8 @@ type Server struct {
 	webhookInfo *webhookInfo
 
 	statusManager *status.Manager
+	// statusManagerOnce ensures a single status manager is started. statusManager is only set once it starts.
+	statusManagerOnce sync.Once
 	// RWConfigStore is the configstore which allows updates, particularly for status.
 	RWConfigStore model.ConfigStoreController
 }
@@ -892,11 +894,18 @@ func (s *Server) initRegistryEventHandlers() {
 			}
 			s.XDSServer.ConfigUpdate(pushReq)
 		}
+		historyHandler := func(prev config.Config, curr config.Config, event model.Event) {
+			if event == model.EventUpdate && !needsPush(prev, curr) {
+				return
+			}
+			s.XDSServer.ConfigHistory.Record(prev, curr, event)
+		}
 		schemas := collections.Pilot.All()
 		if features.EnableGatewayAPI {
 			schemas = collections.PilotGatewayAPI().All()
 		}
 		for _, schema := range schemas {
+			s.configController.RegisterEventHandler(schema.GroupVersionKind(), historyHandler)
 			// This resource type was handled in external/servicediscovery.go, no need to rehandle here.
 			if schema.GroupVersionKind() == gvk.ServiceEntry {
 				continue
@@ -1352,10 +1361,12 @@ func (s *Server) isK8SSigning() bool {
 }
 
 func (s *Server) initStatusManager(_ *PilotArgs) {
-	s.addStartFunc("status manager", func(stop <-chan struct{}) error {
-		s.statusManager = status.NewManager(s.RWConfigStore)
-		s.statusManager.Start(stop)
-		return nil
+	s.statusManagerOnce.Do(func() {
+		s.addStartFunc("status manager", func(stop <-chan struct{}) error {
+			s.statusManager = status.NewManager(s.RWConfigStore)
+			s.statusManager.Start(stop)
+			return nil
+		})
 	})
 }
 
@@ -20,6 +20,7 @@ import (
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	k8s "sigs.k8s.io/gateway-api/apis/v1"
+	k8salpha "sigs.k8s.io/gateway-api/apis/v1alpha2"
 
 	"istio.io/istio/pilot/pkg/features"
 	"istio.io/istio/pilot/pkg/model/kstatus"
@@ -141,7 +142,12 @@ func createRouteStatus(parentResults []RouteParentResult, obj config.Config, cur
 			// * ResolvedRefs - used to describe errors about binding to objects
 			// But no general errors
 			// For now, we will treat all general route errors as "Ref" errors.
-			conds[string(k8s.RouteConditionResolvedRefs)].error = gw.RouteError
+			// Unsupported values are the exception: the spec reports them on Accepted, as the route is not programmed.
+			if gw.RouteError.Reason == UnsupportedValue {
+				conds[string(k8s.RouteConditionAccepted)].error = gw.RouteError
+			} else {
+				conds[string(k8s.RouteConditionResolvedRefs)].error = gw.RouteError
+			}
 		}
 		if gw.DeniedReason != nil {
 			conds[string(k8s.RouteConditionAccepted)].error = &ConfigError{
@@ -172,6 +178,51 @@ func createRouteStatus(parentResults []RouteParentResult, obj config.Config, cur
 	return parents
 }
 
+// policyAncestorResult holds the result of a policy for a specific ancestor
+type policyAncestorResult struct {
+	// AncestorRef is the ancestor the policy applies to
+	AncestorRef k8s.ParentReference
+	// Error, if present, indicates why the policy could not be applied to the ancestor
+	Error *ConfigError
+}
+
+func createPolicyAncestorStatus(results []policyAncestorResult, obj config.Config, current []k8salpha.PolicyAncestorStatus) []k8salpha.PolicyAncestorStatus {
+	ancestors := make([]k8salpha.PolicyAncestorStatus, 0, len(results))
+	// Keep all ancestors that are not owned by us, as other controllers may be reporting status on the same policy.
+	for _, a := range current {
+		if a.ControllerName != k8s.GatewayController(features.ManagedGatewayController) {
+			ancestors = append(ancestors, a)
+		}
+	}
+	for _, res := range results {
+		conds := map[string]*condition{
+			string(k8salpha.PolicyConditionAccepted): {
+				reason:  string(k8salpha.PolicyReasonAccepted),
+				message: "Configuration is valid",
+				error:   res.Error,
+			},
+		}
+		var currentConditions []metav1.Condition
+		currentStatus := slices.FindFunc(current, func(s k8salpha.PolicyAncestorStatus) bool {
+			return parentRefString(s.AncestorRef) == parentRefString(res.AncestorRef) &&
+				s.ControllerName == k8s.GatewayController(features.ManagedGatewayController)
+		})
+		if currentStatus != nil {
+			currentConditions = currentStatus.Conditions
+		}
+		ancestors = append(ancestors, k8salpha.PolicyAncestorStatus{
+			AncestorRef:    res.AncestorRef,
+			ControllerName: k8s.GatewayController(features.ManagedGatewayController),
+			Conditions:     setConditions(obj.Generation, currentConditions, conds),
+		})
+	}
+	// Ensure output is deterministic.
+	sort.SliceStable(ancestors, func(i, j int) bool {
+		return parentRefString(ancestors[i].AncestorRef) > parentRefString(ancestors[j].AncestorRef)
+	})
+	return ancestors
+}
+
 type ParentErrorReason string
 
 const (
@@ -206,8 +257,10 @@ const (
 	InvalidListenerRefNotPermitted ConfigErrorReason = ConfigErrorReason(k8s.ListenerReasonRefNotPermitted)
 	// InvalidConfiguration indicates a generic error for all other invalid configurations
 	InvalidConfiguration ConfigErrorReason = "InvalidConfiguration"
-	InvalidResources     ConfigErrorReason = ConfigErrorReason(k8s.GatewayReasonNoResources)
-	DeprecateFieldUsage                    = "DeprecatedField"
+	// UnsupportedValue indicates the route uses a value that cannot be implemented, such as traffic splitting for UDP
+	UnsupportedValue    ConfigErrorReason = ConfigErrorReason(k8s.RouteReasonUnsupportedValue)
+	InvalidResources    ConfigErrorReason = ConfigErrorReason(k8s.GatewayReasonNoResources)
+	DeprecateFieldUsage                   = "DeprecatedField"
 )
 
 // ParentError represents that a parent could not be referenced
@@ -337,7 +390,8 @@ func generateSupportedKinds(l k8s.Listener) ([]k8s.RouteGroupKind, bool) {
 		} else {
 			supported = []k8s.RouteGroupKind{{Group: (*k8s.Group)(ptr.Of(gvk.TCPRoute.Group)), Kind: k8s.Kind(gvk.TCPRoute.Kind)}}
 		}
-		// UDP route note support
+	case k8s.UDPProtocolType:
+		supported = []k8s.RouteGroupKind{{Group: (*k8s.Group)(ptr.Of(gvk.UDPRoute.Group)), Kind: k8s.Kind(gvk.UDPRoute.Kind)}}
 	}
 	if l.AllowedRoutes != nil && len(l.AllowedRoutes.Kinds) > 0 {
 		// We need to filter down to only ones we actually support
@@ -0,0 +1,514 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gateway
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apiextensions-apiserver/pkg/apis/apiextensions"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	structuralschema "k8s.io/apiextensions-apiserver/pkg/apiserver/schema"
+	structuraldefaulting "k8s.io/apiextensions-apiserver/pkg/apiserver/schema/defaulting"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	k8s "sigs.k8s.io/gateway-api/apis/v1"
+	k8salpha "sigs.k8s.io/gateway-api/apis/v1alpha2"
+	"sigs.k8s.io/gateway-api/conformance"
+	"sigs.k8s.io/gateway-api/conformance/tests"
+	confkube "sigs.k8s.io/gateway-api/conformance/utils/kubernetes"
+	"sigs.k8s.io/gateway-api/conformance/utils/suite"
+	"sigs.k8s.io/gateway-api/pkg/consts"
+	gwfeatures "sigs.k8s.io/gateway-api/pkg/features"
+	"sigs.k8s.io/yaml"
+
+	"istio.io/istio/pilot/pkg/config/kube/crd"
+	credentials "istio.io/istio/pilot/pkg/credentials/kube"
+	"istio.io/istio/pilot/pkg/features"
+	"istio.io/istio/pilot/pkg/model"
+	"istio.io/istio/pilot/pkg/model/kstatus"
+	"istio.io/istio/pilot/pkg/networking/core"
+	kubecontroller "istio.io/istio/pilot/pkg/serviceregistry/kube"
+	"istio.io/istio/pilot/test/util"
+	"istio.io/istio/pkg/config"
+	"istio.io/istio/pkg/config/constants"
+	"istio.io/istio/pkg/config/mesh"
+	"istio.io/istio/pkg/config/schema/collections"
+	"istio.io/istio/pkg/config/schema/gvk"
+	"istio.io/istio/pkg/kube"
+	"istio.io/istio/pkg/ptr"
+	"istio.io/istio/pkg/slices"
+	"istio.io/istio/pkg/test"
+	"istio.io/istio/pkg/test/env"
+	"istio.io/istio/pkg/test/util/yml"
+	"istio.io/istio/pkg/util/sets"
+)
+
+const (
+	conformancePassed   = "Passed"
+	conformanceFailed   = "Failed"
+	conformanceSkipped  = "Skipped"
+	conformanceUntested = "Untested"
+)
+
+// ConformanceReport describes how the upstream Gateway API conformance scenarios fare when run
+// offline through our translation. This does not replace the conformance suite, which needs a
+// cluster to send traffic; it only checks that every scenario translates to valid configuration
+// and that the status reported for each resource is consistent with the generated configuration.
+type ConformanceReport struct {
+	GatewayAPIVersion string                     `json:"gatewayAPIVersion"`
+	Features          []ConformanceFeatureReport `json:"features"`
+	Tests             []ConformanceTestReport    `json:"tests"`
+}
+
+type ConformanceFeatureReport struct {
+	Name    string `json:"name"`
+	Channel string `json:"channel"`
+	// Supported is true if the feature is declared in SupportedFeatures.
+	Supported bool     `json:"supported"`
+	Result    string   `json:"result"`
+	Tests     []string `json:"tests,omitempty"`
+}
+
+type ConformanceTestReport struct {
+	Name     string   `json:"name"`
+	Features []string `json:"features,omitempty"`
+	Result   string   `json:"result"`
+	Errors   []string `json:"errors,omitempty"`
+}
+
+// TestConformanceReport runs the Gateway API conformance scenarios through the translator and
+// compares the result with testdata/conformance-report.yaml. Regenerate with `make gateway-conformance-report`.
+func TestConformanceReport(t *testing.T) {
+	report := ConformanceReport{
+		GatewayAPIVersion: consts.BundleVersion,
+	}
+	defaults := gatewayAPIDefaults(t)
+	secrets := conformanceSecrets(t)
+	base := readConformanceManifests(t, defaults, "base/manifests.yaml", "mesh/manifests.yaml")
+	for _, ct := range tests.ConformanceTests {
+		report.Tests = append(report.Tests, runConformanceTest(t, ct, defaults, base, secrets))
+	}
+	sort.Slice(report.Tests, func(i, j int) bool {
+		return report.Tests[i].Name < report.Tests[j].Name
+	})
+	report.Features = buildFeatureReports(report.Tests)
+
+	out, err := yaml.Marshal(report)
+	if err != nil {
+		t.Fatal(err)
+	}
+	util.CompareContent(t, out, "testdata/conformance-report.yaml")
+}
+
+func buildFeatureReports(testReports []ConformanceTestReport) []ConformanceFeatureReport {
+	byFeature := map[string][]ConformanceTestReport{}
+	for _, tr := range testReports {
+		for _, f := range tr.Features {
+			byFeature[f] = append(byFeature[f], tr)
+		}
+	}
+	res := make([]ConformanceFeatureReport, 0, gwfeatures.AllFeatures.Len())
+	for _, f := range gwfeatures.AllFeatures.UnsortedList() {
+		fr := ConformanceFeatureReport{
+			Name:      string(f.Name),
+			Channel:   string(f.Channel),
+			Supported: SupportedFeatures.Has(f),
+			Result:    conformanceUntested,
+		}
+		for _, tr := range byFeature[string(f.Name)] {
+			fr.Tests = append(fr.Tests, tr.Name)
+			switch tr.Result {
+			case conformanceFailed:
+				fr.Result = conformanceFailed
+			case conformancePassed:
+				if fr.Result == conformanceUntested {
+					fr.Result = conformancePassed
+				}
+			}
+		}
+		res = append(res, fr)
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].Name < res[j].Name
+	})
+	return res
+}
+
+func runConformanceTest(t *testing.T, ct suite.ConformanceTest, defaults gatewayAPISchemas, base string,
+	secrets []runtime.Object,
+) (tr ConformanceTestReport) {
+	tr = ConformanceTestReport{
+		Name: ct.ShortName,
+		Features: slices.Sort(slices.Map(ct.Features, func(f gwfeatures.FeatureName) string {
+			return string(f)
+		})),
+		Result: conformancePassed,
+	}
+	if len(ct.Manifests) == 0 {
+		tr.Result = conformanceSkipped
+		return tr
+	}
+	fail := func(format string, args ...any) {
+		tr.Result = conformanceFailed
+		tr.Errors = append(tr.Errors, fmt.Sprintf(format, args...))
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			fail("translation panicked: %v", r)
+		}
+	}()
+
+	manifests := readConformanceManifests(t, defaults, ct.Manifests...)
+	testConfigs, _, err := crd.ParseInputs(manifests)
+	if err != nil {
+		fail("failed to parse manifests: %v", err)
+		return tr
+	}
+	all := yml.JoinString(base, manifests)
+	configs, _, err := crd.ParseInputs(all)
+	if err != nil {
+		fail("failed to parse manifests: %v", err)
+		return tr
+	}
+	configs = insertDefaults(configs)
+	services, namespaces := conformanceServicesAndNamespaces(t, all)
+
+	cg := core.NewConfigGenTest(t, core.TestOptions{
+		Services: services,
+	})
+	kr := splitInput(t, configs)
+	kr.Namespaces = namespaces
+	client := kube.NewFakeClient(secrets...)
+	kr.Credentials = credentials.NewCredentialsController(client, nil)
+	client.RunAndWait(test.NewStop(t))
+	kr.Context = NewGatewayContext(cg.PushContext(), "Kubernetes")
+	output := convertResources(kr)
+
+	generated := append(append(output.Gateway, output.VirtualService...), output.DestinationRule...)
+	for _, c := range generated {
+		s, _ := collections.All.FindByGroupVersionKind(c.GroupVersionKind)
+		if _, err := s.ValidateConfig(c); err != nil {
+			fail("generated %v %v/%v is invalid: %v", c.GroupVersionKind.Kind, c.Namespace, c.Name, err)
+		}
+	}
+
+	inTest := sets.New(slices.Map(testConfigs, conformanceKey)...)
+	routes := slices.Flatten([][]config.Config{kr.HTTPRoute, kr.GRPCRoute, kr.TLSRoute, kr.TCPRoute, kr.UDPRoute})
+	for _, cfgs := range [][]config.Config{kr.GatewayClass, kr.Gateway, routes, kr.BackendTLSPolicy} {
+		for _, c := range cfgs {
+			if inTest.Contains(conformanceKey(c)) && !statusReported(c) {
+				fail("no status reported for %v %v", c.GroupVersionKind.Kind, conformanceKey(c))
+			}
+		}
+	}
+	for _, err := range checkConformanceStatus(kr, routes, generated, inTest) {
+		fail("%v", err)
+	}
+	return tr
+}
+
+// checkConformanceStatus checks the status the controller reported for the resources in the test against each other
+// and against the generated configuration: GatewayClasses are accepted, Gateways and routes report the conditions
+// the specification requires, routes accepted by a Gateway are counted on its listeners, and accepted routes and
+// programmed Gateways have configuration generated for them.
+func checkConformanceStatus(kr GatewayResources, routes, generated []config.Config, inTest sets.String) []string {
+	var errs []string
+	generatedParents := sets.New[string]()
+	for _, c := range generated {
+		generatedParents.InsertAll(strings.Split(c.Annotations[constants.InternalParentNames], ",")...)
+	}
+
+	for _, c := range kr.GatewayClass {
+		s, ok := wrappedStatus(c).(*k8s.GatewayClassStatus)
+		if !inTest.Contains(conformanceKey(c)) || !ok {
+			continue
+		}
+		if st := conditionStatus(s.Conditions, string(k8s.GatewayClassConditionStatusAccepted)); st != metav1.ConditionTrue {
+			errs = append(errs, fmt.Sprintf("GatewayClass %v is not accepted: %v", c.Name, st))
+		}
+	}
+
+	// attached records each Gateway, and Gateway listener, that accepted a route.
+	attached := sets.New[string]()
+	gateways := map[string]*k8s.GatewayStatus{}
+	for _, c := range kr.Gateway {
+		if s, ok := wrappedStatus(c).(*k8s.GatewayStatus); ok {
+			gateways[c.Namespace+"/"+c.Name] = s
+		}
+	}
+	for _, c := range routes {
+		for _, p := range routeParents(c) {
+			if inTest.Contains(conformanceKey(c)) {
+				for _, ct := range []k8s.RouteConditionType{k8s.RouteConditionAccepted, k8s.RouteConditionResolvedRefs} {
+					if conditionStatus(p.Conditions, string(ct)) == "" {
+						errs = append(errs, fmt.Sprintf("%v has no %v condition for parent %v", conformanceKey(c), ct, p.ParentRef.Name))
+					}
+				}
+			}
+			if conditionStatus(p.Conditions, string(k8s.RouteConditionAccepted)) != metav1.ConditionTrue ||
+				ptr.OrDefault((*string)(p.ParentRef.Kind), gvk.KubernetesGateway.Kind) != gvk.KubernetesGateway.Kind {
+				continue
+			}
+			gw := string(ptr.OrDefault(p.ParentRef.Namespace, k8s.Namespace(c.Namespace))) + "/" + string(p.ParentRef.Name)
+			listener := gw
+			if p.ParentRef.SectionName != nil {
+				listener += "/" + string(*p.ParentRef.SectionName)
+			}
+			attached.InsertAll(gw, listener)
+			if !inTest.Contains(conformanceKey(c)) {
+				continue
+			}
+			s, f := gateways[gw]
+			if !f {
+				errs = append(errs, fmt.Sprintf("%v is accepted by unknown Gateway %v", conformanceKey(c), gw))
+				continue
+			}
+			if slices.FindFunc(s.Listeners, func(l k8s.ListenerStatus) bool {
+				return l.AttachedRoutes > 0 && (p.ParentRef.SectionName == nil || l.Name == *p.ParentRef.SectionName)
+			}) == nil {
+				errs = append(errs, fmt.Sprintf("%v is accepted by %v, but no listener counts it as attached", conformanceKey(c), listener))
+			}
+		}
+	}
+
+	for _, c := range kr.Gateway {
+		s, ok := wrappedStatus(c).(*k8s.GatewayStatus)
+		if !inTest.Contains(conformanceKey(c)) || !ok {
+			continue
+		}
+		gw := c.Namespace + "/" + c.Name
+		accepted := conditionStatus(s.Conditions, string(k8s.GatewayConditionAccepted))
+		programmed := conditionStatus(s.Conditions, string(k8s.GatewayConditionProgrammed))
+		if accepted == "" || programmed == "" {
+			errs = append(errs, fmt.Sprintf("Gateway %v is missing the Accepted or Programmed condition", gw))
+		}
+		if programmed == metav1.ConditionTrue && accepted != metav1.ConditionTrue {
+			errs = append(errs, fmt.Sprintf("Gateway %v is programmed but not accepted", gw))
+		}
+		for _, l := range s.Listeners {
+			if l.AttachedRoutes > 0 && !attached.Contains(gw) {
+				errs = append(errs, fmt.Sprintf("Gateway %v listener %v counts %d attached routes, but no route is accepted by it",
+					gw, l.Name, l.AttachedRoutes))
+			}
+		}
+		if programmed == metav1.ConditionTrue && slices.FindFunc(generatedParents.UnsortedList(), func(p string) bool {
+			return strings.HasPrefix(p, gvk.KubernetesGateway.Kind+"/"+c.Name+"/") && strings.HasSuffix(p, "."+c.Namespace)
+		}) == nil {
+			errs = append(errs, fmt.Sprintf("Gateway %v is programmed, but no Istio Gateway was generated for it", gw))
+		}
+	}
+
+	for _, c := range routes {
+		if !inTest.Contains(conformanceKey(c)) || c.GroupVersionKind == gvk.UDPRoute {
+			continue
+		}
+		parent := fmt.Sprintf("%s/%s.%s", c.GroupVersionKind.Kind, c.Name, c.Namespace)
+		if slices.FindFunc(routeParents(c), func(p k8s.RouteParentStatus) bool {
+			return conditionStatus(p.Conditions, string(k8s.RouteConditionAccepted)) == metav1.ConditionTrue
+		}) != nil && !generatedParents.Contains(parent) {
+			errs = append(errs, fmt.Sprintf("%v is accepted, but no VirtualService was generated for it", conformanceKey(c)))
+		}
+	}
+	return errs
+}
+
+func wrappedStatus(c config.Config) any {
+	ws, ok := c.Status.(*kstatus.WrappedStatus)
+	if !ok || ws == nil {
+		return nil
+	}
+	return ws.Status
+}
+
+func routeParents(c config.Config) []k8s.RouteParentStatus {
+	switch s := wrappedStatus(c).(type) {
+	case *k8s.HTTPRouteStatus:
+		return s.Parents
+	case *k8s.GRPCRouteStatus:
+		return s.Parents
+	case *k8salpha.TLSRouteStatus:
+		return s.Parents
+	case *k8salpha.TCPRouteStatus:
+		return s.Parents
+	case *k8salpha.UDPRouteStatus:
+		return s.Parents
+	}
+	return nil
+}
+
+// conditionStatus returns the status of the condition of the given type, or an empty string if it is not set.
+func conditionStatus(conditions []metav1.Condition, conditionType string) metav1.ConditionStatus {
+	for _, c := range conditions {
+		if c.Type == conditionType {
+			return c.Status
+		}
+	}
+	return ""
+}
+
+var conformanceStatusKinds = []config.GroupVersionKind{
+	gvk.GatewayClass,
+	gvk.KubernetesGateway,
+	gvk.HTTPRoute,
+	gvk.GRPCRoute,
+	gvk.TLSRoute,
+	gvk.TCPRoute,
+	gvk.UDPRoute,
+	gvk.BackendTLSPolicy,
+}
+
+func conformanceKey(c config.Config) string {
+	return c.GroupVersionKind.Kind + "/" + c.Namespace + "/" + c.Name
+}
+
+// statusReported returns true if the controller wrote any status for the resource.
+func statusReported(c config.Config) bool {
+	switch s := wrappedStatus(c).(type) {
+	case *k8s.GatewayClassStatus:
+		return len(s.Conditions) > 0
+	case *k8s.GatewayStatus:
+		return len(s.Conditions) > 0
+	case *k8s.HTTPRouteStatus:
+		return len(s.Parents) > 0
+	case *k8s.GRPCRouteStatus:
+		return len(s.Parents) > 0
+	case *k8salpha.TLSRouteStatus:
+		return len(s.Parents) > 0
+	case *k8salpha.TCPRouteStatus:
+		return len(s.Parents) > 0
+	case *k8salpha.UDPRouteStatus:
+		return len(s.Parents) > 0
+	case *k8salpha.PolicyStatus:
+		return len(s.Ancestors) > 0
+	}
+	return false
+}
+
+// readConformanceManifests reads manifests from the conformance suite, filling in the templated values the
+// suite would apply and the defaults the API server would apply.
+func readConformanceManifests(t test.Failer, defaults gatewayAPISchemas, names ...string) string {
+	res := make([]string, 0, len(names))
+	for _, n := range names {
+		b, err := fs.ReadFile(conformance.Manifests, n)
+		if err != nil {
+			t.Fatal(err)
+		}
+		m := strings.ReplaceAll(string(b), "{GATEWAY_CLASS_NAME}", "istio")
+		m = strings.ReplaceAll(m, "{GATEWAY_CONTROLLER_NAME}", string(features.ManagedGatewayController))
+		res = append(res, m)
+	}
+	return defaults.apply(t, yml.JoinString(res...))
+}
+
+// gatewayAPISchemas holds the Gateway API CRD schemas, by kind, so manifests can be defaulted as the API server would.
+type gatewayAPISchemas map[schema.GroupVersionKind]*structuralschema.Structural
+
+func gatewayAPIDefaults(t test.Failer) gatewayAPISchemas {
+	b, err := os.ReadFile(filepath.Join(env.IstioSrc, "tests/integration/pilot/testdata/gateway-api-crd.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	res := gatewayAPISchemas{}
+	for _, doc := range yml.SplitString(string(b)) {
+		crd := &apiextensionsv1.CustomResourceDefinition{}
+		if err := yaml.Unmarshal([]byte(doc), crd); err != nil {
+			t.Fatal(err)
+		}
+		for _, ver := range crd.Spec.Versions {
+			if ver.Schema == nil {
+				continue
+			}
+			props := &apiextensions.JSONSchemaProps{}
+			if err := apiextensionsv1.Convert_v1_JSONSchemaProps_To_apiextensions_JSONSchemaProps(ver.Schema.OpenAPIV3Schema, props, nil); err != nil {
+				t.Fatal(err)
+			}
+			structural, err := structuralschema.NewStructural(props)
+			if err != nil {
+				t.Fatal(err)
+			}
+			res[schema.GroupVersionKind{Group: crd.Spec.Group, Version: ver.Name, Kind: crd.Spec.Names.Kind}] = structural
+		}
+	}
+	return res
+}
+
+// apply fills in schema defaults for each resource in data. Resources without a known schema are returned unchanged.
+func (s gatewayAPISchemas) apply(t test.Failer, data string) string {
+	items := yml.SplitString(data)
+	res := make([]string, 0, len(items))
+	for _, item := range items {
+		obj := &unstructured.Unstructured{}
+		if err := yaml.Unmarshal([]byte(item), obj); err != nil {
+			t.Fatal(err)
+		}
+		structural, f := s[obj.GroupVersionKind()]
+		if !f {
+			res = append(res, item)
+			continue
+		}
+		structuraldefaulting.Default(obj.Object, structural)
+		b, err := yaml.Marshal(obj.Object)
+		if err != nil {
+			t.Fatal(err)
+		}
+		res = append(res, string(b))
+	}
+	return yml.JoinString(res...)
+}
+
+func conformanceServicesAndNamespaces(t test.Failer, manifests string) ([]*model.Service, map[string]*corev1.Namespace) {
+	services := []*model.Service{}
+	namespaces := map[string]*corev1.Namespace{}
+	for _, part := range yml.SplitString(manifests) {
+		meta := metav1.TypeMeta{}
+		if err := yaml.Unmarshal([]byte(part), &meta); err != nil {
+			t.Fatal(err)
+		}
+		switch meta.Kind {
+		case gvk.Service.Kind:
+			svc := corev1.Service{}
+			if err := yaml.Unmarshal([]byte(part), &svc); err != nil {
+				t.Fatal(err)
+			}
+			services = append(services, kubecontroller.ConvertService(svc, "domain.suffix", "Kubernetes", mesh.DefaultMeshConfig()))
+		case gvk.Namespace.Kind:
+			ns := &corev1.Namespace{}
+			if err := yaml.Unmarshal([]byte(part), ns); err != nil {
+				t.Fatal(err)
+			}
+			namespaces[ns.Name] = ns
+		}
+	}
+	return services, namespaces
+}
+
+// conformanceSecrets returns the certificates the conformance suite creates during setup.
+func conformanceSecrets(t *testing.T) []runtime.Object {
+	return []runtime.Object{
+		confkube.MustCreateSelfSignedCertSecret(t, "gateway-conformance-web-backend", "certificate", []string{"*"}),
+		confkube.MustCreateSelfSignedCertSecret(t, "gateway-conformance-infra", "tls-validity-checks-certificate", []string{"*", "*.org"}),
+		confkube.MustCreateSelfSignedCertSecret(t, "gateway-conformance-infra", "tls-passthrough-checks-certificate", []string{"abc.example.com"}),
+		confkube.MustCreateSelfSignedCertSecret(t, "gateway-conformance-app-backend", "tls-passthrough-checks-certificate", []string{"abc.example.com"}),
+	}
+}
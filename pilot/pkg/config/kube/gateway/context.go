@@ -150,6 +150,10 @@ func (gc GatewayContext) GetService(hostname, namespace string) *model.Service {
 	return gc.ps.ServiceIndex.HostnameAndNamespace[host.Name(hostname)][namespace]
 }
 
+func (gc GatewayContext) RootNamespace() string {
+	return gc.ps.Mesh.GetRootNamespace()
+}
+
 func instancesEmpty(m map[int][]*model.IstioEndpoint) bool {
 	for _, instances := range m {
 		if len(instances) > 0 {
@@ -57,7 +57,7 @@ var errUnsupportedOp = fmt.Errorf("unsupported operation: the gateway config sto
 // Rather than watching the CRs directly, we depend on the existing model.ConfigStoreController which
 // already watches all CRs. When there are updates, a new PushContext will be computed, which will eventually
 // call Controller.Reconcile(). Once this happens, we will inspect the current state of the world, and transform
-// gateway-api types into Istio types (Gateway/VirtualService). Future calls to Get/List will return these
+// gateway-api types into Istio types (Gateway/VirtualService/DestinationRule). Future calls to Get/List will return these
 // Istio types. These are not stored in the cluster at all, and are purely internal; they can be seen on /debug/configz.
 // During Reconcile(), the status on all gateway-api types is also tracked. Once completed, if the status
 // has changed at all, it is queued to asynchronously update the status of the object in Kubernetes.
@@ -133,6 +133,7 @@ func (c *Controller) Schemas() collection.Schemas {
 	return collection.SchemasFor(
 		collections.VirtualService,
 		collections.Gateway,
+		collections.DestinationRule,
 	)
 }
 
@@ -141,7 +142,7 @@ func (c *Controller) Get(typ config.GroupVersionKind, name, namespace string) *c
 }
 
 func (c *Controller) List(typ config.GroupVersionKind, namespace string) []config.Config {
-	if typ != gvk.Gateway && typ != gvk.VirtualService {
+	if typ != gvk.Gateway && typ != gvk.VirtualService && typ != gvk.DestinationRule {
 		return nil
 	}
 
@@ -152,6 +153,8 @@ func (c *Controller) List(typ config.GroupVersionKind, namespace string) []confi
 		return filterNamespace(c.state.Gateway, namespace)
 	case gvk.VirtualService:
 		return filterNamespace(c.state.VirtualService, namespace)
+	case gvk.DestinationRule:
+		return filterNamespace(c.state.DestinationRule, namespace)
 	default:
 		return nil
 	}
@@ -182,20 +185,26 @@ func (c *Controller) Reconcile(ps *model.PushContext) error {
 	grpcRoute := c.cache.List(gvk.GRPCRoute, metav1.NamespaceAll)
 	tcpRoute := c.cache.List(gvk.TCPRoute, metav1.NamespaceAll)
 	tlsRoute := c.cache.List(gvk.TLSRoute, metav1.NamespaceAll)
+	udpRoute := c.cache.List(gvk.UDPRoute, metav1.NamespaceAll)
 	referenceGrant := c.cache.List(gvk.ReferenceGrant, metav1.NamespaceAll)
+	backendTLSPolicy := c.cache.List(gvk.BackendTLSPolicy, metav1.NamespaceAll)
 	serviceEntry := c.cache.List(gvk.ServiceEntry, metav1.NamespaceAll) // TODO lazy load only referenced SEs?
+	destinationRule := c.cache.List(gvk.DestinationRule, metav1.NamespaceAll)
 
 	input := GatewayResources{
-		GatewayClass:   deepCopyStatus(gatewayClass),
-		Gateway:        deepCopyStatus(gateway),
-		HTTPRoute:      deepCopyStatus(httpRoute),
-		GRPCRoute:      deepCopyStatus(grpcRoute),
-		TCPRoute:       deepCopyStatus(tcpRoute),
-		TLSRoute:       deepCopyStatus(tlsRoute),
-		ReferenceGrant: referenceGrant,
-		ServiceEntry:   serviceEntry,
-		Domain:         c.domain,
-		Context:        NewGatewayContext(ps, c.cluster),
+		GatewayClass:     deepCopyStatus(gatewayClass),
+		Gateway:          deepCopyStatus(gateway),
+		HTTPRoute:        deepCopyStatus(httpRoute),
+		GRPCRoute:        deepCopyStatus(grpcRoute),
+		TCPRoute:         deepCopyStatus(tcpRoute),
+		TLSRoute:         deepCopyStatus(tlsRoute),
+		UDPRoute:         deepCopyStatus(udpRoute),
+		ReferenceGrant:   referenceGrant,
+		ServiceEntry:     serviceEntry,
+		BackendTLSPolicy: deepCopyStatus(backendTLSPolicy),
+		DestinationRule:  destinationRule,
+		Domain:           c.domain,
+		Context:          NewGatewayContext(ps, c.cluster),
 	}
 
 	if !input.hasResources() {
@@ -240,6 +249,8 @@ func (c *Controller) QueueStatusUpdates(r GatewayResources) {
 	c.handleStatusUpdates(r.GRPCRoute)
 	c.handleStatusUpdates(r.TCPRoute)
 	c.handleStatusUpdates(r.TLSRoute)
+	c.handleStatusUpdates(r.UDPRoute)
+	c.handleStatusUpdates(r.BackendTLSPolicy)
 }
 
 func (c *Controller) handleStatusUpdates(configs []config.Config) {
@@ -398,5 +409,7 @@ func (kr GatewayResources) hasResources() bool {
 		len(kr.GRPCRoute) > 0 ||
 		len(kr.TCPRoute) > 0 ||
 		len(kr.TLSRoute) > 0 ||
-		len(kr.ReferenceGrant) > 0
+		len(kr.UDPRoute) > 0 ||
+		len(kr.ReferenceGrant) > 0 ||
+		len(kr.BackendTLSPolicy) > 0
 }
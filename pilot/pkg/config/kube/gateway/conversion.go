@@ -30,9 +30,11 @@ import (
 	klabels "k8s.io/apimachinery/pkg/labels"
 	k8s "sigs.k8s.io/gateway-api/apis/v1"
 	k8salpha "sigs.k8s.io/gateway-api/apis/v1alpha2"
+	k8salpha3 "sigs.k8s.io/gateway-api/apis/v1alpha3"
 	k8sbeta "sigs.k8s.io/gateway-api/apis/v1beta1"
 
 	istio "istio.io/api/networking/v1alpha3"
+	"istio.io/api/type/v1beta1"
 	"istio.io/istio/pilot/pkg/features"
 	"istio.io/istio/pilot/pkg/model"
 	creds "istio.io/istio/pilot/pkg/model/credentials"
@@ -45,6 +47,7 @@ import (
 	"istio.io/istio/pkg/config/protocol"
 	"istio.io/istio/pkg/config/schema/gvk"
 	"istio.io/istio/pkg/config/schema/kind"
+	"istio.io/istio/pkg/maps"
 	"istio.io/istio/pkg/ptr"
 	"istio.io/istio/pkg/slices"
 	"istio.io/istio/pkg/util/sets"
@@ -68,6 +71,7 @@ func convertResources(r GatewayResources) IstioResources {
 	// sort HTTPRoutes by creation timestamp and namespace/name
 	sortConfigByCreationTime(r.HTTPRoute)
 	sortConfigByCreationTime(r.GRPCRoute)
+	sortConfigByCreationTime(r.BackendTLSPolicy)
 
 	result := IstioResources{}
 	ctx := configContext{
@@ -81,6 +85,7 @@ func convertResources(r GatewayResources) IstioResources {
 	result.Gateway = gw
 
 	result.VirtualService = convertVirtualService(ctx)
+	result.DestinationRule = convertBackendTLSPolicies(ctx)
 
 	// Once we have gone through all route computation, we will know how many routes bound to each gateway.
 	// Report this in the status.
@@ -124,6 +129,8 @@ func convertReferencePolicies(r GatewayResources) AllowedReferences {
 				fromKey.Kind = gvk.TLSRoute
 			} else if string(from.Group) == gvk.TCPRoute.Group && string(from.Kind) == gvk.TCPRoute.Kind {
 				fromKey.Kind = gvk.TCPRoute
+			} else if string(from.Group) == gvk.UDPRoute.Group && string(from.Kind) == gvk.UDPRoute.Kind {
+				fromKey.Kind = gvk.UDPRoute
 			} else {
 				// Not supported type. Not an error; may be for another controller
 				continue
@@ -170,6 +177,10 @@ func convertVirtualService(r configContext) []config.Config {
 		result = append(result, buildTLSVirtualService(r, obj)...)
 	}
 
+	for _, obj := range r.UDPRoute {
+		result = append(result, buildUDPVirtualService(r, obj)...)
+	}
+
 	// for gateway routes, build one VS per gateway+host
 	gatewayRoutes := make(map[string]map[string]*config.Config)
 	// for mesh routes, build one VS per namespace+host
@@ -193,6 +204,301 @@ func convertVirtualService(r configContext) []config.Config {
 	return result
 }
 
+// backendTLSTarget identifies a Service, and optionally a named port of it, that a BackendTLSPolicy applies to.
+type backendTLSTarget struct {
+	Name        string
+	Namespace   string
+	SectionName string
+}
+
+// backendTLSGateway is a Gateway whose workloads BackendTLSPolicy settings are applied to.
+type backendTLSGateway struct {
+	Name      string
+	Namespace string
+	Selector  map[string]string
+}
+
+// backendTLSGateways returns the Gateways we generated configuration for, along with the labels selecting their
+// workloads. Automatically deployed Gateways are selected by the gateway name label; manually deployed ones by the
+// selector of the Service they are bound to.
+func backendTLSGateways(r configContext) []backendTLSGateway {
+	res := []backendTLSGateway{}
+	for _, obj := range r.Gateway {
+		if _, f := r.GatewayReferences[parentKey{Kind: gvk.KubernetesGateway, Name: obj.Name, Namespace: obj.Namespace}]; !f {
+			continue
+		}
+		kgw := obj.Spec.(*k8s.GatewaySpec)
+		if IsManaged(kgw) {
+			res = append(res, backendTLSGateway{
+				Name:      obj.Name,
+				Namespace: obj.Namespace,
+				Selector:  map[string]string{constants.GatewayNameLabel: obj.Name},
+			})
+			continue
+		}
+		gatewayServices, _ := extractGatewayServices(r.GatewayResources, kgw, obj, classInfo{})
+		for _, hostname := range gatewayServices {
+			svc := r.Context.GetService(hostname, obj.Namespace)
+			if svc == nil || len(svc.Attributes.LabelSelectors) == 0 {
+				continue
+			}
+			res = append(res, backendTLSGateway{
+				Name:      obj.Name,
+				Namespace: obj.Namespace,
+				Selector:  maps.Clone(svc.Attributes.LabelSelectors),
+			})
+			// A Gateway can only be scoped to a single set of workloads
+			break
+		}
+	}
+	sort.Slice(res, func(i, j int) bool {
+		if r := cmp.Compare(res[i].Namespace, res[j].Namespace); r != 0 {
+			return r == -1
+		}
+		return cmp.Compare(res[i].Name, res[j].Name) == -1
+	})
+	return res
+}
+
+// convertBackendTLSPolicies takes all BackendTLSPolicy and generates DestinationRules originating TLS to the targeted
+// Services. A single set of TLS settings is computed per Service; policies targeting a specific port are applied as port
+// level settings. If multiple policies target the same Service (and port), the oldest one wins.
+// The settings only apply to traffic from Gateways, so rather than a mesh wide DestinationRule (which would replace
+// auto mTLS for sidecars), a DestinationRule is generated per Gateway, scoped to its workloads and namespace. As it
+// takes precedence over the DestinationRules without a workload selector, it is merged with the user DestinationRule
+// the Gateway would otherwise use for the Service.
+// The status of each policy is reported for the Gateways it is applied to, its ancestors.
+func convertBackendTLSPolicies(r configContext) []config.Config {
+	gateways := backendTLSGateways(r)
+	rules := map[string]*config.Config{}
+	hosts := []string{}
+	claimed := map[backendTLSTarget]config.Config{}
+	for _, obj := range r.BackendTLSPolicy {
+		spec := obj.Spec.(*k8salpha3.BackendTLSPolicySpec)
+		tls, tlsErr := buildBackendTLSSettings(spec.Validation, obj.Namespace)
+		parentName := parentMeta(obj, nil)[constants.InternalParentNames]
+		var policyErr *ConfigError
+		for _, ref := range spec.TargetRefs {
+			hostname, port, err := resolveBackendTLSTarget(r, ref, obj.Namespace)
+			if err == nil {
+				err = tlsErr
+			}
+			target := backendTLSTarget{Name: string(ref.Name), Namespace: obj.Namespace, SectionName: string(ptr.OrEmpty(ref.SectionName))}
+			if owner, f := claimed[target]; err == nil && f {
+				err = &ConfigError{
+					Reason:  string(k8salpha.PolicyReasonConflicted),
+					Message: fmt.Sprintf("target is already configured by BackendTLSPolicy %s/%s", owner.Namespace, owner.Name),
+				}
+			}
+			if err != nil {
+				if policyErr == nil {
+					policyErr = err
+				}
+				continue
+			}
+			claimed[target] = obj
+
+			cfg, f := rules[hostname]
+			if !f {
+				cfg = &config.Config{
+					Meta: config.Meta{
+						CreationTimestamp: obj.CreationTimestamp,
+						GroupVersionKind:  gvk.DestinationRule,
+						Name:              fmt.Sprintf("%s-%s-backend-tls-%s", ref.Name, obj.Namespace, constants.KubernetesGatewayName),
+						Annotations:       parentMeta(obj, nil),
+						Namespace:         obj.Namespace,
+						Domain:            r.Domain,
+					},
+					Spec: &istio.DestinationRule{
+						Host:          hostname,
+						TrafficPolicy: &istio.TrafficPolicy{},
+					},
+				}
+				rules[hostname] = cfg
+				hosts = append(hosts, hostname)
+			} else if !slices.Contains(strings.Split(cfg.Annotations[constants.InternalParentNames], ","), parentName) {
+				cfg.Annotations[constants.InternalParentNames] += "," + parentName
+			}
+			dr := cfg.Spec.(*istio.DestinationRule)
+			if port == nil {
+				dr.TrafficPolicy.Tls = tls
+			} else {
+				dr.TrafficPolicy.PortLevelSettings = append(dr.TrafficPolicy.PortLevelSettings, &istio.TrafficPolicy_PortTrafficPolicy{
+					Port: &istio.PortSelector{Number: uint32(port.Port)},
+					Tls:  tls,
+				})
+			}
+		}
+		results := make([]policyAncestorResult, 0, len(gateways))
+		for _, gw := range gateways {
+			results = append(results, policyAncestorResult{
+				AncestorRef: k8s.ParentReference{
+					Group:     ptr.Of(k8s.Group(gvk.KubernetesGateway.Group)),
+					Kind:      ptr.Of(k8s.Kind(gvk.KubernetesGateway.Kind)),
+					Name:      k8s.ObjectName(gw.Name),
+					Namespace: ptr.Of(k8s.Namespace(gw.Namespace)),
+				},
+				Error: policyErr,
+			})
+		}
+		obj.Status.(*kstatus.WrappedStatus).Mutate(func(s config.Status) config.Status {
+			ps := s.(*k8salpha.PolicyStatus)
+			ps.Ancestors = createPolicyAncestorStatus(results, obj, ps.Ancestors)
+			return ps
+		})
+	}
+	res := make([]config.Config, 0, len(hosts)*len(gateways))
+	for _, gw := range gateways {
+		for _, h := range hosts {
+			cfg := rules[h].DeepCopy()
+			dr := cfg.Spec.(*istio.DestinationRule)
+			if base := backendTLSBaseRule(r, h, gw.Namespace, cfg.Namespace); base != nil {
+				dr = mergeBackendTLSSettings(base.Spec.(*istio.DestinationRule), dr)
+				cfg.Spec = dr
+			}
+			cfg.Name = fmt.Sprintf("%s-%s", gw.Name, cfg.Name)
+			cfg.Namespace = gw.Namespace
+			dr.ExportTo = []string{"."}
+			dr.WorkloadSelector = &v1beta1.WorkloadSelector{MatchLabels: gw.Selector}
+			// The Gateway needs to be able to read the CA bundle referenced by the policy, which may be in another namespace
+			r.AllowedReferences.allowConfigMap(dr.TrafficPolicy.GetTls().GetCredentialName(), gw.Namespace)
+			for _, pls := range dr.TrafficPolicy.PortLevelSettings {
+				r.AllowedReferences.allowConfigMap(pls.GetTls().GetCredentialName(), gw.Namespace)
+			}
+			res = append(res, cfg)
+		}
+	}
+	return res
+}
+
+// backendTLSBaseRule returns the user DestinationRule for hostname that a Gateway in gwNamespace would use without
+// BackendTLSPolicy, looking in the Gateway namespace, then the Service namespace and finally the root namespace.
+// DestinationRules with a workload selector are not considered.
+func backendTLSBaseRule(r configContext, hostname, gwNamespace, svcNamespace string) *config.Config {
+	for _, ns := range []string{gwNamespace, svcNamespace, r.Context.RootNamespace()} {
+		for i, c := range r.DestinationRule {
+			dr := c.Spec.(*istio.DestinationRule)
+			if c.Namespace != ns || dr.WorkloadSelector != nil {
+				continue
+			}
+			h := dr.Host
+			if !strings.Contains(h, ".") {
+				h = fmt.Sprintf("%s.%s.svc.%s", h, c.Namespace, r.Domain)
+			}
+			if h != hostname {
+				continue
+			}
+			if len(dr.ExportTo) == 0 || slices.Contains(dr.ExportTo, "*") || slices.Contains(dr.ExportTo, gwNamespace) ||
+				(c.Namespace == gwNamespace && slices.Contains(dr.ExportTo, ".")) {
+				return &r.DestinationRule[i]
+			}
+		}
+	}
+	return nil
+}
+
+// mergeBackendTLSSettings returns a copy of base with the TLS settings of generated applied on top.
+func mergeBackendTLSSettings(base, generated *istio.DestinationRule) *istio.DestinationRule {
+	dr := base.DeepCopy()
+	dr.Host = generated.Host
+	if dr.TrafficPolicy == nil {
+		dr.TrafficPolicy = &istio.TrafficPolicy{}
+	}
+	if tls := generated.TrafficPolicy.GetTls(); tls != nil {
+		dr.TrafficPolicy.Tls = tls
+	}
+	for _, pls := range generated.TrafficPolicy.GetPortLevelSettings() {
+		existing := slices.FindFunc(dr.TrafficPolicy.PortLevelSettings, func(p *istio.TrafficPolicy_PortTrafficPolicy) bool {
+			return p.GetPort().GetNumber() == pls.GetPort().GetNumber()
+		})
+		if existing != nil {
+			(*existing).Tls = pls.Tls
+		} else {
+			dr.TrafficPolicy.PortLevelSettings = append(dr.TrafficPolicy.PortLevelSettings, pls)
+		}
+	}
+	return dr
+}
+
+// resolveBackendTLSTarget looks up the Service (and port, if a sectionName is set) a BackendTLSPolicy targets.
+func resolveBackendTLSTarget(
+	r configContext,
+	ref k8salpha.LocalPolicyTargetReferenceWithSectionName,
+	namespace string,
+) (string, *model.Port, *ConfigError) {
+	if string(ref.Group) != gvk.Service.Group || string(ref.Kind) != gvk.Service.Kind {
+		return "", nil, &ConfigError{
+			Reason:  string(k8salpha.PolicyReasonInvalid),
+			Message: fmt.Sprintf("unsupported targetRef: group %q kind %q", ref.Group, ref.Kind),
+		}
+	}
+	hostname := fmt.Sprintf("%s.%s.svc.%s", ref.Name, namespace, r.Domain)
+	svc := r.Context.GetService(hostname, namespace)
+	if svc == nil {
+		return "", nil, &ConfigError{
+			Reason:  string(k8salpha.PolicyReasonTargetNotFound),
+			Message: fmt.Sprintf("Service %s not found", hostname),
+		}
+	}
+	if ref.SectionName == nil {
+		return hostname, nil, nil
+	}
+	port, f := svc.Ports.Get(string(*ref.SectionName))
+	if !f {
+		return "", nil, &ConfigError{
+			Reason:  string(k8salpha.PolicyReasonTargetNotFound),
+			Message: fmt.Sprintf("port %q not found on Service %s", *ref.SectionName, hostname),
+		}
+	}
+	return hostname, port, nil
+}
+
+// buildBackendTLSSettings converts the validation settings of a BackendTLSPolicy into client TLS settings.
+func buildBackendTLSSettings(v k8salpha3.BackendTLSPolicyValidation, namespace string) (*istio.ClientTLSSettings, *ConfigError) {
+	invalid := func(format string, args ...any) *ConfigError {
+		return &ConfigError{Reason: string(k8salpha.PolicyReasonInvalid), Message: fmt.Sprintf(format, args...)}
+	}
+	tls := &istio.ClientTLSSettings{
+		Mode: istio.ClientTLSSettings_SIMPLE,
+		Sni:  string(v.Hostname),
+	}
+	for _, san := range v.SubjectAltNames {
+		switch san.Type {
+		case k8salpha3.HostnameSubjectAltNameType:
+			tls.SubjectAltNames = append(tls.SubjectAltNames, string(san.Hostname))
+		case k8salpha3.URISubjectAltNameType:
+			tls.SubjectAltNames = append(tls.SubjectAltNames, string(san.URI))
+		default:
+			return nil, invalid("unsupported subjectAltName type %q", san.Type)
+		}
+	}
+	// Without any explicit SANs, the certificate is verified against the hostname.
+	if len(tls.SubjectAltNames) == 0 {
+		tls.SubjectAltNames = []string{string(v.Hostname)}
+	}
+
+	switch {
+	case len(v.CACertificateRefs) > 0 && v.WellKnownCACertificates != nil:
+		return nil, invalid("only one of caCertificateRefs or wellKnownCACertificates may be set")
+	case v.WellKnownCACertificates != nil:
+		if *v.WellKnownCACertificates != k8salpha3.WellKnownCACertificatesSystem {
+			return nil, invalid("unsupported wellKnownCACertificates %q", *v.WellKnownCACertificates)
+		}
+		// Leaving the CA unset verifies against the system root certificates.
+	case len(v.CACertificateRefs) == 1:
+		ref := v.CACertificateRefs[0]
+		if string(ref.Group) != gvk.ConfigMap.Group || string(ref.Kind) != gvk.ConfigMap.Kind {
+			return nil, invalid("unsupported caCertificateRef: group %q kind %q", ref.Group, ref.Kind)
+		}
+		tls.CredentialName = creds.KubernetesConfigMapTypeURI + namespace + "/" + string(ref.Name)
+	case len(v.CACertificateRefs) > 1:
+		return nil, invalid("only a single caCertificateRef is supported")
+	default:
+		return nil, invalid("one of caCertificateRefs or wellKnownCACertificates must be set")
+	}
+	return tls, nil
+}
+
 func convertHTTPRoute(r k8s.HTTPRouteRule, ctx configContext,
 	obj config.Config, pos int, enforceRefGrant bool,
 ) (*istio.HTTPRoute, *ConfigError) {
@@ -1222,6 +1528,107 @@ func buildTLSVirtualService(ctx configContext, obj config.Config) []config.Confi
 	return vs
 }
 
+// buildUDPVirtualService converts a UDPRoute to VirtualServices with TCP routes, which gateways serve on UDP servers.
+// UDPRoute is only supported for Gateway parents; the mesh does not allow the kind, so those references are rejected.
+func buildUDPVirtualService(ctx configContext, obj config.Config) []config.Config {
+	route := obj.Spec.(*k8salpha.UDPRouteSpec)
+	parentRefs := extractParentReferenceInfo(ctx.GatewayReferences, route.ParentRefs, nil, gvk.UDPRoute, obj.Namespace)
+
+	var routeErr *ConfigError
+	routes := []*istio.TCPRoute{}
+	if len(route.Rules) > 1 {
+		// UDP has nothing to match on, so only one rule could ever be used
+		routeErr = &ConfigError{
+			Reason:  UnsupportedValue,
+			Message: "UDPRoute supports only a single rule",
+		}
+		routes = nil
+	} else {
+		for _, r := range route.Rules {
+			vs, err := convertUDPRoute(ctx, r, obj)
+			// This was a hard error
+			if vs == nil {
+				routeErr = err
+				routes = nil
+				break
+			}
+			// Got an error but also routes
+			if err != nil {
+				routeErr = err
+			}
+			routes = append(routes, vs)
+		}
+	}
+	obj.Status.(*kstatus.WrappedStatus).Mutate(func(s config.Status) config.Status {
+		rs := s.(*k8salpha.UDPRouteStatus)
+		rs.Parents = createRouteStatus(slices.Map(parentRefs, func(r routeParentReference) RouteParentResult {
+			return RouteParentResult{
+				OriginalReference: r.OriginalReference,
+				DeniedReason:      r.DeniedReason,
+				RouteError:        routeErr,
+			}
+		}), obj, rs.Parents)
+		return rs
+	})
+	if routes == nil {
+		return nil
+	}
+
+	vs := []config.Config{}
+	for i, parent := range filteredReferences(parentRefs) {
+		vs = append(vs, config.Config{
+			Meta: config.Meta{
+				CreationTimestamp: obj.CreationTimestamp,
+				GroupVersionKind:  gvk.VirtualService,
+				Name:              fmt.Sprintf("%s-udp-%d-%s", obj.Name, i, constants.KubernetesGatewayName),
+				Annotations:       routeMeta(obj),
+				Namespace:         obj.Namespace,
+				Domain:            ctx.Domain,
+			},
+			Spec: &istio.VirtualService{
+				Hosts:    []string{"*"},
+				Gateways: []string{parent.InternalName},
+				Tcp:      routes,
+			},
+		})
+	}
+	return vs
+}
+
+func convertUDPRoute(ctx configContext, r k8salpha.UDPRouteRule, obj config.Config) (*istio.TCPRoute, *ConfigError) {
+	if tcpWeightSum(r.BackendRefs) == 0 {
+		// The spec requires us to reject traffic when there are no >0 weight backends
+		return &istio.TCPRoute{
+			Route: []*istio.RouteDestination{{
+				Destination: &istio.Destination{
+					Host:   "internal.cluster.local",
+					Subset: "zero-weight",
+					Port:   &istio.PortSelector{Number: 65535},
+				},
+				Weight: 0,
+			}},
+		}, nil
+	}
+	backends := slices.FilterInPlace(slices.Clone(r.BackendRefs), func(ref k8s.BackendRef) bool {
+		return ptr.OrDefault(ref.Weight, 1) != 0
+	})
+	if len(backends) > 1 {
+		// UDP proxying has no notion of traffic splitting, so we cannot honor weights
+		return nil, &ConfigError{
+			Reason:  UnsupportedValue,
+			Message: "UDPRoute supports only a single backendRef with non-zero weight per rule, as UDP traffic cannot be split",
+		}
+	}
+	// Gateways are the only supported parent, so ReferenceGrant is always enforced
+	dest, backendErr, err := buildTCPDestination(ctx, backends, obj.Namespace, true, gvk.UDPRoute)
+	if err != nil {
+		return nil, err
+	}
+	return &istio.TCPRoute{
+		Route: dest,
+	}, backendErr
+}
+
 func convertTCPRoute(ctx configContext, r k8salpha.TCPRouteRule, obj config.Config, enforceRefGrant bool) (*istio.TCPRoute, *ConfigError) {
 	if tcpWeightSum(r.BackendRefs) == 0 {
 		// The spec requires us to reject connections when there are no >0 weight backends
@@ -2696,5 +3103,15 @@ func (kr GatewayResources) FuzzValidate() bool {
 			return false
 		}
 	}
+	for _, ur := range kr.UDPRoute {
+		if ur.Spec == nil {
+			return false
+		}
+	}
+	for _, p := range kr.BackendTLSPolicy {
+		if p.Spec == nil {
+			return false
+		}
+	}
 	return true
 }
@@ -66,6 +66,11 @@ var ports = []*model.Port{
 		Port:     34001,
 		Protocol: "TCP",
 	},
+	{
+		Name:     "udp",
+		Port:     5353,
+		Protocol: "UDP",
+	},
 }
 
 var services = []*model.Service{
@@ -73,6 +78,9 @@ var services = []*model.Service{
 		Attributes: model.ServiceAttributes{
 			Name:      "istio-ingressgateway",
 			Namespace: "istio-system",
+			LabelSelectors: map[string]string{
+				"istio": "ingressgateway",
+			},
 			ClusterExternalAddresses: &model.AddressMap{
 				Addresses: map[cluster.ID][]string{
 					constants.DefaultClusterName: {"1.2.3.4"},
@@ -415,6 +423,13 @@ func TestConvertResources(t *testing.T) {
 		{name: "route-precedence"},
 		{name: "waypoint"},
 		{name: "isolation"},
+		{name: "backend-tls"},
+		{
+			name: "udp",
+			validationIgnorer: crdvalidation.NewValidationIgnorer(
+				"default/^not-allowed-udp-",
+			),
+		},
 	}
 	for _, tt := range cases {
 		t.Run(tt.name, func(t *testing.T) {
@@ -453,6 +468,7 @@ func TestConvertResources(t *testing.T) {
 			})
 			goldenFile := fmt.Sprintf("testdata/%s.yaml.golden", tt.name)
 			res := append(output.Gateway, output.VirtualService...)
+			res = append(res, output.DestinationRule...)
 			util.CompareContent(t, marshalYaml(t, res), goldenFile)
 			golden := splitOutput(readConfig(t, goldenFile, validator, tt.validationIgnorer))
 
@@ -463,7 +479,7 @@ func TestConvertResources(t *testing.T) {
 
 			assert.Equal(t, golden, output)
 
-			outputStatus := getStatus(t, kr.GatewayClass, kr.Gateway, kr.HTTPRoute, kr.GRPCRoute, kr.TLSRoute, kr.TCPRoute)
+			outputStatus := getStatus(t, kr.GatewayClass, kr.Gateway, kr.HTTPRoute, kr.GRPCRoute, kr.TLSRoute, kr.TCPRoute, kr.UDPRoute, kr.BackendTLSPolicy)
 			goldenStatusFile := fmt.Sprintf("testdata/%s.status.yaml.golden", tt.name)
 			if util.Refresh() {
 				if err := os.WriteFile(goldenStatusFile, outputStatus, 0o644); err != nil {
@@ -1110,11 +1126,56 @@ spec:
 				{"kubernetes-gateway://default/private", "default", false},
 			},
 		},
+		{
+			name: "backend tls configmap",
+			config: `apiVersion: gateway.networking.k8s.io/v1beta1
+kind: GatewayClass
+metadata:
+  name: istio
+spec:
+  controllerName: istio.io/gateway-controller
+---
+apiVersion: gateway.networking.k8s.io/v1beta1
+kind: Gateway
+metadata:
+  name: gateway
+  namespace: istio-system
+spec:
+  gatewayClassName: istio
+  listeners:
+  - name: default
+    port: 80
+    protocol: HTTP
+---
+apiVersion: gateway.networking.k8s.io/v1alpha3
+kind: BackendTLSPolicy
+metadata:
+  name: ca-configmap
+  namespace: default
+spec:
+  targetRefs:
+  - group: ""
+    kind: Service
+    name: httpbin
+  validation:
+    caCertificateRefs:
+    - group: ""
+      kind: ConfigMap
+      name: auth-cert
+    hostname: httpbin.example.com
+`,
+			expectations: []res{
+				{"configmap://default/auth-cert-cacert", "istio-system", true},
+				{"configmap://default/auth-cert-cacert", "default", false},
+				{"configmap://default/other-cacert", "istio-system", false},
+				{"kubernetes-gateway://default/auth-cert", "istio-system", false},
+			},
+		},
 	}
 	for _, tt := range cases {
 		t.Run(tt.name, func(t *testing.T) {
 			input := readConfigString(t, tt.config, validator, nil)
-			cg := core.NewConfigGenTest(t, core.TestOptions{})
+			cg := core.NewConfigGenTest(t, core.TestOptions{Services: services})
 			kr := splitInput(t, input)
 			kr.Context = NewGatewayContext(cg.PushContext(), "Kubernetes")
 			output := convertResources(kr)
@@ -1155,8 +1216,9 @@ var timestampRegex = regexp.MustCompile(`lastTransitionTime:.*`)
 
 func splitOutput(configs []config.Config) IstioResources {
 	out := IstioResources{
-		Gateway:        []config.Config{},
-		VirtualService: []config.Config{},
+		Gateway:         []config.Config{},
+		VirtualService:  []config.Config{},
+		DestinationRule: []config.Config{},
 	}
 	for _, c := range configs {
 		c.Domain = "domain.suffix"
@@ -1165,6 +1227,8 @@ func splitOutput(configs []config.Config) IstioResources {
 			out.Gateway = append(out.Gateway, c)
 		case gvk.VirtualService:
 			out.VirtualService = append(out.VirtualService, c)
+		case gvk.DestinationRule:
+			out.DestinationRule = append(out.DestinationRule, c)
 		}
 	}
 	return out
@@ -1188,10 +1252,16 @@ func splitInput(t test.Failer, configs []config.Config) GatewayResources {
 			out.TCPRoute = append(out.TCPRoute, c)
 		case gvk.TLSRoute:
 			out.TLSRoute = append(out.TLSRoute, c)
+		case gvk.UDPRoute:
+			out.UDPRoute = append(out.UDPRoute, c)
 		case gvk.ReferenceGrant:
 			out.ReferenceGrant = append(out.ReferenceGrant, c)
 		case gvk.ServiceEntry:
 			out.ServiceEntry = append(out.ServiceEntry, c)
+		case gvk.DestinationRule:
+			out.DestinationRule = append(out.DestinationRule, c)
+		case gvk.BackendTLSPolicy:
+			out.BackendTLSPolicy = append(out.BackendTLSPolicy, c)
 		}
 	}
 	out.Namespaces = map[string]*corev1.Namespace{}
@@ -1254,6 +1324,10 @@ func insertDefaults(cfgs []config.Config) []config.Config {
 			c.Status = kstatus.Wrap(&k8salpha.TCPRouteStatus{})
 		case gvk.TLSRoute:
 			c.Status = kstatus.Wrap(&k8salpha.TLSRouteStatus{})
+		case gvk.UDPRoute:
+			c.Status = kstatus.Wrap(&k8salpha.UDPRouteStatus{})
+		case gvk.BackendTLSPolicy:
+			c.Status = kstatus.Wrap(&k8salpha.PolicyStatus{})
 		}
 		res = append(res, c)
 	}
@@ -644,12 +644,22 @@ func extractServicePorts(gw gateway.Gateway) []corev1.ServicePort {
 		Port:        int32(15021),
 		AppProtocol: &tcp,
 	})
-	portNums := sets.New[int32]()
+	type portKey struct {
+		port     int32
+		protocol corev1.Protocol
+	}
+	portNums := sets.New[portKey]()
 	for i, l := range gw.Spec.Listeners {
-		if portNums.Contains(int32(l.Port)) {
+		// UDP listeners are exposed on a separate Service port, as they may share a port number with a TCP listener.
+		var svcProtocol corev1.Protocol
+		if l.Protocol == gatewayv1.UDPProtocolType {
+			svcProtocol = corev1.ProtocolUDP
+		}
+		key := portKey{int32(l.Port), svcProtocol}
+		if portNums.Contains(key) {
 			continue
 		}
-		portNums.Insert(int32(l.Port))
+		portNums.Insert(key)
 		name := sanitizeListenerNameForPort(string(l.Name))
 		if name == "" {
 			// Should not happen since name is required, but in case an invalid resource gets in...
@@ -659,6 +669,7 @@ func extractServicePorts(gw gateway.Gateway) []corev1.ServicePort {
 		svcPorts = append(svcPorts, corev1.ServicePort{
 			Name:        name,
 			Port:        int32(l.Port),
+			Protocol:    svcProtocol,
 			AppProtocol: &appProtocol,
 		})
 	}
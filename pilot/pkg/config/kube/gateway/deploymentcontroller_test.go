@@ -189,6 +189,32 @@ func TestConfigureIstioGateway(t *testing.T) {
 			objects:                  defaultObjects,
 			discoveryNamespaceFilter: discoveryNamespacesFilter,
 		},
+		{
+			name: "udp",
+			gw: k8sbeta.Gateway{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "default",
+					Namespace: "default",
+				},
+				Spec: k8s.GatewaySpec{
+					GatewayClassName: k8s.ObjectName(features.GatewayAPIDefaultGatewayClass),
+					Listeners: []k8s.Listener{
+						{
+							Name:     "dns-tcp",
+							Port:     k8s.PortNumber(53),
+							Protocol: k8s.TCPProtocolType,
+						},
+						{
+							Name:     "dns-udp",
+							Port:     k8s.PortNumber(53),
+							Protocol: k8s.UDPProtocolType,
+						},
+					},
+				},
+			},
+			objects:                  defaultObjects,
+			discoveryNamespaceFilter: discoveryNamespacesFilter,
+		},
 		{
 			name: "multinetwork",
 			gw: k8sbeta.Gateway{
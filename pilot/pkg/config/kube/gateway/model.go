@@ -15,6 +15,8 @@
 package gateway
 
 import (
+	"strings"
+
 	corev1 "k8s.io/api/core/v1"
 	k8s "sigs.k8s.io/gateway-api/apis/v1alpha2"
 
@@ -43,8 +45,13 @@ type GatewayResources struct {
 	GRPCRoute      []config.Config
 	TCPRoute       []config.Config
 	TLSRoute       []config.Config
+	UDPRoute       []config.Config
 	ReferenceGrant []config.Config
 	ServiceEntry   []config.Config
+	// BackendTLSPolicy stores all BackendTLSPolicy in the cluster
+	BackendTLSPolicy []config.Config
+	// DestinationRule stores all user DestinationRule, which BackendTLSPolicy settings are merged with
+	DestinationRule []config.Config
 	// Namespaces stores all namespace in the cluster, keyed by name
 	Namespaces map[string]*corev1.Namespace
 	// Credentials stores all credentials in the cluster
@@ -70,11 +77,34 @@ func (refs AllowedReferences) SecretAllowed(resourceName string, namespace strin
 	}
 	from := Reference{Kind: gvk.KubernetesGateway, Namespace: k8s.Namespace(namespace)}
 	to := Reference{Kind: gvk.Secret, Namespace: k8s.Namespace(p.Namespace)}
+	name := p.Name
+	if p.ResourceType == creds.KubernetesConfigMapType {
+		// ConfigMaps are only referenced for their CA bundle
+		to.Kind = gvk.ConfigMap
+		name = strings.TrimSuffix(name, creds.SdsCaSuffix)
+	}
 	allow := refs[from][to]
 	if allow == nil {
 		return false
 	}
-	return allow.AllowAll || allow.AllowedNames.Contains(p.Name)
+	return allow.AllowAll || allow.AllowedNames.Contains(name)
+}
+
+// allowConfigMap records that Gateways in the namespace may access the ConfigMap referenced by credentialName.
+func (refs AllowedReferences) allowConfigMap(credentialName string, namespace string) {
+	p, err := creds.ParseResourceName(credentialName, "", "", "")
+	if err != nil || p.ResourceType != creds.KubernetesConfigMapType {
+		return
+	}
+	from := Reference{Kind: gvk.KubernetesGateway, Namespace: k8s.Namespace(namespace)}
+	to := Reference{Kind: gvk.ConfigMap, Namespace: k8s.Namespace(p.Namespace)}
+	if _, f := refs[from]; !f {
+		refs[from] = map[Reference]*Grants{}
+	}
+	if _, f := refs[from][to]; !f {
+		refs[from][to] = &Grants{AllowedNames: sets.New[string]()}
+	}
+	refs[from][to].AllowedNames.Insert(p.Name)
 }
 
 func (refs AllowedReferences) BackendAllowed(
@@ -94,8 +124,9 @@ func (refs AllowedReferences) BackendAllowed(
 
 // IstioResources stores all outputs of our conversion
 type IstioResources struct {
-	Gateway        []config.Config
-	VirtualService []config.Config
+	Gateway         []config.Config
+	VirtualService  []config.Config
+	DestinationRule []config.Config
 	// AllowedReferences stores all allowed references, from Reference -> to Reference(s)
 	AllowedReferences AllowedReferences
 	// ReferencedNamespaceKeys stores the label key of all namespace selections. This allows us to quickly
@@ -18,4 +18,7 @@ import (
 	"sigs.k8s.io/gateway-api/pkg/features"
 )
 
+// SupportedFeatures declares the Gateway API features we claim to support.
+// testdata/conformance-report.yaml records how each of them fares when the conformance scenarios are run
+// through our translation; see TestConformanceReport.
 var SupportedFeatures = features.AllFeatures
@@ -0,0 +1,66 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package trafficshift
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// FakeMetricsSource is a MetricsSource returning configured values, for tests.
+type FakeMetricsSource struct {
+	mu      sync.Mutex
+	results map[string]float64
+	errors  map[string]error
+}
+
+var _ MetricsSource = &FakeMetricsSource{}
+
+// NewFakeMetricsSource creates a FakeMetricsSource without any results.
+func NewFakeMetricsSource() *FakeMetricsSource {
+	return &FakeMetricsSource{
+		results: map[string]float64{},
+		errors:  map[string]error{},
+	}
+}
+
+// Set makes query return value.
+func (f *FakeMetricsSource) Set(query string, value float64) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	delete(f.errors, query)
+	f.results[query] = value
+}
+
+// SetError makes query fail with err.
+func (f *FakeMetricsSource) SetError(query string, err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.errors[query] = err
+}
+
+func (f *FakeMetricsSource) Query(_ context.Context, query string) (float64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.errors[query]; err != nil {
+		return 0, err
+	}
+	v, ok := f.results[query]
+	if !ok {
+		return 0, fmt.Errorf("no result for query %q", query)
+	}
+	return v, nil
+}
@@ -0,0 +1,128 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package trafficshift
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// MetricsSource evaluates the success rate query of a traffic shift.
+type MetricsSource interface {
+	// Query evaluates query and returns its single numeric result.
+	Query(ctx context.Context, query string) (float64, error)
+}
+
+// Prometheus is a MetricsSource backed by the Prometheus HTTP API.
+type Prometheus struct {
+	address string
+	client  *http.Client
+}
+
+var _ MetricsSource = &Prometheus{}
+
+// NewPrometheus creates a MetricsSource querying the Prometheus server at address, such as
+// "http://prometheus.istio-system:9090".
+func NewPrometheus(address string) *Prometheus {
+	return &Prometheus{
+		address: strings.TrimSuffix(address, "/"),
+		client:  &http.Client{},
+	}
+}
+
+type promResponse struct {
+	Status    string `json:"status"`
+	ErrorType string `json:"errorType"`
+	Error     string `json:"error"`
+	Data      struct {
+		ResultType string          `json:"resultType"`
+		Result     json.RawMessage `json:"result"`
+	} `json:"data"`
+}
+
+// Query runs an instant query. The result must be a scalar or a vector with exactly one sample.
+func (p *Prometheus) Query(ctx context.Context, query string) (float64, error) {
+	u := p.address + "/api/v1/query?" + url.Values{"query": {query}}.Encode()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return 0, err
+	}
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("prometheus query failed: %v", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read prometheus response: %v", err)
+	}
+	return parsePrometheusResponse(body)
+}
+
+func parsePrometheusResponse(body []byte) (float64, error) {
+	var resp promResponse
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return 0, fmt.Errorf("invalid prometheus response: %v", err)
+	}
+	if resp.Status != "success" {
+		return 0, fmt.Errorf("prometheus query failed: %s: %s", resp.ErrorType, resp.Error)
+	}
+
+	// Samples are encoded as [<unix time>, "<value>"].
+	var sample []any
+	switch resp.Data.ResultType {
+	case "scalar":
+		if err := json.Unmarshal(resp.Data.Result, &sample); err != nil {
+			return 0, fmt.Errorf("invalid scalar result: %v", err)
+		}
+	case "vector":
+		var vector []struct {
+			Value []any `json:"value"`
+		}
+		if err := json.Unmarshal(resp.Data.Result, &vector); err != nil {
+			return 0, fmt.Errorf("invalid vector result: %v", err)
+		}
+		if len(vector) != 1 {
+			return 0, fmt.Errorf("expected a single sample, got %d", len(vector))
+		}
+		sample = vector[0].Value
+	default:
+		return 0, fmt.Errorf("unsupported result type %q", resp.Data.ResultType)
+	}
+
+	if len(sample) != 2 {
+		return 0, fmt.Errorf("invalid sample %v", sample)
+	}
+	s, ok := sample[1].(string)
+	if !ok {
+		return 0, fmt.Errorf("invalid sample value %v", sample[1])
+	}
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid sample value %q: %v", s, err)
+	}
+	if math.IsNaN(v) {
+		// Typically a rate over no requests, which says nothing about the canary.
+		return 0, fmt.Errorf("query returned NaN")
+	}
+	return v, nil
+}
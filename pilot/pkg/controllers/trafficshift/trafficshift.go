@@ -0,0 +1,463 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package trafficshift implements a controller that progressively shifts traffic to a canary destination of a
+// VirtualService, advancing through a list of weights while a success rate query stays above a threshold, and
+// rolling back otherwise.
+package trafficshift
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/yaml"
+
+	"istio.io/api/meta/v1alpha1"
+	networking "istio.io/api/networking/v1alpha3"
+	"istio.io/istio/pilot/pkg/model"
+	"istio.io/istio/pilot/pkg/status"
+	"istio.io/istio/pkg/config"
+	"istio.io/istio/pkg/config/schema/gvk"
+	"istio.io/istio/pkg/kube/controllers"
+	istiolog "istio.io/istio/pkg/log"
+	"istio.io/istio/pkg/slices"
+)
+
+var log = istiolog.RegisterScope("traffic-shift", "traffic shift controller")
+
+const (
+	controllerName = "traffic shift controller"
+
+	// Annotation configures the traffic shift of a VirtualService. The value is a YAML or JSON encoded Spec.
+	Annotation = "networking.istio.io/traffic-shift"
+	// StateAnnotation records the progress of the traffic shift of a VirtualService. It is owned by the controller.
+	StateAnnotation = "networking.istio.io/traffic-shift-state"
+
+	// ConditionType is the type of the VirtualService status condition reporting the traffic shift progress.
+	ConditionType = "TrafficShift"
+
+	// queryTimeout bounds how long a single metrics query may take.
+	queryTimeout = 30 * time.Second
+)
+
+// Phase is the phase of a traffic shift.
+type Phase string
+
+const (
+	// PhaseProgressing means traffic is being shifted to the canary.
+	PhaseProgressing Phase = "Progressing"
+	// PhaseSucceeded means the canary passed the analysis of every step.
+	PhaseSucceeded Phase = "Succeeded"
+	// PhaseRolledBack means the canary failed the analysis and all traffic was shifted back.
+	PhaseRolledBack Phase = "RolledBack"
+	// PhaseInvalid means the traffic shift configuration could not be applied.
+	PhaseInvalid Phase = "Invalid"
+)
+
+// Destination identifies the canary destination in the HTTP routes of a VirtualService.
+type Destination struct {
+	Host   string `json:"host"`
+	Subset string `json:"subset,omitempty"`
+}
+
+// Spec describes the traffic shift of a VirtualService.
+type Spec struct {
+	// Canary is the destination that traffic is shifted to. The remaining weight of each HTTP route
+	// containing it is split evenly between the other destinations of the route. On rollback, the weights
+	// the routes had before the traffic shift are restored.
+	Canary Destination `json:"canary"`
+	// Routes restricts the traffic shift to the HTTP routes with these names. By default, all HTTP routes
+	// containing the canary destination are shifted.
+	Routes []string `json:"routes,omitempty"`
+	// Steps are the increasing weights, between 1 and 100, the canary receives during the traffic shift.
+	Steps []int32 `json:"steps"`
+	// Interval is how long each step lasts before its success rate is evaluated.
+	Interval duration `json:"interval"`
+	// Query is a Prometheus-compatible query returning the success rate of the canary, between 0 and 1.
+	Query string `json:"query"`
+	// Threshold is the minimum success rate for the traffic shift to advance. Lower values roll it back.
+	Threshold float64 `json:"threshold"`
+}
+
+// duration is a time.Duration that is encoded as a string, such as "5m".
+type duration struct {
+	time.Duration
+}
+
+func (d *duration) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return err
+	}
+	v, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	d.Duration = v
+	return nil
+}
+
+func (d duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.Duration.String())
+}
+
+// State is the progress of a traffic shift, stored in StateAnnotation.
+type State struct {
+	// Config is a hash of the Annotation the state belongs to. Changing the annotation starts a new traffic shift.
+	Config string `json:"config"`
+	Phase  Phase  `json:"phase"`
+	// Step is the index of the current step, or -1 if no step started yet.
+	Step               int       `json:"step"`
+	LastTransitionTime time.Time `json:"lastTransitionTime"`
+	// OriginalWeights are the weights of the shifted HTTP routes before the traffic shift, restored on rollback.
+	OriginalWeights []RouteWeights `json:"originalWeights,omitempty"`
+}
+
+// RouteWeights are the weights of the destinations of the HTTP route at index Route.
+type RouteWeights struct {
+	Route   int     `json:"route"`
+	Weights []int32 `json:"weights"`
+}
+
+// ParseSpec parses and validates the value of Annotation.
+func ParseSpec(value string) (*Spec, error) {
+	spec := &Spec{}
+	if err := yaml.Unmarshal([]byte(value), spec); err != nil {
+		return nil, fmt.Errorf("invalid %s annotation: %v", Annotation, err)
+	}
+	if spec.Canary.Host == "" {
+		return nil, fmt.Errorf("canary host must be set")
+	}
+	if len(spec.Steps) == 0 {
+		return nil, fmt.Errorf("at least one step must be set")
+	}
+	prev := int32(0)
+	for _, s := range spec.Steps {
+		if s <= prev || s > 100 {
+			return nil, fmt.Errorf("steps must be increasing weights between 1 and 100, got %v", spec.Steps)
+		}
+		prev = s
+	}
+	if spec.Interval.Duration <= 0 {
+		return nil, fmt.Errorf("interval must be positive")
+	}
+	if spec.Query == "" {
+		return nil, fmt.Errorf("query must be set")
+	}
+	if spec.Threshold < 0 || spec.Threshold > 1 {
+		return nil, fmt.Errorf("threshold must be between 0 and 1, got %v", spec.Threshold)
+	}
+	return spec, nil
+}
+
+// Controller shifts the traffic of VirtualServices annotated with Annotation.
+type Controller struct {
+	store      model.ConfigStoreController
+	metrics    MetricsSource
+	statusctl  *status.Controller
+	queue      controllers.Queue
+	now        func() time.Time
+	queryLimit time.Duration
+}
+
+// statusUpdate is the context of a status update: the condition to set for a given generation.
+type statusUpdate struct {
+	condition  *v1alpha1.IstioCondition
+	generation int64
+}
+
+// NewController creates a traffic shift controller. Status is written through statusManager, if set.
+func NewController(store model.ConfigStoreController, metrics MetricsSource, statusManager *status.Manager) *Controller {
+	c := &Controller{
+		store:      store,
+		metrics:    metrics,
+		now:        time.Now,
+		queryLimit: queryTimeout,
+	}
+	if statusManager != nil {
+		c.statusctl = statusManager.CreateIstioStatusController(func(m status.Manipulator, context any) {
+			u := context.(statusUpdate)
+			st, _ := m.Unwrap().(*v1alpha1.IstioStatus)
+			if st == nil {
+				st = &v1alpha1.IstioStatus{}
+				m.SetInner(st)
+			}
+			st.ObservedGeneration = u.generation
+			setCondition(st, u.condition)
+		})
+	}
+	c.queue = controllers.NewQueue(controllerName, controllers.WithReconciler(c.Reconcile), controllers.WithMaxAttempts(5))
+	store.RegisterEventHandler(gvk.VirtualService, func(_, cfg config.Config, _ model.Event) {
+		if _, f := cfg.Annotations[Annotation]; f {
+			c.queue.Add(types.NamespacedName{Namespace: cfg.Namespace, Name: cfg.Name})
+		}
+	})
+	return c
+}
+
+// Run runs the controller until stop is closed.
+func (c *Controller) Run(stop <-chan struct{}) {
+	log.Infof("starting %s", controllerName)
+	// Resume traffic shifts in progress, as they are only queued again on change.
+	for _, cfg := range c.store.List(gvk.VirtualService, "") {
+		if _, f := cfg.Annotations[Annotation]; f {
+			c.queue.Add(types.NamespacedName{Namespace: cfg.Namespace, Name: cfg.Name})
+		}
+	}
+	c.queue.Run(stop)
+}
+
+// Reconcile advances the traffic shift of the VirtualService with the given name, if it is due.
+func (c *Controller) Reconcile(key types.NamespacedName) error {
+	cfg := c.store.Get(gvk.VirtualService, key.Name, key.Namespace)
+	if cfg == nil {
+		return nil
+	}
+	value, f := cfg.Annotations[Annotation]
+	if !f {
+		return nil
+	}
+	log := log.WithLabels("virtualservice", key)
+
+	state := State{Config: configHash(value), Phase: PhaseProgressing, Step: -1}
+	if raw, f := cfg.Annotations[StateAnnotation]; f {
+		var existing State
+		if err := json.Unmarshal([]byte(raw), &existing); err != nil {
+			log.Warnf("ignoring invalid %s annotation: %v", StateAnnotation, err)
+		} else if existing.Config == state.Config {
+			state = existing
+		} else if existing.Phase == PhaseProgressing {
+			// The previous traffic shift did not complete, so the weights it started from are still the original ones.
+			state.OriginalWeights = existing.OriginalWeights
+		}
+	}
+	if state.Phase != PhaseProgressing {
+		// The traffic shift is complete; it only starts again when its configuration changes.
+		return nil
+	}
+
+	spec, err := ParseSpec(value)
+	if err != nil {
+		state.Phase = PhaseInvalid
+		return c.update(cfg, state, nil, err.Error())
+	}
+
+	now := c.now()
+	message := ""
+	if state.Step >= 0 {
+		if wait := state.LastTransitionTime.Add(spec.Interval.Duration).Sub(now); wait > 0 {
+			c.queue.AddAfter(key, wait)
+			return nil
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), c.queryLimit)
+		rate, err := c.metrics.Query(ctx, spec.Query)
+		cancel()
+		if err != nil {
+			// Without metrics the canary can neither be promoted nor blamed; try again next interval.
+			log.Warnf("failed to query success rate: %v", err)
+			c.queue.AddAfter(key, spec.Interval.Duration)
+			c.writeStatus(cfg, state, fmt.Sprintf("canary at %d%%, failed to query success rate: %v", spec.Steps[state.Step], err))
+			return nil
+		}
+		if rate < spec.Threshold {
+			log.Infof("rolling back, success rate %v is below %v", rate, spec.Threshold)
+			state.Phase = PhaseRolledBack
+			state.LastTransitionTime = now
+			return c.update(cfg, state, spec, fmt.Sprintf("success rate %.4g at %d%% is below threshold %.4g, all traffic shifted back",
+				rate, spec.Steps[state.Step], spec.Threshold))
+		}
+		message = fmt.Sprintf(", success rate %.4g at %d%%", rate, spec.Steps[state.Step])
+	}
+
+	state.LastTransitionTime = now
+	if state.Step == len(spec.Steps)-1 {
+		log.Infof("traffic shift succeeded")
+		state.Phase = PhaseSucceeded
+		return c.update(cfg, state, spec, fmt.Sprintf("canary passed all %d steps%s", len(spec.Steps), message))
+	}
+	state.Step++
+	log.Infof("shifting %d%% of traffic to canary", spec.Steps[state.Step])
+	if err := c.update(cfg, state, spec, fmt.Sprintf("canary at %d%% (step %d/%d)%s",
+		spec.Steps[state.Step], state.Step+1, len(spec.Steps), message)); err != nil {
+		return err
+	}
+	c.queue.AddAfter(key, spec.Interval.Duration)
+	return nil
+}
+
+// update writes the weights for state and the state itself to the VirtualService, and reports it in its status.
+func (c *Controller) update(cfg *config.Config, state State, spec *Spec, message string) error {
+	updated := cfg.DeepCopy()
+	if spec != nil {
+		vs := updated.Spec.(*networking.VirtualService)
+		var found bool
+		if state.Phase == PhaseRolledBack {
+			found = restoreWeights(vs, spec, state.OriginalWeights)
+		} else {
+			if state.OriginalWeights == nil {
+				state.OriginalWeights = routeWeights(vs, spec)
+			}
+			found = setCanaryWeight(vs, spec, spec.Steps[state.Step])
+		}
+		if !found {
+			state.Phase = PhaseInvalid
+			message = fmt.Sprintf("no HTTP route has canary destination %s", destinationString(spec.Canary))
+		}
+	}
+	raw, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	updated.Annotations[StateAnnotation] = string(raw)
+	if _, err := c.store.Update(updated); err != nil {
+		return fmt.Errorf("failed to update traffic shift: %v", err)
+	}
+	c.writeStatus(cfg, state, message)
+	return nil
+}
+
+func (c *Controller) writeStatus(cfg *config.Config, state State, message string) {
+	if c.statusctl == nil {
+		return
+	}
+	condition := &v1alpha1.IstioCondition{
+		Type:               ConditionType,
+		Status:             conditionStatus(state.Phase),
+		Reason:             string(state.Phase),
+		Message:            message,
+		LastProbeTime:      timestamppb.New(c.now()),
+		LastTransitionTime: timestamppb.New(state.LastTransitionTime),
+	}
+	c.statusctl.EnqueueStatusUpdateResource(statusUpdate{condition: condition, generation: cfg.Generation}, status.ResourceFromModelConfig(*cfg))
+}
+
+func conditionStatus(p Phase) string {
+	switch p {
+	case PhaseSucceeded:
+		return "True"
+	case PhaseProgressing:
+		return "Unknown"
+	default:
+		return "False"
+	}
+}
+
+// setCondition replaces the condition of the same type in st, or adds it.
+func setCondition(st *v1alpha1.IstioStatus, condition *v1alpha1.IstioCondition) {
+	for i, existing := range st.Conditions {
+		if existing.Type == condition.Type {
+			st.Conditions[i] = condition
+			return
+		}
+	}
+	st.Conditions = append(st.Conditions, condition)
+}
+
+// canaryIndex returns the index of the canary in the destinations of route, or -1 if the route is not shifted.
+func canaryIndex(route *networking.HTTPRoute, spec *Spec) int {
+	if len(spec.Routes) > 0 && !slices.Contains(spec.Routes, route.Name) {
+		return -1
+	}
+	for i, d := range route.Route {
+		if d.GetDestination().GetHost() == spec.Canary.Host && d.GetDestination().GetSubset() == spec.Canary.Subset {
+			return i
+		}
+	}
+	return -1
+}
+
+// routeWeights returns the weights of the HTTP routes of vs shifted by spec.
+func routeWeights(vs *networking.VirtualService, spec *Spec) []RouteWeights {
+	var weights []RouteWeights
+	for i, route := range vs.Http {
+		if canaryIndex(route, spec) >= 0 {
+			weights = append(weights, RouteWeights{
+				Route:   i,
+				Weights: slices.Map(route.Route, func(d *networking.HTTPRouteDestination) int32 { return d.Weight }),
+			})
+		}
+	}
+	return weights
+}
+
+// restoreWeights sets the weights of the shifted HTTP routes of vs back to original. Routes without original weights,
+// or whose destinations changed since, send no traffic to the canary as with setCanaryWeight. It returns false if no
+// route contains the canary.
+func restoreWeights(vs *networking.VirtualService, spec *Spec, original []RouteWeights) bool {
+	found := setCanaryWeight(vs, spec, 0)
+	for _, rw := range original {
+		if rw.Route >= len(vs.Http) {
+			continue
+		}
+		route := vs.Http[rw.Route]
+		if canaryIndex(route, spec) < 0 || len(route.Route) != len(rw.Weights) {
+			continue
+		}
+		for i, d := range route.Route {
+			d.Weight = rw.Weights[i]
+		}
+	}
+	return found
+}
+
+// setCanaryWeight sets the weight of the canary in the selected HTTP routes of vs, splitting the remaining weight
+// evenly between the other destinations. It returns false if no route contains the canary.
+func setCanaryWeight(vs *networking.VirtualService, spec *Spec, weight int32) bool {
+	found := false
+	for _, route := range vs.Http {
+		canary := canaryIndex(route, spec)
+		if canary < 0 {
+			continue
+		}
+		found = true
+		others := int32(len(route.Route) - 1)
+		if others == 0 {
+			// A single destination always receives all traffic.
+			continue
+		}
+		remaining := 100 - weight
+		first := true
+		for i, d := range route.Route {
+			if i == canary {
+				d.Weight = weight
+				continue
+			}
+			d.Weight = remaining / others
+			if first {
+				// Give the rounding remainder to the first stable destination so the weights add up to 100.
+				d.Weight += remaining % others
+				first = false
+			}
+		}
+	}
+	return found
+}
+
+func destinationString(d Destination) string {
+	if d.Subset == "" {
+		return d.Host
+	}
+	return d.Host + "/" + d.Subset
+}
+
+func configHash(value string) string {
+	sum := sha256.Sum256([]byte(value))
+	return hex.EncodeToString(sum[:8])
+}
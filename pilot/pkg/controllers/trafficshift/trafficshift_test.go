@@ -0,0 +1,323 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package trafficshift
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/types"
+
+	networking "istio.io/api/networking/v1alpha3"
+	"istio.io/istio/pilot/pkg/config/memory"
+	"istio.io/istio/pilot/pkg/model"
+	"istio.io/istio/pkg/config"
+	"istio.io/istio/pkg/config/schema/collections"
+	"istio.io/istio/pkg/config/schema/gvk"
+	"istio.io/istio/pkg/slices"
+	"istio.io/istio/pkg/test/util/assert"
+)
+
+const (
+	testQuery = `sum(rate(istio_requests_total{destination_version="v2",response_code!~"5.."}[1m]))`
+	testSpec  = `
+canary:
+  host: reviews
+  subset: v2
+steps: [10, 50, 100]
+interval: 1m
+query: '` + testQuery + `'
+threshold: 0.99
+`
+)
+
+var key = types.NamespacedName{Namespace: "default", Name: "reviews"}
+
+type fixture struct {
+	t       *testing.T
+	store   model.ConfigStoreController
+	metrics *FakeMetricsSource
+	c       *Controller
+	now     time.Time
+}
+
+func newFixture(t *testing.T, annotation string) *fixture {
+	store := memory.NewController(memory.Make(collections.Pilot))
+	f := &fixture{
+		t:       t,
+		store:   store,
+		metrics: NewFakeMetricsSource(),
+		now:     time.Unix(1700000000, 0),
+	}
+	f.c = NewController(store, f.metrics, nil)
+	f.c.now = func() time.Time { return f.now }
+	_, err := store.Create(config.Config{
+		Meta: config.Meta{
+			GroupVersionKind: gvk.VirtualService,
+			Name:             key.Name,
+			Namespace:        key.Namespace,
+			Annotations:      map[string]string{Annotation: annotation},
+		},
+		Spec: &networking.VirtualService{
+			Hosts: []string{"reviews"},
+			Http: []*networking.HTTPRoute{{
+				Name: "default",
+				Route: []*networking.HTTPRouteDestination{
+					{Destination: &networking.Destination{Host: "reviews", Subset: "v1"}, Weight: 100},
+					{Destination: &networking.Destination{Host: "reviews", Subset: "v2"}},
+				},
+			}},
+		},
+	})
+	assert.NoError(t, err)
+	return f
+}
+
+func (f *fixture) reconcile() {
+	f.t.Helper()
+	assert.NoError(f.t, f.c.Reconcile(key))
+}
+
+func (f *fixture) weights() []int32 {
+	f.t.Helper()
+	cfg := f.store.Get(gvk.VirtualService, key.Name, key.Namespace)
+	return slices.Map(cfg.Spec.(*networking.VirtualService).Http[0].Route, func(d *networking.HTTPRouteDestination) int32 {
+		return d.Weight
+	})
+}
+
+func (f *fixture) state() State {
+	f.t.Helper()
+	cfg := f.store.Get(gvk.VirtualService, key.Name, key.Namespace)
+	var s State
+	assert.NoError(f.t, json.Unmarshal([]byte(cfg.Annotations[StateAnnotation]), &s))
+	return s
+}
+
+func TestTrafficShiftSucceeds(t *testing.T) {
+	f := newFixture(t, testSpec)
+	f.reconcile()
+	assert.Equal(t, f.weights(), []int32{90, 10})
+	assert.Equal(t, f.state().Phase, PhaseProgressing)
+
+	// Nothing happens before the interval elapses, even if the canary is failing.
+	f.metrics.Set(testQuery, 0.5)
+	f.now = f.now.Add(30 * time.Second)
+	f.reconcile()
+	assert.Equal(t, f.weights(), []int32{90, 10})
+	assert.Equal(t, f.state().Step, 0)
+
+	f.metrics.Set(testQuery, 0.995)
+	f.now = f.now.Add(30 * time.Second)
+	f.reconcile()
+	assert.Equal(t, f.weights(), []int32{50, 50})
+	assert.Equal(t, f.state().Step, 1)
+
+	f.now = f.now.Add(time.Minute)
+	f.reconcile()
+	assert.Equal(t, f.weights(), []int32{0, 100})
+
+	f.now = f.now.Add(time.Minute)
+	f.reconcile()
+	assert.Equal(t, f.weights(), []int32{0, 100})
+	assert.Equal(t, f.state().Phase, PhaseSucceeded)
+
+	// A completed traffic shift is left alone.
+	f.metrics.Set(testQuery, 0)
+	f.now = f.now.Add(time.Minute)
+	f.reconcile()
+	assert.Equal(t, f.weights(), []int32{0, 100})
+	assert.Equal(t, f.state().Phase, PhaseSucceeded)
+}
+
+func TestTrafficShiftRollsBack(t *testing.T) {
+	f := newFixture(t, testSpec)
+	f.metrics.Set(testQuery, 1)
+	f.reconcile()
+	f.now = f.now.Add(time.Minute)
+	f.reconcile()
+	assert.Equal(t, f.weights(), []int32{50, 50})
+
+	f.metrics.Set(testQuery, 0.9)
+	f.now = f.now.Add(time.Minute)
+	f.reconcile()
+	assert.Equal(t, f.weights(), []int32{100, 0})
+	assert.Equal(t, f.state().Phase, PhaseRolledBack)
+
+	// Changing the configuration starts over.
+	cfg := f.store.Get(gvk.VirtualService, key.Name, key.Namespace).DeepCopy()
+	cfg.Annotations[Annotation] = testSpec + "\n# retry"
+	_, err := f.store.Update(cfg)
+	assert.NoError(t, err)
+	f.reconcile()
+	assert.Equal(t, f.weights(), []int32{90, 10})
+	assert.Equal(t, f.state().Phase, PhaseProgressing)
+}
+
+func TestTrafficShiftRollbackRestoresWeights(t *testing.T) {
+	f := newFixture(t, testSpec)
+	cfg := f.store.Get(gvk.VirtualService, key.Name, key.Namespace).DeepCopy()
+	route := cfg.Spec.(*networking.VirtualService).Http[0]
+	route.Route = append(route.Route, &networking.HTTPRouteDestination{Destination: &networking.Destination{Host: "reviews", Subset: "v3"}})
+	route.Route[0].Weight, route.Route[1].Weight, route.Route[2].Weight = 75, 5, 20
+	_, err := f.store.Update(cfg)
+	assert.NoError(t, err)
+
+	f.metrics.Set(testQuery, 1)
+	f.reconcile()
+	assert.Equal(t, f.weights(), []int32{45, 10, 45})
+	assert.Equal(t, f.state().OriginalWeights, []RouteWeights{{Route: 0, Weights: []int32{75, 5, 20}}})
+	f.now = f.now.Add(time.Minute)
+	f.reconcile()
+	assert.Equal(t, f.weights(), []int32{25, 50, 25})
+
+	// The weights before the traffic shift are restored, rather than split evenly.
+	f.metrics.Set(testQuery, 0.9)
+	f.now = f.now.Add(time.Minute)
+	f.reconcile()
+	assert.Equal(t, f.weights(), []int32{75, 5, 20})
+	assert.Equal(t, f.state().Phase, PhaseRolledBack)
+}
+
+func TestTrafficShiftMetricsUnavailable(t *testing.T) {
+	f := newFixture(t, testSpec)
+	f.reconcile()
+	f.metrics.SetError(testQuery, fmt.Errorf("connection refused"))
+	f.now = f.now.Add(time.Minute)
+	f.reconcile()
+	// Neither advanced nor rolled back.
+	assert.Equal(t, f.weights(), []int32{90, 10})
+	assert.Equal(t, f.state().Phase, PhaseProgressing)
+	assert.Equal(t, f.state().Step, 0)
+}
+
+func TestTrafficShiftInvalid(t *testing.T) {
+	cases := []struct {
+		name string
+		spec string
+	}{
+		{"decreasing steps", `{canary: {host: reviews, subset: v2}, steps: [50, 10], interval: 1m, query: q, threshold: 0.9}`},
+		{"missing query", `{canary: {host: reviews, subset: v2}, steps: [50], interval: 1m, threshold: 0.9}`},
+		{"bad interval", `{canary: {host: reviews, subset: v2}, steps: [50], interval: soon, query: q, threshold: 0.9}`},
+		{"unknown canary", `{canary: {host: ratings}, steps: [50], interval: 1m, query: q, threshold: 0.9}`},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFixture(t, tt.spec)
+			f.reconcile()
+			assert.Equal(t, f.weights(), []int32{100, 0})
+			assert.Equal(t, f.state().Phase, PhaseInvalid)
+		})
+	}
+}
+
+func TestSetCanaryWeight(t *testing.T) {
+	vs := &networking.VirtualService{
+		Http: []*networking.HTTPRoute{
+			{
+				Name: "shifted",
+				Route: []*networking.HTTPRouteDestination{
+					{Destination: &networking.Destination{Host: "a"}},
+					{Destination: &networking.Destination{Host: "b"}},
+					{Destination: &networking.Destination{Host: "canary"}},
+					{Destination: &networking.Destination{Host: "c"}},
+				},
+			},
+			{
+				Name: "other",
+				Route: []*networking.HTTPRouteDestination{
+					{Destination: &networking.Destination{Host: "a"}, Weight: 100},
+					{Destination: &networking.Destination{Host: "canary"}},
+				},
+			},
+		},
+	}
+	spec := &Spec{Canary: Destination{Host: "canary"}, Routes: []string{"shifted"}}
+	assert.Equal(t, setCanaryWeight(vs, spec, 20), true)
+	weights := func(r *networking.HTTPRoute) []int32 {
+		return slices.Map(r.Route, func(d *networking.HTTPRouteDestination) int32 { return d.Weight })
+	}
+	assert.Equal(t, weights(vs.Http[0]), []int32{28, 26, 20, 26})
+	assert.Equal(t, weights(vs.Http[1]), []int32{100, 0})
+
+	assert.Equal(t, setCanaryWeight(vs, &Spec{Canary: Destination{Host: "missing"}}, 20), false)
+
+	// Routes whose destinations changed since the traffic shift started send no traffic to the canary.
+	original := []RouteWeights{{Route: 0, Weights: []int32{50, 50, 0}}, {Route: 5, Weights: []int32{100}}}
+	assert.Equal(t, restoreWeights(vs, spec, original), true)
+	assert.Equal(t, weights(vs.Http[0]), []int32{34, 33, 0, 33})
+	original[0].Weights = []int32{40, 30, 10, 20}
+	assert.Equal(t, restoreWeights(vs, spec, original), true)
+	assert.Equal(t, weights(vs.Http[0]), []int32{40, 30, 10, 20})
+}
+
+func TestPrometheus(t *testing.T) {
+	cases := []struct {
+		name     string
+		response string
+		want     float64
+		wantErr  bool
+	}{
+		{
+			name:     "vector",
+			response: `{"status":"success","data":{"resultType":"vector","result":[{"metric":{},"value":[1700000000.1,"0.995"]}]}}`,
+			want:     0.995,
+		},
+		{
+			name:     "scalar",
+			response: `{"status":"success","data":{"resultType":"scalar","result":[1700000000.1,"1"]}}`,
+			want:     1,
+		},
+		{
+			name:     "empty vector",
+			response: `{"status":"success","data":{"resultType":"vector","result":[]}}`,
+			wantErr:  true,
+		},
+		{
+			name:     "NaN",
+			response: `{"status":"success","data":{"resultType":"scalar","result":[1700000000.1,"NaN"]}}`,
+			wantErr:  true,
+		},
+		{
+			name:     "error",
+			response: `{"status":"error","errorType":"bad_data","error":"parse error"}`,
+			wantErr:  true,
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != "/api/v1/query" || r.URL.Query().Get("query") != testQuery {
+					w.WriteHeader(http.StatusNotFound)
+					return
+				}
+				_, _ = w.Write([]byte(tt.response))
+			}))
+			defer srv.Close()
+			got, err := NewPrometheus(srv.URL+"/").Query(context.Background(), testQuery)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, got, tt.want)
+		})
+	}
+}
@@ -108,6 +108,22 @@ func (a *AggregateController) GetCaCert(name, namespace string) (certInfo *crede
 	return nil, firstError
 }
 
+func (a *AggregateController) GetConfigMapCaCert(name, namespace string) (certInfo *credentials.CertInfo, err error) {
+	// Search through all clusters, find first non-empty result
+	var firstError error
+	for _, c := range a.controllers {
+		k, err := c.GetConfigMapCaCert(name, namespace)
+		if err != nil {
+			if firstError == nil {
+				firstError = err
+			}
+		} else {
+			return k, nil
+		}
+	}
+	return nil, firstError
+}
+
 func (a *AggregateController) Authorize(serviceAccount, namespace string) error {
 	return a.authController.Authorize(serviceAccount, namespace)
 }
@@ -30,6 +30,7 @@ import (
 	authorizationv1client "k8s.io/client-go/kubernetes/typed/authorization/v1"
 
 	"istio.io/istio/pilot/pkg/credentials"
+	"istio.io/istio/pilot/pkg/features"
 	securitymodel "istio.io/istio/pilot/pkg/security/model"
 	"istio.io/istio/pkg/kube"
 	"istio.io/istio/pkg/kube/controllers"
@@ -57,11 +58,15 @@ const (
 	TLSSecretCaCert = "ca.crt"
 	// The ID/name for the CRL in kubernetes tls secret.
 	TLSSecretCrl = "ca.crl"
+
+	// The ID/name for the CA certificate in a kubernetes ConfigMap
+	ConfigMapCaCert = "ca.crt"
 )
 
 type CredentialsController struct {
-	secrets kclient.Client[*v1.Secret]
-	sar     authorizationv1client.SubjectAccessReviewInterface
+	secrets    kclient.Client[*v1.Secret]
+	configMaps kclient.Client[*v1.ConfigMap]
+	sar        authorizationv1client.SubjectAccessReviewInterface
 
 	mu                 sync.RWMutex
 	authorizationCache map[authorizationKey]authorizationResponse
@@ -92,16 +97,39 @@ func NewCredentialsController(kc kube.Client, handlers []func(name string, names
 		ObjectFilter:  kube.FilterIfEnhancedFilteringEnabled(kc),
 	})
 
+	// ConfigMaps are only used as a source of CA bundles (for Gateway API BackendTLSPolicy). Watching all ConfigMaps
+	// in the cluster is expensive, so only do so if BackendTLSPolicy can be used at all.
+	var configMaps kclient.Client[*v1.ConfigMap]
+	if features.EnableGatewayAPI && features.EnableAlphaGatewayAPI {
+		configMaps = kclient.NewFiltered[*v1.ConfigMap](kc, kclient.Filter{
+			ObjectFilter: kube.FilterIfEnhancedFilteringEnabled(kc),
+		})
+	}
+
 	for _, h := range handlers {
 		h := h
 		// register handler before informer starts
 		secrets.AddEventHandler(controllers.ObjectHandler(func(o controllers.Object) {
 			h(o.GetName(), o.GetNamespace())
 		}))
+		if configMaps == nil {
+			continue
+		}
+		configMaps.AddEventHandler(controllers.FilteredObjectHandler(func(o controllers.Object) {
+			h(o.GetName(), o.GetNamespace())
+		}, func(o controllers.Object) bool {
+			cm := controllers.Extract[*v1.ConfigMap](o)
+			if cm == nil {
+				return false
+			}
+			_, f := cm.Data[ConfigMapCaCert]
+			return f
+		}))
 	}
 
 	return &CredentialsController{
 		secrets:            secrets,
+		configMaps:         configMaps,
 		sar:                kc.Kube().AuthorizationV1().SubjectAccessReviews(),
 		authorizationCache: make(map[authorizationKey]authorizationResponse),
 	}
@@ -109,10 +137,13 @@ func NewCredentialsController(kc kube.Client, handlers []func(name string, names
 
 func (s *CredentialsController) Close() {
 	s.secrets.ShutdownHandlers()
+	if s.configMaps != nil {
+		s.configMaps.ShutdownHandlers()
+	}
 }
 
 func (s *CredentialsController) HasSynced() bool {
-	return s.secrets.HasSynced()
+	return s.secrets.HasSynced() && (s.configMaps == nil || s.configMaps.HasSynced())
 }
 
 const cacheTTL = time.Minute
@@ -210,6 +241,23 @@ func (s *CredentialsController) GetCaCert(name, namespace string) (certInfo *cre
 	return extractRoot(k8sSecret)
 }
 
+// GetConfigMapCaCert fetches the CA bundle stored in the ca.crt key of a ConfigMap.
+func (s *CredentialsController) GetConfigMapCaCert(name, namespace string) (certInfo *credentials.CertInfo, err error) {
+	strippedName := strings.TrimSuffix(name, securitymodel.SdsCaSuffix)
+	if s.configMaps == nil {
+		return nil, fmt.Errorf("configmap %v/%v cannot be read: BackendTLSPolicy support is not enabled", namespace, strippedName)
+	}
+	cm := s.configMaps.Get(strippedName, namespace)
+	if cm == nil {
+		return nil, fmt.Errorf("configmap %v/%v not found", namespace, strippedName)
+	}
+	root := cm.Data[ConfigMapCaCert]
+	if len(root) == 0 {
+		return nil, fmt.Errorf("found configmap %v/%v, but it didn't have a non-empty %q key", namespace, strippedName, ConfigMapCaCert)
+	}
+	return &credentials.CertInfo{Cert: []byte(root)}, nil
+}
+
 func (s *CredentialsController) GetDockerCredential(name, namespace string) ([]byte, error) {
 	k8sSecret := s.secrets.Get(name, namespace)
 	if k8sSecret == nil {
@@ -25,10 +25,12 @@ import (
 	"k8s.io/client-go/kubernetes/fake"
 	k8stesting "k8s.io/client-go/testing"
 
+	"istio.io/istio/pilot/pkg/features"
 	cluster2 "istio.io/istio/pkg/cluster"
 	"istio.io/istio/pkg/kube"
 	"istio.io/istio/pkg/kube/multicluster"
 	"istio.io/istio/pkg/test"
+	"istio.io/istio/pkg/test/util/assert"
 	"istio.io/istio/pkg/util/sets"
 )
 
@@ -331,6 +333,74 @@ func TestDockerCredentials(t *testing.T) {
 	}
 }
 
+func TestConfigMapCaCert(t *testing.T) {
+	test.SetForTest(t, &features.EnableAlphaGatewayAPI, true)
+	configMaps := []runtime.Object{
+		&corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "ca", Namespace: "default"},
+			Data:       map[string]string{ConfigMapCaCert: "configmap-ca"},
+		},
+		&corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "wrong-keys", Namespace: "default"},
+			Data:       map[string]string{"foo": "bar"},
+		},
+	}
+	client := kube.NewFakeClient(configMaps...)
+	sc := NewCredentialsController(client, nil)
+	client.RunAndWait(test.NewStop(t))
+	cases := []struct {
+		name          string
+		namespace     string
+		caCert        string
+		expectedError string
+	}{
+		{
+			name:      "ca",
+			namespace: "default",
+			caCert:    "configmap-ca",
+		},
+		{
+			name:      "ca-cacert",
+			namespace: "default",
+			caCert:    "configmap-ca",
+		},
+		{
+			name:          "ca",
+			namespace:     "wrong-namespace",
+			expectedError: "configmap wrong-namespace/ca not found",
+		},
+		{
+			name:          "wrong-keys",
+			namespace:     "default",
+			expectedError: `found configmap default/wrong-keys, but it didn't have a non-empty "ca.crt" key`,
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			certInfo, err := sc.GetConfigMapCaCert(tt.name, tt.namespace)
+			if certInfo != nil && tt.caCert != string(certInfo.Cert) {
+				t.Errorf("got caCert %q, wanted %q", string(certInfo.Cert), tt.caCert)
+			}
+			if tt.expectedError != errString(err) {
+				t.Errorf("got err %q, wanted %q", errString(err), tt.expectedError)
+			}
+		})
+	}
+}
+
+func TestConfigMapCaCertDisabled(t *testing.T) {
+	test.SetForTest(t, &features.EnableAlphaGatewayAPI, false)
+	client := kube.NewFakeClient(&corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "ca", Namespace: "default"},
+		Data:       map[string]string{ConfigMapCaCert: "configmap-ca"},
+	})
+	sc := NewCredentialsController(client, nil)
+	client.RunAndWait(test.NewStop(t))
+	assert.Equal(t, sc.HasSynced(), true)
+	_, err := sc.GetConfigMapCaCert("ca", "default")
+	assert.Error(t, err)
+}
+
 func errString(e error) string {
 	if e == nil {
 		return ""
@@ -77,6 +77,12 @@ var (
 	EnableUnsafeAdminEndpoints = env.Register("UNSAFE_ENABLE_ADMIN_ENDPOINTS", false,
 		"If this is set to true, dangerous admin endpoints will be exposed on the debug interface. Not recommended for production.").Get()
 
+	ConfigHistorySize = env.Register("PILOT_CONFIG_HISTORY_SIZE", 100,
+		"The number of recent config changes kept in memory and exposed on /debug/confighistory. Set to 0 to disable.").Get()
+
+	PushHistorySize = env.Register("PILOT_PUSH_HISTORY_SIZE", 10,
+		"The number of recent pushes kept in memory for each connected proxy and exposed on /debug/connections. Set to 0 to disable.").Get()
+
 	EnableServiceEntrySelectPods = env.Register("PILOT_ENABLE_SERVICEENTRY_SELECT_PODS", true,
 		"If enabled, service entries with selectors will select pods from the cluster. "+
 			"It is safe to disable it if you are quite sure you don't need this feature").Get()
@@ -141,6 +147,17 @@ var (
 		"If enabled, pilot will start a controller that assigns IP addresses to ServiceEntry which do not have a user-supplied IP. "+
 			"This, when combined with DNS capture allows for tcp routing of traffic sent to the ServiceEntry.").Get()
 
+	EnableTrafficShiftController = env.Register(
+		"PILOT_ENABLE_TRAFFIC_SHIFT_CONTROLLER",
+		false,
+		"If enabled, pilot will start a controller that progressively shifts traffic to the canary destination of VirtualServices "+
+			"annotated with networking.istio.io/traffic-shift, based on the success rate reported by Prometheus.").Get()
+
+	TrafficShiftPrometheusAddress = env.Register(
+		"PILOT_TRAFFIC_SHIFT_PROMETHEUS_ADDRESS",
+		"http://prometheus.istio-system:9090",
+		"The address of the Prometheus server queried by the traffic shift controller.").Get()
+
 	// EnableUnsafeAssertions enables runtime checks to test assertions in our code. This should never be enabled in
 	// production; when assertions fail Istio will panic.
 	EnableUnsafeAssertions = env.Register(
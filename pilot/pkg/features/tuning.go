@@ -74,6 +74,21 @@ var (
 		return min(float64(15+5*procs), 100.0)
 	}()
 
+	PushRateLimit = env.Register(
+		"PILOT_PUSH_RATE_LIMIT",
+		0.0,
+		"Limits the number of proxies pushed per second, in addition to the concurrency limit set by PILOT_PUSH_THROTTLE. "+
+			"If set to 0 or unset, pushes are not rate limited.",
+	).Get()
+
+	PushQueueMaxWait = env.Register(
+		"PILOT_PUSH_QUEUE_MAX_WAIT",
+		5*time.Second,
+		"Proxies are pushed by priority: gateways and waypoints first, then proxies affected by the change, then all others. "+
+			"A proxy that waited longer than this in the push queue is pushed before higher priority proxies, so that it is not starved. "+
+			"If set to 0, proxies are pushed in the order they were queued.",
+	).Get()
+
 	DebounceAfter = env.Register(
 		"PILOT_DEBOUNCE_AFTER",
 		100*time.Millisecond,
@@ -104,6 +119,16 @@ var (
 			"accelerate configuration push, but it also means that istiod will consume more CPU resources.",
 	).Get()
 
+	LazySidecarScopes = env.Register(
+		"PILOT_LAZY_SIDECAR_SCOPES",
+		false,
+		"If enabled, Sidecar resources are converted to SidecarScopes, and the DestinationRules visible only within a "+
+			"namespace are merged, when a proxy in their namespace first needs them rather than for every namespace on each push. "+
+			"This reduces the memory and CPU used by istiod for namespaces without proxies connected to it. "+
+			"The VirtualService, ServiceEntry and exported DestinationRule indexes are still built for the whole mesh, as "+
+			"proxies in any namespace may import them. PILOT_CONVERT_SIDECAR_SCOPE_CONCURRENCY has no effect when this is enabled.",
+	).Get()
+
 	MutexProfileFraction = env.Register("MUTEX_PROFILE_FRACTION", 1000,
 		"If set to a non-zero value, enables mutex profiling a rate of 1/MUTEX_PROFILE_FRACTION events."+
 			" For example, '1000' will record 0.1% of events. "+
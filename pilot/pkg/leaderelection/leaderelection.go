@@ -55,6 +55,7 @@ const (
 	GatewayDeploymentController = "istio-gateway-deployment"
 	NodeUntaintController       = "istio-node-untaint"
 	IPAutoallocateController    = "istio-ip-autoallocate"
+	TrafficShiftController      = "istio-traffic-shift"
 )
 
 // Leader election key prefix for remote istiod managed clusters
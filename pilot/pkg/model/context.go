@@ -1060,6 +1060,8 @@ type GatewayController interface {
 	// SecretAllowed determines if a SDS credential is accessible to a given namespace.
 	// For example, for resourceName of `kubernetes-gateway://ns-name/secret-name` and namespace of `ingress-ns`,
 	// this would return true only if there was a policy allowing `ingress-ns` to access Secrets in the `ns-name` namespace.
+	// ConfigMap resources (`configmap://ns-name/configmap-name`) are allowed when a BackendTLSPolicy applied to
+	// Gateways in the namespace references them.
 	SecretAllowed(resourceName string, namespace string) bool
 }
 
@@ -30,6 +30,11 @@ const (
 	// take the form kubernetes-gateway://namespace/name. They are pulled from the config cluster.
 	KubernetesGatewaySecretType    = "kubernetes-gateway"
 	kubernetesGatewaySecretTypeURI = KubernetesGatewaySecretType + "://"
+	// KubernetesConfigMapType is the name of a CA bundle stored in a Kubernetes ConfigMap, used by the gateway-api
+	// BackendTLSPolicy. These take the form configmap://namespace/name, and are pulled from the config cluster.
+	// Only the public `ca.crt` bundle is ever read from these.
+	KubernetesConfigMapType    = "configmap"
+	KubernetesConfigMapTypeURI = KubernetesConfigMapType + "://"
 	// BuiltinGatewaySecretType is the name of a SDS secret that uses the workloads own mTLS certificate
 	BuiltinGatewaySecretType    = "builtin"
 	BuiltinGatewaySecretTypeURI = BuiltinGatewaySecretType + "://"
@@ -39,7 +44,7 @@ const (
 
 // SecretResource defines a reference to a secret
 type SecretResource struct {
-	// ResourceType is the type of secret. One of KubernetesSecretType or KubernetesGatewaySecretType
+	// ResourceType is the type of secret. One of KubernetesSecretType, KubernetesGatewaySecretType, or KubernetesConfigMapType
 	ResourceType string
 	// Name is the name of the secret
 	Name string
@@ -73,7 +78,8 @@ func ToResourceName(name string) string {
 		return "default"
 	}
 	// If they explicitly defined the type, keep it
-	if strings.HasPrefix(name, KubernetesSecretTypeURI) || strings.HasPrefix(name, kubernetesGatewaySecretTypeURI) {
+	if strings.HasPrefix(name, KubernetesSecretTypeURI) || strings.HasPrefix(name, kubernetesGatewaySecretTypeURI) ||
+		strings.HasPrefix(name, KubernetesConfigMapTypeURI) {
 		return name
 	}
 	// Otherwise, to kubernetes://
@@ -102,20 +108,30 @@ func ParseResourceName(resourceName string, proxyNamespace string, proxyCluster
 		// Valid formats:
 		// * kubernetes-gateway://secret-namespace/secret-name
 		// Namespace is required. The secret is read from the config cluster; this is the primary difference from KubernetesSecretType.
-		res := strings.TrimPrefix(resourceName, kubernetesGatewaySecretTypeURI)
-		split := strings.Split(res, sep)
-		if len(split) <= 1 {
-			return SecretResource{}, fmt.Errorf("invalid resource name %q. Expected namespace and name", resourceName)
-		}
-		namespace := split[0]
-		name := split[1]
-		if len(namespace) == 0 {
-			return SecretResource{}, fmt.Errorf("invalid resource name %q. Expected namespace", resourceName)
-		}
-		if len(name) == 0 {
-			return SecretResource{}, fmt.Errorf("invalid resource name %q. Expected name", resourceName)
-		}
-		return SecretResource{ResourceType: KubernetesGatewaySecretType, Name: name, Namespace: namespace, ResourceName: resourceName, Cluster: configCluster}, nil
+		return parseNamespacedResourceName(resourceName, KubernetesGatewaySecretType, kubernetesGatewaySecretTypeURI, configCluster)
+	} else if strings.HasPrefix(resourceName, KubernetesConfigMapTypeURI) {
+		// Valid formats:
+		// * configmap://configmap-namespace/configmap-name
+		// Namespace is required. The ConfigMap is read from the config cluster.
+		return parseNamespacedResourceName(resourceName, KubernetesConfigMapType, KubernetesConfigMapTypeURI, configCluster)
 	}
 	return SecretResource{}, fmt.Errorf("unknown resource type: %v", resourceName)
 }
+
+// parseNamespacedResourceName parses a resource name of the form <prefix>namespace/name, where both parts are required.
+func parseNamespacedResourceName(resourceName, resourceType, prefix string, c cluster.ID) (SecretResource, error) {
+	res := strings.TrimPrefix(resourceName, prefix)
+	split := strings.Split(res, "/")
+	if len(split) <= 1 {
+		return SecretResource{}, fmt.Errorf("invalid resource name %q. Expected namespace and name", resourceName)
+	}
+	namespace := split[0]
+	name := split[1]
+	if len(namespace) == 0 {
+		return SecretResource{}, fmt.Errorf("invalid resource name %q. Expected namespace", resourceName)
+	}
+	if len(name) == 0 {
+		return SecretResource{}, fmt.Errorf("invalid resource name %q. Expected name", resourceName)
+	}
+	return SecretResource{ResourceType: resourceType, Name: name, Namespace: namespace, ResourceName: resourceName, Cluster: c}, nil
+}
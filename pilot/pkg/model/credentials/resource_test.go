@@ -80,6 +80,24 @@ func TestParseResourceName(t *testing.T) {
 			defaultNamespace: "default",
 			err:              true,
 		},
+		{
+			name:             "configmap",
+			resource:         "configmap://namespace/ca",
+			defaultNamespace: "default",
+			expected: SecretResource{
+				ResourceType: KubernetesConfigMapType,
+				Name:         "ca",
+				Namespace:    "namespace",
+				ResourceName: "configmap://namespace/ca",
+				Cluster:      "config",
+			},
+		},
+		{
+			name:             "configmap without namespace",
+			resource:         "configmap://ca",
+			defaultNamespace: "default",
+			err:              true,
+		},
 		{
 			name:             "plain",
 			resource:         "cert",
@@ -114,6 +132,7 @@ func TestToResourceName(t *testing.T) {
 		{"foo", "kubernetes://foo"},
 		{"kubernetes://bar", "kubernetes://bar"},
 		{"kubernetes-gateway://bar", "kubernetes-gateway://bar"},
+		{"configmap://ns/bar", "configmap://ns/bar"},
 		{"builtin://", "default"},
 		{"builtin://extra", "default"},
 	}
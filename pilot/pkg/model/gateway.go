@@ -65,6 +65,10 @@ type MergedGateway struct {
 	// is limited to HTTP3 only
 	MergedQUICTransportServers map[ServerPort]*MergedServers
 
+	// MergedUDPServers map from physical port to servers listening on plain UDP.
+	// These are served by a dedicated UDP listener, so they never conflict with TCP based servers.
+	MergedUDPServers map[ServerPort]*MergedServers
+
 	// HTTP3AdvertisingRoutes represents the set of HTTP routes which advertise HTTP/3.
 	// This mapping is used to generate alt-svc header that is needed for HTTP/3 server discovery.
 	HTTP3AdvertisingRoutes sets.String
@@ -160,6 +164,7 @@ func mergeGateways(gateways []gatewayWithInstances, proxy *Proxy, ps *PushContex
 	nonPlainTextGatewayPortsBindMap := map[uint32]sets.String{}
 	mergedServers := make(map[ServerPort]*MergedServers)
 	mergedQUICServers := make(map[ServerPort]*MergedServers)
+	mergedUDPServers := make(map[ServerPort]*MergedServers)
 	serverPorts := make([]ServerPort, 0)
 	plainTextServers := make(map[uint32]ServerPort)
 	serversByRouteName := make(map[string][]*networking.Server)
@@ -213,6 +218,18 @@ func mergeGateways(gateways []gatewayWithInstances, proxy *Proxy, ps *PushContex
 				}
 			}
 			for _, resolvedPort := range resolvePorts(s.Port.Number, gwAndInstance.instances, gwAndInstance.legacyGatewaySelector) {
+				if protocol.Parse(s.Port.Protocol) == protocol.UDP {
+					// UDP servers listen on a different transport, so they are tracked separately and are never
+					// merged with (or rejected due to) TCP based servers on the same port.
+					serverPort := ServerPort{resolvedPort, s.Port.Protocol, s.Bind}
+					if mergedUDPServers[serverPort] == nil {
+						mergedUDPServers[serverPort] = &MergedServers{Servers: []*networking.Server{}}
+						serverPorts = append(serverPorts, serverPort)
+					}
+					mergedUDPServers[serverPort].Servers = append(mergedUDPServers[serverPort].Servers, s)
+					log.Debugf("mergeGateways: gateway %q merged UDP server %v", gatewayName, s.Hosts)
+					continue
+				}
 				routeName := gatewayRDSRouteName(s, resolvedPort, gatewayConfig)
 				if s.Tls != nil {
 					// Envoy will reject config that has multiple filter chain matches with the same matching rules.
@@ -379,6 +396,7 @@ func mergeGateways(gateways []gatewayWithInstances, proxy *Proxy, ps *PushContex
 	return &MergedGateway{
 		MergedServers:                   mergedServers,
 		MergedQUICTransportServers:      mergedQUICServers,
+		MergedUDPServers:                mergedUDPServers,
 		ServerPorts:                     serverPorts,
 		GatewayNameForServer:            gatewayNameForServer,
 		TLSServerInfo:                   tlsServerInfo,
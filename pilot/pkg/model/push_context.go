@@ -131,23 +131,45 @@ func newVirtualServiceIndex() virtualServiceIndex {
 // destinationRuleIndex is the index of destination rules by various fields.
 type destinationRuleIndex struct {
 	//  namespaceLocal contains all public/private dest rules pertaining to a service defined in a given namespace.
+	// With PILOT_LAZY_SIDECAR_SCOPES, these are lazy-loaded from namespaceLocalConfigs.
+	// Access protected by namespaceLocalMutex.
 	namespaceLocal map[string]*consolidatedDestRules
+	// namespaceLocalConfigs contains the dest rules visible to their own namespace, in merge order.
+	// Only set with PILOT_LAZY_SIDECAR_SCOPES, in which case they are merged into namespaceLocal
+	// the first time a proxy in the namespace looks up a dest rule.
+	namespaceLocalConfigs map[string][]namespaceLocalDestRule
 	//  exportedByNamespace contains all dest rules pertaining to a service exported by a namespace.
 	exportedByNamespace map[string]*consolidatedDestRules
 	rootNamespaceLocal  *consolidatedDestRules
+
+	// mutex to protect lazy-loaded namespaceLocal dest rules.
+	namespaceLocalMutex *sync.RWMutex
+}
+
+// namespaceLocalDestRule is a dest rule waiting to be merged into the namespaceLocal index.
+type namespaceLocalDestRule struct {
+	config   config.Config
+	exportTo sets.Set[visibility.Instance]
 }
 
 func newDestinationRuleIndex() destinationRuleIndex {
 	return destinationRuleIndex{
 		namespaceLocal:      map[string]*consolidatedDestRules{},
 		exportedByNamespace: map[string]*consolidatedDestRules{},
+		namespaceLocalMutex: &sync.RWMutex{},
 	}
 }
 
 // sidecarIndex is the index of sidecar rules
 type sidecarIndex struct {
 	// user configured sidecars for each namespace if available.
+	// With PILOT_LAZY_SIDECAR_SCOPES, these are lazy-loaded from sidecarConfigsByNamespace.
+	// Access protected by derivedSidecarMutex.
 	sidecarsByNamespace map[string][]*SidecarScope
+	// sidecarConfigsByNamespace contains the user configured sidecars for each namespace, in priority order.
+	// Only set with PILOT_LAZY_SIDECAR_SCOPES, in which case they are converted to sidecarsByNamespace
+	// the first time a proxy in the namespace needs them.
+	sidecarConfigsByNamespace map[string][]config.Config
 	// the Sidecar for the root namespace (if present). This applies to any namespace without its own Sidecar.
 	meshRootSidecarConfig *config.Config
 	// meshRootSidecarsByNamespace contains the default sidecar for namespaces that do not have a sidecar.
@@ -1101,8 +1123,8 @@ func (ps *PushContext) DelegateVirtualServices(vses []config.Config) []ConfigHas
 // proxy. The SidecarScope object is a semi-processed view of the service
 // registry, and config state associated with the sidecar crd. The scope contains
 // a set of inbound and outbound listeners, services/configs per listener,
-// etc. The sidecar scopes are precomputed in the initSidecarContext
-// function based on the Sidecar API objects in each namespace. If there is
+// etc. The sidecar scopes are computed from the Sidecar API objects in each
+// namespace, either in initSidecarScopes or on first use. If there is
 // no sidecar api object, a default sidecarscope is assigned to the
 // namespace which enables connectivity to all services in the mesh.
 //
@@ -1111,7 +1133,6 @@ func (ps *PushContext) DelegateVirtualServices(vses []config.Config) []ConfigHas
 func (ps *PushContext) getSidecarScope(proxy *Proxy, workloadLabels labels.Instance) *SidecarScope {
 	// TODO: logic to merge multiple sidecar resources
 	// Currently we assume that there will be only one sidecar config for a namespace.
-	sidecars, hasSidecar := ps.sidecarIndex.sidecarsByNamespace[proxy.ConfigNamespace]
 	switch proxy.Type {
 	case Router, Waypoint:
 		ps.sidecarIndex.derivedSidecarMutex.Lock()
@@ -1131,7 +1152,7 @@ func (ps *PushContext) getSidecarScope(proxy *Proxy, workloadLabels labels.Insta
 		ps.sidecarIndex.sidecarsForGatewayByNamespace[proxy.ConfigNamespace] = computed
 		return computed
 	case SidecarProxy:
-		if hasSidecar {
+		if sidecars, hasSidecar := ps.sidecarsForNamespace(proxy.ConfigNamespace); hasSidecar {
 			for _, wrapper := range sidecars {
 				if wrapper.Sidecar != nil {
 					sidecar := wrapper.Sidecar
@@ -1179,6 +1200,61 @@ func (ps *PushContext) getSidecarScope(proxy *Proxy, workloadLabels labels.Insta
 	return nil
 }
 
+// sidecarsForNamespace returns the scopes of the user configured sidecars in a namespace, in priority order.
+// With PILOT_LAZY_SIDECAR_SCOPES, the scopes of a namespace are computed on first use.
+func (ps *PushContext) sidecarsForNamespace(namespace string) ([]*SidecarScope, bool) {
+	ps.sidecarIndex.derivedSidecarMutex.RLock()
+	sidecars, f := ps.sidecarIndex.sidecarsByNamespace[namespace]
+	configs := ps.sidecarIndex.sidecarConfigsByNamespace[namespace]
+	ps.sidecarIndex.derivedSidecarMutex.RUnlock()
+	if f || len(configs) == 0 {
+		return sidecars, f
+	}
+
+	ps.sidecarIndex.derivedSidecarMutex.Lock()
+	defer ps.sidecarIndex.derivedSidecarMutex.Unlock()
+	if sidecars, f := ps.sidecarIndex.sidecarsByNamespace[namespace]; f {
+		return sidecars, true
+	}
+	// We need to compute this namespace
+	sidecars = make([]*SidecarScope, 0, len(configs))
+	for i := range configs {
+		sidecars = append(sidecars, convertToSidecarScope(ps, &configs[i], namespace))
+	}
+	ps.sidecarIndex.sidecarsByNamespace[namespace] = sidecars
+	return sidecars, true
+}
+
+// namespaceLocalDestRules returns the public/private dest rules of a namespace.
+// With PILOT_LAZY_SIDECAR_SCOPES, the dest rules of a namespace are merged on first use.
+func (ps *PushContext) namespaceLocalDestRules(namespace string) *consolidatedDestRules {
+	index := &ps.destinationRuleIndex
+	if index.namespaceLocalConfigs == nil {
+		// Merged eagerly, no need to lock.
+		return index.namespaceLocal[namespace]
+	}
+	configs := index.namespaceLocalConfigs[namespace]
+	index.namespaceLocalMutex.RLock()
+	local, f := index.namespaceLocal[namespace]
+	index.namespaceLocalMutex.RUnlock()
+	if f || len(configs) == 0 {
+		return local
+	}
+
+	index.namespaceLocalMutex.Lock()
+	defer index.namespaceLocalMutex.Unlock()
+	if local, f := index.namespaceLocal[namespace]; f {
+		return local
+	}
+	// We need to compute this namespace
+	local = newConsolidatedDestRules()
+	for _, dr := range configs {
+		ps.mergeDestinationRule(local, dr.config, dr.exportTo)
+	}
+	index.namespaceLocal[namespace] = local
+	return local
+}
+
 // destinationRule returns a destination rule for a service name in a given namespace.
 func (ps *PushContext) destinationRule(proxyNameSpace string, service *Service) []*ConsolidatedDestRule {
 	if service == nil {
@@ -1196,10 +1272,10 @@ func (ps *PushContext) destinationRule(proxyNameSpace string, service *Service)
 	// 1. select destination rule from proxy config namespace
 	if proxyNameSpace != ps.Mesh.RootNamespace {
 		// search through the DestinationRules in proxy's namespace first
-		if ps.destinationRuleIndex.namespaceLocal[proxyNameSpace] != nil {
+		if local := ps.namespaceLocalDestRules(proxyNameSpace); local != nil {
 			if _, drs, ok := MostSpecificHostMatch(service.Hostname,
-				ps.destinationRuleIndex.namespaceLocal[proxyNameSpace].specificDestRules,
-				ps.destinationRuleIndex.namespaceLocal[proxyNameSpace].wildcardDestRules,
+				local.specificDestRules,
+				local.wildcardDestRules,
 			); ok {
 				return drs
 			}
@@ -1357,6 +1433,8 @@ func (ps *PushContext) updateContext(
 			servicesChanged = true
 		case kind.DestinationRule:
 			destinationRulesChanged = true
+			// DR generated from BackendTLSPolicy are merged with user DR, so we need to update those as well
+			gatewayAPIChanged = true
 		case kind.VirtualService:
 			virtualServicesChanged = true
 		case kind.Gateway:
@@ -1373,11 +1451,15 @@ func (ps *PushContext) updateContext(
 		case kind.RequestAuthentication,
 			kind.PeerAuthentication:
 			authnChanged = true
-		case kind.HTTPRoute, kind.TCPRoute, kind.TLSRoute, kind.GRPCRoute, kind.GatewayClass, kind.KubernetesGateway, kind.ReferenceGrant:
+		case kind.HTTPRoute, kind.TCPRoute, kind.TLSRoute, kind.GRPCRoute, kind.UDPRoute, kind.GatewayClass, kind.KubernetesGateway, kind.ReferenceGrant:
 			gatewayAPIChanged = true
 			// VS and GW are derived from gatewayAPI, so if it changed we need to update those as well
 			virtualServicesChanged = true
 			gatewayChanged = true
+		case kind.BackendTLSPolicy:
+			gatewayAPIChanged = true
+			// DR are derived from BackendTLSPolicy, so if it changed we need to update those as well
+			destinationRulesChanged = true
 		case kind.Telemetry:
 			telemetryChanged = true
 		case kind.ProxyConfig:
@@ -1906,6 +1988,15 @@ func (ps *PushContext) initSidecarScopes(env *Environment) {
 	ps.sidecarIndex.meshRootSidecarConfig = rootNSConfig
 
 	ps.sidecarIndex.sidecarsByNamespace = make(map[string][]*SidecarScope)
+	if features.LazySidecarScopes {
+		// Only index the configs here; namespaces without proxies never pay for the conversion.
+		ps.sidecarIndex.sidecarConfigsByNamespace = make(map[string][]config.Config)
+		for _, sidecarConfig := range sidecarConfigs {
+			ps.sidecarIndex.sidecarConfigsByNamespace[sidecarConfig.Namespace] = append(
+				ps.sidecarIndex.sidecarConfigsByNamespace[sidecarConfig.Namespace], sidecarConfig)
+		}
+		return
+	}
 	ps.convertSidecarScopes(sidecarConfigs)
 }
 
@@ -2021,6 +2112,11 @@ func (ps *PushContext) setDestinationRules(configs []config.Config) {
 	// we take the first one.
 	sortConfigBySelectorAndCreationTime(configs)
 	namespaceLocalDestRules := make(map[string]*consolidatedDestRules)
+	var namespaceLocalConfigs map[string][]namespaceLocalDestRule
+	if features.LazySidecarScopes {
+		// Only index the configs here; namespaces without proxies never pay for the merge.
+		namespaceLocalConfigs = make(map[string][]namespaceLocalDestRule)
+	}
 	exportedDestRulesByNamespace := make(map[string]*consolidatedDestRules)
 	rootNamespaceLocalDestRules := newConsolidatedDestRules()
 
@@ -2044,15 +2140,20 @@ func (ps *PushContext) setDestinationRules(configs []config.Config) {
 		// The global exportTo doesn't matter here (its either . or * - both of which are applicable here)
 		if exportToSet.IsEmpty() || exportToSet.Contains(visibility.Public) || exportToSet.Contains(visibility.Private) ||
 			exportToSet.Contains(visibility.Instance(configs[i].Namespace)) {
-			// Store in an index for the config's namespace
-			// a proxy from this namespace will first look here for the destination rule for a given service
-			// This pool consists of both public/private destination rules.
-			if _, exist := namespaceLocalDestRules[configs[i].Namespace]; !exist {
-				namespaceLocalDestRules[configs[i].Namespace] = newConsolidatedDestRules()
+			if namespaceLocalConfigs != nil {
+				namespaceLocalConfigs[configs[i].Namespace] = append(namespaceLocalConfigs[configs[i].Namespace],
+					namespaceLocalDestRule{config: configs[i], exportTo: exportToSet})
+			} else {
+				// Store in an index for the config's namespace
+				// a proxy from this namespace will first look here for the destination rule for a given service
+				// This pool consists of both public/private destination rules.
+				if _, exist := namespaceLocalDestRules[configs[i].Namespace]; !exist {
+					namespaceLocalDestRules[configs[i].Namespace] = newConsolidatedDestRules()
+				}
+				// Merge this destination rule with any public/private dest rules for same host in the same namespace
+				// If there are no duplicates, the dest rule will be added to the list
+				ps.mergeDestinationRule(namespaceLocalDestRules[configs[i].Namespace], configs[i], exportToSet)
 			}
-			// Merge this destination rule with any public/private dest rules for same host in the same namespace
-			// If there are no duplicates, the dest rule will be added to the list
-			ps.mergeDestinationRule(namespaceLocalDestRules[configs[i].Namespace], configs[i], exportToSet)
 		}
 
 		isPrivateOnly := false
@@ -2078,6 +2179,7 @@ func (ps *PushContext) setDestinationRules(configs []config.Config) {
 	}
 
 	ps.destinationRuleIndex.namespaceLocal = namespaceLocalDestRules
+	ps.destinationRuleIndex.namespaceLocalConfigs = namespaceLocalConfigs
 	ps.destinationRuleIndex.exportedByNamespace = exportedDestRulesByNamespace
 	ps.destinationRuleIndex.rootNamespaceLocal = rootNamespaceLocalDestRules
 }
@@ -2473,11 +2575,11 @@ func (ps *PushContext) initKubernetesGateways(env *Environment) error {
 // accessed by `namespace`, based of specific reference policies.
 // Note: this function only determines if a reference is *explicitly* allowed; the reference may not require
 // explicit authorization to be made at all in most cases. Today, this only is for allowing cross-namespace
-// secret access.
+// secret and ConfigMap access.
 func (ps *PushContext) ReferenceAllowed(kind config.GroupVersionKind, resourceName string, namespace string) bool {
-	// Currently, only Secret has reference policy, and only implemented by Gateway API controller.
+	// Currently, only Secret and ConfigMap have reference policy, and only implemented by Gateway API controller.
 	switch kind {
-	case gvk.Secret:
+	case gvk.Secret, gvk.ConfigMap:
 		if ps.GatewayAPIController != nil {
 			return ps.GatewayAPIController.SecretAllowed(resourceName, namespace)
 		}
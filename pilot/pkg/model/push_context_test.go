@@ -1642,7 +1642,7 @@ func TestInitPushContext(t *testing.T) {
 		cmp.AllowUnexported(PushContext{}, exportToDefaults{}, serviceIndex{}, virtualServiceIndex{},
 			destinationRuleIndex{}, gatewayIndex{}, consolidatedDestRules{}, IstioEgressListenerWrapper{}, SidecarScope{},
 			AuthenticationPolicies{}, NetworkManager{}, sidecarIndex{}, Telemetries{}, ProxyConfigs{}, ConsolidatedDestRule{},
-			ClusterLocalHosts{}),
+			ClusterLocalHosts{}, namespaceLocalDestRule{}),
 		// These are not feasible/worth comparing
 		cmpopts.IgnoreTypes(sync.RWMutex{}, localServiceDiscovery{}, FakeStore{}, atomic.Bool{}, sync.Mutex{}),
 		cmpopts.IgnoreUnexported(IstioEndpoint{}),
@@ -1655,6 +1655,7 @@ func TestInitPushContext(t *testing.T) {
 }
 
 func TestSidecarScope(t *testing.T) {
+	test.SetForTest(t, &features.LazySidecarScopes, false)
 	test.SetForTest(t, &features.ConvertSidecarScopeConcurrency, 10)
 	ps := NewPushContext()
 	env := &Environment{Watcher: mesh.NewFixedWatcher(&meshconfig.MeshConfig{RootNamespace: "istio-system"})}
@@ -1745,6 +1746,48 @@ func TestSidecarScope(t *testing.T) {
 	}
 }
 
+func TestLazySidecarScopes(t *testing.T) {
+	test.SetForTest(t, &features.LazySidecarScopes, true)
+	ps := NewPushContext()
+	env := &Environment{Watcher: mesh.NewFixedWatcher(&meshconfig.MeshConfig{RootNamespace: "istio-system"})}
+	ps.Mesh = env.Mesh()
+
+	configStore := NewFakeStore()
+	sidecar := func(name, namespace string, selector map[string]string) config.Config {
+		sc := &networking.Sidecar{
+			Egress: []*networking.IstioEgressListener{{Hosts: []string{"./*"}}},
+		}
+		if selector != nil {
+			sc.WorkloadSelector = &networking.WorkloadSelector{Labels: selector}
+		}
+		return config.Config{
+			Meta: config.Meta{GroupVersionKind: gvk.Sidecar, Name: name, Namespace: namespace},
+			Spec: sc,
+		}
+	}
+	for _, c := range []config.Config{
+		sidecar("default", "default", nil),
+		sidecar("foo", "default", map[string]string{"app": "foo"}),
+		sidecar("other", "other", nil),
+	} {
+		_, _ = configStore.Create(c)
+	}
+	env.ConfigStore = configStore
+	ps.initSidecarScopes(env)
+	assert.Equal(t, len(ps.sidecarIndex.sidecarsByNamespace), 0)
+
+	proxy := &Proxy{Type: SidecarProxy, ConfigNamespace: "default"}
+	assert.Equal(t, scopeToSidecar(ps.getSidecarScope(proxy, labels.Instance{"app": "foo"})), "default/foo")
+	assert.Equal(t, scopeToSidecar(ps.getSidecarScope(proxy, labels.Instance{"app": "bar"})), "default/default")
+	// Only the namespace that was asked for is converted, and only once.
+	assert.Equal(t, maps.Keys(ps.sidecarIndex.sidecarsByNamespace), []string{"default"})
+	first := ps.getSidecarScope(proxy, nil)
+	assert.Equal(t, ps.getSidecarScope(proxy, nil) == first, true)
+
+	assert.Equal(t, scopeToSidecar(ps.getSidecarScope(&Proxy{Type: SidecarProxy, ConfigNamespace: "nosidecar"}, nil)), "nosidecar/default-sidecar")
+	assert.Equal(t, len(ps.sidecarIndex.sidecarsByNamespace), 1)
+}
+
 func TestRootSidecarScopePropagation(t *testing.T) {
 	rootNS := "istio-system"
 	defaultNS := "default"
@@ -2153,7 +2196,7 @@ func TestSetDestinationRuleWithWorkloadSelector(t *testing.T) {
 					t.Errorf("destinationRuleName expected %v got %v", tt.expectedDrName[i], dr.rule.Name)
 				}
 			}
-			testLocal := ps.destinationRuleIndex.namespaceLocal[tt.proxyNs]
+			testLocal := ps.namespaceLocalDestRules(tt.proxyNs)
 			if testLocal != nil {
 				destRules := testLocal.specificDestRules
 				for _, dr := range destRules[host.Name(testhost)] {
@@ -2235,7 +2278,7 @@ func TestSetDestinationRuleMerging(t *testing.T) {
 		{Namespace: "test", Name: "rule2"},
 	}
 	ps.setDestinationRules([]config.Config{destinationRuleNamespace1, destinationRuleNamespace2, destinationRuleNamespace3})
-	private := ps.destinationRuleIndex.namespaceLocal["test"].specificDestRules[host.Name(testhost)]
+	private := ps.namespaceLocalDestRules("test").specificDestRules[host.Name(testhost)]
 	public := ps.destinationRuleIndex.exportedByNamespace["test"].specificDestRules[host.Name(testhost)]
 	assert.Equal(t, len(private), 1)
 	assert.Equal(t, len(public), 2)
@@ -2263,6 +2306,41 @@ func TestSetDestinationRuleMerging(t *testing.T) {
 	}
 }
 
+func TestLazyNamespaceLocalDestinationRules(t *testing.T) {
+	test.SetForTest(t, &features.LazySidecarScopes, true)
+	ps := NewPushContext()
+	ps.Mesh = &meshconfig.MeshConfig{RootNamespace: "istio-system"}
+	testhost := "httpbin.org"
+	destinationRule := func(name, namespace string, subset string) config.Config {
+		return config.Config{
+			Meta: config.Meta{Name: name, Namespace: namespace},
+			Spec: &networking.DestinationRule{
+				Host:     testhost,
+				ExportTo: []string{"."},
+				Subsets:  []*networking.Subset{{Name: subset}},
+			},
+		}
+	}
+	ps.setDestinationRules([]config.Config{
+		destinationRule("rule1", "test", "subset1"),
+		destinationRule("rule2", "test", "subset2"),
+		destinationRule("rule3", "other", "subset3"),
+	})
+	assert.Equal(t, len(ps.destinationRuleIndex.namespaceLocal), 0)
+
+	svc := &Service{Hostname: host.Name(testhost), Attributes: ServiceAttributes{Namespace: "test"}}
+	drs := ps.destinationRule("test", svc)
+	assert.Equal(t, len(drs), 1)
+	assert.Equal(t, drs[0].from, []types.NamespacedName{{Namespace: "test", Name: "rule1"}, {Namespace: "test", Name: "rule2"}})
+	assert.Equal(t, len(drs[0].rule.Spec.(*networking.DestinationRule).Subsets), 2)
+	// Only the namespace that was asked for is merged, and only once.
+	assert.Equal(t, maps.Keys(ps.destinationRuleIndex.namespaceLocal), []string{"test"})
+	assert.Equal(t, ps.destinationRule("test", svc)[0] == drs[0], true)
+
+	assert.Equal(t, len(ps.destinationRule("nodr", svc)), 0)
+	assert.Equal(t, len(ps.destinationRuleIndex.namespaceLocal), 1)
+}
+
 func TestSetDestinationRuleWithExportTo(t *testing.T) {
 	ps := NewPushContext()
 	ps.Mesh = &meshconfig.MeshConfig{RootNamespace: "istio-system"}
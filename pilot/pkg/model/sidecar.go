@@ -52,6 +52,7 @@ var (
 		kind.TCPRoute,
 		kind.TLSRoute,
 		kind.GRPCRoute,
+		kind.UDPRoute,
 	)
 
 	// clusterScopedKnownConfigTypes includes configs when they are in root namespace,
@@ -41,6 +41,7 @@ import (
 	"istio.io/istio/pkg/config/schema/kind"
 	"istio.io/istio/pkg/log"
 	"istio.io/istio/pkg/security"
+	"istio.io/istio/pkg/slices"
 	netutil "istio.io/istio/pkg/util/net"
 	"istio.io/istio/pkg/util/sets"
 )
@@ -128,6 +129,22 @@ func (configgen *ConfigGeneratorImpl) BuildDeltaClusters(proxy *model.Proxy, upd
 
 		deletedClusters.InsertAll(deleted...)
 	}
+	if features.FilterGatewayClusterConfig && proxy.Type == model.Router {
+		// Gateways only get clusters for the services they route to. Clusters of updated services that are not
+		// routed to anymore are removed, as they are not rebuilt.
+		gatewayServices := sets.New[host.Name]()
+		for _, svc := range updates.Push.GatewayServices(proxy) {
+			gatewayServices.Insert(svc.Hostname)
+		}
+		services = slices.FilterInPlace(services, func(svc *model.Service) bool {
+			if gatewayServices.Contains(svc.Hostname) {
+				return true
+			}
+			deletedClusters.InsertAll(serviceClusters[svc.Hostname.String()].UnsortedList()...)
+			deletedClusters.InsertAll(subsetClusters[svc.Hostname.String()].UnsortedList()...)
+			return false
+		})
+	}
 	clusters, log := configgen.buildClusters(proxy, updates, services)
 	// DeletedClusters contains list of all subset clusters for the deleted DR or updated DR.
 	// When clusters are rebuilt, we rebuild the subset clusters as well. So, we know what
@@ -307,7 +324,7 @@ func (configgen *ConfigGeneratorImpl) buildOutboundClusters(cb *ClusterBuilder,
 			continue
 		}
 		for _, port := range service.Ports {
-			if port.Protocol == protocol.UDP {
+			if port.Protocol == protocol.UDP && !udpClusterNeeded(proxy, service, port) {
 				continue
 			}
 			clusterKey := buildClusterKey(service, port, cb, proxy, efKeys)
@@ -374,6 +391,21 @@ func (configgen *ConfigGeneratorImpl) buildOutboundClusters(cb *ClusterBuilder,
 	return resources, cacheStats{hits: hit, miss: miss}
 }
 
+// udpClusterNeeded determines if an outbound cluster should be built for a UDP Service port. UDP is only proxied by
+// gateways with UDP servers (for UDPRoute). If the Service exposes the same port number with another protocol, that
+// cluster is reused.
+func udpClusterNeeded(proxy *model.Proxy, service *model.Service, port *model.Port) bool {
+	if proxy.Type != model.Router || proxy.MergedGateway == nil || len(proxy.MergedGateway.MergedUDPServers) == 0 {
+		return false
+	}
+	for _, p := range service.Ports {
+		if p.Port == port.Port && p.Protocol != protocol.UDP {
+			return false
+		}
+	}
+	return true
+}
+
 type clusterPatcher struct {
 	efw  *model.EnvoyFilterWrapper
 	pctx networking.EnvoyFilter_PatchContext
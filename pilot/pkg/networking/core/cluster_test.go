@@ -3527,6 +3527,55 @@ func TestBuildDeltaClusters(t *testing.T) {
 	}
 }
 
+func TestBuildDeltaClustersGatewayFiltering(t *testing.T) {
+	test.SetForTest(t, &features.FilterGatewayClusterConfig, true)
+	service := func(hostname string) *model.Service {
+		return &model.Service{
+			Hostname:   host.Name(hostname),
+			Ports:      []*model.Port{{Name: "http", Port: 8080, Protocol: protocol.HTTP}},
+			Resolution: model.ClientSideLB,
+			Attributes: model.ServiceAttributes{Namespace: TestServiceNamespace},
+		}
+	}
+	configs := []config.Config{
+		{
+			Meta: config.Meta{GroupVersionKind: gvk.Gateway, Name: "gateway", Namespace: TestServiceNamespace},
+			Spec: &networking.Gateway{
+				Selector: map[string]string{"istio": "ingressgateway"},
+				Servers: []*networking.Server{{
+					Hosts: []string{"*"},
+					Port:  &networking.Port{Name: "http", Number: 80, Protocol: "HTTP"},
+				}},
+			},
+		},
+		{
+			Meta: config.Meta{GroupVersionKind: gvk.VirtualService, Name: "vs", Namespace: TestServiceNamespace},
+			Spec: &networking.VirtualService{
+				Hosts:    []string{"*"},
+				Gateways: []string{"gateway"},
+				Http: []*networking.HTTPRoute{{
+					Route: []*networking.HTTPRouteDestination{{Destination: &networking.Destination{Host: "routed.com"}}},
+				}},
+			},
+		},
+	}
+	cg := NewConfigGenTest(t, TestOptions{
+		Services: []*model.Service{service("routed.com"), service("unrouted.com")},
+		Configs:  configs,
+	})
+	proxy := cg.SetupProxy(&model.Proxy{Type: model.Router, Labels: map[string]string{"istio": "ingressgateway"}})
+
+	// Clusters are only built for services the gateway routes to, and clusters of services which are not routed
+	// to anymore are removed.
+	clusters, removed, delta := cg.DeltaClusters(proxy, sets.New(
+		model.ConfigKey{Kind: kind.ServiceEntry, Name: "routed.com", Namespace: TestServiceNamespace},
+		model.ConfigKey{Kind: kind.ServiceEntry, Name: "unrouted.com", Namespace: TestServiceNamespace},
+	), &model.WatchedResource{ResourceNames: []string{"outbound|8080||routed.com", "outbound|8080||unrouted.com"}})
+	assert.Equal(t, delta, true)
+	assert.Equal(t, removed, []string{"outbound|8080||unrouted.com"})
+	assert.Equal(t, xdstest.MapKeys(xdstest.ExtractClusters(clusters)), []string{"BlackHoleCluster", "outbound|8080||routed.com"})
+}
+
 func TestBuildStaticClusterWithCredentialSocket(t *testing.T) {
 	g := NewWithT(t)
 
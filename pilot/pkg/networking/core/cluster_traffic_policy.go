@@ -51,7 +51,8 @@ func (cb *ClusterBuilder) applyTrafficPolicy(opts buildClusterOpts) {
 		cb.applyH2Upgrade(opts.mutable, opts.port, opts.mesh, connectionPool)
 		applyOutlierDetection(opts.mutable.cluster, outlierDetection)
 		applyLoadBalancer(opts.mutable.cluster, loadBalancer, opts.port, cb.locality, cb.proxyLabels, opts.mesh)
-		if opts.clusterMode != SniDnatClusterMode {
+		// UDP is proxied as plain datagrams, so TLS and PROXY protocol do not apply.
+		if opts.clusterMode != SniDnatClusterMode && (opts.port == nil || opts.port.Protocol != protocol.UDP) {
 			autoMTLSEnabled := opts.mesh.GetEnableAutoMtls().Value
 			tls, mtlsCtxType := cb.buildUpstreamTLSSettings(tls, opts.serviceAccounts, opts.istioMtlsSni,
 				autoMTLSEnabled, opts.meshExternal, opts.serviceMTLSMode)
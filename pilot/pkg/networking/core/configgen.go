@@ -31,6 +31,11 @@ type ConfigGenerator interface {
 	// once and shared across multiple invocations of this function.
 	BuildListeners(node *model.Proxy, push *model.PushContext) []*listener.Listener
 
+	// BuildDeltaListeners returns the listeners affected by the updated configs and the names of the listeners that
+	// were removed, along with whether delta was used. This is Delta LDS output.
+	BuildDeltaListeners(proxy *model.Proxy, updates *model.PushRequest,
+		watched *model.WatchedResource) ([]*listener.Listener, []string, bool)
+
 	// BuildClusters returns the list of clusters for the given proxy. This is the CDS output
 	BuildClusters(node *model.Proxy, req *model.PushRequest) ([]*discovery.Resource, model.XdsLogDetails)
 
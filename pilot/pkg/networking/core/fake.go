@@ -298,6 +298,14 @@ func (f *ConfigGenTest) DeltaClusters(
 	return res, removed, delta
 }
 
+func (f *ConfigGenTest) DeltaListeners(
+	p *model.Proxy,
+	configUpdated sets.Set[model.ConfigKey],
+	watched *model.WatchedResource,
+) ([]*listener.Listener, []string, bool) {
+	return f.ConfigGen.BuildDeltaListeners(p, &model.PushRequest{Push: f.PushContext(), ConfigsUpdated: configUpdated}, watched)
+}
+
 func (f *ConfigGenTest) RoutesFromListeners(p *model.Proxy, l []*listener.Listener) []*route.RouteConfiguration {
 	resources, _ := f.ConfigGen.BuildHTTPRoutes(p, &model.PushRequest{Push: f.PushContext()}, ExtractRoutesFromListeners(l))
 	out := make([]*route.RouteConfiguration, 0, len(resources))
@@ -22,11 +22,14 @@ import (
 	"strings"
 	"unsafe"
 
+	xds "github.com/cncf/xds/go/xds/core/v3"
+	matcher "github.com/cncf/xds/go/xds/type/matcher/v3"
 	core "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
 	listener "github.com/envoyproxy/go-control-plane/envoy/config/listener/v3"
 	route "github.com/envoyproxy/go-control-plane/envoy/config/route/v3"
 	statefulsession "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/http/stateful_session/v3"
 	hcm "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/network/http_connection_manager/v3"
+	udp "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/udp/udp_proxy/v3"
 	tls "github.com/envoyproxy/go-control-plane/envoy/extensions/transport_sockets/tls/v3"
 	"github.com/hashicorp/go-multierror"
 	"google.golang.org/protobuf/types/known/anypb"
@@ -130,6 +133,7 @@ func (configgen *ConfigGeneratorImpl) buildGatewayListeners(builder *ListenerBui
 	proxyConfig := builder.node.Metadata.ProxyConfigOrDefault(builder.push.Mesh.DefaultConfig)
 	// listener port -> host/bind
 	tlsHostsByPort := map[uint32]map[string]string{}
+	var udpListeners []*listener.Listener
 	for _, port := range mergedGateway.ServerPorts {
 		// Skip ports we cannot bind to. Note that mergeGateways will already translate Service port to
 		// targetPort, which handles the common case of exposing ports like 80 and 443 but listening on
@@ -156,6 +160,7 @@ func (configgen *ConfigGeneratorImpl) buildGatewayListeners(builder *ListenerBui
 		transportToServers := map[istionetworking.TransportProtocol]map[model.ServerPort]*model.MergedServers{
 			istionetworking.TransportProtocolTCP:  mergedGateway.MergedServers,
 			istionetworking.TransportProtocolQUIC: mergedGateway.MergedQUICTransportServers,
+			istionetworking.TransportProtocolUDP:  mergedGateway.MergedUDPServers,
 		}
 
 		for transport, gwServers := range transportToServers {
@@ -164,7 +169,7 @@ func (configgen *ConfigGeneratorImpl) buildGatewayListeners(builder *ListenerBui
 				continue
 			}
 
-			needPROXYProtocol := transport != istionetworking.TransportProtocolQUIC &&
+			needPROXYProtocol := transport == istionetworking.TransportProtocolTCP &&
 				proxyConfig.GetGatewayTopology().GetProxyProtocol() != nil
 
 			// on a given port, we can either have plain text HTTP servers or
@@ -195,6 +200,13 @@ func (configgen *ConfigGeneratorImpl) buildGatewayListeners(builder *ListenerBui
 				// have to be the case (it is just the most common case now, in the future
 				// we will support more cases)
 				configgen.buildGatewayHTTP3FilterChains(builder, serversForPort, mergedGateway, proxyConfig, opts)
+			case istionetworking.TransportProtocolUDP:
+				// UDP listeners have no filter chains; the proxying is done by a listener filter, so they are
+				// built directly rather than through the mutable listener.
+				if l := buildGatewayUDPListener(builder, port, serversForPort, mergedGateway, opts); l != nil {
+					udpListeners = append(udpListeners, l)
+				}
+				continue
 			}
 
 			if mopts, exists := mutableopts[lname]; !exists {
@@ -218,6 +230,7 @@ func (configgen *ConfigGeneratorImpl) buildGatewayListeners(builder *ListenerBui
 		}
 		listeners = append(listeners, ml.mutable.Listener)
 	}
+	listeners = append(listeners, udpListeners...)
 	// We'll try to return any listeners we successfully marshaled; if we have none, we'll emit the error we built up
 	err := errs.ErrorOrNil()
 	if err != nil {
@@ -225,7 +238,7 @@ func (configgen *ConfigGeneratorImpl) buildGatewayListeners(builder *ListenerBui
 		log.Info(err.Error())
 	}
 
-	if len(mutableopts) == 0 {
+	if len(mutableopts) == 0 && len(udpListeners) == 0 {
 		log.Warnf("gateway has zero listeners for node %v", builder.node.ID)
 		return builder
 	}
@@ -315,12 +328,75 @@ func getListenerName(bind string, port int, transport istionetworking.TransportP
 	switch transport {
 	case istionetworking.TransportProtocolTCP:
 		return bind + "_" + strconv.Itoa(port)
-	case istionetworking.TransportProtocolQUIC:
+	case istionetworking.TransportProtocolQUIC, istionetworking.TransportProtocolUDP:
 		return "udp_" + bind + "_" + strconv.Itoa(port)
 	}
 	return "unknown"
 }
 
+// buildGatewayUDPListener builds a listener proxying datagrams to the destination of the first VirtualService TCP route
+// matching one of the UDP servers on the port. Unlike TCP, there is no way to distinguish between servers (such as
+// SNI), so only a single destination can be served per port.
+func buildGatewayUDPListener(
+	builder *ListenerBuilder,
+	port model.ServerPort,
+	serversForPort *model.MergedServers,
+	mergedGateway *model.MergedGateway,
+	opts *gatewayListenerOpts,
+) *listener.Listener {
+	for sp := range mergedGateway.MergedQUICTransportServers {
+		if sp.Number == port.Number && sp.Bind == port.Bind {
+			log.Warnf("gateway UDP server on port %d conflicts with HTTP/3 server on the same port, skipping", port.Number)
+			model.RecordRejectedConfig(mergedGateway.GatewayNameForServer[serversForPort.Servers[0]])
+			return nil
+		}
+	}
+	for _, server := range serversForPort.Servers {
+		gatewayName := mergedGateway.GatewayNameForServer[server]
+		clusterName := builder.buildGatewayUDPClusterFromTCPRoutes(server, gatewayName)
+		if clusterName == "" {
+			continue
+		}
+		udpProxy := &udp.UdpProxyConfig{
+			StatPrefix: clusterName,
+			RouteSpecifier: &udp.UdpProxyConfig_Matcher{
+				Matcher: &matcher.Matcher{
+					OnNoMatch: &matcher.Matcher_OnMatch{
+						OnMatch: &matcher.Matcher_OnMatch_Action{
+							Action: &xds.TypedExtensionConfig{
+								Name:        "route",
+								TypedConfig: protoconv.MessageToAny(&udp.Route{Cluster: clusterName}),
+							},
+						},
+					},
+				},
+			},
+		}
+		res := &listener.Listener{
+			Name:             getListenerName(opts.bind, opts.port, istionetworking.TransportProtocolUDP),
+			TrafficDirection: core.TrafficDirection_OUTBOUND,
+			Address:          util.BuildNetworkAddress(opts.bind, uint32(opts.port), istionetworking.TransportProtocolUDP),
+			UdpListenerConfig: &listener.UdpListenerConfig{
+				DownstreamSocketConfig: &core.UdpSocketConfig{},
+			},
+			ListenerFilters: []*listener.ListenerFilter{{
+				Name:       util.UDPProxyListenerFilter,
+				ConfigType: &listener.ListenerFilter_TypedConfig{TypedConfig: protoconv.MessageToAny(udpProxy)},
+			}},
+		}
+		if features.EnableDualStack && len(opts.extraBind) > 0 {
+			res.AdditionalAddresses = util.BuildAdditionalAddresses(opts.extraBind, uint32(opts.port))
+			for _, additionalAddress := range res.AdditionalAddresses {
+				additionalAddress.GetAddress().GetSocketAddress().Protocol = core.SocketAddress_UDP
+			}
+		}
+		log.Debugf("buildGatewayListeners: building UDP listener %s for gateway %s", res.Name, gatewayName)
+		return res
+	}
+	log.Warnf("gateway UDP listener on port %d missed a destination", port.Number)
+	return nil
+}
+
 func buildNameToServiceMapForHTTPRoutes(node *model.Proxy, push *model.PushContext,
 	virtualService config.Config,
 ) map[host.Name]*model.Service {
@@ -856,6 +932,36 @@ func (lb *ListenerBuilder) buildGatewayNetworkFiltersFromTCPRoutes(server *netwo
 	return nil
 }
 
+// buildGatewayUDPClusterFromTCPRoutes finds the VirtualService TCP route bound to the UDP server, returning the cluster
+// that datagrams should be forwarded to. As UDP proxying does not support traffic splitting, only the first
+// destination of the route is used. UDPRoutes with traffic splits are rejected, so this only applies to VirtualServices.
+func (lb *ListenerBuilder) buildGatewayUDPClusterFromTCPRoutes(server *networking.Server, gateway string) string {
+	gatewayServerHosts := sets.NewWithLength[host.Name](len(server.Hosts))
+	for _, hostname := range server.Hosts {
+		gatewayServerHosts.Insert(host.Name(hostname))
+	}
+
+	for _, v := range lb.push.VirtualServicesForGateway(lb.node.ConfigNamespace, gateway) {
+		vsvc := v.Spec.(*networking.VirtualService)
+		if len(pickMatchingGatewayHosts(gatewayServerHosts, v)) == 0 {
+			continue
+		}
+		for _, tcp := range vsvc.Tcp {
+			if !l4MultiMatch(tcp.Match, server, gateway) || len(tcp.Route) == 0 {
+				continue
+			}
+			if len(tcp.Route) > 1 {
+				log.Warnf("VirtualService %s/%s has multiple destinations for UDP server on port %d of gateway %s, only the first is used",
+					v.Namespace, v.Name, server.Port.Number, gateway)
+			}
+			destination := tcp.Route[0].Destination
+			service := lb.push.ServiceForHostname(lb.node, host.Name(destination.GetHost()))
+			return istio_route.GetDestinationCluster(destination, service, int(server.Port.Number))
+		}
+	}
+	return ""
+}
+
 // buildGatewayNetworkFiltersFromTLSRoutes builds tcp proxy routes for all VirtualServices with TLS blocks.
 // It first obtains all virtual services bound to the set of Gateways for this workload, filters them by this
 // server's port and hostnames, and produces network filters for each destination from the filtered services
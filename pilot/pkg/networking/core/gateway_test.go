@@ -23,6 +23,7 @@ import (
 	core "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
 	route "github.com/envoyproxy/go-control-plane/envoy/config/route/v3"
 	hcm "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/network/http_connection_manager/v3"
+	udp "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/udp/udp_proxy/v3"
 	auth "github.com/envoyproxy/go-control-plane/envoy/extensions/transport_sockets/tls/v3"
 	"github.com/google/go-cmp/cmp"
 	"github.com/google/uuid"
@@ -3349,6 +3350,68 @@ func TestBuildGatewayListeners(t *testing.T) {
 			},
 			[]string{"10.0.0.1_443", "10.0.0.2_443"},
 		},
+		{
+			"udp and tcp on the same port",
+			&pilot_model.Proxy{},
+			[]config.Config{
+				{
+					Meta: config.Meta{Name: "gateway", Namespace: "testns", GroupVersionKind: gvk.Gateway},
+					Spec: &networking.Gateway{
+						Servers: []*networking.Server{
+							{
+								Port:  &networking.Port{Name: "dns-tcp", Number: 53, Protocol: "TCP"},
+								Hosts: []string{"*"},
+							},
+							{
+								Port:  &networking.Port{Name: "dns-udp", Number: 53, Protocol: "UDP"},
+								Hosts: []string{"*"},
+							},
+						},
+					},
+				},
+			},
+			[]config.Config{
+				{
+					Meta: config.Meta{Name: uuid.NewString(), Namespace: uuid.NewString(), GroupVersionKind: gvk.VirtualService},
+					Spec: &networking.VirtualService{
+						Gateways: []string{"testns/gateway"},
+						Hosts:    []string{"*"},
+						Tcp: []*networking.TCPRoute{
+							{
+								Route: []*networking.RouteDestination{
+									{
+										Destination: &networking.Destination{
+											Host: "dns.example.com",
+											Port: &networking.PortSelector{Number: 53},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			[]string{"0.0.0.0_53", "udp_0.0.0.0_53"},
+		},
+		{
+			"udp without route",
+			&pilot_model.Proxy{},
+			[]config.Config{
+				{
+					Meta: config.Meta{Name: "gateway", Namespace: "testns", GroupVersionKind: gvk.Gateway},
+					Spec: &networking.Gateway{
+						Servers: []*networking.Server{
+							{
+								Port:  &networking.Port{Name: "dns-udp", Number: 53, Protocol: "UDP"},
+								Hosts: []string{"*"},
+							},
+						},
+					},
+				},
+			},
+			nil,
+			[]string{},
+		},
 	}
 
 	for _, tt := range cases {
@@ -3386,6 +3449,83 @@ func TestBuildGatewayListeners(t *testing.T) {
 	}
 }
 
+func TestBuildGatewayUDPListener(t *testing.T) {
+	svc := &pilot_model.Service{
+		Hostname: "dns.default.svc.cluster.local",
+		Ports: pilot_model.PortList{
+			{Name: "dns", Port: 53, Protocol: protocol.UDP},
+		},
+		Resolution: pilot_model.ClientSideLB,
+		Attributes: pilot_model.ServiceAttributes{Namespace: "default"},
+	}
+	configs := []config.Config{
+		{
+			Meta: config.Meta{Name: "gateway", Namespace: "testns", GroupVersionKind: gvk.Gateway},
+			Spec: &networking.Gateway{
+				Servers: []*networking.Server{
+					{
+						Port:  &networking.Port{Name: "dns", Number: 53, Protocol: "UDP"},
+						Hosts: []string{"*"},
+					},
+				},
+			},
+		},
+		{
+			Meta: config.Meta{Name: "dns", Namespace: "testns", GroupVersionKind: gvk.VirtualService},
+			Spec: &networking.VirtualService{
+				Gateways: []string{"testns/gateway"},
+				Hosts:    []string{"*"},
+				Tcp: []*networking.TCPRoute{{
+					Route: []*networking.RouteDestination{{
+						Destination: &networking.Destination{
+							Host: "dns.default.svc.cluster.local",
+							Port: &networking.PortSelector{Number: 53},
+						},
+					}},
+				}},
+			},
+		},
+	}
+	cg := NewConfigGenTest(t, TestOptions{Configs: configs, Services: []*pilot_model.Service{svc}})
+	proxy := cg.SetupProxy(&proxyGateway)
+	proxy.Metadata = &proxyGatewayMetadata
+
+	builder := cg.ConfigGen.buildGatewayListeners(NewListenerBuilder(proxy, cg.PushContext()))
+	l := xdstest.ExtractListener("udp_0.0.0.0_53", builder.gatewayListeners)
+	if l == nil {
+		t.Fatalf("expected UDP listener, got %v", xdstest.ExtractListenerNames(builder.gatewayListeners))
+	}
+	if got := l.GetAddress().GetSocketAddress().GetProtocol(); got != core.SocketAddress_UDP {
+		t.Fatalf("expected UDP socket address, got %v", got)
+	}
+	udpProxy := xdstest.UnmarshalAny[udp.UdpProxyConfig](t, xdstest.ExtractListenerFilters(l)[util.UDPProxyListenerFilter].GetTypedConfig())
+	udpRoute := xdstest.UnmarshalAny[udp.Route](t, udpProxy.GetMatcher().GetOnNoMatch().GetAction().GetTypedConfig())
+	wantCluster := "outbound|53||dns.default.svc.cluster.local"
+	if udpRoute.Cluster != wantCluster {
+		t.Fatalf("expected cluster %v, got %v", wantCluster, udpRoute.Cluster)
+	}
+
+	c := xdstest.ExtractClusters(cg.Clusters(proxy))[wantCluster]
+	if c == nil {
+		t.Fatalf("expected cluster %v to be generated for the gateway", wantCluster)
+	}
+	if c.TransportSocket != nil || len(c.TransportSocketMatches) > 0 {
+		t.Fatalf("expected no TLS for UDP cluster, got %v", c)
+	}
+	if xdstest.ExtractClusters(cg.Clusters(cg.SetupProxy(nil)))[wantCluster] != nil {
+		t.Fatalf("expected no UDP cluster for sidecars")
+	}
+
+	// Gateways without UDP servers do not get UDP clusters either.
+	configs[0].Spec.(*networking.Gateway).Servers[0].Port = &networking.Port{Name: "tcp", Number: 53, Protocol: "TCP"}
+	cg = NewConfigGenTest(t, TestOptions{Configs: configs, Services: []*pilot_model.Service{svc}})
+	proxy = cg.SetupProxy(&proxyGateway)
+	proxy.Metadata = &proxyGatewayMetadata
+	if xdstest.ExtractClusters(cg.Clusters(proxy))[wantCluster] != nil {
+		t.Fatalf("expected no UDP cluster for gateways without UDP servers")
+	}
+}
+
 func TestBuildNameToServiceMapForHttpRoutes(t *testing.T) {
 	virtualServiceSpec := &networking.VirtualService{
 		Hosts: []string{"*"},
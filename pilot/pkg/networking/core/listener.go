@@ -45,6 +45,7 @@ import (
 	"istio.io/istio/pkg/config/constants"
 	"istio.io/istio/pkg/config/host"
 	"istio.io/istio/pkg/config/protocol"
+	"istio.io/istio/pkg/config/schema/kind"
 	"istio.io/istio/pkg/log"
 	"istio.io/istio/pkg/monitoring"
 	"istio.io/istio/pkg/proto"
@@ -124,6 +125,78 @@ func (configgen *ConfigGeneratorImpl) BuildListeners(node *model.Proxy,
 	return l
 }
 
+// BuildDeltaListeners returns the listeners affected by the updated configs, along with the names of the listeners that
+// were removed. For sidecars, only outbound listeners depend on other services, and each of them is bound to a single
+// port, so when only services are updated, only the listeners of their ports are rebuilt. Otherwise, all listeners are
+// built and delta is not used.
+func (configgen *ConfigGeneratorImpl) BuildDeltaListeners(proxy *model.Proxy, updates *model.PushRequest,
+	watched *model.WatchedResource,
+) ([]*listener.Listener, []string, bool) {
+	ports, ok := deltaListenerPorts(proxy, updates)
+	if !ok {
+		return configgen.BuildListeners(proxy, updates.Push), nil, false
+	}
+	builder := NewListenerBuilder(proxy, updates.Push)
+	builder.outboundPorts = ports
+	listeners := builder.appendSidecarOutboundListeners().outboundListeners
+
+	built := sets.New[string]()
+	for _, l := range listeners {
+		built.Insert(l.Name)
+	}
+	removed := sets.New[string]()
+	for _, name := range watched.ResourceNames {
+		if built.Contains(name) {
+			continue
+		}
+		// Outbound listeners are named <bind>_<port>.
+		idx := strings.LastIndex(name, "_")
+		if idx < 0 {
+			continue
+		}
+		if port, err := strconv.Atoi(name[idx+1:]); err == nil && ports.Contains(port) {
+			removed.Insert(name)
+		}
+	}
+	return listeners, sets.SortedList(removed), true
+}
+
+// deltaListenerPorts returns the ports of the outbound listeners affected by updates, if only the listeners of these
+// ports need to be rebuilt.
+func deltaListenerPorts(proxy *model.Proxy, updates *model.PushRequest) (sets.Set[int], bool) {
+	if proxy.Type != model.SidecarProxy || updates == nil || len(updates.ConfigsUpdated) == 0 {
+		return nil, false
+	}
+	// Without the previous scope, the ports of removed services are unknown. Listeners built outside of
+	// buildSidecarOutboundListeners, or patched by EnvoyFilters, may depend on any service.
+	if proxy.PrevSidecarScope == nil || updates.Push.Mesh.ProxyListenPort == 0 || proxy.SidecarScope.HasIngressListener() ||
+		proxy.Metadata.HTTPProxyPort != "" || updates.Push.EnvoyFilters(proxy) != nil {
+		return nil, false
+	}
+	ports := sets.New[int]()
+	for key := range updates.ConfigsUpdated {
+		if key.Kind != kind.ServiceEntry {
+			return nil, false
+		}
+		hostname := host.Name(key.Name)
+		// The services of the proxy itself are used to build inbound listeners.
+		for _, target := range proxy.ServiceTargets {
+			if target.Service.Hostname == hostname {
+				return nil, false
+			}
+		}
+		// Include the ports of the service before the update, to rebuild or remove the listeners of removed ports.
+		for _, scope := range []*model.SidecarScope{proxy.SidecarScope, proxy.PrevSidecarScope} {
+			for _, svc := range scope.ServicesForHostname(hostname) {
+				for _, port := range svc.Ports {
+					ports.Insert(port.Port)
+				}
+			}
+		}
+	}
+	return ports, true
+}
+
 func BuildListenerTLSContext(serverTLSSettings *networking.ServerTLSSettings,
 	proxy *model.Proxy, mesh *meshconfig.MeshConfig, transportProtocol istionetworking.TransportProtocol, gatewayTCPServerWithTerminatingTLS bool,
 ) *auth.DownstreamTlsContext {
@@ -405,6 +478,9 @@ func (lb *ListenerBuilder) buildSidecarOutboundListeners(node *model.Proxy,
 				Protocol: protocol.Parse(egressListener.IstioListener.Port.Protocol),
 				Name:     egressListener.IstioListener.Port.Name,
 			}
+			if !lb.buildsOutboundPort(listenPort.Port) {
+				continue
+			}
 			if canbind, knownlistener := lb.node.CanBindToPort(bind.bindToPort, node, push, bind.Primary(),
 				listenPort.Port, listenPort.Protocol, wildcard); !canbind {
 				if knownlistener {
@@ -457,6 +533,9 @@ func (lb *ListenerBuilder) buildSidecarOutboundListeners(node *model.Proxy,
 			for _, service := range services {
 				saddress := service.GetAddressForProxy(node)
 				for _, servicePort := range service.Ports {
+					if !lb.buildsOutboundPort(servicePort.Port) {
+						continue
+					}
 					// Skip ports we cannot bind to
 					wildcard := wildCards[node.GetIPMode()][0]
 					if canbind, knownlistener := lb.node.CanBindToPort(bind.bindToPort, node, push, bind.Primary(),
@@ -539,6 +618,11 @@ func (lb *ListenerBuilder) buildSidecarOutboundListeners(node *model.Proxy,
 	return finalizeOutboundListeners(lb, listenerMap)
 }
 
+// buildsOutboundPort returns true if outbound listeners are built for port.
+func (lb *ListenerBuilder) buildsOutboundPort(port int) bool {
+	return lb.outboundPorts == nil || lb.outboundPorts.Contains(port)
+}
+
 func finalizeOutboundListeners(lb *ListenerBuilder, listenerMap map[listenerKey]*outboundListenerEntry) []*listener.Listener {
 	listeners := make([]*listener.Listener, 0, len(listenerMap))
 	for _, le := range listenerMap {
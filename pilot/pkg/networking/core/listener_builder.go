@@ -43,6 +43,7 @@ import (
 	"istio.io/istio/pkg/config/protocol"
 	"istio.io/istio/pkg/log"
 	"istio.io/istio/pkg/proto"
+	"istio.io/istio/pkg/util/sets"
 	"istio.io/istio/pkg/wellknown"
 )
 
@@ -56,6 +57,9 @@ type ListenerBuilder struct {
 	gatewayListeners  []*listener.Listener
 	inboundListeners  []*listener.Listener
 	outboundListeners []*listener.Listener
+	// outboundPorts, if set, restricts the outbound listeners built to these ports. It is used to only build
+	// the listeners affected by a change.
+	outboundPorts sets.Set[int]
 	// HttpProxyListener is a specialize outbound listener. See MeshConfig.proxyHttpPort
 	httpProxyListener       *listener.Listener
 	virtualOutboundListener *listener.Listener
@@ -54,10 +54,12 @@ import (
 	"istio.io/istio/pkg/config/mesh"
 	"istio.io/istio/pkg/config/protocol"
 	"istio.io/istio/pkg/config/schema/gvk"
+	"istio.io/istio/pkg/config/schema/kind"
 	"istio.io/istio/pkg/config/xds"
 	"istio.io/istio/pkg/slices"
 	"istio.io/istio/pkg/test"
 	"istio.io/istio/pkg/test/util/assert"
+	"istio.io/istio/pkg/util/sets"
 	"istio.io/istio/pkg/wellknown"
 )
 
@@ -3100,3 +3102,109 @@ func TestOutboundListenerConfig_WithAutoAllocatedAddress(t *testing.T) {
 		})
 	}
 }
+
+func TestBuildDeltaListeners(t *testing.T) {
+	now := time.Now()
+	httpService := buildService("http.com", "10.0.0.1", protocol.HTTP, now)
+	otherHTTPService := buildService("other.com", "10.0.0.2", protocol.HTTP, now)
+	tcpService := buildService("tcp.com", "10.0.0.3", protocol.TCP, now)
+	tcpService.Ports = append(tcpService.Ports, &model.Port{Name: "tcp-9090", Port: 9090, Protocol: protocol.TCP})
+	tcpServiceWithoutPort := buildService("tcp.com", "10.0.0.3", protocol.TCP, now)
+	newService := buildServiceWithPort("new.com", 7070, protocol.HTTP, now)
+
+	key := func(hostname string) model.ConfigKey {
+		return model.ConfigKey{Kind: kind.ServiceEntry, Name: hostname, Namespace: "default"}
+	}
+	cases := []struct {
+		name          string
+		prevServices  []*model.Service
+		services      []*model.Service
+		configUpdated sets.Set[model.ConfigKey]
+		usedDelta     bool
+		// expected are the listeners built with delta, which must match the listeners built without delta
+		expected []string
+		removed  []string
+	}{
+		{
+			name:          "service added on a new port",
+			prevServices:  []*model.Service{httpService},
+			services:      []*model.Service{httpService, newService},
+			configUpdated: sets.New(key("new.com")),
+			usedDelta:     true,
+			expected:      []string{"0.0.0.0_7070"},
+		},
+		{
+			name:          "service updated on a shared port",
+			prevServices:  []*model.Service{httpService, otherHTTPService, tcpService},
+			services:      []*model.Service{httpService, otherHTTPService, tcpService},
+			configUpdated: sets.New(key("other.com")),
+			usedDelta:     true,
+			expected:      []string{"0.0.0.0_8080", "10.0.0.3_8080"},
+		},
+		{
+			name:          "service port removed",
+			prevServices:  []*model.Service{httpService, tcpService},
+			services:      []*model.Service{httpService, tcpServiceWithoutPort},
+			configUpdated: sets.New(key("tcp.com")),
+			usedDelta:     true,
+			expected:      []string{"0.0.0.0_8080", "10.0.0.3_8080"},
+			removed:       []string{"10.0.0.3_9090"},
+		},
+		{
+			name:          "service removed",
+			prevServices:  []*model.Service{httpService, tcpService},
+			services:      []*model.Service{httpService},
+			configUpdated: sets.New(key("tcp.com")),
+			usedDelta:     true,
+			expected:      []string{"0.0.0.0_8080"},
+			removed:       []string{"10.0.0.3_8080", "10.0.0.3_9090"},
+		},
+		{
+			name:          "config update that is not delta aware",
+			prevServices:  []*model.Service{httpService},
+			services:      []*model.Service{httpService},
+			configUpdated: sets.New(model.ConfigKey{Kind: kind.VirtualService, Name: "vs", Namespace: "default"}),
+			usedDelta:     false,
+		},
+		{
+			name:          "no previous scope",
+			services:      []*model.Service{httpService, newService},
+			configUpdated: sets.New(key("new.com")),
+			usedDelta:     false,
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			proxy := &model.Proxy{}
+			var watched []string
+			if tt.prevServices != nil {
+				prev := NewConfigGenTest(t, TestOptions{Services: tt.prevServices})
+				proxy = prev.SetupProxy(proxy)
+				for _, l := range prev.Listeners(proxy) {
+					watched = append(watched, l.Name)
+				}
+			}
+			cg := NewConfigGenTest(t, TestOptions{Services: tt.services})
+			proxy = cg.SetupProxy(proxy)
+			if tt.prevServices == nil {
+				proxy.PrevSidecarScope = nil
+			}
+
+			listeners, removed, usedDelta := cg.DeltaListeners(proxy, tt.configUpdated, &model.WatchedResource{ResourceNames: watched})
+			assert.Equal(t, usedDelta, tt.usedDelta)
+			full := slices.GroupUnique(cg.Listeners(proxy), (*listener.Listener).GetName)
+			if !usedDelta {
+				assert.Equal(t, len(listeners), len(full))
+				return
+			}
+			names := make([]string, 0, len(listeners))
+			for _, l := range listeners {
+				names = append(names, l.Name)
+				// Listeners built with delta are identical to the ones built for all services.
+				assert.Equal(t, l, full[l.Name])
+			}
+			assert.Equal(t, sets.SortedList(sets.New(names...)), tt.expected)
+			assert.Equal(t, removed, tt.removed)
+		})
+	}
+}
@@ -22,9 +22,7 @@ import (
 
 	core "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
 	listener "github.com/envoyproxy/go-control-plane/envoy/config/listener/v3"
-	rbacpb "github.com/envoyproxy/go-control-plane/envoy/config/rbac/v3"
 	route "github.com/envoyproxy/go-control-plane/envoy/config/route/v3"
-	rbachttp "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/http/rbac/v3"
 	hcm "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/network/http_connection_manager/v3"
 	tls "github.com/envoyproxy/go-control-plane/envoy/extensions/transport_sockets/tls/v3"
 	discovery "github.com/envoyproxy/go-control-plane/envoy/service/discovery/v3"
@@ -34,11 +32,13 @@ import (
 	"istio.io/istio/pilot/pkg/model"
 	"istio.io/istio/pilot/pkg/networking/util"
 	"istio.io/istio/pilot/pkg/security/authn"
-	authzmodel "istio.io/istio/pilot/pkg/security/authz/model"
+	"istio.io/istio/pilot/pkg/security/authz/builder"
+	"istio.io/istio/pilot/pkg/security/trustdomain"
 	"istio.io/istio/pilot/pkg/util/protoconv"
 	xdsfilters "istio.io/istio/pilot/pkg/xds/filters"
 	"istio.io/istio/pkg/istio-agent/grpcxds"
 	"istio.io/istio/pkg/util/sets"
+	"istio.io/istio/pkg/wellknown"
 )
 
 var supportedFilters = []*hcm.HttpFilter{
@@ -50,8 +50,8 @@ var supportedFilters = []*hcm.HttpFilter{
 }
 
 const (
-	RBACHTTPFilterName     = "envoy.filters.http.rbac"
-	RBACHTTPFilterNameDeny = "envoy.filters.http.rbac.DENY"
+	RBACHTTPFilterName     = wellknown.HTTPRoleBasedAccessControl
+	RBACHTTPFilterNameDeny = builder.GRPCRBACFilterNameDeny
 )
 
 // BuildListeners handles a LDS request, returning listeners of ApiListener type.
@@ -176,32 +176,7 @@ func buildInboundFilterChain(node *model.Proxy, push *model.PushContext, nameSuf
 	fc := []*hcm.HttpFilter{}
 	// See security/authz/builder and grpc internal/xds/rbac
 	// grpc supports ALLOW and DENY actions (fail if it is not one of them), so we can't use the normal generator
-	selectionOpts := model.PolicyMatcherForProxy(node)
-	policies := push.AuthzPolicies.ListAuthorizationPolicies(selectionOpts)
-	if len(policies.Deny)+len(policies.Allow) > 0 {
-		rules := buildRBAC(node, push, nameSuffix, tlsContext, rbacpb.RBAC_DENY, policies.Deny)
-		if rules != nil && len(rules.Policies) > 0 {
-			rbac := &rbachttp.RBAC{
-				Rules: rules,
-			}
-			fc = append(fc,
-				&hcm.HttpFilter{
-					Name:       RBACHTTPFilterNameDeny,
-					ConfigType: &hcm.HttpFilter_TypedConfig{TypedConfig: protoconv.MessageToAny(rbac)},
-				})
-		}
-		arules := buildRBAC(node, push, nameSuffix, tlsContext, rbacpb.RBAC_ALLOW, policies.Allow)
-		if arules != nil && len(arules.Policies) > 0 {
-			rbac := &rbachttp.RBAC{
-				Rules: arules,
-			}
-			fc = append(fc,
-				&hcm.HttpFilter{
-					Name:       RBACHTTPFilterName,
-					ConfigType: &hcm.HttpFilter_TypedConfig{TypedConfig: protoconv.MessageToAny(rbac)},
-				})
-		}
-	}
+	fc = append(fc, buildRBACFilters(node, push)...)
 
 	// Must be last
 	fc = append(fc, xdsfilters.BuildRouterFilter(xdsfilters.RouterFilterContext{
@@ -245,7 +220,7 @@ func buildInboundFilterChain(node *model.Proxy, push *model.PushContext, nameSuf
 	return out
 }
 
-// buildRBAC builds the RBAC config expected by gRPC.
+// buildRBACFilters builds the RBAC filters expected by gRPC, using the same builder as Envoy.
 //
 // See: xds/internal/httpfilter/rbac
 //
@@ -254,29 +229,15 @@ func buildInboundFilterChain(node *model.Proxy, push *model.PushContext, nameSuf
 //
 // For gateways it would make a lot of sense to use this concept, same for moving path prefix at top level ( more scalable, easier for users)
 // This should probably be done for the v2 API.
-//
-// nolint: unparam
-func buildRBAC(node *model.Proxy, push *model.PushContext, suffix string, context *tls.DownstreamTlsContext,
-	a rbacpb.RBAC_Action, policies []model.AuthorizationPolicy,
-) *rbacpb.RBAC {
-	rules := &rbacpb.RBAC{
-		Action:   a,
-		Policies: map[string]*rbacpb.Policy{},
-	}
-	for _, policy := range policies {
-		for i, rule := range policy.Spec.Rules {
-			name := fmt.Sprintf("%s-%s-%d", policy.Namespace, policy.Name, i)
-			m, err := authzmodel.New(rule, true)
-			if err != nil {
-				log.Warnf("Invalid rule %v: %v", rule, err)
-				continue
-			}
-			generated, _ := m.Generate(false, true, a)
-			rules.Policies[name] = generated
-		}
+func buildRBACFilters(node *model.Proxy, push *model.PushContext) []*hcm.HttpFilter {
+	selectionOpts := model.PolicyMatcherForProxy(node)
+	policies := push.AuthzPolicies.ListAuthorizationPolicies(selectionOpts)
+	tdBundle := trustdomain.NewBundle(push.Mesh.TrustDomain, push.Mesh.TrustDomainAliases)
+	b := builder.New(tdBundle, push, policies, builder.Option{UseExtendedJwt: true})
+	if b == nil {
+		return nil
 	}
-
-	return rules
+	return b.BuildGRPC()
 }
 
 // nolint: unparam
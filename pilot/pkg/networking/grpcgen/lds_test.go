@@ -16,13 +16,21 @@ package grpcgen
 
 import (
 	"fmt"
+	"os"
 	"sort"
+	"strings"
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
 
+	"istio.io/istio/pilot/pkg/config/kube/crd"
+	"istio.io/istio/pilot/pkg/config/memory"
 	"istio.io/istio/pilot/pkg/model"
+	"istio.io/istio/pilot/test/util"
+	"istio.io/istio/pkg/config/mesh"
+	"istio.io/istio/pkg/config/schema/collections"
 	"istio.io/istio/pkg/istio-agent/grpcxds"
+	"istio.io/istio/pkg/util/protomarshal"
 	"istio.io/istio/pkg/util/sets"
 )
 
@@ -158,3 +166,62 @@ func TestTryFindFQDN(t *testing.T) {
 		})
 	}
 }
+
+func TestBuildRBACFilters(t *testing.T) {
+	cases := []struct {
+		name    string
+		aliases []string
+	}{
+		{name: "allow-deny"},
+		{name: "allow-deny-trust-domain-alias", aliases: []string{"td-alias"}},
+		{name: "dry-run-audit"},
+		{name: "custom"},
+		{name: "empty-rule"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			input := strings.TrimSuffix(tc.name, "-trust-domain-alias")
+			m := mesh.DefaultMeshConfig()
+			m.TrustDomainAliases = tc.aliases
+			push := &model.PushContext{
+				AuthzPolicies: yamlAuthzPolicies(t, "testdata/rbac/"+input+"-in.yaml"),
+				Mesh:          m,
+			}
+			proxy := &model.Proxy{
+				ID:              "echo.foo",
+				ConfigNamespace: "foo",
+				Labels:          map[string]string{"app": "echo"},
+				Metadata:        &model.NodeMetadata{Namespace: "foo"},
+			}
+
+			var out []string
+			for _, f := range buildRBACFilters(proxy, push) {
+				y, err := protomarshal.ToYAML(f)
+				if err != nil {
+					t.Fatal(err)
+				}
+				out = append(out, y)
+			}
+			util.CompareContent(t, []byte(strings.Join(out, "---\n")), "testdata/rbac/"+tc.name+"-out.yaml")
+		})
+	}
+}
+
+func yamlAuthzPolicies(t *testing.T, filename string) *model.AuthorizationPolicies {
+	t.Helper()
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	configs, _, err := crd.ParseInputs(string(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := memory.Make(collections.Pilot)
+	for _, c := range configs {
+		if _, err := store.Create(c); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return model.GetAuthorizationPolicies(&model.Environment{ConfigStore: store})
+}
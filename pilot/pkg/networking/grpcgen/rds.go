@@ -15,12 +15,28 @@
 package grpcgen
 
 import (
+	"strings"
+
 	route "github.com/envoyproxy/go-control-plane/envoy/config/route/v3"
 	discovery "github.com/envoyproxy/go-control-plane/envoy/service/discovery/v3"
 
 	"istio.io/istio/pilot/pkg/model"
 	"istio.io/istio/pilot/pkg/networking/core"
+	"istio.io/istio/pilot/pkg/networking/util"
 	"istio.io/istio/pilot/pkg/util/protoconv"
+	"istio.io/istio/pkg/util/sets"
+	"istio.io/istio/pkg/wellknown"
+)
+
+var (
+	// supportedPerFilterConfigs are the per-route filter overrides gRPC understands. gRPC will NACK a route
+	// with an override for a filter it does not support, so anything else must be removed.
+	supportedPerFilterConfigs = sets.New(wellknown.Fault, util.StatefulSessionFilter)
+
+	// SupportedRetryOn are the retry conditions supported by gRPC. These are gRPC status codes; other Envoy
+	// conditions, such as connect-failure, are ignored by gRPC.
+	// See https://github.com/grpc/proposal/blob/master/A44-xds-retry.md
+	SupportedRetryOn = sets.New("cancelled", "deadline-exceeded", "internal", "resource-exhausted", "unavailable")
 )
 
 // BuildHTTPRoutes supports per-VIP routes, as used by GRPC.
@@ -48,6 +64,11 @@ func buildHTTPRoute(node *model.Proxy, push *model.PushContext, routeName string
 	}
 
 	virtualHosts, _, _ := core.BuildSidecarOutboundVirtualHosts(node, push, routeName, port, nil, &model.DisabledCache{})
+	for _, vh := range virtualHosts {
+		for _, r := range vh.Routes {
+			filterRouteForGRPC(r)
+		}
+	}
 
 	// Only generate the required route for grpc. Will need to generate more
 	// as GRPC adds more features.
@@ -56,3 +77,39 @@ func buildHTTPRoute(node *model.Proxy, push *model.PushContext, routeName string
 		VirtualHosts: virtualHosts,
 	}
 }
+
+// filterRouteForGRPC removes the parts of a route that gRPC does not support.
+// Fault injection is supported through the fault filter override, and timeouts through max_stream_duration,
+// which is already set for proxyless gRPC when building the route.
+func filterRouteForGRPC(r *route.Route) {
+	for name := range r.TypedPerFilterConfig {
+		if !supportedPerFilterConfigs.Contains(name) {
+			delete(r.TypedPerFilterConfig, name)
+		}
+	}
+	if action := r.GetRoute(); action != nil {
+		action.RetryPolicy = buildGRPCRetryPolicy(action.RetryPolicy)
+	}
+}
+
+// buildGRPCRetryPolicy converts an Envoy retry policy to the subset supported by gRPC.
+// Returns nil if none of the retry conditions are supported.
+func buildGRPCRetryPolicy(in *route.RetryPolicy) *route.RetryPolicy {
+	if in == nil {
+		return nil
+	}
+	var retryOn []string
+	for _, cond := range strings.Split(in.RetryOn, ",") {
+		if cond = strings.TrimSpace(cond); SupportedRetryOn.Contains(cond) {
+			retryOn = append(retryOn, cond)
+		}
+	}
+	if len(retryOn) == 0 {
+		return nil
+	}
+	return &route.RetryPolicy{
+		RetryOn:      strings.Join(retryOn, ","),
+		NumRetries:   in.NumRetries,
+		RetryBackOff: in.RetryBackOff,
+	}
+}
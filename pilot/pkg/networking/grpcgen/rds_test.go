@@ -0,0 +1,139 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package grpcgen_test
+
+import (
+	"testing"
+	"time"
+
+	route "github.com/envoyproxy/go-control-plane/envoy/config/route/v3"
+	fault "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/http/fault/v3"
+
+	"istio.io/istio/pilot/pkg/model"
+	"istio.io/istio/pilot/pkg/networking/grpcgen"
+	"istio.io/istio/pilot/test/xds"
+	"istio.io/istio/pkg/maps"
+	"istio.io/istio/pkg/test/util/assert"
+	"istio.io/istio/pkg/wellknown"
+)
+
+func buildGRPCRoute(t *testing.T, vs string) *route.Route {
+	t.Helper()
+	ds := xds.NewFakeDiscoveryServer(t, xds.FakeOptions{
+		KubernetesObjectString: `
+apiVersion: v1
+kind: Service
+metadata:
+  name: echo-app
+  namespace: default
+spec:
+  clusterIP: 1.2.3.4
+  selector:
+    app: echo
+  ports:
+  - name: grpc
+    port: 7070
+`,
+		ConfigString: vs,
+	})
+	proxy := ds.SetupProxy(&model.Proxy{Metadata: &model.NodeMetadata{Generator: "grpc"}})
+	resources := (&grpcgen.GrpcConfigGenerator{}).BuildHTTPRoutes(proxy, ds.PushContext(),
+		[]string{"outbound|7070||echo-app.default.svc.cluster.local"})
+	assert.Equal(t, len(resources), 1)
+	rc := &route.RouteConfiguration{}
+	if err := resources[0].Resource.UnmarshalTo(rc); err != nil {
+		t.Fatal(err)
+	}
+	for _, vh := range rc.VirtualHosts {
+		for _, r := range vh.Routes {
+			return r
+		}
+	}
+	t.Fatal("no routes generated")
+	return nil
+}
+
+func TestRouteResilience(t *testing.T) {
+	t.Run("default", func(t *testing.T) {
+		r := buildGRPCRoute(t, "")
+		assert.Equal(t, r.GetRoute().GetRetryPolicy().GetRetryOn(), "unavailable,cancelled")
+		assert.Equal(t, r.GetRoute().GetRetryPolicy().GetNumRetries().GetValue(), uint32(2))
+		assert.Equal(t, r.GetRoute().GetRetryPolicy().GetRetryHostPredicate(), nil)
+		assert.Equal(t, r.GetRoute().GetMaxStreamDuration().GetMaxStreamDuration().AsDuration(), 0)
+	})
+	t.Run("virtual service", func(t *testing.T) {
+		r := buildGRPCRoute(t, `
+apiVersion: networking.istio.io/v1
+kind: VirtualService
+metadata:
+  name: echo
+  namespace: default
+spec:
+  hosts:
+  - echo-app.default.svc.cluster.local
+  http:
+  - fault:
+      abort:
+        grpcStatus: UNAVAILABLE
+        percentage:
+          value: 50
+    retries:
+      attempts: 3
+      perTryTimeout: 1s
+      retryOn: connect-failure,resource-exhausted,503,internal
+    timeout: 5s
+    corsPolicy:
+      allowOrigins:
+      - exact: example.com
+    route:
+    - destination:
+        host: echo-app.default.svc.cluster.local
+`)
+		rp := r.GetRoute().GetRetryPolicy()
+		assert.Equal(t, rp.GetRetryOn(), "resource-exhausted,internal")
+		assert.Equal(t, rp.GetNumRetries().GetValue(), uint32(3))
+		assert.Equal(t, rp.GetPerTryTimeout(), nil)
+		assert.Equal(t, rp.GetRetriableStatusCodes(), nil)
+		assert.Equal(t, r.GetRoute().GetMaxStreamDuration().GetMaxStreamDuration().AsDuration(), 5*time.Second)
+
+		assert.Equal(t, maps.Keys(r.TypedPerFilterConfig), []string{wellknown.Fault})
+		f := &fault.HTTPFault{}
+		if err := r.TypedPerFilterConfig[wellknown.Fault].UnmarshalTo(f); err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, f.GetAbort().GetGrpcStatus(), uint32(14))
+		assert.Equal(t, f.GetAbort().GetPercentage().GetNumerator(), uint32(500000))
+	})
+	t.Run("no supported retry conditions", func(t *testing.T) {
+		r := buildGRPCRoute(t, `
+apiVersion: networking.istio.io/v1
+kind: VirtualService
+metadata:
+  name: echo
+  namespace: default
+spec:
+  hosts:
+  - echo-app.default.svc.cluster.local
+  http:
+  - retries:
+      attempts: 3
+      retryOn: 5xx,gateway-error
+    route:
+    - destination:
+        host: echo-app.default.svc.cluster.local
+`)
+		assert.Equal(t, r.GetRoute().GetRetryPolicy(), nil)
+	})
+}
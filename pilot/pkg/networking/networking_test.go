@@ -98,6 +98,11 @@ func TestString(t *testing.T) {
 			TransportProtocolQUIC,
 			"quic",
 		},
+		{
+			"test String method for udp transport protocol",
+			TransportProtocolUDP,
+			"udp",
+		},
 		{
 			"test String method for invalid transport protocol",
 			3,
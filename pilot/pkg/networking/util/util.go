@@ -87,6 +87,10 @@ const (
 	// TODO: Move to well known.
 	StatefulSessionFilter = "envoy.filters.http.stateful_session"
 
+	// Envoy UDP Proxy Listener Filter
+	// TODO: Move to well known.
+	UDPProxyListenerFilter = "envoy.filters.udp_listener.udp_proxy"
+
 	// AlpnOverrideMetadataKey is the key under which metadata is added
 	// to indicate whether Istio rewrite the ALPN headers
 	AlpnOverrideMetadataKey = "alpn_override"
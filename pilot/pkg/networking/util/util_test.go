@@ -26,6 +26,7 @@ import (
 	hcm "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/network/http_connection_manager/v3"
 	cookiev3 "github.com/envoyproxy/go-control-plane/envoy/extensions/http/stateful_session/cookie/v3"
 	httpv3 "github.com/envoyproxy/go-control-plane/envoy/type/http/v3"
+	typev3 "github.com/envoyproxy/go-control-plane/envoy/type/v3"
 	"github.com/google/go-cmp/cmp"
 	"google.golang.org/protobuf/testing/protocmp"
 	structpb "google.golang.org/protobuf/types/known/structpb"
@@ -1811,3 +1812,54 @@ func TestMeshNetworksToEnvoyInternalAddressConfig(t *testing.T) {
 		})
 	}
 }
+
+func TestEvaluateListenerFilterPredicates(t *testing.T) {
+	portRange := func(start, end int32) *listener.ListenerFilterChainMatchPredicate {
+		return &listener.ListenerFilterChainMatchPredicate{
+			Rule: &listener.ListenerFilterChainMatchPredicate_DestinationPortRange{
+				DestinationPortRange: &typev3.Int32Range{Start: start, End: end},
+			},
+		}
+	}
+	or := &listener.ListenerFilterChainMatchPredicate{
+		Rule: &listener.ListenerFilterChainMatchPredicate_OrMatch{
+			OrMatch: &listener.ListenerFilterChainMatchPredicate_MatchSet{
+				Rules: []*listener.ListenerFilterChainMatchPredicate{portRange(80, 81), portRange(443, 444)},
+			},
+		},
+	}
+	not := &listener.ListenerFilterChainMatchPredicate{
+		Rule: &listener.ListenerFilterChainMatchPredicate_NotMatch{NotMatch: or},
+	}
+	cases := []struct {
+		name      string
+		predicate *listener.ListenerFilterChainMatchPredicate
+		port      int
+		want      bool
+		wantErr   bool
+	}{
+		{name: "nil", predicate: nil, port: 80, want: true},
+		{name: "or match", predicate: or, port: 443, want: true},
+		{name: "or no match", predicate: or, port: 8080, want: false},
+		{name: "not", predicate: not, port: 8080, want: true},
+		{
+			name: "unsupported",
+			predicate: &listener.ListenerFilterChainMatchPredicate{
+				Rule: &listener.ListenerFilterChainMatchPredicate_AnyMatch{AnyMatch: true},
+			},
+			port:    80,
+			wantErr: true,
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := EvaluateListenerFilterPredicates(tt.predicate, tt.port)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, got, tt.want)
+		})
+	}
+}
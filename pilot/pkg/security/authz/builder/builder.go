@@ -31,9 +31,13 @@ import (
 	"istio.io/istio/pilot/pkg/security/trustdomain"
 	"istio.io/istio/pilot/pkg/util/protoconv"
 	"istio.io/istio/pkg/maps"
+	"istio.io/istio/pkg/slices"
 	"istio.io/istio/pkg/wellknown"
 )
 
+// GRPCRBACFilterNameDeny is the name of the RBAC filter for the DENY action on proxyless gRPC servers.
+const GRPCRBACFilterNameDeny = wellknown.HTTPRoleBasedAccessControl + ".DENY"
+
 var rbacPolicyMatchNever = &rbacpb.Policy{
 	Permissions: []*rbacpb.Permission{{Rule: &rbacpb.Permission_NotRule{
 		NotRule: &rbacpb.Permission{Rule: &rbacpb.Permission_Any{Any: true}},
@@ -66,6 +70,8 @@ type Builder struct {
 
 	// logger emits logs about policies
 	logger *AuthzLogger
+	// forGRPC is set when building for proxyless gRPC, which does not evaluate shadow rules.
+	forGRPC bool
 }
 
 // New returns a new builder for the given workload with the authorization policy.
@@ -83,10 +89,12 @@ func New(trustDomainBundle trustdomain.Bundle, push *model.PushContext, policies
 		}
 	}
 
-	if len(policies.Deny) == 0 && len(policies.Allow) == 0 && len(policies.Audit) == 0 {
+	if len(policies.Deny) == 0 && len(policies.Allow) == 0 && len(policies.Audit) == 0 && len(policies.Custom) == 0 {
 		return nil
 	}
 	return &Builder{
+		// CUSTOM policies are only used by BuildGRPC, as proxyless gRPC has no separate CUSTOM builder.
+		customPolicies:    policies.Custom,
 		denyPolicies:      policies.Deny,
 		allowPolicies:     policies.Allow,
 		auditPolicies:     policies.Audit,
@@ -152,6 +160,64 @@ func (b Builder) BuildTCP() []*listener.Filter {
 	return filters
 }
 
+// BuildGRPC returns the HTTP filters built from the authorization policy for proxyless gRPC servers.
+// gRPC only supports the ALLOW and DENY actions, and does not evaluate shadow rules, so AUDIT and dry-run policies are skipped.
+// gRPC also requires HTTP filter names to be unique, so the DENY filter is given a distinct name.
+func (b Builder) BuildGRPC() []*hcm.HttpFilter {
+	b.logger = &AuthzLogger{}
+	b.forGRPC = true
+	defer b.logger.Report()
+	if b.option.IsCustomBuilder {
+		b.logger.AppendError(fmt.Errorf("CUSTOM action is not supported for proxyless gRPC"))
+		return nil
+	}
+	if len(b.auditPolicies) > 0 {
+		b.logger.AppendDebugf("ignored %d AUDIT policies, not supported for proxyless gRPC", len(b.auditPolicies))
+	}
+
+	denyPolicies := b.denyPolicies
+	if len(b.customPolicies) > 0 {
+		// gRPC has no ext_authz support. Fail closed, the same as Envoy does for an unknown provider, by denying
+		// requests that match the CUSTOM rules.
+		for _, policy := range b.customPolicies {
+			b.logger.AppendError(fmt.Errorf("CUSTOM policy %s.%s is not supported for proxyless gRPC, requests matching it are denied",
+				policy.Namespace, policy.Name))
+		}
+		denyPolicies = append(slices.Clone(denyPolicies), b.customPolicies...)
+	}
+
+	var filters []*hcm.HttpFilter
+	if configs := b.build(b.enforcedPolicies(denyPolicies), rbacpb.RBAC_DENY, false); configs != nil {
+		b.logger.AppendDebugf("built %d gRPC filters for DENY action", len(configs.http))
+		filters = append(filters, renameFilters(configs.http, GRPCRBACFilterNameDeny)...)
+	}
+	if configs := b.build(b.enforcedPolicies(b.allowPolicies), rbacpb.RBAC_ALLOW, false); configs != nil {
+		b.logger.AppendDebugf("built %d gRPC filters for ALLOW action", len(configs.http))
+		filters = append(filters, renameFilters(configs.http, wellknown.HTTPRoleBasedAccessControl)...)
+	}
+	return filters
+}
+
+// enforcedPolicies returns the policies that are not in dry-run mode.
+func (b Builder) enforcedPolicies(policies []model.AuthorizationPolicy) []model.AuthorizationPolicy {
+	var out []model.AuthorizationPolicy
+	for _, policy := range policies {
+		if b.isDryRun(policy) {
+			b.logger.AppendDebugf("ignored dry-run policy %s.%s, not supported for proxyless gRPC", policy.Namespace, policy.Name)
+			continue
+		}
+		out = append(out, policy)
+	}
+	return out
+}
+
+func renameFilters(filters []*hcm.HttpFilter, name string) []*hcm.HttpFilter {
+	for _, f := range filters {
+		f.Name = name
+	}
+	return filters
+}
+
 type builtConfigs struct {
 	http []*hcm.HttpFilter
 	tcp  []*listener.Filter
@@ -261,9 +327,11 @@ func (b Builder) build(policies []model.AuthorizationPolicy, action rbacpb.RBAC_
 func (b Builder) buildHTTP(rules *rbacpb.RBAC, shadowRules *rbacpb.RBAC, providers []string) []*hcm.HttpFilter {
 	if !b.option.IsCustomBuilder {
 		rbac := &rbachttp.RBAC{
-			Rules:                 rules,
-			ShadowRules:           shadowRules,
-			ShadowRulesStatPrefix: shadowRuleStatPrefix(shadowRules),
+			Rules:       rules,
+			ShadowRules: shadowRules,
+		}
+		if !b.forGRPC {
+			rbac.ShadowRulesStatPrefix = shadowRuleStatPrefix(shadowRules)
 		}
 		return []*hcm.HttpFilter{
 			{
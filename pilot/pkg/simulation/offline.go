@@ -0,0 +1,114 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package simulation
+
+import (
+	"fmt"
+	"strings"
+
+	cluster "github.com/envoyproxy/go-control-plane/envoy/config/cluster/v3"
+	listener "github.com/envoyproxy/go-control-plane/envoy/config/listener/v3"
+	route "github.com/envoyproxy/go-control-plane/envoy/config/route/v3"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"istio.io/istio/pilot/pkg/config/kube/gateway"
+	"istio.io/istio/pilot/pkg/model"
+	"istio.io/istio/pilot/pkg/networking/core"
+	"istio.io/istio/pilot/pkg/serviceregistry"
+	kube "istio.io/istio/pilot/pkg/serviceregistry/kube/controller"
+	"istio.io/istio/pkg/config/constants"
+	"istio.io/istio/pkg/config/mesh"
+	"istio.io/istio/pkg/config/schema/gvr"
+	kubelib "istio.io/istio/pkg/kube"
+	"istio.io/istio/pkg/test"
+)
+
+// RunOffline generates configuration for proxy from Istio configuration and Kubernetes objects, both given
+// as YAML, in an in-memory control plane and calls f with a simulation of the generated configuration.
+// Failures are returned as an error rather than reported to a test.
+func RunOffline(istioConfig, kubeObjects string, proxy *model.Proxy, f func(sim *Simulation)) error {
+	objects, err := kubernetesObjectsFromString(kubeObjects)
+	if err != nil {
+		return err
+	}
+	return test.Wrap(func(t test.Failer) {
+		m := mesh.DefaultMeshConfig()
+		client := kubelib.NewFakeClient(objects...)
+		k8s, _ := kube.NewFakeControllerWithOptions(t, kube.FakeControllerOptions{
+			Client:        client,
+			ClusterID:     constants.DefaultClusterName,
+			DomainSuffix:  constants.DefaultClusterLocalDomain,
+			SkipRun:       true,
+			ConfigCluster: true,
+			MeshWatcher:   mesh.NewFixedWatcher(m),
+			CRDs:          []schema.GroupVersionResource{gvr.KubernetesGateway},
+		})
+		var gwc *gateway.Controller
+		cg := core.NewConfigGenTest(t, core.TestOptions{
+			ConfigString:      istioConfig,
+			MeshConfig:        m,
+			ServiceRegistries: []serviceregistry.Instance{k8s},
+			CreateConfigStore: func(c model.ConfigStoreController) model.ConfigStoreController {
+				gwc = gateway.NewController(client, c, func(schema.GroupVersionResource, <-chan struct{}) bool {
+					return true
+				}, nil, kube.Options{
+					DomainSuffix: constants.DefaultClusterLocalDomain,
+				})
+				return gwc
+			},
+			SkipRun:   true,
+			ClusterID: constants.DefaultClusterName,
+		})
+		// Start the informers added by the Gateway API controller.
+		client.RunAndWait(test.NewStop(t))
+		env := cg.Env()
+		env.GatewayAPIController = gwc
+		cg.Run()
+		if err := env.InitNetworksManager(model.NewEndpointIndexUpdater(env.EndpointIndex)); err != nil {
+			t.Fatal(err)
+		}
+		if err := env.PushContext().InitContext(env, nil, nil); err != nil {
+			t.Fatalf("failed to initialize push context: %v", err)
+		}
+		f(NewSimulationFromConfigGen(t, cg, cg.SetupProxy(proxy)))
+	})
+}
+
+// RunFromResources calls f with a simulation of already generated resources, such as those read from an
+// Envoy config dump. Failures are returned as an error rather than reported to a test.
+func RunFromResources(listeners []*listener.Listener, clusters []*cluster.Cluster, routes []*route.RouteConfiguration,
+	f func(sim *Simulation),
+) error {
+	return test.Wrap(func(t test.Failer) {
+		f(NewSimulationFromResources(t, listeners, clusters, routes))
+	})
+}
+
+func kubernetesObjectsFromString(s string) ([]runtime.Object, error) {
+	var objects []runtime.Object
+	decode := kubelib.IstioCodec.UniversalDeserializer().Decode
+	for _, s := range strings.Split(s, "---") {
+		if len(strings.TrimSpace(s)) == 0 {
+			continue
+		}
+		o, _, err := decode([]byte(s), nil, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed deserializing kubernetes object: %v (%v)", err, s)
+		}
+		objects = append(objects, o)
+	}
+	return objects, nil
+}
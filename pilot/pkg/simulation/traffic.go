@@ -31,15 +31,15 @@ import (
 	listener "github.com/envoyproxy/go-control-plane/envoy/config/listener/v3"
 	route "github.com/envoyproxy/go-control-plane/envoy/config/route/v3"
 	tls "github.com/envoyproxy/go-control-plane/envoy/extensions/transport_sockets/tls/v3"
+	matcher "github.com/envoyproxy/go-control-plane/envoy/type/matcher/v3"
 	"github.com/google/go-cmp/cmp"
 	"github.com/google/go-cmp/cmp/cmpopts"
 	"google.golang.org/protobuf/types/known/wrapperspb"
 
 	"istio.io/istio/pilot/pkg/model"
 	"istio.io/istio/pilot/pkg/networking/core"
+	"istio.io/istio/pilot/pkg/networking/util"
 	xdsfilters "istio.io/istio/pilot/pkg/xds/filters"
-	"istio.io/istio/pilot/test/xds"
-	"istio.io/istio/pilot/test/xdstest"
 	"istio.io/istio/pkg/config/host"
 	istiolog "istio.io/istio/pkg/log"
 	"istio.io/istio/pkg/maps"
@@ -177,6 +177,8 @@ type Result struct {
 	// just ensures we notice a test is wrong
 	Skip string
 	t    test.Failer
+	// filterChain is the filter chain that was matched, if any
+	filterChain *listener.FilterChain
 }
 
 func (r Result) Matches(t *testing.T, want Result) {
@@ -184,61 +186,79 @@ func (r Result) Matches(t *testing.T, want Result) {
 	r.StrictMatch = want.StrictMatch // to make diff pass
 	r.Skip = want.Skip               // to make diff pass
 	diff := cmp.Diff(want, r, cmpopts.IgnoreUnexported(Result{}), cmpopts.EquateErrors())
-	if want.StrictMatch && diff != "" {
-		t.Errorf("Diff: %v", diff)
+	errs, want := r.mismatches(want)
+	for _, err := range errs {
+		t.Error(err)
+	}
+	if want.StrictMatch && len(errs) > 0 {
 		return
 	}
+	if t.Failed() {
+		t.Logf("Diff: %+v", diff)
+		t.Logf("Full Diff: %+v", cmp.Diff(want, r, cmpopts.IgnoreUnexported(Result{}), cmpopts.EquateErrors()))
+	} else if want.Skip != "" {
+		t.Skipf("Known bug: %v", r.Skip)
+	}
+}
+
+// mismatches returns a description of each way the result does not match want. Fields of want that were not
+// checked are populated from the result in the returned Result.
+func (r Result) mismatches(want Result) ([]string, Result) {
+	r.StrictMatch = want.StrictMatch // to make diff pass
+	r.Skip = want.Skip               // to make diff pass
+	if want.StrictMatch {
+		if diff := cmp.Diff(want, r, cmpopts.IgnoreUnexported(Result{}), cmpopts.EquateErrors()); diff != "" {
+			return []string{fmt.Sprintf("Diff: %v", diff)}, want
+		}
+		return nil, want
+	}
+	var errs []string
 	if want.Error != r.Error {
-		t.Errorf("want error %v got %v", want.Error, r.Error)
+		errs = append(errs, fmt.Sprintf("want error %v got %v", want.Error, r.Error))
 	}
 	if want.ListenerMatched != "" && want.ListenerMatched != r.ListenerMatched {
-		t.Errorf("want listener matched %q got %q", want.ListenerMatched, r.ListenerMatched)
+		errs = append(errs, fmt.Sprintf("want listener matched %q got %q", want.ListenerMatched, r.ListenerMatched))
 	} else {
 		// Populate each field in case we did not care about it. This avoids confusing errors when we have fields
 		// we don't care about in the test that are present in the result.
 		want.ListenerMatched = r.ListenerMatched
 	}
 	if want.FilterChainMatched != "" && want.FilterChainMatched != r.FilterChainMatched {
-		t.Errorf("want filter chain matched %q got %q", want.FilterChainMatched, r.FilterChainMatched)
+		errs = append(errs, fmt.Sprintf("want filter chain matched %q got %q", want.FilterChainMatched, r.FilterChainMatched))
 	} else {
 		want.FilterChainMatched = r.FilterChainMatched
 	}
 	if want.RouteMatched != "" && want.RouteMatched != r.RouteMatched {
-		t.Errorf("want route matched %q got %q", want.RouteMatched, r.RouteMatched)
+		errs = append(errs, fmt.Sprintf("want route matched %q got %q", want.RouteMatched, r.RouteMatched))
 	} else {
 		want.RouteMatched = r.RouteMatched
 	}
 	if want.RouteConfigMatched != "" && want.RouteConfigMatched != r.RouteConfigMatched {
-		t.Errorf("want route config matched %q got %q", want.RouteConfigMatched, r.RouteConfigMatched)
+		errs = append(errs, fmt.Sprintf("want route config matched %q got %q", want.RouteConfigMatched, r.RouteConfigMatched))
 	} else {
 		want.RouteConfigMatched = r.RouteConfigMatched
 	}
 	if want.VirtualHostMatched != "" && want.VirtualHostMatched != r.VirtualHostMatched {
-		t.Errorf("want virtual host matched %q got %q", want.VirtualHostMatched, r.VirtualHostMatched)
+		errs = append(errs, fmt.Sprintf("want virtual host matched %q got %q", want.VirtualHostMatched, r.VirtualHostMatched))
 	} else {
 		want.VirtualHostMatched = r.VirtualHostMatched
 	}
 	if want.ClusterMatched != "" && want.ClusterMatched != r.ClusterMatched {
-		t.Errorf("want cluster matched %q got %q", want.ClusterMatched, r.ClusterMatched)
+		errs = append(errs, fmt.Sprintf("want cluster matched %q got %q", want.ClusterMatched, r.ClusterMatched))
 	} else {
 		want.ClusterMatched = r.ClusterMatched
 	}
-	if t.Failed() {
-		t.Logf("Diff: %+v", diff)
-		t.Logf("Full Diff: %+v", cmp.Diff(want, r, cmpopts.IgnoreUnexported(Result{}), cmpopts.EquateErrors()))
-	} else if want.Skip != "" {
-		t.Skipf("Known bug: %v", r.Skip)
-	}
+	return errs, want
 }
 
 type Simulation struct {
-	t         *testing.T
+	t         test.Failer
 	Listeners []*listener.Listener
 	Clusters  []*cluster.Cluster
 	Routes    []*route.RouteConfiguration
 }
 
-func NewSimulationFromConfigGen(t *testing.T, s *core.ConfigGenTest, proxy *model.Proxy) *Simulation {
+func NewSimulationFromConfigGen(t test.Failer, s *core.ConfigGenTest, proxy *model.Proxy) *Simulation {
 	l := s.Listeners(proxy)
 	sim := &Simulation{
 		t:         t,
@@ -249,8 +269,17 @@ func NewSimulationFromConfigGen(t *testing.T, s *core.ConfigGenTest, proxy *mode
 	return sim
 }
 
-func NewSimulation(t *testing.T, s *xds.FakeDiscoveryServer, proxy *model.Proxy) *Simulation {
-	return NewSimulationFromConfigGen(t, s.ConfigGenTest, proxy)
+// NewSimulationFromResources builds a simulation from already generated resources, such as those read
+// from an Envoy config dump.
+func NewSimulationFromResources(t test.Failer, listeners []*listener.Listener, clusters []*cluster.Cluster,
+	routes []*route.RouteConfiguration,
+) *Simulation {
+	return &Simulation{
+		t:         t,
+		Listeners: listeners,
+		Clusters:  clusters,
+		Routes:    routes,
+	}
 }
 
 // withT swaps out the testing struct. This allows executing sub tests.
@@ -260,23 +289,33 @@ func (sim *Simulation) withT(t *testing.T) *Simulation {
 	return &cpy
 }
 
+// RunExpectations runs each expectation. With a *testing.T, each is run as a sub test; otherwise the
+// first expectation that does not match fails the simulation.
 func (sim *Simulation) RunExpectations(es []Expect) {
+	sim.t.Helper()
 	for _, e := range es {
-		sim.t.Run(e.Name, func(t *testing.T) {
-			sim.withT(t).Run(e.Call).Matches(t, e.Result)
-		})
+		if tt, ok := sim.t.(*testing.T); ok {
+			tt.Run(e.Name, func(t *testing.T) {
+				sim.withT(t).Run(e.Call).Matches(t, e.Result)
+			})
+			continue
+		}
+		if errs, _ := sim.Run(e.Call).mismatches(e.Result); len(errs) > 0 {
+			sim.t.Fatalf("%s: %s", e.Name, strings.Join(errs, "; "))
+		}
 	}
 }
 
-func hasFilterOnPort(l *listener.Listener, filter string, port int) bool {
-	got, f := xdstest.ExtractListenerFilters(l)[filter]
+func hasFilterOnPort(l *listener.Listener, filter string, port int) (bool, error) {
+	got, f := util.ExtractListenerFilters(l)[filter]
 	if !f {
-		return false
+		return false, nil
 	}
 	if got.FilterDisabled == nil {
-		return true
+		return true, nil
 	}
-	return !xdstest.EvaluateListenerFilterPredicates(got.FilterDisabled, port)
+	disabled, err := util.EvaluateListenerFilterPredicates(got.FilterDisabled, port)
+	return !disabled, err
 }
 
 func (sim *Simulation) Run(input Call) (result Result) {
@@ -295,7 +334,11 @@ func (sim *Simulation) Run(input Call) (result Result) {
 	}
 	result.ListenerMatched = l.Name
 
-	hasTLSInspector := hasFilterOnPort(l, xdsfilters.TLSInspector.Name, input.Port)
+	hasTLSInspector, err := hasFilterOnPort(l, xdsfilters.TLSInspector.Name, input.Port)
+	if err != nil {
+		result.Error = err
+		return
+	}
 	if !hasTLSInspector {
 		// Without tls inspector, Envoy would not read the ALPN in the TLS handshake
 		// HTTP inspector still may set it though
@@ -303,7 +346,12 @@ func (sim *Simulation) Run(input Call) (result Result) {
 	}
 
 	// Apply listener filters
-	if hasFilterOnPort(l, xdsfilters.HTTPInspector.Name, input.Port) {
+	hasHTTPInspector, err := hasFilterOnPort(l, xdsfilters.HTTPInspector.Name, input.Port)
+	if err != nil {
+		result.Error = err
+		return
+	}
+	if hasHTTPInspector {
 		if alpn := protocolToAlpn(input.Protocol); alpn != "" && input.TLS == Plaintext {
 			input.Alpn = alpn
 		}
@@ -315,6 +363,7 @@ func (sim *Simulation) Run(input Call) (result Result) {
 		return
 	}
 	result.FilterChainMatched = fc.Name
+	result.filterChain = fc
 	// Plaintext to TLS is an error
 	if fc.TransportSocket != nil && input.TLS == Plaintext {
 		result.Error = ErrTLSError
@@ -341,7 +390,17 @@ func (sim *Simulation) Run(input Call) (result Result) {
 		}
 	}
 
-	if hcm := xdstest.ExtractHTTPConnectionManager(sim.t, fc); hcm != nil {
+	hcm, err := util.ExtractHTTPConnectionManager(fc)
+	if err != nil {
+		result.Error = err
+		return
+	}
+	tcp, err := util.ExtractTCPProxy(fc)
+	if err != nil {
+		result.Error = err
+		return
+	}
+	if hcm != nil {
 		// We matched HCM and didn't terminate TLS, but we are sending TLS traffic - decoding will fail
 		if input.TLS != Plaintext && fc.TransportSocket == nil {
 			result.Error = ErrProtocolError
@@ -359,7 +418,7 @@ func (sim *Simulation) Run(input Call) (result Result) {
 			// If not set, fallback to RDS
 			routeName := hcm.GetRds().RouteConfigName
 			result.RouteConfigMatched = routeName
-			rc = xdstest.ExtractRouteConfigurations(sim.Routes)[routeName]
+			rc = util.ExtractRouteConfigurations(sim.Routes)[routeName]
 		}
 		hostHeader := ""
 		if len(input.Headers["Host"]) > 0 {
@@ -386,14 +445,19 @@ func (sim *Simulation) Run(input Call) (result Result) {
 		case *route.Route_Route:
 			result.ClusterMatched = t.Route.GetCluster()
 		}
-	} else if tcp := xdstest.ExtractTCPProxy(sim.t, fc); tcp != nil {
+	} else if tcp != nil {
 		result.ClusterMatched = tcp.GetCluster()
 	}
 	return
 }
 
+// MTLSRequired reports whether the filter chain matched by a result only accepts Istio mutual TLS traffic.
+func (sim *Simulation) MTLSRequired(r Result) bool {
+	return sim.requiresMTLS(r.filterChain, "default")
+}
+
 func (sim *Simulation) requiresMTLS(fc *listener.FilterChain, mTLSSecretConfigName string) bool {
-	if fc.TransportSocket == nil {
+	if fc.GetTransportSocket() == nil {
 		return false
 	}
 	t := &tls.DownstreamTlsContext{}
@@ -442,13 +506,77 @@ func (sim *Simulation) matchRoute(vh *route.VirtualHost, input Call) *route.Rout
 			sim.t.Fatalf("unknown route path type %T", pt)
 		}
 
-		// TODO this only handles path - we need to add headers, query params, etc to be complete.
+		if !sim.matchHeaders(r.Match.GetHeaders(), input.Headers) {
+			continue
+		}
+
+		// TODO this only handles path and headers - we need to add query params, etc to be complete.
 
 		return r
 	}
 	return nil
 }
 
+func (sim *Simulation) matchHeaders(matchers []*route.HeaderMatcher, headers http.Header) bool {
+	for _, hm := range matchers {
+		var values []string
+		if hm.Name == ":authority" {
+			values = headers.Values("Host")
+		} else {
+			values = headers.Values(hm.Name)
+		}
+		matched := false
+		switch m := hm.GetHeaderMatchSpecifier().(type) {
+		case *route.HeaderMatcher_PresentMatch:
+			matched = (len(values) > 0) == m.PresentMatch
+		case *route.HeaderMatcher_StringMatch:
+			for _, v := range values {
+				if sim.matchString(m.StringMatch, v) {
+					matched = true
+					break
+				}
+			}
+		default:
+			sim.t.Fatalf("unknown header match type %T", m)
+		}
+		if matched == hm.InvertMatch {
+			return false
+		}
+	}
+	return true
+}
+
+func (sim *Simulation) matchString(m *matcher.StringMatcher, v string) bool {
+	if m.GetIgnoreCase() {
+		v = strings.ToLower(v)
+	}
+	lower := func(s string) string {
+		if m.GetIgnoreCase() {
+			return strings.ToLower(s)
+		}
+		return s
+	}
+	switch mt := m.GetMatchPattern().(type) {
+	case *matcher.StringMatcher_Exact:
+		return v == lower(mt.Exact)
+	case *matcher.StringMatcher_Prefix:
+		return strings.HasPrefix(v, lower(mt.Prefix))
+	case *matcher.StringMatcher_Suffix:
+		return strings.HasSuffix(v, lower(mt.Suffix))
+	case *matcher.StringMatcher_Contains:
+		return strings.Contains(v, lower(mt.Contains))
+	case *matcher.StringMatcher_SafeRegex:
+		r, err := regexp.Compile("^(?:" + mt.SafeRegex.GetRegex() + ")$")
+		if err != nil {
+			sim.t.Fatalf("invalid regex %v: %v", mt.SafeRegex.GetRegex(), err)
+		}
+		return r.MatchString(v)
+	default:
+		sim.t.Fatalf("unknown string match type %T", mt)
+	}
+	return false
+}
+
 func (sim *Simulation) matchVirtualHost(rc *route.RouteConfiguration, host string) *route.VirtualHost {
 	if rc.GetIgnorePortInHostMatching() {
 		if h, _, err := net.SplitHostPort(host); err == nil {
@@ -674,7 +802,7 @@ func protocolToAlpn(s Protocol) string {
 
 func matchListener(listeners []*listener.Listener, input Call) *listener.Listener {
 	if input.CallMode == CallModeInbound {
-		return xdstest.ExtractListener(model.VirtualInboundListenerName, listeners)
+		return util.ExtractListener(model.VirtualInboundListenerName, listeners)
 	}
 	// First find exact match for the IP/Port, then fallback to wildcard IP/Port
 	// There is no wildcard port
@@ -84,6 +84,9 @@ type Connection struct {
 
 	s   *DiscoveryServer
 	ids []string
+
+	// pushHistory records the most recent pushes to this connection.
+	pushHistory *pushHistory
 }
 
 func (conn *Connection) XdsConnection() *xds.Connection {
@@ -105,7 +108,8 @@ type Event struct {
 
 func newConnection(peerAddr string, stream DiscoveryStream) *Connection {
 	return &Connection{
-		Connection: xds.NewConnection(peerAddr, stream),
+		Connection:  xds.NewConnection(peerAddr, stream),
+		pushHistory: newPushHistory(features.PushHistorySize),
 	}
 }
 
@@ -419,7 +423,8 @@ func (s *DiscoveryServer) computeProxyState(proxy *model.Proxy, request *model.P
 		}
 		for conf := range request.ConfigsUpdated {
 			switch conf.Kind {
-			case kind.ServiceEntry, kind.DestinationRule, kind.VirtualService, kind.Sidecar, kind.HTTPRoute, kind.TCPRoute, kind.TLSRoute, kind.GRPCRoute:
+			case kind.ServiceEntry, kind.DestinationRule, kind.VirtualService, kind.Sidecar, kind.HTTPRoute, kind.TCPRoute, kind.TLSRoute, kind.GRPCRoute,
+				kind.UDPRoute, kind.BackendTLSPolicy:
 				shouldResetSidecarScope = true
 			case kind.Gateway, kind.KubernetesGateway, kind.GatewayClass, kind.ReferenceGrant:
 				shouldResetGateway = true
@@ -482,6 +487,8 @@ func (s *DiscoveryServer) pushConnection(con *Connection, pushEv *Event) error {
 		log.Debugf("Skipping push to %v, no updates required", con.ID())
 		return nil
 	}
+	con.startPush(pushRequest)
+	defer con.finishPush()
 
 	// Send pushes to all generators
 	// Each Generator is responsible for determining if the push event requires a push
@@ -19,6 +19,7 @@ import (
 	"fmt"
 	"os"
 	"path"
+	"runtime"
 	"strconv"
 	"strings"
 	"sync"
@@ -188,6 +189,36 @@ func BenchmarkInitPushContext(b *testing.B) {
 	}
 }
 
+// BenchmarkPushContextMemory measures the heap retained by a PushContext for a mesh with many namespaces,
+// each with its own Sidecar and DestinationRule, when only a single namespace has proxies connected.
+func BenchmarkPushContextMemory(b *testing.B) {
+	configureBenchmark(b)
+	tt := ConfigInput{Name: "namespaces", Services: 1000}
+	for _, lazy := range []bool{false, true} {
+		b.Run(fmt.Sprintf("lazy=%v", lazy), func(b *testing.B) {
+			test.SetForTest(b, &features.LazySidecarScopes, lazy)
+			s, proxy := setupTest(b, tt)
+			var retained int64
+			b.ResetTimer()
+			for n := 0; n < b.N; n++ {
+				var before, after runtime.MemStats
+				runtime.GC()
+				runtime.ReadMemStats(&before)
+				push := model.NewPushContext()
+				if err := push.InitContext(s.Env(), nil, nil); err != nil {
+					b.Fatal(err)
+				}
+				proxy.SetSidecarScope(push)
+				runtime.GC()
+				runtime.ReadMemStats(&after)
+				retained += int64(after.HeapAlloc) - int64(before.HeapAlloc)
+				runtime.KeepAlive(push)
+			}
+			b.ReportMetric(float64(retained)/float64(b.N), "retained-B/op")
+		})
+	}
+}
+
 func BenchmarkRouteGeneration(b *testing.B) {
 	runBenchmark(b, v3.RouteType, testCases)
 }
@@ -56,6 +56,7 @@ var pushCdsGatewayConfig = func() sets.Set[kind.Kind] {
 		kind.TCPRoute,
 		kind.TLSRoute,
 		kind.GRPCRoute,
+		kind.UDPRoute,
 	)
 	if features.JwksFetchMode != jwt.Istiod {
 		s.Insert(kind.RequestAuthentication)
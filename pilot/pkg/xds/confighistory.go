@@ -0,0 +1,166 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package xds
+
+import (
+	"net/http"
+	"sync"
+	"time"
+
+	"istio.io/istio/pilot/pkg/config/kube/crd"
+	"istio.io/istio/pilot/pkg/model"
+	"istio.io/istio/pkg/config"
+)
+
+// ConfigHistoryEntry describes a single config change, as returned by /debug/confighistory.
+type ConfigHistoryEntry struct {
+	// ID uniquely identifies the change. IDs increase with each change.
+	ID        uint64    `json:"id"`
+	Time      time.Time `json:"time"`
+	Event     string    `json:"event"`
+	Kind      string    `json:"kind"`
+	Name      string    `json:"name"`
+	Namespace string    `json:"namespace"`
+	// Before is the config prior to the change. It is unset for additions.
+	Before *crd.IstioKind `json:"before,omitempty"`
+	// After is the config after the change. It is unset for deletions.
+	After *crd.IstioKind `json:"after,omitempty"`
+	// PushVersion is the version of the first push started after the change. It is unset if no push has started yet.
+	PushVersion string `json:"pushVersion,omitempty"`
+}
+
+type configHistoryRecord struct {
+	id          uint64
+	time        time.Time
+	event       model.Event
+	before      *config.Config
+	after       *config.Config
+	pushVersion string
+}
+
+// ConfigHistory keeps a bounded, in-memory record of the most recent config changes, for debugging.
+type ConfigHistory struct {
+	mu      sync.RWMutex
+	records ring[configHistoryRecord]
+	lastID  uint64
+}
+
+// NewConfigHistory creates a ConfigHistory retaining up to size changes. A size of 0 disables recording.
+func NewConfigHistory(size int) *ConfigHistory {
+	return &ConfigHistory{records: newRing[configHistoryRecord](size)}
+}
+
+// Record adds a change to the history, evicting the oldest change if the history is full.
+func (h *ConfigHistory) Record(prev, curr config.Config, event model.Event) {
+	if cap(h.records.items) == 0 {
+		return
+	}
+	r := configHistoryRecord{time: time.Now(), event: event}
+	switch event {
+	case model.EventAdd:
+		r.after = &curr
+	case model.EventUpdate:
+		r.before, r.after = &prev, &curr
+	case model.EventDelete:
+		r.before = &curr
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.lastID++
+	r.id = h.lastID
+	h.records.add(r)
+}
+
+// MarkPushed sets the push version for all changes that were recorded before the push started.
+func (h *ConfigHistory) MarkPushed(version string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	// Changes without a push version are always the most recent ones, so walk backwards until we find one with a version.
+	h.records.reverse(func(r *configHistoryRecord) bool {
+		if r.pushVersion != "" {
+			return false
+		}
+		r.pushVersion = version
+		return true
+	})
+}
+
+// Entries returns the recorded changes, oldest first.
+func (h *ConfigHistory) Entries() ([]ConfigHistoryEntry, error) {
+	h.mu.RLock()
+	records := h.records.list()
+	h.mu.RUnlock()
+
+	out := make([]ConfigHistoryEntry, 0, len(records))
+	for _, r := range records {
+		cfg := r.after
+		if cfg == nil {
+			cfg = r.before
+		}
+		e := ConfigHistoryEntry{
+			ID:          r.id,
+			Time:        r.time,
+			Event:       r.event.String(),
+			Kind:        cfg.GroupVersionKind.Kind,
+			Name:        cfg.Name,
+			Namespace:   cfg.Namespace,
+			PushVersion: r.pushVersion,
+		}
+		var err error
+		if e.Before, err = convertHistoryConfig(r.before); err != nil {
+			return nil, err
+		}
+		if e.After, err = convertHistoryConfig(r.after); err != nil {
+			return nil, err
+		}
+		out = append(out, e)
+	}
+	return out, nil
+}
+
+func convertHistoryConfig(cfg *config.Config) (*crd.IstioKind, error) {
+	if cfg == nil {
+		return nil, nil
+	}
+	obj, err := crd.ConvertConfig(*cfg)
+	if err != nil {
+		return nil, err
+	}
+	return obj.(*crd.IstioKind), nil
+}
+
+// configHistory returns the recent config changes, optionally filtered by the kind, name, and namespace query parameters.
+func (s *DiscoveryServer) configHistory(w http.ResponseWriter, req *http.Request) {
+	entries, err := s.ConfigHistory.Entries()
+	if err != nil {
+		handleHTTPError(w, err)
+		return
+	}
+	q := req.URL.Query()
+	out := make([]ConfigHistoryEntry, 0, len(entries))
+	for _, e := range entries {
+		if kind := q.Get("kind"); kind != "" && kind != e.Kind {
+			continue
+		}
+		if name := q.Get("name"); name != "" && name != e.Name {
+			continue
+		}
+		if ns := q.Get("namespace"); ns != "" && ns != e.Namespace {
+			continue
+		}
+		out = append(out, e)
+	}
+	writeJSON(w, out, req)
+}
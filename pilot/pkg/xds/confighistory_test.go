@@ -0,0 +1,120 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package xds_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	networking "istio.io/api/networking/v1alpha3"
+	"istio.io/istio/pilot/pkg/model"
+	"istio.io/istio/pilot/pkg/xds"
+	xdsfake "istio.io/istio/pilot/test/xds"
+	"istio.io/istio/pkg/config"
+	"istio.io/istio/pkg/config/schema/gvk"
+	"istio.io/istio/pkg/slices"
+	"istio.io/istio/pkg/test/util/assert"
+)
+
+func historyConfig(name string, hosts ...string) config.Config {
+	return config.Config{
+		Meta: config.Meta{
+			GroupVersionKind: gvk.VirtualService,
+			Name:             name,
+			Namespace:        "default",
+		},
+		Spec: &networking.VirtualService{Hosts: hosts},
+	}
+}
+
+func historyIDs(t *testing.T, h *xds.ConfigHistory) []uint64 {
+	t.Helper()
+	entries, err := h.Entries()
+	assert.NoError(t, err)
+	return slices.Map(entries, func(e xds.ConfigHistoryEntry) uint64 { return e.ID })
+}
+
+func TestConfigHistory(t *testing.T) {
+	t.Run("ring", func(t *testing.T) {
+		h := xds.NewConfigHistory(3)
+		assert.Equal(t, len(historyIDs(t, h)), 0)
+		for i := 0; i < 5; i++ {
+			h.Record(config.Config{}, historyConfig("a", "a.example.com"), model.EventAdd)
+		}
+		assert.Equal(t, historyIDs(t, h), []uint64{3, 4, 5})
+	})
+	t.Run("disabled", func(t *testing.T) {
+		h := xds.NewConfigHistory(0)
+		h.Record(config.Config{}, historyConfig("a", "a.example.com"), model.EventAdd)
+		h.MarkPushed("1")
+		assert.Equal(t, len(historyIDs(t, h)), 0)
+	})
+	t.Run("events", func(t *testing.T) {
+		h := xds.NewConfigHistory(10)
+		h.Record(config.Config{}, historyConfig("a", "v1.example.com"), model.EventAdd)
+		h.Record(historyConfig("a", "v1.example.com"), historyConfig("a", "v2.example.com"), model.EventUpdate)
+		h.Record(config.Config{}, historyConfig("a", "v2.example.com"), model.EventDelete)
+		entries, err := h.Entries()
+		assert.NoError(t, err)
+		assert.Equal(t, slices.Map(entries, func(e xds.ConfigHistoryEntry) string { return e.Event }), []string{"add", "update", "delete"})
+
+		assert.Equal(t, entries[0].Before, nil)
+		assert.Equal(t, string(entries[0].After.Spec), `{"hosts":["v1.example.com"]}`)
+		assert.Equal(t, string(entries[1].Before.Spec), `{"hosts":["v1.example.com"]}`)
+		assert.Equal(t, string(entries[1].After.Spec), `{"hosts":["v2.example.com"]}`)
+		assert.Equal(t, string(entries[2].Before.Spec), `{"hosts":["v2.example.com"]}`)
+		assert.Equal(t, entries[2].After, nil)
+		assert.Equal(t, entries[2].Kind, gvk.VirtualService.Kind)
+		assert.Equal(t, entries[2].Name, "a")
+		assert.Equal(t, entries[2].Namespace, "default")
+	})
+	t.Run("push version", func(t *testing.T) {
+		h := xds.NewConfigHistory(2)
+		h.Record(config.Config{}, historyConfig("a", "a.example.com"), model.EventAdd)
+		h.MarkPushed("1")
+		h.Record(config.Config{}, historyConfig("b", "b.example.com"), model.EventAdd)
+		h.Record(config.Config{}, historyConfig("c", "c.example.com"), model.EventAdd)
+		h.MarkPushed("2")
+		h.Record(config.Config{}, historyConfig("d", "d.example.com"), model.EventAdd)
+		entries, err := h.Entries()
+		assert.NoError(t, err)
+		assert.Equal(t, slices.Map(entries, func(e xds.ConfigHistoryEntry) string { return e.Name + "=" + e.PushVersion }),
+			[]string{"c=2", "d="})
+	})
+}
+
+func TestConfigHistoryHandler(t *testing.T) {
+	s := xdsfake.NewFakeDiscoveryServer(t, xdsfake.FakeOptions{})
+	s.Discovery.ConfigHistory.Record(config.Config{}, historyConfig("a", "a.example.com"), model.EventAdd)
+	s.Discovery.ConfigHistory.Record(config.Config{}, historyConfig("b", "b.example.com"), model.EventAdd)
+
+	mux := s.Discovery.InitDebug(http.NewServeMux(), false, nil)
+	get := func(url string) []xds.ConfigHistoryEntry {
+		rr := httptest.NewRecorder()
+		req, err := http.NewRequest(http.MethodGet, url, nil)
+		assert.NoError(t, err)
+		mux.ServeHTTP(rr, req)
+		assert.Equal(t, rr.Code, http.StatusOK)
+		var entries []xds.ConfigHistoryEntry
+		assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &entries))
+		return entries
+	}
+	assert.Equal(t, len(get("/debug/confighistory")), 2)
+	assert.Equal(t, slices.Map(get("/debug/confighistory?name=b&kind=VirtualService"), func(e xds.ConfigHistoryEntry) string { return e.Name }),
+		[]string{"b"})
+	assert.Equal(t, len(get("/debug/confighistory?namespace=other")), 0)
+}
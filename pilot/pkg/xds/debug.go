@@ -108,6 +108,7 @@ type AdsClient struct {
 	Metadata     *model.NodeMetadata `json:"metadata,omitempty"`
 	Locality     *core.Locality      `json:"locality,omitempty"`
 	Watches      map[string][]string `json:"watches,omitempty"`
+	PushHistory  []PushHistoryEntry  `json:"pushHistory,omitempty"`
 }
 
 // AdsClients is collection of AdsClient connected to this Istiod.
@@ -201,6 +202,7 @@ func (s *DiscoveryServer) AddDebugHandlers(mux, internalMux *http.ServeMux, enab
 	s.addDebugHandler(mux, internalMux, "/debug/cachez?sizes=true", "Info about the size of the internal XDS caches", s.cachez)
 	s.addDebugHandler(mux, internalMux, "/debug/cachez?clear=true", "Clear the XDS caches", s.cachez)
 	s.addDebugHandler(mux, internalMux, "/debug/configz", "Debug support for config", s.configz)
+	s.addDebugHandler(mux, internalMux, "/debug/confighistory", "Recent config changes, filtered by kind, name, and namespace", s.configHistory)
 	s.addDebugHandler(mux, internalMux, "/debug/sidecarz", "Debug sidecar scope for a proxy", s.sidecarz)
 	s.addDebugHandler(mux, internalMux, "/debug/resourcesz", "Debug support for watched resources", s.resourcez)
 	s.addDebugHandler(mux, internalMux, "/debug/instancesz", "Debug support for service instances", s.instancesz)
@@ -212,6 +214,8 @@ func (s *DiscoveryServer) AddDebugHandlers(mux, internalMux *http.ServeMux, enab
 	s.addDebugHandler(mux, internalMux, "/debug/push_status", "Last PushContext Details", s.pushStatusHandler)
 	s.addDebugHandler(mux, internalMux, "/debug/pushcontext", "Debug support for current push context", s.pushContextHandler)
 	s.addDebugHandler(mux, internalMux, "/debug/connections", "Info about the connected XDS clients", s.connectionsHandler)
+	s.addDebugHandler(mux, internalMux, "/debug/connections?pushHistory=true", "Info about the connected XDS clients and their recent pushes",
+		s.connectionsHandler)
 
 	s.addDebugHandler(mux, internalMux, "/debug/inject", "Active inject template", s.injectTemplateHandler(webhook))
 	s.addDebugHandler(mux, internalMux, "/debug/mesh", "Active mesh config", s.meshHandler)
@@ -468,16 +472,31 @@ func (s *DiscoveryServer) telemetryz(w http.ResponseWriter, req *http.Request) {
 // connectionsHandler implements interface for displaying current connections.
 // It is mapped to /debug/connections.
 func (s *DiscoveryServer) connectionsHandler(w http.ResponseWriter, req *http.Request) {
+	proxyID, con := s.getDebugConnection(req)
+	if proxyID != "" && con == nil {
+		s.errorHandler(w, proxyID, con)
+		return
+	}
+	var connections []*Connection
+	if con != nil {
+		connections = []*Connection{con}
+	} else {
+		connections = s.SortedClients()
+	}
+	// Push history is only included when requested, as it is large for many connections.
+	pushHistory := con != nil || req.URL.Query().Has("pushHistory")
+
 	adsClients := &AdsClients{}
-	connections := s.SortedClients()
 	adsClients.Total = len(connections)
-
 	for _, c := range connections {
 		adsClient := AdsClient{
 			ConnectionID: c.ID(),
 			ConnectedAt:  c.ConnectedAt(),
 			PeerAddress:  c.Peer(),
 		}
+		if pushHistory {
+			adsClient.PushHistory = c.pushHistory.list()
+		}
 		adsClients.Connected = append(adsClients.Connected, adsClient)
 	}
 	writeJSON(w, adsClients, req)
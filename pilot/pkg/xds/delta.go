@@ -164,6 +164,8 @@ func (s *DiscoveryServer) pushConnectionDelta(con *Connection, pushEv *Event) er
 		deltaLog.Debugf("Skipping push to %v, no updates required", con.ID())
 		return nil
 	}
+	con.startPush(pushRequest)
+	defer con.finishPush()
 
 	// Send pushes to all generators
 	// Each Generator is responsible for determining if the push event requires a push
@@ -570,6 +572,7 @@ func (s *DiscoveryServer) pushDeltaXds(con *Connection, w *model.WatchedResource
 		}
 		return err
 	}
+	con.pushHistory.add(w.TypeUrl, len(res), len(resp.RemovedResources), configSize, t0)
 
 	switch {
 	case !req.Full:
@@ -620,6 +623,7 @@ func newDeltaConnection(peerAddr string, stream DeltaDiscoveryStream) *Connectio
 		Connection:   xds.NewConnection(peerAddr, nil),
 		deltaStream:  stream,
 		deltaReqChan: make(chan *discovery.DeltaDiscoveryRequest, 1),
+		pushHistory:  newPushHistory(features.PushHistorySize),
 	}
 }
 
@@ -110,6 +110,31 @@ func TestDeltaCDS(t *testing.T) {
 	assert.Equal(t, resp.RemovedResources, []string{"inbound|80||", "outbound|8080||eds.test.svc.cluster.local"})
 }
 
+func TestDeltaLDS(t *testing.T) {
+	s := xds.NewFakeDiscoveryServer(t, xds.FakeOptions{})
+	s.MemRegistry.AddHTTPService(edsIncSvc, edsIncVip, 8080)
+	s.EnsureSynced(t)
+
+	ads := s.ConnectDeltaADS().WithType(v3.ListenerType).WithID("sidecar~127.0.0.1~test.default~default.svc.cluster.local")
+	ads.Request(&discovery.DeltaDiscoveryRequest{
+		ResourceNamesSubscribe: []string{},
+	})
+	resp := ads.ExpectResponse()
+	assert.Equal(t, sets.New(slices.Map(resp.Resources, (*discovery.Resource).GetName)...).Contains("0.0.0.0_8080"), true)
+
+	// A service on a new port only sends the listener for that port
+	s.MemRegistry.AddHTTPService("other.test.svc.cluster.local", "10.10.1.2", 9090)
+	resp = ads.ExpectResponse()
+	assert.Equal(t, slices.Map(resp.Resources, (*discovery.Resource).GetName), []string{"0.0.0.0_9090"})
+	assert.Equal(t, resp.RemovedResources, nil)
+
+	// Removing the only service on a port removes its listener
+	s.MemRegistry.RemoveService("other.test.svc.cluster.local")
+	resp = ads.ExpectResponse()
+	assert.Equal(t, len(resp.Resources), 0)
+	assert.Equal(t, resp.RemovedResources, []string{"0.0.0.0_9090"})
+}
+
 func TestDeltaCDSReconnect(t *testing.T) {
 	base := sets.New("BlackHoleCluster", "PassthroughCluster", "InboundPassthroughCluster")
 	assertResources := func(resp *discovery.DeltaDiscoveryResponse, names ...string) {
@@ -130,6 +130,9 @@ type DiscoveryServer struct {
 
 	// DiscoveryStartTime is the time since the binary started
 	DiscoveryStartTime time.Time
+
+	// ConfigHistory records recent config changes, for debugging.
+	ConfigHistory *ConfigHistory
 }
 
 // NewDiscoveryServer creates DiscoveryServer that sources data from Pilot's internal mesh data structures
@@ -153,6 +156,7 @@ func NewDiscoveryServer(env *model.Environment, clusterAliases map[string]string
 		},
 		Cache:              env.Cache,
 		DiscoveryStartTime: processStartTime,
+		ConfigHistory:      NewConfigHistory(features.ConfigHistorySize),
 	}
 
 	out.ClusterAliases = make(map[cluster.ID]cluster.ID)
@@ -267,6 +271,8 @@ func (s *DiscoveryServer) Push(req *model.PushRequest) {
 	// saved.
 	t0 := time.Now()
 	versionLocal := s.NextVersion()
+	// The push context is built from the current config, so it includes every change recorded so far.
+	s.ConfigHistory.MarkPushed(versionLocal)
 	push, err := s.initPushContext(req, oldPushContext, versionLocal)
 	if err != nil {
 		return
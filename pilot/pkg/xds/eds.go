@@ -106,6 +106,7 @@ var skippedEdsConfigs = sets.New(
 	kind.TCPRoute,
 	kind.TLSRoute,
 	kind.GRPCRoute,
+	kind.UDPRoute,
 )
 
 func edsNeedsPush(updates model.XdsUpdates) bool {
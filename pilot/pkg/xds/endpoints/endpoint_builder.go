@@ -35,6 +35,7 @@ import (
 	"istio.io/istio/pkg/config/constants"
 	"istio.io/istio/pkg/config/host"
 	"istio.io/istio/pkg/config/labels"
+	"istio.io/istio/pkg/config/protocol"
 	"istio.io/istio/pkg/config/schema/kind"
 	istiolog "istio.io/istio/pkg/log"
 	"istio.io/istio/pkg/network"
@@ -132,6 +133,15 @@ func (b *EndpointBuilder) servicePort(port int) *model.Port {
 		return nil
 	}
 	svcPort, f := b.service.Ports.GetByPort(port)
+	if !f && b.nodeType == model.Router {
+		// Gateways proxy UDP only ports for UDPRoute; GetByPort intentionally skips these.
+		for _, p := range b.service.Ports {
+			if p.Port == port && p.Protocol == protocol.UDP {
+				svcPort, f = p, true
+				break
+			}
+		}
+	}
 	if !f {
 		log.Debugf("can not find the service port %d for cluster %s", b.port, b.clusterName)
 		return nil
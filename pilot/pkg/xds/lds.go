@@ -15,6 +15,7 @@
 package xds
 
 import (
+	listener "github.com/envoyproxy/go-control-plane/envoy/config/listener/v3"
 	discovery "github.com/envoyproxy/go-control-plane/envoy/service/discovery/v3"
 
 	"istio.io/istio/pilot/pkg/model"
@@ -28,7 +29,7 @@ type LdsGenerator struct {
 	ConfigGenerator core.ConfigGenerator
 }
 
-var _ model.XdsResourceGenerator = &LdsGenerator{}
+var _ model.XdsDeltaResourceGenerator = &LdsGenerator{}
 
 // Map of all configs that do not impact LDS
 var skippedLdsConfigs = map[model.NodeType]sets.Set[kind.Kind]{
@@ -102,6 +103,21 @@ func (l LdsGenerator) Generate(proxy *model.Proxy, _ *model.WatchedResource, req
 		return nil, model.DefaultXdsLogDetails, nil
 	}
 	listeners := l.ConfigGenerator.BuildListeners(proxy, req.Push)
+	return listenerResources(listeners), model.DefaultXdsLogDetails, nil
+}
+
+// GenerateDeltas for LDS only builds the listeners affected by service changes; otherwise all listeners are built.
+func (l LdsGenerator) GenerateDeltas(proxy *model.Proxy, req *model.PushRequest,
+	w *model.WatchedResource,
+) (model.Resources, model.DeletedResources, model.XdsLogDetails, bool, error) {
+	if !ldsNeedsPush(proxy, req) {
+		return nil, nil, model.DefaultXdsLogDetails, false, nil
+	}
+	listeners, removed, usedDelta := l.ConfigGenerator.BuildDeltaListeners(proxy, req, w)
+	return listenerResources(listeners), removed, model.DefaultXdsLogDetails, usedDelta, nil
+}
+
+func listenerResources(listeners []*listener.Listener) model.Resources {
 	resources := model.Resources{}
 	for _, c := range listeners {
 		resources = append(resources, &discovery.Resource{
@@ -109,5 +125,5 @@ func (l LdsGenerator) Generate(proxy *model.Proxy, _ *model.WatchedResource, req
 			Resource: protoconv.MessageToAny(c),
 		})
 	}
-	return resources, model.DefaultXdsLogDetails, nil
+	return resources
 }
@@ -58,6 +58,8 @@ var skippedNdsConfigs = sets.New(
 	kind.TCPRoute,
 	kind.TLSRoute,
 	kind.GRPCRoute,
+	kind.UDPRoute,
+	kind.BackendTLSPolicy,
 )
 
 func ndsNeedsPush(req *model.PushRequest) bool {
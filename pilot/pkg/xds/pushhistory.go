@@ -0,0 +1,156 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package xds
+
+import (
+	"sync"
+	"time"
+
+	"istio.io/istio/pilot/pkg/model"
+	"istio.io/istio/pkg/maps"
+	"istio.io/istio/pkg/slices"
+)
+
+// maxPushHistoryConfigs bounds the number of updated configs stored for each push, as a single push may include
+// many thousands of changes.
+const maxPushHistoryConfigs = 10
+
+// PushHistoryEntry describes a push to a single proxy, as returned by /debug/connections.
+type PushHistoryEntry struct {
+	Time time.Time `json:"time"`
+	// Version is the version of the PushContext used for the push.
+	Version string            `json:"version"`
+	Full    bool              `json:"full"`
+	Reasons model.ReasonStats `json:"reasons,omitempty"`
+	// ConfigsUpdated are the configs whose changes triggered the push. Only the first few are included.
+	ConfigsUpdated []string `json:"configsUpdated,omitempty"`
+	// OmittedConfigs is the number of updated configs not included in ConfigsUpdated.
+	OmittedConfigs int          `json:"omittedConfigs,omitempty"`
+	Types          []PushedType `json:"types,omitempty"`
+	Duration       string       `json:"duration"`
+}
+
+// PushedType describes the resources of a single type sent to a proxy as part of a push.
+type PushedType struct {
+	TypeURL   string `json:"typeUrl"`
+	Resources int    `json:"resources"`
+	Removed   int    `json:"removed,omitempty"`
+	Bytes     int    `json:"bytes"`
+	Duration  string `json:"duration"`
+}
+
+// activePush accumulates the types sent during a push, until the push completes.
+type activePush struct {
+	start time.Time
+	req   *model.PushRequest
+	types []PushedType
+}
+
+func (p *activePush) add(typeURL string, resources, removed, bytes int, start time.Time) {
+	if p == nil {
+		return
+	}
+	p.types = append(p.types, PushedType{
+		TypeURL:   typeURL,
+		Resources: resources,
+		Removed:   removed,
+		Bytes:     bytes,
+		Duration:  time.Since(start).String(),
+	})
+}
+
+// startPush begins tracking a push to the connection.
+func (conn *Connection) startPush(req *model.PushRequest) {
+	if conn.pushHistory != nil {
+		conn.pushHistory.active = &activePush{start: time.Now(), req: req}
+	}
+}
+
+// finishPush records the push in progress in the connection's push history.
+func (conn *Connection) finishPush() {
+	if conn.pushHistory != nil {
+		conn.pushHistory.record(conn.pushHistory.active)
+		conn.pushHistory.active = nil
+	}
+}
+
+// pushHistory is a bounded record of the most recent pushes to a connection.
+type pushHistory struct {
+	mu      sync.RWMutex
+	entries ring[PushHistoryEntry]
+	// active tracks the push in progress, if any. It is only accessed from the connection's goroutine.
+	active *activePush
+}
+
+// newPushHistory creates a pushHistory retaining up to size pushes. A nil history, which records nothing, is returned
+// if size is 0.
+func newPushHistory(size int) *pushHistory {
+	if size <= 0 {
+		return nil
+	}
+	return &pushHistory{entries: newRing[PushHistoryEntry](size)}
+}
+
+// add records a type sent as part of the push in progress.
+func (h *pushHistory) add(typeURL string, resources, removed, bytes int, start time.Time) {
+	if h == nil {
+		return
+	}
+	h.active.add(typeURL, resources, removed, bytes, start)
+}
+
+// record adds a completed push to the history. Pushes that did not send any resources are ignored.
+func (h *pushHistory) record(p *activePush) {
+	if h == nil || p == nil || len(p.types) == 0 {
+		return
+	}
+	// The request may be shared with other connections, which continue to merge into it, so copy the reasons.
+	e := PushHistoryEntry{
+		Time:     p.start,
+		Full:     p.req.Full,
+		Reasons:  maps.Clone(p.req.Reason),
+		Types:    p.types,
+		Duration: time.Since(p.start).String(),
+	}
+	if p.req.Push != nil {
+		e.Version = p.req.Push.PushVersion
+	}
+	if len(p.req.ConfigsUpdated) > 0 {
+		configs := make([]string, 0, len(p.req.ConfigsUpdated))
+		for k := range p.req.ConfigsUpdated {
+			configs = append(configs, k.String())
+		}
+		configs = slices.Sort(configs)
+		if len(configs) > maxPushHistoryConfigs {
+			e.OmittedConfigs = len(configs) - maxPushHistoryConfigs
+			configs = configs[:maxPushHistoryConfigs]
+		}
+		e.ConfigsUpdated = configs
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.entries.add(e)
+}
+
+// list returns the recorded pushes, oldest first.
+func (h *pushHistory) list() []PushHistoryEntry {
+	if h == nil {
+		return nil
+	}
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.entries.list()
+}
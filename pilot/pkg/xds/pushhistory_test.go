@@ -0,0 +1,98 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package xds_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	discovery "github.com/envoyproxy/go-control-plane/envoy/service/discovery/v3"
+
+	"istio.io/istio/pilot/pkg/model"
+	"istio.io/istio/pilot/pkg/xds"
+	v3 "istio.io/istio/pilot/pkg/xds/v3"
+	xdsfake "istio.io/istio/pilot/test/xds"
+	"istio.io/istio/pkg/config/schema/kind"
+	"istio.io/istio/pkg/slices"
+	"istio.io/istio/pkg/test/util/assert"
+	"istio.io/istio/pkg/test/util/retry"
+	"istio.io/istio/pkg/util/sets"
+)
+
+func TestPushHistory(t *testing.T) {
+	s := xdsfake.NewFakeDiscoveryServer(t, xdsfake.FakeOptions{})
+	ads := s.ConnectADS()
+	ads.RequestResponseAck(t, &discovery.DiscoveryRequest{TypeUrl: v3.ClusterType})
+	ads.RequestResponseAck(t, &discovery.DiscoveryRequest{TypeUrl: v3.ListenerType})
+
+	configs := sets.New[model.ConfigKey]()
+	for i := 0; i < 12; i++ {
+		configs.Insert(model.ConfigKey{Kind: kind.EnvoyFilter, Name: fmt.Sprintf("filter-%02d", i), Namespace: "default"})
+	}
+	s.Discovery.ConfigUpdate(&model.PushRequest{
+		Full:           true,
+		ConfigsUpdated: configs,
+		Reason:         model.NewReasonStats(model.ConfigUpdate),
+	})
+	ads.ExpectResponse(t)
+	ads.ExpectResponse(t)
+
+	mux := s.Discovery.InitDebug(http.NewServeMux(), false, nil)
+	get := func(url string) (int, xds.AdsClients) {
+		rr := httptest.NewRecorder()
+		req, err := http.NewRequest(http.MethodGet, url, nil)
+		assert.NoError(t, err)
+		mux.ServeHTTP(rr, req)
+		var clients xds.AdsClients
+		if rr.Code == http.StatusOK {
+			assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &clients))
+		}
+		return rr.Code, clients
+	}
+
+	// Pushes are recorded once the push to the connection completes, which may be after the responses are received.
+	retry.UntilSuccessOrFail(t, func() error {
+		_, clients := get("/debug/connections?proxyID=test.default")
+		if len(clients.Connected) != 1 || len(clients.Connected[0].PushHistory) != 1 {
+			return fmt.Errorf("expected a single push, got %+v", clients)
+		}
+		return nil
+	})
+	_, clients := get("/debug/connections?proxyID=test.default")
+	push := clients.Connected[0].PushHistory[0]
+	assert.Equal(t, push.Full, true)
+	assert.Equal(t, push.Version, s.PushContext().PushVersion)
+	assert.Equal(t, push.Reasons, model.NewReasonStats(model.ConfigUpdate))
+	assert.Equal(t, len(push.ConfigsUpdated), 10)
+	assert.Equal(t, push.ConfigsUpdated[0], "EnvoyFilter/default/filter-00")
+	assert.Equal(t, push.OmittedConfigs, 2)
+	assert.Equal(t, slices.Map(push.Types, func(p xds.PushedType) string { return p.TypeURL }), []string{v3.ClusterType, v3.ListenerType})
+	for _, p := range push.Types {
+		assert.Equal(t, p.Resources > 0, true)
+		assert.Equal(t, p.Bytes > 0, true)
+	}
+
+	// Push history is omitted unless requested
+	_, clients = get("/debug/connections")
+	assert.Equal(t, len(clients.Connected[0].PushHistory), 0)
+	_, clients = get("/debug/connections?pushHistory=true")
+	assert.Equal(t, len(clients.Connected[0].PushHistory), 1)
+
+	code, _ := get("/debug/connections?proxyID=missing.default")
+	assert.Equal(t, code, http.StatusNotFound)
+}
@@ -15,35 +15,128 @@
 package xds
 
 import (
+	"context"
+	"math"
 	"sync"
+	"time"
 
+	"golang.org/x/time/rate"
+
+	"istio.io/istio/pilot/pkg/features"
 	"istio.io/istio/pilot/pkg/model"
 )
 
+// PushPriority is the priority class of a proxy in the push queue. Proxies of a higher priority class,
+// with a lower value, are pushed first.
+type PushPriority int
+
+const (
+	// PushPriorityGateway is the priority of gateways, waypoints and ztunnels, which serve the traffic of many workloads.
+	PushPriorityGateway PushPriority = iota
+	// PushPriorityChanged is the priority of proxies affected by the change that triggered the push.
+	PushPriorityChanged
+	// PushPriorityDefault is the priority of all other proxies.
+	PushPriorityDefault
+
+	numPushPriorities
+)
+
+func (p PushPriority) String() string {
+	switch p {
+	case PushPriorityGateway:
+		return "gateway"
+	case PushPriorityChanged:
+		return "changed"
+	default:
+		return "default"
+	}
+}
+
+// pushPriority returns the priority class of a push to con. Proxies are considered affected by a change
+// if it was made to the proxy itself or to configuration in its namespace; the complete dependency check is
+// done when the proxy is pushed, as it requires state owned by the connection.
+func pushPriority(con *Connection, req *model.PushRequest) PushPriority {
+	proxy := con.proxy
+	if proxy == nil {
+		return PushPriorityDefault
+	}
+	switch proxy.Type {
+	case model.Router, model.Waypoint, model.Ztunnel:
+		return PushPriorityGateway
+	}
+	if req.IsProxyUpdate() {
+		return PushPriorityChanged
+	}
+	for key := range req.ConfigsUpdated {
+		if key.Namespace == proxy.ConfigNamespace {
+			return PushPriorityChanged
+		}
+	}
+	return PushPriorityDefault
+}
+
+// queueItem is a connection pending a push.
+type queueItem struct {
+	con      *Connection
+	request  *model.PushRequest
+	priority PushPriority
+	enqueued time.Time
+	// removed is set if the connection was moved to a higher priority queue. The item is skipped when it
+	// reaches the head of its queue.
+	removed bool
+}
+
 type PushQueue struct {
 	cond *sync.Cond
 
 	// pending stores all connections in the queue. If the same connection is enqueued again,
 	// the PushRequest will be merged.
-	pending map[*Connection]*model.PushRequest
+	pending map[*Connection]*queueItem
 
-	// queue maintains ordering of the queue
-	queue []*Connection
+	// queues maintain ordering of the queue, per priority class
+	queues [numPushPriorities][]*queueItem
 
 	// processing stores all connections that have been Dequeue(), but not MarkDone().
 	// The value stored will be initially be nil, but may be populated if the connection is Enqueue().
-	// If model.PushRequest is not nil, it will be Enqueued again once MarkDone has been called.
-	processing map[*Connection]*model.PushRequest
+	// If the item is not nil, it will be Enqueued again once MarkDone has been called, keeping the time
+	// it was first enqueued.
+	processing map[*Connection]*queueItem
+
+	// priority returns the priority class of a push.
+	priority func(con *Connection, req *model.PushRequest) PushPriority
+	// maxWait is the time after which a connection is dequeued before connections of higher priority.
+	maxWait time.Duration
+	// limiter limits the rate of Dequeue, if set.
+	limiter *rate.Limiter
+	// stop is canceled on shutdown, to release Dequeue waiting for the rate limiter.
+	stop       context.Context
+	cancelStop context.CancelFunc
 
 	shuttingDown bool
 }
 
 func NewPushQueue() *PushQueue {
-	return &PushQueue{
-		pending:    make(map[*Connection]*model.PushRequest),
-		processing: make(map[*Connection]*model.PushRequest),
+	return newPushQueue(features.PushRateLimit, features.PushQueueMaxWait)
+}
+
+// newPushQueue creates a PushQueue dequeuing at most pushesPerSecond connections per second, or without
+// limit if it is 0.
+func newPushQueue(pushesPerSecond float64, maxWait time.Duration) *PushQueue {
+	stop, cancel := context.WithCancel(context.Background())
+	p := &PushQueue{
+		pending:    make(map[*Connection]*queueItem),
+		processing: make(map[*Connection]*queueItem),
 		cond:       sync.NewCond(&sync.Mutex{}),
+		priority:   pushPriority,
+		maxWait:    maxWait,
+		stop:       stop,
+		cancelStop: cancel,
 	}
+	if pushesPerSecond > 0 {
+		// Allow the budget of a second to be spent at once, so pushes start immediately after a quiet period.
+		p.limiter = rate.NewLimiter(rate.Limit(pushesPerSecond), int(math.Ceil(pushesPerSecond)))
+	}
+	return p
 }
 
 // Enqueue will mark a proxy as pending a push. If it is already pending, pushInfo will be merged.
@@ -57,63 +150,122 @@ func (p *PushQueue) Enqueue(con *Connection, pushRequest *model.PushRequest) {
 	}
 
 	// If its already in progress, merge the info and return
-	if request, f := p.processing[con]; f {
-		p.processing[con] = request.CopyMerge(pushRequest)
+	if item, f := p.processing[con]; f {
+		if item == nil {
+			p.processing[con] = &queueItem{con: con, request: pushRequest, enqueued: time.Now()}
+		} else {
+			item.request = item.request.CopyMerge(pushRequest)
+		}
 		return
 	}
 
-	if request, f := p.pending[con]; f {
-		p.pending[con] = request.CopyMerge(pushRequest)
+	priority := p.priority(con, pushRequest)
+	if item, f := p.pending[con]; f {
+		item.request = item.request.CopyMerge(pushRequest)
+		if priority < item.priority {
+			// Move the connection to the higher priority queue, keeping the time it has been waiting.
+			item.removed = true
+			p.add(con, item.request, priority, item.enqueued)
+		}
 		return
 	}
 
-	p.pending[con] = pushRequest
-	p.queue = append(p.queue, con)
+	p.add(con, pushRequest, priority, time.Now())
 	// Signal waiters on Dequeue that a new item is available
 	p.cond.Signal()
 }
 
+func (p *PushQueue) add(con *Connection, request *model.PushRequest, priority PushPriority, enqueued time.Time) {
+	item := &queueItem{con: con, request: request, priority: priority, enqueued: enqueued}
+	p.pending[con] = item
+	p.queues[priority] = append(p.queues[priority], item)
+}
+
 // Remove a proxy from the queue. If there are no proxies ready to be removed, this will block
 func (p *PushQueue) Dequeue() (con *Connection, request *model.PushRequest, shutdown bool) {
 	p.cond.L.Lock()
-	defer p.cond.L.Unlock()
 
 	// Block until there is one to remove. Enqueue will signal when one is added.
-	for len(p.queue) == 0 && !p.shuttingDown {
+	for len(p.pending) == 0 && !p.shuttingDown {
 		p.cond.Wait()
 	}
 
-	if len(p.queue) == 0 {
+	if len(p.pending) == 0 {
 		// We must be shutting down.
+		p.cond.L.Unlock()
 		return nil, nil, true
 	}
 
-	con = p.queue[0]
-	// The underlying array will still exist, despite the slice changing, so the object may not GC without this
-	// See https://github.com/grpc/grpc-go/issues/4758
-	p.queue[0] = nil
-	p.queue = p.queue[1:]
-
-	request = p.pending[con]
+	item := p.next()
+	con, request = item.con, item.request
 	delete(p.pending, con)
 
-	// Mark the connection as in progress
+	// Mark the connection as in progress, so pushes enqueued while waiting for the push budget are merged.
 	p.processing[con] = nil
+	p.cond.L.Unlock()
+
+	if p.limiter != nil {
+		// Wait for the push budget without holding the lock, so other workers and Enqueue are not blocked.
+		// On shutdown, the remaining proxies are drained without limit.
+		_ = p.limiter.Wait(p.stop)
+	}
+	pushQueueWaitTime.With(priorityTag.Value(item.priority.String())).Record(time.Since(item.enqueued).Seconds())
 
 	return con, request, false
 }
 
+// next removes the next item from the queues. This is the head of the highest priority queue, unless
+// the head of another queue has waited for more than maxWait, in which case the item that waited longest
+// is returned, so that lower priorities are not starved by a steady flow of higher priority pushes.
+func (p *PushQueue) next() *queueItem {
+	var next, starved *queueItem
+	for priority := range p.queues {
+		head := p.head(PushPriority(priority))
+		if head == nil {
+			continue
+		}
+		if next == nil {
+			next = head
+		}
+		if time.Since(head.enqueued) >= p.maxWait && (starved == nil || head.enqueued.Before(starved.enqueued)) {
+			starved = head
+		}
+	}
+	if starved != nil {
+		next = starved
+	}
+	q := p.queues[next.priority]
+	// The underlying array will still exist, despite the slice changing, so the object may not GC without this
+	// See https://github.com/grpc/grpc-go/issues/4758
+	q[0] = nil
+	p.queues[next.priority] = q[1:]
+	return next
+}
+
+// head returns the first item of a queue, dropping items moved to a higher priority queue.
+func (p *PushQueue) head(priority PushPriority) *queueItem {
+	q := p.queues[priority]
+	for len(q) > 0 && q[0].removed {
+		q[0] = nil
+		q = q[1:]
+	}
+	p.queues[priority] = q
+	if len(q) == 0 {
+		return nil
+	}
+	return q[0]
+}
+
 func (p *PushQueue) MarkDone(con *Connection) {
 	p.cond.L.Lock()
 	defer p.cond.L.Unlock()
-	request := p.processing[con]
+	item := p.processing[con]
 	delete(p.processing, con)
 
 	// If the info is present, that means Enqueue was called while connection was not yet marked done.
 	// This means we need to add it back to the queue.
-	if request != nil {
-		p.pending[con] = request
-		p.queue = append(p.queue, con)
+	if item != nil {
+		p.add(con, item.request, p.priority(con, item.request), item.enqueued)
 		p.cond.Signal()
 	}
 }
@@ -122,7 +274,7 @@ func (p *PushQueue) MarkDone(con *Connection) {
 func (p *PushQueue) Pending() int {
 	p.cond.L.Lock()
 	defer p.cond.L.Unlock()
-	return len(p.queue)
+	return len(p.pending)
 }
 
 // ShutDown will cause queue to ignore all new items added to it. As soon as the
@@ -132,5 +284,6 @@ func (p *PushQueue) ShutDown() {
 	p.cond.L.Lock()
 	defer p.cond.L.Unlock()
 	p.shuttingDown = true
+	p.cancelStop()
 	p.cond.Broadcast()
 }
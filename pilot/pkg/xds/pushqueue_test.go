@@ -373,3 +373,148 @@ func TestProxyQueue(t *testing.T) {
 		}
 	})
 }
+
+func newTestConnection(id string, proxyType model.NodeType, namespace string) *Connection {
+	con := newConnection("", nil)
+	con.SetID(id)
+	con.proxy = &model.Proxy{Type: proxyType, ConfigNamespace: namespace}
+	return con
+}
+
+func TestPushQueuePriority(t *testing.T) {
+	sidecar := newTestConnection("sidecar", model.SidecarProxy, "default")
+	changed := newTestConnection("changed", model.SidecarProxy, "changed")
+	gateway := newTestConnection("gateway", model.Router, "istio-system")
+	waypoint := newTestConnection("waypoint", model.Waypoint, "default")
+	req := &model.PushRequest{
+		Full:           true,
+		ConfigsUpdated: sets.New(model.ConfigKey{Kind: kind.VirtualService, Name: "vs", Namespace: "changed"}),
+	}
+
+	t.Run("priority order", func(t *testing.T) {
+		p := newPushQueue(0, time.Minute)
+		defer p.ShutDown()
+		for _, con := range []*Connection{sidecar, changed, gateway, waypoint} {
+			p.Enqueue(con, req)
+		}
+		ExpectDequeue(t, p, gateway)
+		ExpectDequeue(t, p, waypoint)
+		ExpectDequeue(t, p, changed)
+		ExpectDequeue(t, p, sidecar)
+		ExpectTimeout(t, p)
+	})
+
+	t.Run("merged request raises priority", func(t *testing.T) {
+		p := newPushQueue(0, time.Minute)
+		defer p.ShutDown()
+		other := newTestConnection("other", model.SidecarProxy, "default")
+		p.Enqueue(sidecar, req)
+		p.Enqueue(other, req)
+		p.Enqueue(sidecar, &model.PushRequest{Full: true, Reason: model.NewReasonStats(model.ProxyUpdate)})
+		if got := p.Pending(); got != 2 {
+			t.Fatalf("expected 2 pending, got %v", got)
+		}
+		ExpectDequeue(t, p, sidecar)
+		ExpectDequeue(t, p, other)
+		// The connection is only dequeued once, although it was queued at two priorities.
+		ExpectTimeout(t, p)
+	})
+
+	t.Run("lower priorities are not starved", func(t *testing.T) {
+		maxWait := 100 * time.Millisecond
+		p := newPushQueue(0, maxWait)
+		defer p.ShutDown()
+		p.Enqueue(sidecar, req)
+		p.Enqueue(changed, req)
+		p.Enqueue(gateway, req)
+		// Before maxWait, priorities are respected.
+		ExpectDequeue(t, p, gateway)
+		p.MarkDone(gateway)
+
+		time.Sleep(2 * maxWait)
+		// A steady flow of gateway pushes keeps arriving, but the proxies which waited longer than maxWait go first,
+		// longest waiting first.
+		gateways := make([]*Connection, 0, 10)
+		for i := 0; i < 10; i++ {
+			gw := newTestConnection(fmt.Sprintf("gateway-%d", i), model.Router, "istio-system")
+			gateways = append(gateways, gw)
+			p.Enqueue(gw, req)
+		}
+		ExpectDequeue(t, p, sidecar)
+		ExpectDequeue(t, p, changed)
+		for _, gw := range gateways {
+			ExpectDequeue(t, p, gw)
+		}
+	})
+
+	t.Run("no max wait dequeues in order", func(t *testing.T) {
+		p := newPushQueue(0, 0)
+		defer p.ShutDown()
+		for _, con := range []*Connection{sidecar, changed, gateway} {
+			p.Enqueue(con, req)
+			time.Sleep(time.Millisecond)
+		}
+		ExpectDequeue(t, p, sidecar)
+		ExpectDequeue(t, p, changed)
+		ExpectDequeue(t, p, gateway)
+	})
+
+	t.Run("markdone requeues with priority", func(t *testing.T) {
+		p := newPushQueue(0, time.Minute)
+		defer p.ShutDown()
+		p.Enqueue(gateway, req)
+		ExpectDequeue(t, p, gateway)
+		p.Enqueue(gateway, req)
+		p.Enqueue(sidecar, req)
+		p.MarkDone(gateway)
+		ExpectDequeue(t, p, gateway)
+		ExpectDequeue(t, p, sidecar)
+	})
+
+	t.Run("markdone requeues with original enqueue time", func(t *testing.T) {
+		maxWait := 100 * time.Millisecond
+		p := newPushQueue(0, maxWait)
+		defer p.ShutDown()
+		p.Enqueue(sidecar, req)
+		ExpectDequeue(t, p, sidecar)
+		p.Enqueue(sidecar, req)
+		time.Sleep(2 * maxWait)
+		p.Enqueue(gateway, req)
+		p.MarkDone(sidecar)
+		// The sidecar has waited for longer than maxWait since it was enqueued while being pushed.
+		ExpectDequeue(t, p, sidecar)
+		ExpectDequeue(t, p, gateway)
+	})
+}
+
+func TestPushQueueRateLimit(t *testing.T) {
+	// With a budget of 100 pushes per second, the first 100 are dequeued at once and the next 50 take half a second.
+	p := newPushQueue(100, time.Minute)
+	defer p.ShutDown()
+	for i := 0; i < 150; i++ {
+		p.Enqueue(newTestConnection(fmt.Sprintf("proxy-%d", i), model.SidecarProxy, "default"), &model.PushRequest{})
+	}
+	start := time.Now()
+	for i := 0; i < 150; i++ {
+		if con, _, _ := p.Dequeue(); con == nil {
+			t.Fatal("unexpected shutdown")
+		}
+	}
+	if elapsed := time.Since(start); elapsed < 400*time.Millisecond {
+		t.Fatalf("expected dequeue to be rate limited, took %v", elapsed)
+	}
+
+	// Shutting down releases Dequeue waiting for the budget, and the remaining items are drained.
+	for i := 0; i < 100; i++ {
+		p.Enqueue(newTestConnection(fmt.Sprintf("proxy-%d", i), model.SidecarProxy, "default"), &model.PushRequest{})
+	}
+	p.ShutDown()
+	for i := 0; i < 100; i++ {
+		if con, _, _ := p.Dequeue(); con == nil {
+			t.Fatal("expected pending proxies to be drained")
+		}
+	}
+	if _, _, shutdown := p.Dequeue(); !shutdown {
+		t.Fatal("expected shutdown")
+	}
+}
@@ -0,0 +1,59 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package xds
+
+// ring is a fixed size buffer retaining the most recently added items. It is not safe for concurrent use; callers
+// are expected to hold their own lock.
+type ring[T any] struct {
+	items []T
+	// next is the index the next item is written to.
+	next int
+}
+
+func newRing[T any](size int) ring[T] {
+	return ring[T]{items: make([]T, 0, size)}
+}
+
+// add appends an item, evicting the oldest one if the ring is full. Adding to a ring of size 0 is a no-op.
+func (r *ring[T]) add(item T) {
+	if cap(r.items) == 0 {
+		return
+	}
+	if len(r.items) < cap(r.items) {
+		r.items = append(r.items, item)
+	} else {
+		r.items[r.next] = item
+	}
+	r.next = (r.next + 1) % cap(r.items)
+}
+
+// list returns a copy of the items, oldest first.
+func (r *ring[T]) list() []T {
+	out := make([]T, 0, len(r.items))
+	if len(r.items) == cap(r.items) {
+		out = append(out, r.items[r.next:]...)
+		return append(out, r.items[:r.next]...)
+	}
+	return append(out, r.items...)
+}
+
+// reverse calls f on each item, newest first, until f returns false. Items may be modified in place.
+func (r *ring[T]) reverse(f func(item *T) bool) {
+	for i := 1; i <= len(r.items); i++ {
+		if !f(&r.items[(r.next-i+len(r.items))%len(r.items)]) {
+			return
+		}
+	}
+}
@@ -0,0 +1,56 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package xds
+
+import (
+	"testing"
+
+	"istio.io/istio/pkg/test/util/assert"
+)
+
+func TestRing(t *testing.T) {
+	t.Run("empty", func(t *testing.T) {
+		r := newRing[int](0)
+		r.add(1)
+		assert.Equal(t, r.list(), []int{})
+	})
+	t.Run("partial", func(t *testing.T) {
+		r := newRing[int](3)
+		r.add(1)
+		r.add(2)
+		assert.Equal(t, r.list(), []int{1, 2})
+	})
+	t.Run("wrap", func(t *testing.T) {
+		r := newRing[int](3)
+		for i := 1; i <= 5; i++ {
+			r.add(i)
+		}
+		assert.Equal(t, r.list(), []int{3, 4, 5})
+	})
+	t.Run("reverse", func(t *testing.T) {
+		r := newRing[int](3)
+		for i := 1; i <= 4; i++ {
+			r.add(i)
+		}
+		seen := []int{}
+		r.reverse(func(i *int) bool {
+			seen = append(seen, *i)
+			*i *= 10
+			return *i != 30
+		})
+		assert.Equal(t, seen, []int{4, 3})
+		assert.Equal(t, r.list(), []int{2, 30, 40})
+	})
+}
@@ -39,6 +39,7 @@ import (
 	securitymodel "istio.io/istio/pilot/pkg/security/model"
 	"istio.io/istio/pilot/pkg/util/protoconv"
 	"istio.io/istio/pkg/cluster"
+	"istio.io/istio/pkg/config/schema/gvk"
 	"istio.io/istio/pkg/config/schema/kind"
 	"istio.io/istio/pkg/util/sets"
 )
@@ -136,7 +137,7 @@ func (s *SecretGen) Generate(proxy *model.Proxy, w *model.WatchedResource, req *
 	// Filter down to resources we can access. We do not return an error if they attempt to access a Secret
 	// they cannot; instead we just exclude it. This ensures that a single bad reference does not break the whole
 	// SDS flow. The pilotSDSCertificateErrors metric and logs handle visibility into invalid references.
-	resources := filterAuthorizedResources(s.parseResources(w.ResourceNames, proxy), proxy, proxyClusterSecrets)
+	resources := filterAuthorizedResources(s.parseResources(w.ResourceNames, proxy), proxy, req.Push, proxyClusterSecrets)
 
 	var results model.Resources
 	cached, regenerated := 0, 0
@@ -173,12 +174,26 @@ func (s *SecretGen) generate(sr SecretResource, configClusterSecrets, proxyClust
 	// Fetch the appropriate cluster's secret, based on the credential type
 	var secretController credscontroller.Controller
 	switch sr.ResourceType {
-	case credentials.KubernetesGatewaySecretType:
+	case credentials.KubernetesGatewaySecretType, credentials.KubernetesConfigMapType:
 		secretController = configClusterSecrets
 	default:
 		secretController = proxyClusterSecrets
 	}
 
+	if sr.ResourceType == credentials.KubernetesConfigMapType {
+		// ConfigMaps only ever hold a CA bundle.
+		caCertInfo, err := secretController.GetConfigMapCaCert(sr.Name, sr.Namespace)
+		if err != nil {
+			pilotSDSCertificateErrors.Increment()
+			log.Warnf("failed to fetch ca certificate for %s: %v", sr.ResourceName, err)
+			return nil
+		}
+		if err := ValidateCertificate(caCertInfo.Cert); err != nil {
+			recordInvalidCertificate(sr.ResourceName, err)
+		}
+		return toEnvoyCaSecret(sr.ResourceName, caCertInfo)
+	}
+
 	isCAOnlySecret := strings.HasSuffix(sr.Name, securitymodel.SdsCaSuffix)
 	if isCAOnlySecret {
 		caCertInfo, err := secretController.GetCaCert(sr.Name, sr.Namespace)
@@ -231,7 +246,12 @@ func recordInvalidCertificate(name string, err error) {
 }
 
 // filterAuthorizedResources takes a list of SecretResource and filters out resources that proxy cannot access
-func filterAuthorizedResources(resources []SecretResource, proxy *model.Proxy, secrets credscontroller.Controller) []SecretResource {
+func filterAuthorizedResources(
+	resources []SecretResource,
+	proxy *model.Proxy,
+	push *model.PushContext,
+	secrets credscontroller.Controller,
+) []SecretResource {
 	var authzResult *bool
 	var authzError error
 	// isAuthorized is a small wrapper around credscontroller.Authorize so we only call it once instead of each time in the loop
@@ -269,6 +289,16 @@ func filterAuthorizedResources(resources []SecretResource, proxy *model.Proxy, s
 			} else {
 				deniedResources = append(deniedResources, r.Name)
 			}
+		case credentials.KubernetesConfigMapType:
+			// ConfigMap references are only used for CA bundles (from BackendTLSPolicy). Like KubernetesSecretType,
+			// we allow same namespace references if authorized. Additionally, Gateways may read ConfigMaps referenced
+			// by a BackendTLSPolicy applied to them.
+			if (sameNamespace && isAuthorized()) ||
+				(push != nil && push.ReferenceAllowed(gvk.ConfigMap, r.ResourceName, proxy.VerifiedIdentity.Namespace)) {
+				allowedResources = append(allowedResources, r)
+			} else {
+				deniedResources = append(deniedResources, r.Name)
+			}
 		case credentials.KubernetesSecretType:
 			// For Kubernetes, we require the secret to be in the same namespace as the proxy and for it to be
 			// authorized for access.
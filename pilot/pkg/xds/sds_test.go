@@ -31,6 +31,7 @@ import (
 
 	meshconfig "istio.io/api/mesh/v1alpha1"
 	credentials "istio.io/istio/pilot/pkg/credentials/kube"
+	"istio.io/istio/pilot/pkg/features"
 	"istio.io/istio/pilot/pkg/model"
 	v3 "istio.io/istio/pilot/pkg/xds/v3"
 	"istio.io/istio/pilot/test/xds"
@@ -39,6 +40,7 @@ import (
 	"istio.io/istio/pkg/config/schema/kind"
 	"istio.io/istio/pkg/kube"
 	"istio.io/istio/pkg/spiffe"
+	"istio.io/istio/pkg/test"
 	"istio.io/istio/pkg/test/env"
 	"istio.io/istio/pkg/util/sets"
 	xdsserver "istio.io/istio/pkg/xds"
@@ -82,6 +84,22 @@ var (
 	genericMtlsCertSplitCa = makeSecret("generic-mtls-split-cacert", map[string]string{
 		credentials.GenericScrtCaCert: readFile(filepath.Join(certDir, "mountedcerts-client/root-cert.pem")),
 	})
+	caConfigMap = &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "ca-bundle",
+			Namespace: "istio-system",
+		},
+		Data: map[string]string{
+			credentials.ConfigMapCaCert: readFile(filepath.Join(certDir, "dns/root-cert.pem")),
+		},
+	}
+	caConfigMapOtherNamespace = &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "ca-bundle",
+			Namespace: "default",
+		},
+		Data: caConfigMap.Data,
+	}
 )
 
 func readFile(name string) string {
@@ -90,6 +108,8 @@ func readFile(name string) string {
 }
 
 func TestGenerateSDS(t *testing.T) {
+	// BackendTLSPolicy, and so ConfigMap credentials, require alpha Gateway API support
+	test.SetForTest(t, &features.EnableAlphaGatewayAPI, true)
 	type Expected struct {
 		Key    string
 		Cert   string
@@ -282,6 +302,36 @@ func TestGenerateSDS(t *testing.T) {
 				},
 			},
 		},
+		{
+			name:      "configmap",
+			proxy:     &model.Proxy{VerifiedIdentity: &spiffe.Identity{Namespace: "istio-system"}},
+			resources: []string{"configmap://istio-system/ca-bundle-cacert", "configmap://istio-system/not-found-cacert"},
+			request:   &model.PushRequest{Full: true},
+			expect: map[string]Expected{
+				"configmap://istio-system/ca-bundle-cacert": {
+					CaCert: caConfigMap.Data[credentials.ConfigMapCaCert],
+				},
+			},
+		},
+		{
+			// Cross namespace ConfigMaps are only allowed when referenced by a BackendTLSPolicy for the Gateway
+			name:      "configmap cross namespace",
+			proxy:     &model.Proxy{VerifiedIdentity: &spiffe.Identity{Namespace: "istio-system"}, Type: model.Router},
+			resources: []string{"configmap://default/ca-bundle-cacert"},
+			request:   &model.PushRequest{Full: true},
+			expect:    map[string]Expected{},
+		},
+		{
+			// proxy without authorization
+			name:      "configmap unauthorized",
+			proxy:     &model.Proxy{VerifiedIdentity: &spiffe.Identity{Namespace: "istio-system"}},
+			resources: []string{"configmap://istio-system/ca-bundle-cacert"},
+			request:   &model.PushRequest{Full: true},
+			expect:    map[string]Expected{},
+			accessReviewResponse: func(action k8stesting.Action) (bool, runtime.Object, error) {
+				return true, nil, errors.New("not authorized")
+			},
+		},
 		{
 			// If an unknown resource is request, we return all the ones we do know about
 			name:      "unknown",
@@ -315,7 +365,9 @@ func TestGenerateSDS(t *testing.T) {
 			}
 			tt.proxy.Metadata.ClusterID = constants.DefaultClusterName
 			s := xds.NewFakeDiscoveryServer(t, xds.FakeOptions{
-				KubernetesObjects: []runtime.Object{genericCert, genericMtlsCert, genericMtlsCertCrl, genericMtlsCertSplit, genericMtlsCertSplitCa},
+				KubernetesObjects: []runtime.Object{
+					genericCert, genericMtlsCert, genericMtlsCertCrl, genericMtlsCertSplit, genericMtlsCertSplitCa, caConfigMap, caConfigMapOtherNamespace,
+				},
 			})
 			cc := s.KubeClient().Kube().(*fake.Clientset)
 
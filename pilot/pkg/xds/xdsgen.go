@@ -158,6 +158,7 @@ func (s *DiscoveryServer) pushXds(con *Connection, w *model.WatchedResource, req
 		}
 		return err
 	}
+	con.pushHistory.add(w.TypeUrl, len(res), 0, configSize, t0)
 
 	switch {
 	case !req.Full:
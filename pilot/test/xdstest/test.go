@@ -16,26 +16,16 @@ package xdstest
 
 import (
 	listener "github.com/envoyproxy/go-control-plane/envoy/config/listener/v3"
+
+	"istio.io/istio/pilot/pkg/networking/util"
 )
 
 // EvaluateListenerFilterPredicates runs through the ListenerFilterChainMatchPredicate logic
 // This is exposed for testing only, and should not be used in XDS generation code
 func EvaluateListenerFilterPredicates(predicate *listener.ListenerFilterChainMatchPredicate, port int) bool {
-	if predicate == nil {
-		return true
-	}
-	switch r := predicate.Rule.(type) {
-	case *listener.ListenerFilterChainMatchPredicate_NotMatch:
-		return !EvaluateListenerFilterPredicates(r.NotMatch, port)
-	case *listener.ListenerFilterChainMatchPredicate_OrMatch:
-		matches := false
-		for _, r := range r.OrMatch.Rules {
-			matches = matches || EvaluateListenerFilterPredicates(r, port)
-		}
-		return matches
-	case *listener.ListenerFilterChainMatchPredicate_DestinationPortRange:
-		return int32(port) >= r.DestinationPortRange.GetStart() && int32(port) < r.DestinationPortRange.GetEnd()
-	default:
-		panic("unsupported predicate")
+	matches, err := util.EvaluateListenerFilterPredicates(predicate, port)
+	if err != nil {
+		panic(err)
 	}
+	return matches
 }
@@ -470,6 +470,17 @@ var testGrid = []testCase{
 			{msg.JwtClaimBasedRoutingWithoutRequestAuthN, "VirtualService foo"},
 		},
 	},
+	{
+		name:       "virtualServiceProxylessGRPC",
+		inputFiles: []string{"testdata/virtualservice_proxylessgrpc.yaml"},
+		analyzer:   &virtualservice.ProxylessGRPCAnalyzer{},
+		expected: []message{
+			{msg.UnsupportedProxylessGRPCField, "VirtualService default/unsupported"},
+			{msg.UnsupportedProxylessGRPCField, "VirtualService default/unsupported"},
+			{msg.UnsupportedProxylessGRPCField, "VirtualService default/unsupported"},
+			{msg.UnsupportedProxylessGRPCField, "VirtualService default/tcp"},
+		},
+	},
 	{
 		name:       "virtualServiceInternalGatewayRef",
 		inputFiles: []string{"testdata/virtualservice_internal_gateway_ref.yaml"},
@@ -529,6 +540,21 @@ var testGrid = []testCase{
 			{msg.NoMatchingWorkloadsFound, "AuthorizationPolicy test-ambient/no-workload"},
 		},
 	},
+	{
+		name: "authorizationpolicies rules",
+		inputFiles: []string{
+			"testdata/authorizationpolicies-rules.yaml",
+		},
+		analyzer: &authz.AuthorizationPolicyRulesAnalyzer{},
+		expected: []message{
+			{msg.UnreachableAuthorizationPolicyRule, "AuthorizationPolicy httpbin/allow-admin"},
+			{msg.UnreachableAuthorizationPolicyRule, "AuthorizationPolicy httpbin/allow-ip"},
+			{msg.UnreachableAuthorizationPolicyRule, "AuthorizationPolicy httpbin/contradictory"},
+			{msg.UnreachableAuthorizationPolicyRule, "AuthorizationPolicy httpbin/contradictory"},
+			{msg.UnreachableAuthorizationPolicyRule, "AuthorizationPolicy httpbin/unexposed-ports"},
+			{msg.AuthorizationPolicyAllowsAll, "AuthorizationPolicy httpbin/allow-all"},
+		},
+	},
 	{
 		name: "destinationrule with no cacert, simple at destinationlevel",
 		inputFiles: []string{
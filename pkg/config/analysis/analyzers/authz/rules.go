@@ -0,0 +1,500 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package authz
+
+import (
+	"fmt"
+	"net/netip"
+	"strconv"
+	"strings"
+
+	v1 "k8s.io/api/core/v1"
+	klabels "k8s.io/apimachinery/pkg/labels"
+
+	"istio.io/api/annotation"
+	"istio.io/api/mesh/v1alpha1"
+	"istio.io/api/security/v1beta1"
+	"istio.io/istio/pkg/config"
+	"istio.io/istio/pkg/config/analysis"
+	"istio.io/istio/pkg/config/analysis/analyzers/util"
+	"istio.io/istio/pkg/config/analysis/msg"
+	"istio.io/istio/pkg/config/resource"
+	"istio.io/istio/pkg/config/schema/gvk"
+	"istio.io/istio/pkg/slices"
+	"istio.io/istio/pkg/util/sets"
+)
+
+// AuthorizationPolicyRulesAnalyzer checks for authorization policy rules that can never match a request, and for
+// ALLOW policies that allow all requests.
+type AuthorizationPolicyRulesAnalyzer struct{}
+
+var _ analysis.Analyzer = &AuthorizationPolicyRulesAnalyzer{}
+
+func (a *AuthorizationPolicyRulesAnalyzer) Metadata() analysis.Metadata {
+	return analysis.Metadata{
+		Name:        "auth.AuthorizationPolicyRulesAnalyzer",
+		Description: "Checks for authorization policy rules that can never match, and ALLOW policies that allow all requests",
+		Inputs: []config.GroupVersionKind{
+			gvk.MeshConfig,
+			gvk.AuthorizationPolicy,
+			gvk.Pod,
+			gvk.Service,
+		},
+	}
+}
+
+type policyInfo struct {
+	r      *resource.Instance
+	policy *v1beta1.AuthorizationPolicy
+	// meshWide is true if the policy is in the root namespace.
+	meshWide bool
+}
+
+func (p policyInfo) namespace() string {
+	return p.r.Metadata.FullName.Namespace.String()
+}
+
+// attachedToWorkloads returns true if the policy applies using a selector, rather than attaching to a waypoint or gateway.
+func (p policyInfo) attachedToWorkloads() bool {
+	return p.policy.GetTargetRef() == nil && len(p.policy.GetTargetRefs()) == 0
+}
+
+func (p policyInfo) dryRun() bool {
+	v, err := strconv.ParseBool(p.r.Metadata.Annotations[annotation.IoIstioDryRun.Name])
+	return err == nil && v
+}
+
+func (a *AuthorizationPolicyRulesAnalyzer) Analyze(c analysis.Context) {
+	rootNamespace := ""
+	c.ForEach(gvk.MeshConfig, func(r *resource.Instance) bool {
+		rootNamespace = r.Message.(*v1alpha1.MeshConfig).GetRootNamespace()
+		return r.Metadata.FullName.Name != util.MeshConfigName
+	})
+	var policies []policyInfo
+	c.ForEach(gvk.AuthorizationPolicy, func(r *resource.Instance) bool {
+		ns := r.Metadata.FullName.Namespace.String()
+		policies = append(policies, policyInfo{
+			r:        r,
+			policy:   r.Message.(*v1beta1.AuthorizationPolicy),
+			meshWide: rootNamespace != "" && ns == rootNamespace,
+		})
+		return true
+	})
+	var denies []policyInfo
+	for _, p := range policies {
+		if p.policy.Action == v1beta1.AuthorizationPolicy_DENY && !p.dryRun() && p.attachedToWorkloads() {
+			denies = append(denies, p)
+		}
+	}
+	workloads := initWorkloadPorts(c)
+
+	for _, p := range policies {
+		if p.policy.Action == v1beta1.AuthorizationPolicy_ALLOW && !p.dryRun() {
+			for i, rule := range p.policy.Rules {
+				if rule != nil && len(rule.From) == 0 && len(rule.To) == 0 && len(rule.When) == 0 {
+					c.Report(gvk.AuthorizationPolicy, msg.NewAuthorizationPolicyAllowsAll(p.r, i))
+				}
+			}
+		}
+
+		var exposed sets.Set[string]
+		if p.attachedToWorkloads() {
+			exposed = workloads.exposedPorts(p)
+		}
+		for i, rule := range p.policy.Rules {
+			if rule == nil {
+				continue
+			}
+			reason := contradictoryRule(rule)
+			if reason == "" && p.policy.Action == v1beta1.AuthorizationPolicy_ALLOW && p.attachedToWorkloads() {
+				reason = shadowedRule(p, rule, denies)
+			}
+			if reason == "" && len(exposed) > 0 {
+				reason = unexposedPortsRule(rule, exposed)
+			}
+			if reason != "" {
+				c.Report(gvk.AuthorizationPolicy, msg.NewUnreachableAuthorizationPolicyRule(p.r, i, reason))
+			}
+		}
+	}
+}
+
+// contradictoryRule returns the reason a rule can never match due to conflicting conditions, if any.
+func contradictoryRule(rule *v1beta1.Rule) string {
+	if len(rule.From) > 0 {
+		var reasons []string
+		for i, from := range rule.From {
+			if r := contradictorySource(from.GetSource()); r != "" {
+				reasons = append(reasons, fmt.Sprintf("from[%d] has contradictory %s", i, r))
+			}
+		}
+		if len(reasons) == len(rule.From) {
+			return strings.Join(reasons, ", ")
+		}
+	}
+	if len(rule.To) > 0 {
+		var reasons []string
+		for i, to := range rule.To {
+			if r := contradictoryOperation(to.GetOperation()); r != "" {
+				reasons = append(reasons, fmt.Sprintf("to[%d] has contradictory %s", i, r))
+			}
+		}
+		if len(reasons) == len(rule.To) {
+			return strings.Join(reasons, ", ")
+		}
+	}
+	for i, when := range rule.When {
+		if excludesAll(when.Values, when.NotValues, coversString) {
+			return fmt.Sprintf("when[%d] has contradictory values and notValues for %s", i, when.Key)
+		}
+	}
+	return ""
+}
+
+func contradictorySource(s *v1beta1.Source) string {
+	switch {
+	case excludesAll(s.GetPrincipals(), s.GetNotPrincipals(), coversString):
+		return "principals and notPrincipals"
+	case excludesAll(s.GetRequestPrincipals(), s.GetNotRequestPrincipals(), coversString):
+		return "requestPrincipals and notRequestPrincipals"
+	case excludesAll(s.GetNamespaces(), s.GetNotNamespaces(), coversString):
+		return "namespaces and notNamespaces"
+	case excludesAll(s.GetIpBlocks(), s.GetNotIpBlocks(), coversCIDR):
+		return "ipBlocks and notIpBlocks"
+	case excludesAll(s.GetRemoteIpBlocks(), s.GetNotRemoteIpBlocks(), coversCIDR):
+		return "remoteIpBlocks and notRemoteIpBlocks"
+	}
+	return ""
+}
+
+func contradictoryOperation(o *v1beta1.Operation) string {
+	switch {
+	case excludesAll(o.GetHosts(), o.GetNotHosts(), coversString):
+		return "hosts and notHosts"
+	case excludesAll(o.GetPorts(), o.GetNotPorts(), coversExact):
+		return "ports and notPorts"
+	case excludesAll(o.GetMethods(), o.GetNotMethods(), coversString):
+		return "methods and notMethods"
+	case excludesAll(o.GetPaths(), o.GetNotPaths(), coversString):
+		return "paths and notPaths"
+	}
+	return ""
+}
+
+// excludesAll returns true if every value is also excluded by notValues, so nothing can match.
+func excludesAll(values, notValues []string, covers func(pattern, value string) bool) bool {
+	if len(values) == 0 {
+		return false
+	}
+	for _, v := range values {
+		if slices.FindFunc(notValues, func(n string) bool { return covers(n, v) }) == nil {
+			return false
+		}
+	}
+	return true
+}
+
+// shadowedRule returns the reason an ALLOW rule can never match because a DENY policy for the same workloads denies
+// everything it matches, if any. DENY policies are always evaluated before ALLOW policies.
+func shadowedRule(allow policyInfo, rule *v1beta1.Rule, denies []policyInfo) string {
+	for _, deny := range denies {
+		if !scopeCovers(deny, allow) {
+			continue
+		}
+		for i, denyRule := range deny.policy.Rules {
+			if denyRule != nil && ruleCovers(denyRule, rule) {
+				return fmt.Sprintf("it is shadowed by rule %d of DENY policy %s", i, deny.r.Metadata.FullName)
+			}
+		}
+	}
+	return ""
+}
+
+// scopeCovers returns true if the DENY policy applies to every workload the other policy applies to.
+func scopeCovers(deny, other policyInfo) bool {
+	if !deny.meshWide && (other.meshWide || deny.namespace() != other.namespace()) {
+		return false
+	}
+	for k, v := range deny.policy.GetSelector().GetMatchLabels() {
+		if ov, f := other.policy.GetSelector().GetMatchLabels()[k]; !f || ov != v {
+			return false
+		}
+	}
+	return true
+}
+
+// ruleCovers returns true if every request matched by rule is also matched by d.
+func ruleCovers(d, rule *v1beta1.Rule) bool {
+	return fromCovers(d.From, rule.From) && toCovers(d.To, rule.To) && whenCovers(d.When, rule.When)
+}
+
+func fromCovers(d, from []*v1beta1.Rule_From) bool {
+	if len(d) == 0 {
+		return true
+	}
+	if len(from) == 0 {
+		return false
+	}
+	for _, f := range from {
+		if slices.FindFunc(d, func(df *v1beta1.Rule_From) bool { return sourceCovers(df.GetSource(), f.GetSource()) }) == nil {
+			return false
+		}
+	}
+	return true
+}
+
+func sourceCovers(d, s *v1beta1.Source) bool {
+	return valuesCover(d.GetPrincipals(), s.GetPrincipals(), coversString) &&
+		notValuesCover(d.GetNotPrincipals(), s.GetNotPrincipals(), coversString) &&
+		valuesCover(d.GetRequestPrincipals(), s.GetRequestPrincipals(), coversString) &&
+		notValuesCover(d.GetNotRequestPrincipals(), s.GetNotRequestPrincipals(), coversString) &&
+		valuesCover(d.GetNamespaces(), s.GetNamespaces(), coversString) &&
+		notValuesCover(d.GetNotNamespaces(), s.GetNotNamespaces(), coversString) &&
+		valuesCover(d.GetIpBlocks(), s.GetIpBlocks(), coversCIDR) &&
+		notValuesCover(d.GetNotIpBlocks(), s.GetNotIpBlocks(), coversCIDR) &&
+		valuesCover(d.GetRemoteIpBlocks(), s.GetRemoteIpBlocks(), coversCIDR) &&
+		notValuesCover(d.GetNotRemoteIpBlocks(), s.GetNotRemoteIpBlocks(), coversCIDR)
+}
+
+func toCovers(d, to []*v1beta1.Rule_To) bool {
+	if len(d) == 0 {
+		return true
+	}
+	if len(to) == 0 {
+		return false
+	}
+	for _, t := range to {
+		if slices.FindFunc(d, func(dt *v1beta1.Rule_To) bool { return operationCovers(dt.GetOperation(), t.GetOperation()) }) == nil {
+			return false
+		}
+	}
+	return true
+}
+
+func operationCovers(d, o *v1beta1.Operation) bool {
+	return valuesCover(d.GetHosts(), o.GetHosts(), coversString) &&
+		notValuesCover(d.GetNotHosts(), o.GetNotHosts(), coversString) &&
+		valuesCover(d.GetPorts(), o.GetPorts(), coversExact) &&
+		notValuesCover(d.GetNotPorts(), o.GetNotPorts(), coversExact) &&
+		valuesCover(d.GetMethods(), o.GetMethods(), coversString) &&
+		notValuesCover(d.GetNotMethods(), o.GetNotMethods(), coversString) &&
+		valuesCover(d.GetPaths(), o.GetPaths(), coversString) &&
+		notValuesCover(d.GetNotPaths(), o.GetNotPaths(), coversString)
+}
+
+func whenCovers(d, when []*v1beta1.Condition) bool {
+	for _, dc := range d {
+		covered := slices.FindFunc(when, func(c *v1beta1.Condition) bool {
+			return c.Key == dc.Key && valuesCover(dc.Values, c.Values, coversString) && notValuesCover(dc.NotValues, c.NotValues, coversString)
+		}) != nil
+		if !covered {
+			return false
+		}
+	}
+	return true
+}
+
+// valuesCover returns true if every value in values is matched by one of the patterns. No patterns matches everything.
+func valuesCover(patterns, values []string, covers func(pattern, value string) bool) bool {
+	if len(patterns) == 0 {
+		return true
+	}
+	if len(values) == 0 {
+		return false
+	}
+	for _, v := range values {
+		if slices.FindFunc(patterns, func(p string) bool { return covers(p, v) }) == nil {
+			return false
+		}
+	}
+	return true
+}
+
+// notValuesCover returns true if everything excluded by notPatterns is also excluded by notValues.
+func notValuesCover(notPatterns, notValues []string, covers func(pattern, value string) bool) bool {
+	for _, np := range notPatterns {
+		if slices.FindFunc(notValues, func(nv string) bool { return covers(nv, np) }) == nil {
+			return false
+		}
+	}
+	return true
+}
+
+// coversString returns true if everything matched by value is also matched by pattern.
+// Both may use the prefix, suffix, or presence wildcards supported by authorization policies.
+func coversString(pattern, value string) bool {
+	switch {
+	case pattern == "*":
+		return true
+	case pattern == value:
+		return true
+	case strings.HasSuffix(pattern, "*"):
+		return !strings.HasPrefix(value, "*") && strings.HasPrefix(strings.TrimSuffix(value, "*"), strings.TrimSuffix(pattern, "*"))
+	case strings.HasPrefix(pattern, "*"):
+		return !strings.HasSuffix(value, "*") && strings.HasSuffix(strings.TrimPrefix(value, "*"), strings.TrimPrefix(pattern, "*"))
+	}
+	return false
+}
+
+func coversExact(pattern, value string) bool {
+	return pattern == value
+}
+
+// coversCIDR returns true if the value IP or CIDR is contained in the pattern IP or CIDR.
+func coversCIDR(pattern, value string) bool {
+	p, err := parseCIDR(pattern)
+	if err != nil {
+		return false
+	}
+	v, err := parseCIDR(value)
+	if err != nil {
+		return false
+	}
+	return p.Bits() <= v.Bits() && p.Contains(v.Addr())
+}
+
+func parseCIDR(s string) (netip.Prefix, error) {
+	if strings.Contains(s, "/") {
+		return netip.ParsePrefix(s)
+	}
+	a, err := netip.ParseAddr(s)
+	if err != nil {
+		return netip.Prefix{}, err
+	}
+	return netip.PrefixFrom(a, a.BitLen()), nil
+}
+
+// unexposedPortsRule returns the reason a rule can never match because none of the ports it matches are exposed, if any.
+func unexposedPortsRule(rule *v1beta1.Rule, exposed sets.Set[string]) string {
+	if len(rule.To) == 0 {
+		return ""
+	}
+	var ports []string
+	for _, to := range rule.To {
+		opPorts := to.GetOperation().GetPorts()
+		if len(opPorts) == 0 || slices.FindFunc(opPorts, exposed.Contains) != nil {
+			return ""
+		}
+		ports = append(ports, opPorts...)
+	}
+	return fmt.Sprintf("ports %s are not exposed by the selected workloads", strings.Join(ports, ", "))
+}
+
+type workload struct {
+	namespace string
+	labels    klabels.Set
+	// ports the workload receives traffic on, or nil if they are not known.
+	ports sets.Set[string]
+}
+
+type workloadPorts []workload
+
+// serviceGroup is the ports of the services in a namespace sharing a selector.
+type serviceGroup struct {
+	selector klabels.Selector
+	ports    []v1.ServicePort
+	// headless is set if any of the services is headless, so clients may reach the pods on any port.
+	headless bool
+}
+
+// initWorkloadPorts builds the set of ports each pod receives traffic on, from its container ports and the target
+// ports of the services selecting it. The ports of pods selected by a headless service, or without any declared
+// ports, are not known, as clients may reach them on any port.
+func initWorkloadPorts(c analysis.Context) workloadPorts {
+	// Index services by namespace and selector, so each pod is only matched against the distinct selectors of its namespace.
+	services := map[string]map[string]*serviceGroup{}
+	c.ForEach(gvk.Service, func(r *resource.Instance) bool {
+		svc := r.Message.(*v1.ServiceSpec)
+		if len(svc.Selector) == 0 {
+			return true
+		}
+		ns := r.Metadata.FullName.Namespace.String()
+		if services[ns] == nil {
+			services[ns] = map[string]*serviceGroup{}
+		}
+		selector := klabels.SelectorFromSet(svc.Selector)
+		group := services[ns][selector.String()]
+		if group == nil {
+			group = &serviceGroup{selector: selector}
+			services[ns][selector.String()] = group
+		}
+		group.ports = append(group.ports, svc.Ports...)
+		group.headless = group.headless || svc.ClusterIP == v1.ClusterIPNone
+		return true
+	})
+
+	var out workloadPorts
+	c.ForEach(gvk.Pod, func(r *resource.Instance) bool {
+		w := workload{
+			namespace: r.Metadata.FullName.Namespace.String(),
+			labels:    klabels.Set(r.Metadata.Labels),
+			ports:     sets.New[string](),
+		}
+		named := map[string]int32{}
+		for _, container := range r.Message.(*v1.PodSpec).Containers {
+			for _, p := range container.Ports {
+				w.ports.Insert(strconv.Itoa(int(p.ContainerPort)))
+				if p.Name != "" {
+					named[p.Name] = p.ContainerPort
+				}
+			}
+		}
+		headless := false
+		for _, group := range services[w.namespace] {
+			if !group.selector.Matches(w.labels) {
+				continue
+			}
+			headless = headless || group.headless
+			for _, p := range group.ports {
+				switch {
+				case p.TargetPort.StrVal != "":
+					if port, f := named[p.TargetPort.StrVal]; f {
+						w.ports.Insert(strconv.Itoa(int(port)))
+					}
+				case p.TargetPort.IntVal != 0:
+					w.ports.Insert(strconv.Itoa(int(p.TargetPort.IntVal)))
+				default:
+					w.ports.Insert(strconv.Itoa(int(p.Port)))
+				}
+			}
+		}
+		if headless || len(w.ports) == 0 {
+			w.ports = nil
+		}
+		out = append(out, w)
+		return true
+	})
+	return out
+}
+
+// exposedPorts returns the ports exposed by any of the workloads selected by the policy.
+// An empty set is returned if no workloads are selected, or the ports of any of them are not known.
+func (w workloadPorts) exposedPorts(p policyInfo) sets.Set[string] {
+	selector := klabels.SelectorFromSet(p.policy.GetSelector().GetMatchLabels())
+	out := sets.New[string]()
+	for _, wl := range w {
+		if !p.meshWide && wl.namespace != p.namespace() {
+			continue
+		}
+		if !selector.Matches(wl.labels) {
+			continue
+		}
+		if wl.ports == nil {
+			return sets.New[string]()
+		}
+		out.Merge(wl.ports)
+	}
+	return out
+}
@@ -0,0 +1,186 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package virtualservice
+
+import (
+	"fmt"
+	"strings"
+
+	"istio.io/api/annotation"
+	"istio.io/api/networking/v1alpha3"
+	"istio.io/istio/pilot/pkg/networking/grpcgen"
+	"istio.io/istio/pkg/config"
+	"istio.io/istio/pkg/config/analysis"
+	"istio.io/istio/pkg/config/analysis/analyzers/util"
+	"istio.io/istio/pkg/config/analysis/msg"
+	"istio.io/istio/pkg/config/resource"
+	"istio.io/istio/pkg/config/schema/gvk"
+	"istio.io/istio/pkg/maps"
+	"istio.io/istio/pkg/slices"
+	"istio.io/istio/pkg/util/sets"
+)
+
+// ProxylessGRPCAnalyzer checks for VirtualService fields that are not supported by proxyless gRPC clients.
+type ProxylessGRPCAnalyzer struct{}
+
+var _ analysis.Analyzer = &ProxylessGRPCAnalyzer{}
+
+// proxylessGRPCTemplates are the injection templates used for proxyless gRPC workloads.
+var proxylessGRPCTemplates = sets.New("grpc-agent", "grpc-simple")
+
+// Metadata implements Analyzer
+func (a *ProxylessGRPCAnalyzer) Metadata() analysis.Metadata {
+	return analysis.Metadata{
+		Name:        "virtualservice.ProxylessGRPCAnalyzer",
+		Description: "Checks the VirtualService does not use fields that are unsupported by proxyless gRPC clients",
+		Inputs: []config.GroupVersionKind{
+			gvk.VirtualService,
+			gvk.Pod,
+		},
+	}
+}
+
+// Analyze implements Analyzer
+func (a *ProxylessGRPCAnalyzer) Analyze(c analysis.Context) {
+	// Find a proxyless gRPC pod in each namespace, which are the clients that would apply the VirtualService.
+	podsByNamespace := map[string]string{}
+	c.ForEach(gvk.Pod, func(r *resource.Instance) bool {
+		templates := strings.Split(r.Metadata.Annotations[annotation.InjectTemplates.Name], ",")
+		if slices.FindFunc(templates, func(t string) bool { return proxylessGRPCTemplates.Contains(strings.TrimSpace(t)) }) == nil {
+			return true
+		}
+		ns := r.Metadata.FullName.Namespace.String()
+		if _, f := podsByNamespace[ns]; !f {
+			podsByNamespace[ns] = r.Metadata.FullName.String()
+		}
+		return true
+	})
+	if len(podsByNamespace) == 0 {
+		return
+	}
+
+	c.ForEach(gvk.VirtualService, func(r *resource.Instance) bool {
+		a.analyze(r, c, podsByNamespace)
+		return true
+	})
+}
+
+func (a *ProxylessGRPCAnalyzer) analyze(r *resource.Instance, c analysis.Context, podsByNamespace map[string]string) {
+	vs := r.Message.(*v1alpha3.VirtualService)
+	if len(vs.Gateways) > 0 && !slices.Contains(vs.Gateways, util.MeshGateway) {
+		// Only applies to gateways, not gRPC clients
+		return
+	}
+	pod := proxylessGRPCClient(vs, r.Metadata.FullName.Namespace.String(), podsByNamespace)
+	if pod == "" {
+		return
+	}
+
+	for _, f := range unsupportedProxylessGRPCFields(vs) {
+		m := msg.NewUnsupportedProxylessGRPCField(r, f.field, pod)
+		if line, ok := util.ErrorLine(r, fmt.Sprintf("{.spec.%s}", f.path)); ok {
+			m.Line = line
+		}
+		c.Report(gvk.VirtualService, m)
+	}
+}
+
+// proxylessGRPCClient returns a proxyless gRPC pod the VirtualService is visible to, if any.
+func proxylessGRPCClient(vs *v1alpha3.VirtualService, vsNamespace string, podsByNamespace map[string]string) string {
+	if len(vs.ExportTo) == 0 || slices.Contains(vs.ExportTo, "*") {
+		// Pick the pod deterministically, as there may be many.
+		namespaces := slices.Sort(maps.Keys(podsByNamespace))
+		return podsByNamespace[namespaces[0]]
+	}
+	for _, ns := range vs.ExportTo {
+		if ns == "." {
+			ns = vsNamespace
+		}
+		if pod, f := podsByNamespace[ns]; f {
+			return pod
+		}
+	}
+	return ""
+}
+
+type unsupportedField struct {
+	// path is the path to the field, used to find its line number.
+	path string
+	// field is a human readable description of the field.
+	field string
+}
+
+// unsupportedProxylessGRPCFields returns the fields of the VirtualService that proxyless gRPC clients ignore.
+// Fault injection, retries (attempts and gRPC status conditions), timeouts, matches, and weighted routes are supported.
+func unsupportedProxylessGRPCFields(vs *v1alpha3.VirtualService) []unsupportedField {
+	var out []unsupportedField
+	add := func(path string) {
+		out = append(out, unsupportedField{path: path, field: path})
+	}
+	for i, h := range vs.Http {
+		prefix := fmt.Sprintf("http[%d].", i)
+		if r := h.Retries; r != nil {
+			if r.PerTryTimeout != nil {
+				add(prefix + "retries.perTryTimeout")
+			}
+			if r.RetryRemoteLocalities != nil {
+				add(prefix + "retries.retryRemoteLocalities")
+			}
+			var unsupported []string
+			for _, cond := range strings.Split(r.RetryOn, ",") {
+				if cond = strings.TrimSpace(cond); cond != "" && !grpcgen.SupportedRetryOn.Contains(cond) {
+					unsupported = append(unsupported, cond)
+				}
+			}
+			if len(unsupported) > 0 {
+				out = append(out, unsupportedField{
+					path:  prefix + "retries.retryOn",
+					field: fmt.Sprintf("%sretries.retryOn (%s)", prefix, strings.Join(unsupported, ",")),
+				})
+			}
+		}
+		if h.CorsPolicy != nil {
+			add(prefix + "corsPolicy")
+		}
+		if h.Mirror != nil {
+			add(prefix + "mirror")
+		}
+		if len(h.Mirrors) > 0 {
+			add(prefix + "mirrors")
+		}
+		if h.Redirect != nil {
+			add(prefix + "redirect")
+		}
+		if h.DirectResponse != nil {
+			add(prefix + "directResponse")
+		}
+		if h.Rewrite != nil {
+			add(prefix + "rewrite")
+		}
+		if h.Headers != nil {
+			add(prefix + "headers")
+		}
+		if h.Delegate != nil {
+			add(prefix + "delegate")
+		}
+	}
+	if len(vs.Tcp) > 0 {
+		add("tcp")
+	}
+	if len(vs.Tls) > 0 {
+		add("tls")
+	}
+	return out
+}
@@ -251,6 +251,18 @@ var (
 	// MultiClusterInconsistentService defines a diag.MessageType for message "MultiClusterInconsistentService".
 	// Description: The services live in different clusters under multi-cluster deployment model are inconsistent
 	MultiClusterInconsistentService = diag.NewMessageType(diag.Warning, "IST0170", "The service %v in namespace %q is inconsistent across clusters %q, which can lead to undefined behaviors. The inconsistent behaviors are: %v.")
+
+	// UnsupportedProxylessGRPCField defines a diag.MessageType for message "UnsupportedProxylessGRPCField".
+	// Description: A VirtualService uses a field that is not supported by proxyless gRPC clients
+	UnsupportedProxylessGRPCField = diag.NewMessageType(diag.Warning, "IST0171", "The field %s is not supported by proxyless gRPC clients, such as pod %s, and will be ignored for them.")
+
+	// UnreachableAuthorizationPolicyRule defines a diag.MessageType for message "UnreachableAuthorizationPolicyRule".
+	// Description: An authorization policy rule can never match a request
+	UnreachableAuthorizationPolicyRule = diag.NewMessageType(diag.Warning, "IST0172", "Rule %d can never match: %s.")
+
+	// AuthorizationPolicyAllowsAll defines a diag.MessageType for message "AuthorizationPolicyAllowsAll".
+	// Description: An ALLOW authorization policy allows all requests to the workloads it selects
+	AuthorizationPolicyAllowsAll = diag.NewMessageType(diag.Warning, "IST0173", "Rule %d allows all requests to the selected workloads, so they are only restricted by CUSTOM and DENY policies.")
 )
 
 // All returns a list of all known message types.
@@ -317,6 +329,9 @@ func All() []*diag.MessageType {
 		UnknownUpgradeCompatibility,
 		UpdateIncompatibility,
 		MultiClusterInconsistentService,
+		UnsupportedProxylessGRPCField,
+		UnreachableAuthorizationPolicyRule,
+		AuthorizationPolicyAllowsAll,
 	}
 }
 
@@ -919,3 +934,32 @@ func NewMultiClusterInconsistentService(r *resource.Instance, serviceName string
 		error,
 	)
 }
+
+// NewUnsupportedProxylessGRPCField returns a new diag.Message based on UnsupportedProxylessGRPCField.
+func NewUnsupportedProxylessGRPCField(r *resource.Instance, field string, pod string) diag.Message {
+	return diag.NewMessage(
+		UnsupportedProxylessGRPCField,
+		r,
+		field,
+		pod,
+	)
+}
+
+// NewUnreachableAuthorizationPolicyRule returns a new diag.Message based on UnreachableAuthorizationPolicyRule.
+func NewUnreachableAuthorizationPolicyRule(r *resource.Instance, rule int, reason string) diag.Message {
+	return diag.NewMessage(
+		UnreachableAuthorizationPolicyRule,
+		r,
+		rule,
+		reason,
+	)
+}
+
+// NewAuthorizationPolicyAllowsAll returns a new diag.Message based on AuthorizationPolicyAllowsAll.
+func NewAuthorizationPolicyAllowsAll(r *resource.Instance, rule int) diag.Message {
+	return diag.NewMessage(
+		AuthorizationPolicyAllowsAll,
+		r,
+		rule,
+	)
+}
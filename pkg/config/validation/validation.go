@@ -2710,8 +2710,12 @@ func validateReadinessProbe(probe *networking.ReadinessProbe) (errs error) {
 			break
 		}
 		errs = appendErrors(errs, agent.ValidatePort(int(h.Port)))
-		if h.Scheme != "" && h.Scheme != string(apimirror.URISchemeHTTPS) && h.Scheme != string(apimirror.URISchemeHTTP) {
-			errs = appendErrors(errs, fmt.Errorf(`httpGet.scheme must be one of %q, %q`, apimirror.URISchemeHTTPS, apimirror.URISchemeHTTP))
+		// GRPC and GRPCS select a gRPC health checking protocol probe, with the path as the service name.
+		switch h.Scheme {
+		case "", string(apimirror.URISchemeHTTPS), string(apimirror.URISchemeHTTP), "GRPC", "GRPCS":
+		default:
+			errs = appendErrors(errs, fmt.Errorf(`httpGet.scheme must be one of %q, %q, %q, %q`,
+				apimirror.URISchemeHTTPS, apimirror.URISchemeHTTP, "GRPC", "GRPCS"))
 		}
 		for _, header := range h.HttpHeaders {
 			if header == nil {
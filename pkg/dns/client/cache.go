@@ -0,0 +1,192 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"strings"
+	"time"
+
+	lru "github.com/hashicorp/golang-lru/v2"
+	"github.com/miekg/dns"
+
+	"istio.io/istio/pkg/slices"
+)
+
+// DefaultCacheMaxTTL bounds how long an upstream answer is kept, regardless of the TTL in the answer.
+const DefaultCacheMaxTTL = 5 * time.Minute
+
+type cacheKey struct {
+	name   string
+	qtype  uint16
+	qclass uint16
+}
+
+type cacheEntry struct {
+	msg      *dns.Msg
+	negative bool
+	stored   time.Time
+	expires  time.Time
+}
+
+// CacheEntry is the debug representation of a cached upstream answer.
+type CacheEntry struct {
+	Name     string   `json:"name"`
+	Type     string   `json:"type"`
+	Rcode    string   `json:"rcode"`
+	Negative bool     `json:"negative,omitempty"`
+	TTL      uint32   `json:"ttl"`
+	Answers  []string `json:"answers,omitempty"`
+}
+
+// answerCache caches upstream DNS responses until their TTL expires. Positive answers are kept for the
+// minimum TTL of their records; NXDOMAIN and NODATA answers are kept for the negative TTL derived from the
+// SOA record in the authority section, as described in RFC 2308. Responses without a SOA are not cached.
+type answerCache struct {
+	entries *lru.Cache[cacheKey, *cacheEntry]
+	maxTTL  time.Duration
+	now     func() time.Time
+}
+
+func newAnswerCache(size int, maxTTL time.Duration) (*answerCache, error) {
+	entries, err := lru.New[cacheKey, *cacheEntry](size)
+	if err != nil {
+		return nil, err
+	}
+	if maxTTL <= 0 {
+		maxTTL = DefaultCacheMaxTTL
+	}
+	return &answerCache{entries: entries, maxTTL: maxTTL, now: time.Now}, nil
+}
+
+func keyFor(q dns.Question) cacheKey {
+	return cacheKey{name: strings.ToLower(q.Name), qtype: q.Qtype, qclass: q.Qclass}
+}
+
+// get returns the cached answer for req, with TTLs adjusted for the time spent in the cache,
+// or nil if there is no live entry.
+func (c *answerCache) get(req *dns.Msg) *dns.Msg {
+	key := keyFor(req.Question[0])
+	e, ok := c.entries.Get(key)
+	if !ok {
+		cacheMisses.Increment()
+		return nil
+	}
+	now := c.now()
+	if !now.Before(e.expires) {
+		c.entries.Remove(key)
+		cacheEntries.Record(float64(c.entries.Len()))
+		cacheMisses.Increment()
+		return nil
+	}
+	if e.negative {
+		cacheHits.With(answerType.Value("negative")).Increment()
+	} else {
+		cacheHits.With(answerType.Value("positive")).Increment()
+	}
+
+	response := e.msg.Copy()
+	response.Id = req.Id
+	response.Question = slices.Clone(req.Question)
+	elapsed := uint32(now.Sub(e.stored) / time.Second)
+	for _, rrs := range [][]dns.RR{response.Answer, response.Ns, response.Extra} {
+		for _, rr := range rrs {
+			h := rr.Header()
+			if h.Ttl > elapsed {
+				h.Ttl -= elapsed
+			} else {
+				h.Ttl = 0
+			}
+		}
+	}
+	if opt := req.IsEdns0(); opt != nil {
+		response.SetEdns0(opt.UDPSize(), opt.Do())
+	}
+	return response
+}
+
+// put stores an upstream response for req, if it is cacheable.
+func (c *answerCache) put(req, response *dns.Msg) {
+	if response == nil || response.Truncated || len(req.Question) == 0 {
+		return
+	}
+	ttl, negative, ok := cacheTTL(response)
+	if !ok || ttl == 0 {
+		return
+	}
+	ttl = min(ttl, uint32(c.maxTTL/time.Second))
+
+	msg := response.Copy()
+	// The EDNS options belong to the exchange with upstream, not to the answer.
+	msg.Extra = slices.FilterInPlace(msg.Extra, func(rr dns.RR) bool {
+		return rr.Header().Rrtype != dns.TypeOPT
+	})
+	now := c.now()
+	if c.entries.Add(keyFor(req.Question[0]), &cacheEntry{
+		msg:      msg,
+		negative: negative,
+		stored:   now,
+		expires:  now.Add(time.Duration(ttl) * time.Second),
+	}) {
+		cacheEvictions.Increment()
+	}
+	cacheEntries.Record(float64(c.entries.Len()))
+}
+
+// dump returns the live entries in the cache, most recently used last.
+func (c *answerCache) dump() []CacheEntry {
+	now := c.now()
+	res := []CacheEntry{}
+	for _, key := range c.entries.Keys() {
+		e, ok := c.entries.Peek(key)
+		if !ok || !now.Before(e.expires) {
+			continue
+		}
+		res = append(res, CacheEntry{
+			Name:     key.name,
+			Type:     dns.TypeToString[key.qtype],
+			Rcode:    dns.RcodeToString[e.msg.Rcode],
+			Negative: e.negative,
+			TTL:      uint32(e.expires.Sub(now) / time.Second),
+			Answers: slices.Map(e.msg.Answer, func(rr dns.RR) string {
+				return rr.String()
+			}),
+		})
+	}
+	return res
+}
+
+// cacheTTL returns how long, in seconds, a response may be cached, and whether it is a negative answer.
+func cacheTTL(response *dns.Msg) (ttl uint32, negative bool, ok bool) {
+	switch response.Rcode {
+	case dns.RcodeSuccess:
+		if len(response.Answer) > 0 {
+			ttl = response.Answer[0].Header().Ttl
+			for _, rr := range response.Answer[1:] {
+				ttl = min(ttl, rr.Header().Ttl)
+			}
+			return ttl, false, true
+		}
+		// NODATA: the name exists but has no records of the requested type.
+	case dns.RcodeNameError:
+	default:
+		return 0, false, false
+	}
+	for _, rr := range response.Ns {
+		if soa, isSOA := rr.(*dns.SOA); isSOA {
+			return min(soa.Hdr.Ttl, soa.Minttl), true, true
+		}
+	}
+	return 0, true, false
+}
@@ -0,0 +1,214 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/miekg/dns"
+	"go.uber.org/atomic"
+
+	"istio.io/istio/pkg/test/util/assert"
+)
+
+func soa(zone string, ttl, minttl uint32) *dns.SOA {
+	return &dns.SOA{
+		Hdr:    dns.RR_Header{Name: zone, Rrtype: dns.TypeSOA, Class: dns.ClassINET, Ttl: ttl},
+		Ns:     "ns." + zone,
+		Mbox:   "admin." + zone,
+		Minttl: minttl,
+	}
+}
+
+func withTTL(rrs []dns.RR, ttl uint32) []dns.RR {
+	for _, rr := range rrs {
+		rr.Header().Ttl = ttl
+	}
+	return rrs
+}
+
+func reply(req *dns.Msg, rcode int, answer []dns.RR, ns ...dns.RR) *dns.Msg {
+	m := new(dns.Msg)
+	m.SetReply(req)
+	m.Rcode = rcode
+	m.Answer = answer
+	m.Ns = ns
+	return m
+}
+
+func TestCacheTTL(t *testing.T) {
+	req := new(dns.Msg).SetQuestion("example.com.", dns.TypeA)
+	cases := []struct {
+		name     string
+		response *dns.Msg
+		ttl      uint32
+		negative bool
+		ok       bool
+	}{
+		{
+			name: "positive uses min record ttl",
+			response: reply(req, dns.RcodeSuccess, append(
+				withTTL(a("example.com.", []netip.Addr{netip.MustParseAddr("1.1.1.1")}), 60),
+				withTTL(a("example.com.", []netip.Addr{netip.MustParseAddr("2.2.2.2")}), 20)...)),
+			ttl: 20,
+			ok:  true,
+		},
+		{
+			name:     "nxdomain uses soa minimum",
+			response: reply(req, dns.RcodeNameError, nil, soa("com.", 900, 60)),
+			ttl:      60,
+			negative: true,
+			ok:       true,
+		},
+		{
+			name:     "nodata uses soa ttl when lower",
+			response: reply(req, dns.RcodeSuccess, nil, soa("example.com.", 10, 60)),
+			ttl:      10,
+			negative: true,
+			ok:       true,
+		},
+		{
+			name:     "negative without soa",
+			response: reply(req, dns.RcodeNameError, nil),
+			negative: true,
+		},
+		{
+			name:     "server failure",
+			response: reply(req, dns.RcodeServerFailure, nil, soa("com.", 900, 60)),
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			ttl, negative, ok := cacheTTL(tt.response)
+			assert.Equal(t, ttl, tt.ttl)
+			assert.Equal(t, negative, tt.negative)
+			assert.Equal(t, ok, tt.ok)
+		})
+	}
+}
+
+func TestAnswerCache(t *testing.T) {
+	now := time.Unix(1000, 0)
+	c, err := newAnswerCache(2, time.Minute)
+	assert.NoError(t, err)
+	c.now = func() time.Time { return now }
+
+	req := new(dns.Msg).SetQuestion("Example.com.", dns.TypeA)
+	c.put(req, reply(req, dns.RcodeSuccess, withTTL(a("example.com.", []netip.Addr{netip.MustParseAddr("1.1.1.1")}), 30)))
+
+	// Lookups are case-insensitive and take the ID and question of the new request.
+	now = now.Add(10 * time.Second)
+	other := new(dns.Msg).SetQuestion("EXAMPLE.com.", dns.TypeA)
+	got := c.get(other)
+	assert.Equal(t, got != nil, true)
+	assert.Equal(t, got.Id, other.Id)
+	assert.Equal(t, got.Question[0].Name, "EXAMPLE.com.")
+	assert.Equal(t, got.Answer[0].Header().Ttl, uint32(20))
+
+	// Different query types are cached separately.
+	assert.Equal(t, c.get(new(dns.Msg).SetQuestion("example.com.", dns.TypeAAAA)) == nil, true)
+
+	// Entries expire with their TTL.
+	now = now.Add(20 * time.Second)
+	assert.Equal(t, c.get(req) == nil, true)
+	assert.Equal(t, len(c.dump()), 0)
+
+	// TTLs are capped at the maximum TTL.
+	c.put(req, reply(req, dns.RcodeSuccess, withTTL(a("example.com.", []netip.Addr{netip.MustParseAddr("1.1.1.1")}), 3600)))
+	assert.Equal(t, c.dump()[0].TTL, uint32(60))
+
+	// Negative answers are cached with the SOA derived TTL.
+	nx := new(dns.Msg).SetQuestion("missing.example.com.", dns.TypeA)
+	c.put(nx, reply(nx, dns.RcodeNameError, nil, soa("example.com.", 300, 5)))
+	got = c.get(nx)
+	assert.Equal(t, got.Rcode, dns.RcodeNameError)
+	assert.Equal(t, got.Ns[0].Header().Ttl, uint32(300))
+	now = now.Add(5 * time.Second)
+	assert.Equal(t, c.get(nx) == nil, true)
+
+	// Truncated and uncacheable responses are ignored.
+	tc := reply(nx, dns.RcodeSuccess, withTTL(a("missing.example.com.", []netip.Addr{netip.MustParseAddr("1.1.1.1")}), 30))
+	tc.Truncated = true
+	c.put(nx, tc)
+	c.put(nx, reply(nx, dns.RcodeServerFailure, nil))
+	assert.Equal(t, c.get(nx) == nil, true)
+
+	// The cache is bounded; the least recently used entry is evicted.
+	for _, name := range []string{"a.example.com.", "b.example.com."} {
+		r := new(dns.Msg).SetQuestion(name, dns.TypeA)
+		c.put(r, reply(r, dns.RcodeSuccess, withTTL(a(name, []netip.Addr{netip.MustParseAddr("1.1.1.1")}), 30)))
+	}
+	dump := c.dump()
+	assert.Equal(t, len(dump), 2)
+	assert.Equal(t, dump[0].Name, "a.example.com.")
+	assert.Equal(t, dump[1].Name, "b.example.com.")
+	assert.Equal(t, dump[1].Type, "A")
+	assert.Equal(t, dump[1].Rcode, "NOERROR")
+	assert.Equal(t, dump[1].Answers, []string{"b.example.com.\t30\tIN\tA\t1.1.1.1"})
+}
+
+func TestDNSCache(t *testing.T) {
+	var queries atomic.Int32
+	mux := dns.NewServeMux()
+	mux.HandleFunc(".", func(w dns.ResponseWriter, req *dns.Msg) {
+		queries.Inc()
+		var resp *dns.Msg
+		if req.Question[0].Name == "cached.example.com." {
+			resp = reply(req, dns.RcodeSuccess, withTTL(a("cached.example.com.", []netip.Addr{netip.MustParseAddr("3.3.3.3")}), 300))
+		} else {
+			resp = reply(req, dns.RcodeNameError, nil, soa("example.com.", 300, 60))
+		}
+		_ = w.WriteMsg(resp)
+	})
+	up := make(chan struct{})
+	upstream := &dns.Server{Addr: "127.0.0.1:0", Net: "udp", Handler: mux, NotifyStartedFunc: func() { close(up) }}
+	go func() {
+		_ = upstream.ListenAndServe()
+	}()
+	<-up
+	t.Cleanup(func() { _ = upstream.Shutdown() })
+
+	server, err := NewLocalDNSServer("ns1", "ns1.svc.cluster.local", "localhost:0", false)
+	assert.NoError(t, err)
+	assert.NoError(t, server.EnableCache(10, 0))
+	server.resolvConfServers = []string{upstream.PacketConn.LocalAddr().String()}
+	server.StartDNS()
+	fillTable(server)
+	t.Cleanup(server.Close)
+
+	client := &dns.Client{Net: "udp", Timeout: 5 * time.Second}
+	for _, name := range []string{"cached.example.com.", "missing.example.com."} {
+		for i := 0; i < 3; i++ {
+			res, _, err := client.Exchange(new(dns.Msg).SetQuestion(name, dns.TypeA), server.dnsProxies[0].Address())
+			assert.NoError(t, err)
+			if name == "cached.example.com." {
+				assert.Equal(t, res.Rcode, dns.RcodeSuccess)
+				assert.Equal(t, res.Answer[0].(*dns.A).A.String(), "3.3.3.3")
+			} else {
+				assert.Equal(t, res.Rcode, dns.RcodeNameError)
+			}
+		}
+	}
+	// Each name is only sent upstream once; later queries are served from the cache.
+	assert.Equal(t, queries.Load(), int32(2))
+	assert.Equal(t, len(server.CacheEntries()), 2)
+
+	// Names in the lookup table are never cached.
+	_, _, err = client.Exchange(new(dns.Msg).SetQuestion("www.google.com.", dns.TypeA), server.dnsProxies[0].Address())
+	assert.NoError(t, err)
+	assert.Equal(t, len(server.CacheEntries()), 2)
+}
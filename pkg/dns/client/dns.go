@@ -58,6 +58,9 @@ type LocalDNSServer struct {
 
 	respondBeforeSync         bool
 	forwardToUpstreamParallel bool
+
+	// cache holds upstream answers until their TTL expires. Nil if caching is disabled.
+	cache *answerCache
 }
 
 // LookupTable is borrowed from https://github.com/coredns/coredns/blob/master/plugin/hosts/hostsfile.go
@@ -225,15 +228,44 @@ func (h *LocalDNSServer) BuildAlternateHosts(nt *dnsProto.NameTable,
 	}
 }
 
+// EnableCache enables caching of upstream answers, keeping at most size entries. Answers are kept
+// for their TTL, capped at maxTTL. It must be called before StartDNS.
+func (h *LocalDNSServer) EnableCache(size int, maxTTL time.Duration) error {
+	cache, err := newAnswerCache(size, maxTTL)
+	if err != nil {
+		return err
+	}
+	h.cache = cache
+	return nil
+}
+
+// CacheEntries returns the upstream answers currently cached, for debugging. It returns nil if
+// caching is disabled.
+func (h *LocalDNSServer) CacheEntries() []CacheEntry {
+	if h.cache == nil {
+		return nil
+	}
+	return h.cache.dump()
+}
+
 // upstream sends the request to the upstream server, with associated logs and metrics
 func (h *LocalDNSServer) upstream(proxy *dnsProxy, req *dns.Msg, hostname string) *dns.Msg {
+	if h.cache != nil {
+		if response := h.cache.get(req); response != nil {
+			log.Debugf("response for hostname %q found in upstream answer cache", hostname)
+			return response
+		}
+	}
 	upstreamRequests.Increment()
 	start := time.Now()
 	// We did not find the host in our internal cache. Query upstream and return the response as is.
 	log.Debugf("response for hostname %q not found in dns proxy, querying upstream", hostname)
-	response := h.queryUpstream(proxy.upstreamClient, req, log)
+	response := h.queryUpstream(proxy, req, log)
 	requestDuration.Record(time.Since(start).Seconds())
 	log.Debugf("upstream response for hostname %q : %v", hostname, response)
+	if h.cache != nil {
+		h.cache.put(req, response)
+	}
 	return response
 }
 
@@ -296,6 +328,11 @@ func (h *LocalDNSServer) ServeDNS(proxy *dnsProxy, w dns.ResponseWriter, req *dn
 		if len(answers) > 0 {
 			roundRobinResponse(response)
 		}
+		// A response to a request with an OPT record must carry one as well (RFC 6891), and it counts
+		// towards the size budget when the response is truncated below.
+		if opt := req.IsEdns0(); opt != nil {
+			response.SetEdns0(ednsSize("udp", opt.UDPSize()), false)
+		}
 		log.Debugf("response for hostname %q (found=true): %v", hostname, response)
 	} else {
 		response = h.upstream(proxy, req, hostname)
@@ -388,15 +425,15 @@ func (h *LocalDNSServer) Close() {
 	}
 }
 
-func (h *LocalDNSServer) queryUpstream(upstreamClient *dns.Client, req *dns.Msg, scope *istiolog.Scope) *dns.Msg {
+func (h *LocalDNSServer) queryUpstream(proxy *dnsProxy, req *dns.Msg, scope *istiolog.Scope) *dns.Msg {
 	if h.forwardToUpstreamParallel {
-		return h.queryUpstreamParallel(upstreamClient, req, scope)
+		return h.queryUpstreamParallel(proxy, req, scope)
 	}
 
 	var response *dns.Msg
 
 	for _, upstream := range h.resolvConfServers {
-		cResponse, _, err := upstreamClient.Exchange(req, upstream)
+		cResponse, err := proxy.exchange(context.Background(), req, upstream)
 		if err == nil {
 			response = cResponse
 			break
@@ -420,7 +457,7 @@ func (h *LocalDNSServer) queryUpstream(upstreamClient *dns.Client, req *dns.Msg,
 //     response—or defer to the operating system, which we have no control over.
 //   - systemd-resolved: which is used as a default resolver in many Linux distributions nowadays also performs parallel
 //     lookups for multiple DNS servers and returns the first successful response.
-func (h *LocalDNSServer) queryUpstreamParallel(upstreamClient *dns.Client, req *dns.Msg, scope *istiolog.Scope) *dns.Msg {
+func (h *LocalDNSServer) queryUpstreamParallel(proxy *dnsProxy, req *dns.Msg, scope *istiolog.Scope) *dns.Msg {
 	// Guarantee that the ctx we use below is done when this function returns.
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
@@ -430,7 +467,7 @@ func (h *LocalDNSServer) queryUpstreamParallel(upstreamClient *dns.Client, req *
 
 	queryOne := func(upstream string) {
 		// Note: After DialContext in ExchangeContext is called, this function cannot be cancelled by context.
-		cResponse, _, err := upstreamClient.ExchangeContext(ctx, req, upstream)
+		cResponse, err := proxy.exchange(ctx, req, upstream)
 		if err == nil {
 			// Only reserve first response and ignore others.
 			select {
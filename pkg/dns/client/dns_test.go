@@ -309,8 +309,9 @@ func testDNS(t *testing.T, d *LocalDNSServer) {
 		{
 			name: "udp: large request",
 			host: "giant.",
-			// Upstream UDP server returns big response, we cannot serve it. Compliant server would truncate it.
-			expectResolutionFailure: dns.RcodeServerFailure,
+			// Upstream UDP server returns big response, we truncate it to what the client supports.
+			expectResolutionFailure: dns.RcodeSuccess,
+			expected:                giantResponse[:29],
 		},
 		{
 			name:     "tcp: large request",
@@ -587,6 +588,141 @@ func makeUpstream(t test.Failer, responses map[string]string) string {
 	return server.Addr
 }
 
+// makeTruncatingUpstream starts an upstream that behaves like a compliant server: answers over UDP
+// are truncated to the size advertised by the request, while answers over TCP are complete.
+func makeTruncatingUpstream(t *testing.T) (addr string, udpQueries, tcpQueries *atomic.Int32) {
+	udpQueries, tcpQueries = atomic.NewInt32(0), atomic.NewInt32(0)
+	handler := func(queries *atomic.Int32, proto string) dns.HandlerFunc {
+		return func(resp dns.ResponseWriter, msg *dns.Msg) {
+			queries.Inc()
+			answer := &dns.Msg{Answer: giantResponse}
+			answer.SetReply(msg)
+			if opt := msg.IsEdns0(); opt != nil {
+				answer.SetEdns0(opt.UDPSize(), false)
+			}
+			answer.Truncate(size(proto, msg))
+			_ = resp.WriteMsg(answer)
+		}
+	}
+	tcp := &dns.Server{Addr: "127.0.0.1:0", Net: "tcp", Handler: handler(tcpQueries, "tcp")}
+	up := make(chan struct{})
+	tcp.NotifyStartedFunc = func() { close(up) }
+	go func() {
+		_ = tcp.ListenAndServe()
+	}()
+	<-up
+	t.Cleanup(func() { _ = tcp.Shutdown() })
+
+	udp := &dns.Server{Addr: tcp.Listener.Addr().String(), Net: "udp", Handler: handler(udpQueries, "udp")}
+	up = make(chan struct{})
+	udp.NotifyStartedFunc = func() { close(up) }
+	go func() {
+		_ = udp.ListenAndServe()
+	}()
+	<-up
+	t.Cleanup(func() { _ = udp.Shutdown() })
+	return udp.PacketConn.LocalAddr().String(), udpQueries, tcpQueries
+}
+
+func TestDNSTruncation(t *testing.T) {
+	upstream, udpQueries, tcpQueries := makeTruncatingUpstream(t)
+	d, err := NewLocalDNSServer("ns1", "ns1.svc.cluster.local", "localhost:0", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d.resolvConfServers = []string{upstream}
+	d.StartDNS()
+	t.Cleanup(d.Close)
+	ips := make([]string, 0, 64)
+	for i := 0; i < 64; i++ {
+		ips = append(ips, fmt.Sprintf("240.0.1.%d", i))
+	}
+	d.UpdateLookupTable(&dnsProto.NameTable{
+		Table: map[string]*dnsProto.NameTable_NameInfo{
+			"headless.ns1.svc.cluster.local": {
+				Ips:       ips,
+				Registry:  "Kubernetes",
+				Namespace: "ns1",
+				Shortname: "headless",
+			},
+		},
+	})
+
+	cases := []struct {
+		name      string
+		host      string
+		net       string
+		ednsSize  uint16
+		answers   int
+		truncated bool
+	}{
+		{name: "mesh udp", host: "headless.ns1.svc.cluster.local.", net: "udp", answers: 29, truncated: true},
+		{name: "mesh udp edns", host: "headless.ns1.svc.cluster.local.", net: "udp", ednsSize: 4096, answers: 64},
+		{name: "mesh udp small edns", host: "headless.ns1.svc.cluster.local.", net: "udp", ednsSize: 100, answers: 28, truncated: true},
+		{name: "mesh tcp", host: "headless.ns1.svc.cluster.local.", net: "tcp", answers: 64},
+		{name: "upstream udp", host: "giant.example.com.", net: "udp", answers: 28, truncated: true},
+		{name: "upstream udp edns", host: "giant.example.com.", net: "udp", ednsSize: 4096, answers: 64},
+		{name: "upstream tcp", host: "giant.example.com.", net: "tcp", answers: 64},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			addr := d.dnsProxies[0].Address()
+			if tt.net == "tcp" {
+				addr = d.dnsProxies[1].Address()
+			}
+			req := new(dns.Msg).SetQuestion(tt.host, dns.TypeA)
+			if tt.ednsSize > 0 {
+				req.SetEdns0(tt.ednsSize, false)
+			}
+			client := dns.Client{Net: tt.net, Timeout: 3 * time.Second, UDPSize: dns.MaxMsgSize}
+			res, _, err := client.Exchange(req, addr)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if res.Rcode != dns.RcodeSuccess {
+				t.Fatalf("unexpected rcode %v", dns.RcodeToString[res.Rcode])
+			}
+			if len(res.Answer) != tt.answers {
+				t.Fatalf("expected %d answers, got %d", tt.answers, len(res.Answer))
+			}
+			if res.Truncated != tt.truncated {
+				t.Fatalf("expected truncated=%v, got %v", tt.truncated, res.Truncated)
+			}
+			if (res.IsEdns0() != nil) != (tt.ednsSize > 0) {
+				t.Fatalf("expected OPT record in response: %v, got %v", tt.ednsSize > 0, res.IsEdns0())
+			}
+		})
+	}
+	// Only the UDP query without EDNS is truncated upstream and retried over TCP; the other TCP
+	// query comes from the TCP client.
+	if got := udpQueries.Load(); got != 2 {
+		t.Fatalf("expected 2 upstream UDP queries, got %d", got)
+	}
+	if got := tcpQueries.Load(); got != 2 {
+		t.Fatalf("expected 2 upstream TCP queries, got %d", got)
+	}
+}
+
+func TestEDNSSize(t *testing.T) {
+	cases := []struct {
+		proto string
+		size  uint16
+		want  uint16
+	}{
+		{"udp", 0, dns.MinMsgSize},
+		{"udp", 100, dns.MinMsgSize},
+		{"udp", 1232, 1232},
+		{"udp", dns.MaxMsgSize, dns.MaxMsgSize},
+		{"tcp", 0, dns.MaxMsgSize},
+		{"tcp", 1232, dns.MaxMsgSize},
+	}
+	for _, tt := range cases {
+		if got := ednsSize(tt.proto, tt.size); got != tt.want {
+			t.Errorf("ednsSize(%q, %d) = %d, want %d", tt.proto, tt.size, got, tt.want)
+		}
+	}
+}
+
 func initDNS(t test.Failer, forwardToUpstreamParallel bool) *LocalDNSServer {
 	srv := makeUpstream(t, map[string]string{"www.bing.com.": "1.1.1.1"})
 	testAgentDNS, err := NewLocalDNSServer("ns1", "ns1.svc.cluster.local", "localhost:0", forwardToUpstreamParallel)
@@ -19,6 +19,8 @@ import (
 )
 
 var (
+	answerType = monitoring.CreateLabel("type")
+
 	requests = monitoring.NewSum(
 		"dns_requests_total",
 		"Total number of DNS requests.",
@@ -34,9 +36,34 @@ var (
 		"Total number of DNS failures.",
 	)
 
+	upstreamTCPRetries = monitoring.NewSum(
+		"dns_upstream_tcp_retries_total",
+		"Total number of truncated upstream UDP answers retried over TCP.",
+	)
+
 	requestDuration = monitoring.NewDistribution(
 		"dns_upstream_request_duration_seconds",
 		"Total time in seconds Istio takes to get DNS response from upstream.",
 		[]float64{.001, .005, 0.01, 0.1, 1, 5},
 	)
+
+	cacheHits = monitoring.NewSum(
+		"dns_cache_hits_total",
+		"Total number of DNS requests answered from the upstream answer cache.",
+	)
+
+	cacheMisses = monitoring.NewSum(
+		"dns_cache_misses_total",
+		"Total number of DNS requests not found in the upstream answer cache.",
+	)
+
+	cacheEvictions = monitoring.NewSum(
+		"dns_cache_evictions_total",
+		"Total number of upstream answers evicted from the cache because it was full.",
+	)
+
+	cacheEntries = monitoring.NewGauge(
+		"dns_cache_entries",
+		"Number of upstream answers currently held in the cache.",
+	)
 )
@@ -15,6 +15,7 @@
 package client
 
 import (
+	"context"
 	"net"
 	"time"
 
@@ -28,22 +29,23 @@ type dnsProxy struct {
 	// This is the upstream Client used to make upstream DNS queries
 	// in case the data is not in our name table.
 	upstreamClient *dns.Client
-	protocol       string
-	resolver       *LocalDNSServer
+	// upstreamTCPClient is used to retry queries whose upstream answer over UDP was truncated.
+	upstreamTCPClient *dns.Client
+	protocol          string
+	resolver          *LocalDNSServer
 }
 
 func newDNSProxy(protocol, addr string, resolver *LocalDNSServer) (*dnsProxy, error) {
 	p := &dnsProxy{
-		serveMux: dns.NewServeMux(),
-		server:   &dns.Server{},
-		upstreamClient: &dns.Client{
-			Net:          protocol,
-			DialTimeout:  5 * time.Second,
-			ReadTimeout:  5 * time.Second,
-			WriteTimeout: 5 * time.Second,
-		},
-		protocol: protocol,
-		resolver: resolver,
+		serveMux:       dns.NewServeMux(),
+		server:         &dns.Server{},
+		upstreamClient: newUpstreamClient(protocol),
+		protocol:       protocol,
+		resolver:       resolver,
+	}
+	p.upstreamTCPClient = p.upstreamClient
+	if protocol == "udp" {
+		p.upstreamTCPClient = newUpstreamClient("tcp")
 	}
 
 	var err error
@@ -62,6 +64,35 @@ func newDNSProxy(protocol, addr string, resolver *LocalDNSServer) (*dnsProxy, er
 	return p, nil
 }
 
+func newUpstreamClient(protocol string) *dns.Client {
+	return &dns.Client{
+		Net:          protocol,
+		DialTimeout:  5 * time.Second,
+		ReadTimeout:  5 * time.Second,
+		WriteTimeout: 5 * time.Second,
+		// Accept upstream answers of any size when the request does not advertise an EDNS buffer size.
+		// The answer is truncated to the size supported by the client before it is sent back.
+		UDPSize: dns.MaxMsgSize,
+	}
+}
+
+// exchange sends req to upstream. If the answer received over UDP is truncated, the query is retried
+// over TCP so that the full answer can be cached and then truncated to the size the client supports.
+func (p *dnsProxy) exchange(ctx context.Context, req *dns.Msg, upstream string) (*dns.Msg, error) {
+	response, _, err := p.upstreamClient.ExchangeContext(ctx, req, upstream)
+	if err != nil || !response.Truncated || p.upstreamClient == p.upstreamTCPClient {
+		return response, err
+	}
+	upstreamTCPRetries.Increment()
+	tcpResponse, _, err := p.upstreamTCPClient.ExchangeContext(ctx, req, upstream)
+	if err != nil {
+		// The truncated answer is still valid; the client may retry over TCP itself.
+		log.Debugf("upstream TCP retry to %s failed: %v", upstream, err)
+		return response, nil
+	}
+	return tcpResponse, nil
+}
+
 func (p *dnsProxy) start() {
 	err := p.server.ActivateAndServe()
 	if err != nil {
@@ -164,6 +164,10 @@ type AgentOptions struct {
 	DNSAddr string
 	// DNSForwardParallel indicates whether the agent should send parallel DNS queries to all upstream nameservers.
 	DNSForwardParallel bool
+	// DNSCacheSize is the maximum number of upstream DNS answers the agent caches. Zero disables the cache.
+	DNSCacheSize int
+	// DNSCacheMaxTTL caps how long an upstream DNS answer is cached, regardless of its TTL.
+	DNSCacheMaxTTL time.Duration
 	// ProxyType is the type of proxy we are configured to handle
 	ProxyType model.NodeType
 	// ProxyNamespace to use for local dns resolution
@@ -542,6 +546,11 @@ func (a *Agent) initLocalDNSServer() (err error) {
 			a.cfg.DNSForwardParallel); err != nil {
 			return err
 		}
+		if a.cfg.DNSCacheSize > 0 {
+			if err = a.localDNSServer.EnableCache(a.cfg.DNSCacheSize, a.cfg.DNSCacheMaxTTL); err != nil {
+				return err
+			}
+		}
 		a.localDNSServer.StartDNS()
 	}
 	return nil
@@ -585,6 +594,14 @@ func (a *Agent) Check() (err error) {
 	return nil
 }
 
+// GetDNSCache returns the upstream DNS answers cached by the agent, used in debugging interface.
+func (a *Agent) GetDNSCache() []dnsClient.CacheEntry {
+	if a.localDNSServer == nil {
+		return nil
+	}
+	return a.localDNSServer.CacheEntries()
+}
+
 // GetDNSTable builds DNS table used in debugging interface.
 func (a *Agent) GetDNSTable() *dnsProto.NameTable {
 	if a.localDNSServer != nil && a.localDNSServer.NameTable() != nil {
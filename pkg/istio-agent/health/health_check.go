@@ -90,7 +90,11 @@ func NewWorkloadHealthChecker(cfg *v1alpha3.ReadinessProbe, envoyProbe ready.Pro
 	var prober Prober
 	switch healthCheckMethod := cfg.HealthCheckMethod.(type) {
 	case *v1alpha3.ReadinessProbe_HttpGet:
-		prober = NewHTTPProber(healthCheckMethod.HttpGet, ipv6)
+		if isGRPCScheme(healthCheckMethod.HttpGet.Scheme) {
+			prober = NewGRPCProber(healthCheckMethod.HttpGet, ipv6)
+		} else {
+			prober = NewHTTPProber(healthCheckMethod.HttpGet, ipv6)
+		}
 	case *v1alpha3.ReadinessProbe_TcpSocket:
 		prober = &TCPProber{Config: healthCheckMethod.TcpSocket}
 	case *v1alpha3.ReadinessProbe_Exec:
@@ -116,6 +120,10 @@ func NewWorkloadHealthChecker(cfg *v1alpha3.ReadinessProbe, envoyProbe ready.Pro
 	}
 }
 
+func isGRPCScheme(scheme string) bool {
+	return scheme == SchemeGRPC || scheme == SchemeGRPCS
+}
+
 func orDefault(val int32, def int32) int32 {
 	if val == 0 {
 		return def
@@ -26,6 +26,7 @@ import (
 	"time"
 
 	"go.uber.org/atomic"
+	grpcHealth "google.golang.org/grpc/health/grpc_health_v1"
 
 	"istio.io/api/networking/v1alpha3"
 	"istio.io/istio/pkg/test"
@@ -227,4 +228,51 @@ func TestWorkloadHealthChecker_PerformApplicationHealthCheck(t *testing.T) {
 			return nil
 		}, retry.Delay(time.Millisecond*10), retry.Timeout(time.Second))
 	})
+	t.Run("grpc", func(t *testing.T) {
+		server, hs, port := createGRPCHealthServer(t, false, grpcHealth.HealthCheckResponse_SERVING)
+		t.Cleanup(server.Stop)
+		grpcHealthChecker := NewWorkloadHealthChecker(&v1alpha3.ReadinessProbe{
+			InitialDelaySeconds: 0,
+			TimeoutSeconds:      1,
+			PeriodSeconds:       1,
+			SuccessThreshold:    1,
+			FailureThreshold:    1,
+			HealthCheckMethod: &v1alpha3.ReadinessProbe_HttpGet{
+				HttpGet: &v1alpha3.HTTPHealthCheckConfig{
+					Path:   "/test.Service",
+					Port:   port,
+					Scheme: "GRPC",
+					Host:   "127.0.0.1",
+				},
+			},
+		}, nil, []string{"127.0.0.1"}, false)
+		// Speed up tests
+		grpcHealthChecker.config.CheckFrequency = time.Millisecond
+		quitChan := test.NewStop(t)
+		expectedGRPCEvents := [2]*ProbeEvent{
+			{Healthy: true},
+			{Healthy: false},
+		}
+
+		eventNum := atomic.NewInt32(0)
+		go grpcHealthChecker.PerformApplicationHealthCheck(func(event *ProbeEvent) {
+			if int(eventNum.Load()) >= len(expectedGRPCEvents) {
+				return
+			}
+			if event.Healthy != expectedGRPCEvents[eventNum.Load()].Healthy {
+				t.Errorf("grpc: got event healthy: %v at idx %v when expected healthy: %v",
+					event.Healthy, eventNum.Load(), expectedGRPCEvents[eventNum.Load()].Healthy)
+			}
+			if eventNum.Inc() == 1 {
+				hs.SetServingStatus("test.Service", grpcHealth.HealthCheckResponse_NOT_SERVING)
+			}
+		}, quitChan)
+
+		retry.UntilSuccessOrFail(t, func() error {
+			if int(eventNum.Load()) != len(expectedGRPCEvents) {
+				return fmt.Errorf("waiting for %v events", len(expectedGRPCEvents)-int(eventNum.Load()))
+			}
+			return nil
+		}, retry.Delay(time.Millisecond*10), retry.Timeout(time.Second))
+	})
 }
@@ -23,8 +23,17 @@ import (
 	"net/url"
 	"os/exec"
 	"strconv"
+	"strings"
 	"time"
 
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	grpcHealth "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	grpcStatus "google.golang.org/grpc/status"
+
 	"istio.io/api/networking/v1alpha3"
 	"istio.io/istio/pilot/cmd/pilot-agent/status"
 	"istio.io/istio/pilot/cmd/pilot-agent/status/ready"
@@ -144,6 +153,105 @@ func (h *HTTPProber) Probe(timeout time.Duration) (ProbeResult, error) {
 	return Unhealthy, fmt.Errorf("status code was not from [200,400), bad code %v", res.StatusCode)
 }
 
+const (
+	// SchemeGRPC selects a gRPC health checking protocol probe for an HTTP health check config.
+	SchemeGRPC = "grpc"
+	// SchemeGRPCS is like SchemeGRPC, but connects to the target over TLS.
+	SchemeGRPCS = "grpcs"
+)
+
+// GRPCProber probes the target with the gRPC health checking protocol (grpc.health.v1.Health/Check).
+// It is configured from an HTTP health check config with a grpc or grpcs scheme; the path, if set,
+// is used as the service name.
+type GRPCProber struct {
+	Config      *v1alpha3.HTTPHealthCheckConfig
+	Service     string
+	DialOptions []grpc.DialOption
+}
+
+var _ Prober = &GRPCProber{}
+
+func NewGRPCProber(cfg *v1alpha3.HTTPHealthCheckConfig, ipv6 bool) *GRPCProber {
+	g := &GRPCProber{
+		Config:  cfg,
+		Service: strings.TrimPrefix(cfg.Path, "/"),
+	}
+
+	var creds credentials.TransportCredentials
+	if cfg.Scheme == SchemeGRPCS {
+		// nolint: gosec
+		// Same as the HTTPS prober, it is just a health check over localhost.
+		creds = credentials.NewTLS(&tls.Config{InsecureSkipVerify: true})
+	} else {
+		creds = insecure.NewCredentials()
+	}
+	d := status.ProbeDialer()
+	d.LocalAddr = status.UpstreamLocalAddressIPv4
+	if ipv6 {
+		d.LocalAddr = status.UpstreamLocalAddressIPv6
+	}
+	userAgent := "istio-probe/1.0"
+	for _, h := range cfg.HttpHeaders {
+		if strings.EqualFold(h.Name, "User-Agent") {
+			userAgent = h.Value
+		}
+	}
+	g.DialOptions = []grpc.DialOption{
+		grpc.WithTransportCredentials(creds),
+		grpc.WithUserAgent(userAgent),
+		grpc.WithContextDialer(func(ctx context.Context, addr string) (net.Conn, error) {
+			return d.DialContext(ctx, "tcp", addr)
+		}),
+	}
+	return g
+}
+
+// Probe will return whether or not the target is healthy (true -> healthy)
+// by calling the gRPC health check service and expecting SERVING.
+func (g *GRPCProber) Probe(timeout time.Duration) (ProbeResult, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	target := net.JoinHostPort(g.Config.Host, strconv.Itoa(int(g.Config.Port)))
+	conn, err := grpc.NewClient(target, g.DialOptions...)
+	if err != nil {
+		return Unknown, err
+	}
+	defer func() {
+		if err := conn.Close(); err != nil {
+			healthCheckLog.Errorf("unable to close gRPC connection: %v", err)
+		}
+	}()
+
+	md := metadata.MD{}
+	for _, h := range g.Config.HttpHeaders {
+		if strings.EqualFold(h.Name, "User-Agent") {
+			continue
+		}
+		md.Append(h.Name, h.Value)
+	}
+	if len(md) > 0 {
+		ctx = metadata.NewOutgoingContext(ctx, md)
+	}
+
+	resp, err := grpcHealth.NewHealthClient(conn).Check(ctx, &grpcHealth.HealthCheckRequest{Service: g.Service})
+	if err != nil {
+		if s, ok := grpcStatus.FromError(err); ok {
+			switch s.Code() {
+			case codes.Unimplemented:
+				return Unhealthy, fmt.Errorf("server does not implement the grpc health protocol (grpc.health.v1.Health): %v", s.Message())
+			case codes.DeadlineExceeded:
+				return Unhealthy, fmt.Errorf("grpc request not finished within timeout: %v", s.Message())
+			}
+		}
+		return Unhealthy, err
+	}
+	if resp.GetStatus() != grpcHealth.HealthCheckResponse_SERVING {
+		return Unhealthy, fmt.Errorf("grpc health check status was %v, expected SERVING", resp.GetStatus())
+	}
+	return Healthy, nil
+}
+
 type TCPProber struct {
 	Config *v1alpha3.TCPHealthCheckConfig
 }
@@ -15,16 +15,24 @@
 package health
 
 import (
+	"crypto/tls"
 	"errors"
 	"net"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"path/filepath"
 	"strconv"
 	"testing"
 	"time"
 
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/health"
+	grpcHealth "google.golang.org/grpc/health/grpc_health_v1"
+
 	"istio.io/api/networking/v1alpha3"
+	"istio.io/istio/pkg/test/env"
 )
 
 func TestHttpProber(t *testing.T) {
@@ -78,6 +86,88 @@ func TestHttpProber(t *testing.T) {
 	}
 }
 
+func TestGRPCProber(t *testing.T) {
+	tests := []struct {
+		desc                string
+		scheme              string
+		path                string
+		tls                 bool
+		status              grpcHealth.HealthCheckResponse_ServingStatus
+		closed              bool
+		expectedProbeResult ProbeResult
+	}{
+		{
+			desc:                "Healthy",
+			scheme:              SchemeGRPC,
+			status:              grpcHealth.HealthCheckResponse_SERVING,
+			expectedProbeResult: Healthy,
+		},
+		{
+			desc:                "Unhealthy - not serving",
+			scheme:              SchemeGRPC,
+			status:              grpcHealth.HealthCheckResponse_NOT_SERVING,
+			expectedProbeResult: Unhealthy,
+		},
+		{
+			desc:                "Healthy - service name",
+			scheme:              SchemeGRPC,
+			path:                "/test.Service",
+			status:              grpcHealth.HealthCheckResponse_SERVING,
+			expectedProbeResult: Healthy,
+		},
+		{
+			desc:                "Unhealthy - unknown service name",
+			scheme:              SchemeGRPC,
+			path:                "/unknown.Service",
+			status:              grpcHealth.HealthCheckResponse_SERVING,
+			expectedProbeResult: Unhealthy,
+		},
+		{
+			desc:                "Healthy - TLS",
+			scheme:              SchemeGRPCS,
+			tls:                 true,
+			status:              grpcHealth.HealthCheckResponse_SERVING,
+			expectedProbeResult: Healthy,
+		},
+		{
+			desc:                "Unhealthy - plaintext to TLS server",
+			scheme:              SchemeGRPC,
+			tls:                 true,
+			status:              grpcHealth.HealthCheckResponse_SERVING,
+			expectedProbeResult: Unhealthy,
+		},
+		{
+			desc:                "Unhealthy - Could not connect to server",
+			scheme:              SchemeGRPC,
+			closed:              true,
+			expectedProbeResult: Unhealthy,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			server, _, port := createGRPCHealthServer(t, tt.tls, tt.status)
+			defer server.Stop()
+			grpcProber := NewGRPCProber(
+				&v1alpha3.HTTPHealthCheckConfig{
+					Path:   tt.path,
+					Port:   port,
+					Host:   "127.0.0.1",
+					Scheme: tt.scheme,
+				}, false)
+
+			if tt.closed {
+				server.Stop()
+			}
+
+			got, err := grpcProber.Probe(time.Second)
+			if got != tt.expectedProbeResult || (got == Healthy) != (err == nil) {
+				t.Errorf("%s: got: %v, expected: %v, got error: %v", tt.desc, got, tt.expectedProbeResult, err)
+			}
+		})
+	}
+}
+
 func TestTcpProber(t *testing.T) {
 	tests := []struct {
 		desc                string
@@ -182,3 +272,34 @@ func createHTTPServer(statusCode int) (*httptest.Server, uint32) {
 
 	return server, uint32(port)
 }
+
+func createGRPCHealthServer(t *testing.T, useTLS bool, status grpcHealth.HealthCheckResponse_ServingStatus) (*grpc.Server, *health.Server, uint32) {
+	t.Helper()
+	var opts []grpc.ServerOption
+	if useTLS {
+		cert, err := tls.LoadX509KeyPair(
+			filepath.Join(env.IstioSrc, "./tests/testdata/certs/pilot/cert-chain.pem"),
+			filepath.Join(env.IstioSrc, "./tests/testdata/certs/pilot/key.pem"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		opts = append(opts, grpc.Creds(credentials.NewTLS(&tls.Config{
+			Certificates: []tls.Certificate{cert},
+			MinVersion:   tls.VersionTLS12,
+		})))
+	}
+	server := grpc.NewServer(opts...)
+	hs := health.NewServer()
+	hs.SetServingStatus("", status)
+	hs.SetServingStatus("test.Service", status)
+	grpcHealth.RegisterHealthServer(server, hs)
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go func() {
+		_ = server.Serve(l)
+	}()
+	return server, hs, uint32(l.Addr().(*net.TCPAddr).Port)
+}
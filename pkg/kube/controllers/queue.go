@@ -101,6 +101,11 @@ func (q Queue) Add(item any) {
 	q.queue.Add(item)
 }
 
+// AddAfter adds an item to the queue after the given delay.
+func (q Queue) AddAfter(item any, duration time.Duration) {
+	q.queue.AddAfter(item, duration)
+}
+
 // AddObject takes an Object and adds the types.NamespacedName associated.
 func (q Queue) AddObject(obj Object) {
 	q.queue.Add(config.NamespacedName(obj))
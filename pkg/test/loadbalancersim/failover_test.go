@@ -0,0 +1,173 @@
+//go:build lbsim
+// +build lbsim
+
+//  Copyright Istio Authors
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package loadbalancersim
+
+import (
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+
+	networking "istio.io/api/networking/v1alpha3"
+	"istio.io/istio/pkg/test/loadbalancersim/loadbalancer"
+	"istio.io/istio/pkg/test/loadbalancersim/locality"
+	"istio.io/istio/pkg/test/loadbalancersim/mesh"
+	"istio.io/istio/pkg/test/loadbalancersim/timeseries"
+)
+
+func TestOutlierDetectionFailover(t *testing.T) {
+	serviceTime := 20 * time.Millisecond
+	clientRPS := 1000
+	clientRequests := 4000
+	failAfter := time.Second
+	window := 250 * time.Millisecond
+	sameZone := locality.Parse("us-east/ny")
+	sameRegion := locality.Parse("us-east/boston")
+	otherRegion := locality.Parse("asia-east/hongkong")
+
+	outlierDetection := func(consecutiveErrors uint32) *networking.OutlierDetection {
+		return &networking.OutlierDetection{
+			Consecutive_5XxErrors: wrapperspb.UInt32(consecutiveErrors),
+			Interval:              durationpb.New(500 * time.Millisecond),
+			BaseEjectionTime:      durationpb.New(10 * time.Second),
+			MaxEjectionPercent:    100,
+		}
+	}
+
+	cases := []struct {
+		name             string
+		outlierDetection *networking.OutlierDetection
+		// failover is whether the failing zone is expected to be drained by the end of the simulation.
+		failover bool
+	}{
+		{
+			name: "no outlier detection",
+		},
+		{
+			name:             "consecutive errors 1",
+			outlierDetection: outlierDetection(1),
+			failover:         true,
+		},
+		{
+			name:             "consecutive errors 5",
+			outlierDetection: outlierDetection(5),
+			failover:         true,
+		},
+	}
+
+	out := "CASE,TIME,LATENCY (AVG),ERROR RATE,IN-ZONE,IN-REGION,OUT-REGION\n"
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			m := mesh.New(mesh.Settings{})
+			defer m.ShutDown()
+
+			client := m.NewClient(mesh.ClientSettings{
+				RPS:      clientRPS,
+				Locality: sameZone,
+			})
+			nodesSameZone := m.NewNodes(3, serviceTime, false, sameZone)
+			nodesSameRegion := m.NewNodes(3, serviceTime, false, sameRegion)
+			nodesOtherRegion := m.NewNodes(3, serviceTime, false, otherRegion)
+
+			lb, err := loadbalancer.NewFromDestinationRule(loadbalancer.DestinationRuleSettings{
+				Client: client,
+				Nodes:  m.Nodes(),
+				DestinationRule: &networking.DestinationRule{
+					Host: "reviews",
+					TrafficPolicy: &networking.TrafficPolicy{
+						OutlierDetection: c.outlierDetection,
+					},
+				},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// Fail every request to the client's zone after a while.
+			start := time.Now()
+			failTimer := time.AfterFunc(failAfter, func() {
+				nodesSameZone.SetErrorRate(1)
+			})
+			defer failTimer.Stop()
+
+			done := make(chan struct{})
+			client.SendRequests(lb, clientRequests, func() {
+				close(done)
+			})
+			<-done
+
+			latency := lb.Latency().Windows(start, window)
+			errs := lb.Errors().Windows(start, window)
+			zone := nodesSameZone.Latency().Windows(start, window)
+			region := nodesSameRegion.Latency().Windows(start, window)
+			other := nodesOtherRegion.Latency().Windows(start, window)
+			var last []float64
+			for i := range latency {
+				total := float64(len(windowAt(zone, i)) + len(windowAt(region, i)) + len(windowAt(other, i)))
+				row := []float64{
+					windowAt(latency, i).Mean(),
+					windowAt(errs, i).Mean(),
+					float64(len(windowAt(zone, i))) / total * 100,
+					float64(len(windowAt(region, i))) / total * 100,
+					float64(len(windowAt(other, i))) / total * 100,
+				}
+				out += fmt.Sprintf("%s,%.3f,%.3f,%.3f,%.3f,%.3f,%.3f\n", c.name, (time.Duration(i) * window).Seconds(),
+					row[0], row[1], row[2], row[3], row[4])
+				// Skip the final window, which may only have a few requests.
+				if i < len(latency)-1 {
+					last = row
+				}
+			}
+			t.Logf("last window: latency=%.3fs errors=%.3f in-zone=%.1f%% in-region=%.1f%% out-region=%.1f%%",
+				last[0], last[1], last[2], last[3], last[4])
+
+			if c.failover {
+				if last[1] > 0.01 {
+					t.Errorf("expected failing hosts to be ejected, got error rate %.3f", last[1])
+				}
+				if last[3] < 90 {
+					t.Errorf("expected traffic to fail over to the same region, got %.1f%%", last[3])
+				}
+			} else if last[1] < 0.1 {
+				t.Errorf("expected failing hosts to keep receiving traffic without outlier detection, got error rate %.3f", last[1])
+			}
+		})
+	}
+
+	outputFile := os.Getenv("LB_SIM_FAILOVER_OUTPUT_FILE")
+	if len(outputFile) == 0 {
+		homeDir, err := os.UserHomeDir()
+		if err != nil {
+			t.Fatal(err)
+		}
+		outputFile = fmt.Sprintf("%s/lb_failover_output.csv", homeDir)
+	}
+	if err := os.WriteFile(outputFile, []byte(out), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func windowAt(windows []timeseries.Data, i int) timeseries.Data {
+	if i < len(windows) {
+		return windows[i]
+	}
+	return nil
+}
@@ -0,0 +1,130 @@
+//  Copyright Istio Authors
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package loadbalancer
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	core "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	endpoint "github.com/envoyproxy/go-control-plane/envoy/config/endpoint/v3"
+
+	networking "istio.io/api/networking/v1alpha3"
+	pilotlb "istio.io/istio/pilot/pkg/networking/core/loadbalancer"
+	"istio.io/istio/pkg/config/mesh"
+	mesh2 "istio.io/istio/pkg/test/loadbalancersim/mesh"
+	"istio.io/istio/pkg/test/loadbalancersim/network"
+)
+
+type DestinationRuleSettings struct {
+	Client *mesh2.Client
+	Nodes  mesh2.Nodes
+	// DestinationRule configures the load balancer, outlier detection and locality load balancing.
+	DestinationRule *networking.DestinationRule
+	// MeshLocalityLbSetting is the mesh wide locality load balancer setting. If nil, the mesh default is used.
+	MeshLocalityLbSetting *networking.LocalityLoadBalancerSetting
+}
+
+// NewFromDestinationRule creates the load balancer that the proxy of the client would use to reach the nodes
+// with the given DestinationRule. Endpoint priorities and locality weights are computed by the same code istiod
+// uses. Priority failover based on labels (failoverPriority) is not simulated.
+func NewFromDestinationRule(s DestinationRuleSettings) (network.Connection, error) {
+	policy := s.DestinationRule.GetTrafficPolicy()
+
+	var newLB func(conns []*WeightedConnection) network.Connection
+	switch simple := policy.GetLoadBalancer().GetSimple(); simple {
+	case networking.LoadBalancerSettings_UNSPECIFIED, networking.LoadBalancerSettings_LEAST_REQUEST:
+		newLB = func(conns []*WeightedConnection) network.Connection {
+			return NewLeastRequest(LeastRequestSettings{
+				Connections:       conns,
+				ActiveRequestBias: 1.0,
+			})
+		}
+	case networking.LoadBalancerSettings_ROUND_ROBIN:
+		newLB = NewRoundRobin
+	default:
+		return nil, fmt.Errorf("unsupported load balancer %v", simple)
+	}
+
+	meshSetting := s.MeshLocalityLbSetting
+	if meshSetting == nil {
+		meshSetting = mesh.DefaultMeshConfig().GetLocalityLbSetting()
+	}
+	localityLB := pilotlb.GetLocalityLbSetting(meshSetting, policy.GetLoadBalancer().GetLocalityLbSetting())
+
+	// Group the nodes by locality, the way istiod groups endpoints.
+	cla := &endpoint.ClusterLoadAssignment{}
+	nodesByLocality := map[*endpoint.LocalityLbEndpoints]mesh2.Nodes{}
+	for _, n := range s.Nodes {
+		var group *endpoint.LocalityLbEndpoints
+		for _, llb := range cla.Endpoints {
+			if llb.Locality.Region == n.Locality().Region && llb.Locality.Zone == n.Locality().Zone {
+				group = llb
+				break
+			}
+		}
+		if group == nil {
+			group = &endpoint.LocalityLbEndpoints{
+				Locality: &core.Locality{Region: n.Locality().Region, Zone: n.Locality().Zone},
+			}
+			cla.Endpoints = append(cla.Endpoints, group)
+		}
+		group.LbEndpoints = append(group.LbEndpoints, &endpoint.LbEndpoint{})
+		nodesByLocality[group] = append(nodesByLocality[group], n)
+	}
+
+	// Failover is only enabled with outlier detection, otherwise unhealthy hosts would never be detected.
+	outlierDetection := OutlierDetectionSettingsFromDestinationRule(policy.GetOutlierDetection())
+	clientLocality := &core.Locality{Region: s.Client.Locality().Region, Zone: s.Client.Locality().Zone}
+	pilotlb.ApplyLocalityLoadBalancer(cla, nil, clientLocality, nil, localityLB, outlierDetection != nil)
+
+	priorities := map[uint32][]*WeightedConnection{}
+	for _, llb := range cla.Endpoints {
+		// Distribute drops the endpoints of localities without weight.
+		if len(llb.LbEndpoints) == 0 {
+			continue
+		}
+		nodes := nodesByLocality[llb]
+		weight := uint32(1)
+		if w := llb.GetLoadBalancingWeight(); w != nil {
+			// Envoy picks a locality by its weight, then a host in it; spread the weight across the hosts.
+			weight = uint32(math.Max(1, math.Round(float64(w.Value)*100/float64(len(nodes)))))
+		}
+		for _, n := range nodes {
+			priorities[llb.Priority] = append(priorities[llb.Priority], &WeightedConnection{
+				Connection: s.Client.Mesh().NewConnection(s.Client, n),
+				Weight:     weight,
+			})
+		}
+	}
+	if len(priorities) == 0 {
+		return nil, fmt.Errorf("no endpoints selected by the destination rule")
+	}
+
+	levels := make([]uint32, 0, len(priorities))
+	for p := range priorities {
+		levels = append(levels, p)
+	}
+	sort.Slice(levels, func(i, j int) bool { return levels[i] < levels[j] })
+	ps := PrioritySettings{
+		OutlierDetection: outlierDetection,
+		NewLB:            newLB,
+	}
+	for _, p := range levels {
+		ps.Priorities = append(ps.Priorities, priorities[p])
+	}
+	return NewPriority(ps), nil
+}
@@ -0,0 +1,207 @@
+//  Copyright Istio Authors
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package loadbalancer
+
+import (
+	"math"
+	"math/rand"
+	"strings"
+	"sync"
+
+	"istio.io/istio/pkg/test/loadbalancersim/network"
+	"istio.io/istio/pkg/test/loadbalancersim/timeseries"
+)
+
+// overprovisioningFactor is the Envoy default, allowing a priority to lose some hosts before it sheds load.
+const overprovisioningFactor = 1.4
+
+type PrioritySettings struct {
+	// Priorities are the connections of each priority level, from the highest (0) to the lowest.
+	Priorities [][]*WeightedConnection
+	// OutlierDetection ejects failing connections from the pool. If nil, all connections are always healthy.
+	OutlierDetection *OutlierDetectionSettings
+	// NewLB creates the load balancer for the healthy connections of a priority.
+	NewLB func(conns []*WeightedConnection) network.Connection
+}
+
+// NewPriority creates a load balancer that distributes requests across priority levels based on their health,
+// like Envoy does, and balances requests within a priority with a load balancer created by NewLB.
+func NewPriority(s PrioritySettings) network.Connection {
+	var all []network.Connection
+	for _, conns := range s.Priorities {
+		for _, c := range conns {
+			all = append(all, c.Connection)
+		}
+	}
+
+	lb := &priorityLB{
+		helper: network.NewConnectionHelper("PriorityLB"),
+		newLB:  s.NewLB,
+	}
+	if s.OutlierDetection != nil {
+		lb.detector = newOutlierDetector(*s.OutlierDetection, all)
+	}
+
+	for _, conns := range s.Priorities {
+		level := &priorityLevel{lbs: map[string]network.Connection{}}
+		for _, c := range conns {
+			conn := c.Connection
+			if lb.detector != nil {
+				conn = &detectedConnection{Connection: c.Connection, d: lb.detector}
+			}
+			level.conns = append(level.conns, &WeightedConnection{Connection: conn, Weight: c.Weight})
+			level.hosts = append(level.hosts, c.Connection)
+		}
+		lb.levels = append(lb.levels, level)
+	}
+	return lb
+}
+
+type priorityLevel struct {
+	conns []*WeightedConnection
+	// hosts are the unwrapped connections, as tracked by the outlier detector.
+	hosts []network.Connection
+
+	// lbs caches the load balancer for each set of healthy connections.
+	lbs   map[string]network.Connection
+	mutex sync.Mutex
+}
+
+type priorityLB struct {
+	helper   *network.ConnectionHelper
+	levels   []*priorityLevel
+	detector *outlierDetector
+	newLB    func(conns []*WeightedConnection) network.Connection
+}
+
+func (lb *priorityLB) Name() string {
+	return lb.helper.Name()
+}
+
+func (lb *priorityLB) TotalRequests() uint64 {
+	return lb.helper.TotalRequests()
+}
+
+func (lb *priorityLB) ActiveRequests() uint64 {
+	return lb.helper.ActiveRequests()
+}
+
+func (lb *priorityLB) Latency() *timeseries.Instance {
+	return lb.helper.Latency()
+}
+
+func (lb *priorityLB) Errors() *timeseries.Instance {
+	return lb.helper.Errors()
+}
+
+func (lb *priorityLB) Request(onDone func(err error)) {
+	healthy := make([][]bool, len(lb.levels))
+	health := make([]float64, len(lb.levels))
+	for p, level := range lb.levels {
+		healthy[p] = make([]bool, len(level.hosts))
+		count := 0
+		for i, h := range level.hosts {
+			healthy[p][i] = lb.detector == nil || lb.detector.Healthy(h)
+			if healthy[p][i] {
+				count++
+			}
+		}
+		if len(level.hosts) > 0 {
+			health[p] = math.Min(100, overprovisioningFactor*100*float64(count)/float64(len(level.hosts)))
+		}
+		if lb.detector != nil && count*100 < lb.detector.s.MinHealthPercent*len(level.hosts) {
+			// Panic mode: use all hosts of the priority.
+			for i := range healthy[p] {
+				healthy[p][i] = true
+			}
+		}
+	}
+
+	p := selectPriority(health)
+	selected := lb.levels[p].lbFor(healthy[p], lb.newLB)
+	lb.helper.Request(selected.Request, onDone)
+}
+
+// selectPriority picks a priority level at random, following the priority load distribution of Envoy.
+func selectPriority(health []float64) int {
+	loads := PriorityLoads(health)
+	r := rand.Float64() * 100
+	for p, load := range loads {
+		if r < load {
+			return p
+		}
+		r -= load
+	}
+	// Rounding errors, or no priority has healthy hosts: use the highest priority.
+	for p, load := range loads {
+		if load > 0 {
+			return p
+		}
+	}
+	return 0
+}
+
+// PriorityLoads returns the percentage of requests sent to each priority level, given the health of each
+// level (the percentage of healthy hosts, scaled by the overprovisioning factor and capped at 100).
+// Higher priorities receive as much load as their health allows, and lower priorities the rest. If the total
+// health is below 100, the load is distributed proportionally to the health of each level.
+func PriorityLoads(health []float64) []float64 {
+	loads := make([]float64, len(health))
+	total := 0.0
+	for _, h := range health {
+		total += h
+	}
+	if total == 0 {
+		if len(loads) > 0 {
+			loads[0] = 100
+		}
+		return loads
+	}
+	scale := 1.0
+	if total < 100 {
+		scale = 100 / total
+	}
+	remaining := 100.0
+	for p, h := range health {
+		loads[p] = math.Min(remaining, h*scale)
+		remaining -= loads[p]
+	}
+	return loads
+}
+
+func (l *priorityLevel) lbFor(healthy []bool, newLB func(conns []*WeightedConnection) network.Connection) network.Connection {
+	var key strings.Builder
+	var conns []*WeightedConnection
+	for i, h := range healthy {
+		if h {
+			key.WriteByte('1')
+			conns = append(conns, l.conns[i])
+		} else {
+			key.WriteByte('0')
+		}
+	}
+	if len(conns) == 0 {
+		conns = l.conns
+	}
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	lb, ok := l.lbs[key.String()]
+	if !ok {
+		lb = newLB(conns)
+		l.lbs[key.String()] = lb
+	}
+	return lb
+}
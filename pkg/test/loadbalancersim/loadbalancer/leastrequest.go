@@ -67,7 +67,7 @@ func (lb *unweightedLeastRequest) pick2() (*WeightedConnection, *WeightedConnect
 	return lb.get(index1), lb.get(index2)
 }
 
-func (lb *unweightedLeastRequest) Request(onDone func()) {
+func (lb *unweightedLeastRequest) Request(onDone func(err error)) {
 	if len(lb.conns) == 1 {
 		lb.doRequest(lb.get(0), onDone)
 		return
@@ -108,7 +108,7 @@ func newWeightedLeastRequest(conn *weightedConnections, activeRequestBias float6
 	return lb
 }
 
-func (lb *weightedLeastRequest) Request(onDone func()) {
+func (lb *weightedLeastRequest) Request(onDone func(err error)) {
 	// Pick the next endpoint and re-add it with the updated weight.
 	lb.edfMutex.Lock()
 	selected := lb.edf.PickAndAdd(lb.calcEDFWeight).(*WeightedConnection)
@@ -0,0 +1,167 @@
+//  Copyright Istio Authors
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package loadbalancer
+
+import (
+	"sync"
+	"time"
+
+	networking "istio.io/api/networking/v1alpha3"
+	"istio.io/istio/pkg/test/loadbalancersim/network"
+)
+
+// OutlierDetectionSettings configures consecutive 5xx outlier detection, as Envoy implements it.
+type OutlierDetectionSettings struct {
+	// Consecutive5xxErrors is the number of consecutive errors after which a host is ejected. Zero disables ejection.
+	Consecutive5xxErrors uint32
+	// Interval is the time between ejection sweeps, which return hosts to the pool.
+	Interval time.Duration
+	// BaseEjectionTime is multiplied by the number of times a host was ejected to get its ejection time.
+	BaseEjectionTime time.Duration
+	// MaxEjectionTime caps the ejection time of a host.
+	MaxEjectionTime time.Duration
+	// MaxEjectionPercent is the maximum percentage of hosts that can be ejected at the same time.
+	MaxEjectionPercent int
+	// MinHealthPercent is the panic threshold: below this percentage of healthy hosts in a priority,
+	// all of its hosts are used regardless of their health.
+	MinHealthPercent int
+}
+
+// OutlierDetectionSettingsFromDestinationRule returns the settings for the outlier detection of a DestinationRule,
+// applying the Envoy defaults to unset fields. It returns nil if outlier detection is not configured.
+func OutlierDetectionSettingsFromDestinationRule(od *networking.OutlierDetection) *OutlierDetectionSettings {
+	if od == nil {
+		return nil
+	}
+	s := &OutlierDetectionSettings{
+		Consecutive5xxErrors: 5,
+		Interval:             10 * time.Second,
+		BaseEjectionTime:     30 * time.Second,
+		MaxEjectionTime:      300 * time.Second,
+		MaxEjectionPercent:   10,
+		MinHealthPercent:     int(od.MinHealthPercent),
+	}
+	if od.Consecutive_5XxErrors != nil {
+		s.Consecutive5xxErrors = od.Consecutive_5XxErrors.GetValue()
+	}
+	if od.Interval != nil {
+		s.Interval = od.Interval.AsDuration()
+	}
+	if od.BaseEjectionTime != nil {
+		s.BaseEjectionTime = od.BaseEjectionTime.AsDuration()
+	}
+	if od.MaxEjectionPercent > 0 {
+		s.MaxEjectionPercent = int(od.MaxEjectionPercent)
+	}
+	return s
+}
+
+type hostState struct {
+	consecutiveErrors uint32
+	ejected           bool
+	ejectedAt         time.Time
+	// ejections is the ejection multiplier. It decreases for every interval the host stays healthy.
+	ejections int
+}
+
+// outlierDetector tracks the health of a set of connections from the results of their requests.
+type outlierDetector struct {
+	s OutlierDetectionSettings
+
+	mutex     sync.Mutex
+	hosts     map[network.Connection]*hostState
+	ejected   int
+	nextSweep time.Time
+}
+
+func newOutlierDetector(s OutlierDetectionSettings, conns []network.Connection) *outlierDetector {
+	d := &outlierDetector{
+		s:         s,
+		hosts:     make(map[network.Connection]*hostState, len(conns)),
+		nextSweep: time.Now().Add(s.Interval),
+	}
+	for _, c := range conns {
+		d.hosts[c] = &hostState{}
+	}
+	return d
+}
+
+// Healthy returns whether the connection is currently in the load balancing pool.
+func (d *outlierDetector) Healthy(c network.Connection) bool {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	d.maybeSweep(time.Now())
+	return !d.hosts[c].ejected
+}
+
+func (d *outlierDetector) report(c network.Connection, err error) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	h := d.hosts[c]
+	if err == nil {
+		h.consecutiveErrors = 0
+		return
+	}
+	h.consecutiveErrors++
+	if h.ejected || d.s.Consecutive5xxErrors == 0 || h.consecutiveErrors < d.s.Consecutive5xxErrors {
+		return
+	}
+	h.consecutiveErrors = 0
+	if (d.ejected+1)*100 > d.s.MaxEjectionPercent*len(d.hosts) {
+		// Ejecting this host would exceed the maximum ejection percentage.
+		return
+	}
+	h.ejected = true
+	h.ejectedAt = time.Now()
+	h.ejections++
+	d.ejected++
+}
+
+// maybeSweep returns hosts whose ejection time elapsed to the pool, once per interval.
+func (d *outlierDetector) maybeSweep(now time.Time) {
+	if now.Before(d.nextSweep) {
+		return
+	}
+	d.nextSweep = now.Add(d.s.Interval)
+	for _, h := range d.hosts {
+		if !h.ejected {
+			if h.ejections > 0 {
+				h.ejections--
+			}
+			continue
+		}
+		ejectionTime := d.s.BaseEjectionTime * time.Duration(h.ejections)
+		if d.s.MaxEjectionTime > 0 && ejectionTime > d.s.MaxEjectionTime {
+			ejectionTime = d.s.MaxEjectionTime
+		}
+		if now.Sub(h.ejectedAt) >= ejectionTime {
+			h.ejected = false
+			d.ejected--
+		}
+	}
+}
+
+// detectedConnection reports the result of each request to an outlier detector.
+type detectedConnection struct {
+	network.Connection
+	d *outlierDetector
+}
+
+func (c *detectedConnection) Request(onDone func(err error)) {
+	c.Connection.Request(func(err error) {
+		c.d.report(c.Connection, err)
+		onDone(err)
+	})
+}
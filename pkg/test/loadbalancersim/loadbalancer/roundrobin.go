@@ -47,7 +47,7 @@ type roundRobin struct {
 	nextMutex sync.Mutex
 }
 
-func (lb *roundRobin) Request(onDone func()) {
+func (lb *roundRobin) Request(onDone func(err error)) {
 	// Select the connection to use for this request.
 	lb.nextMutex.Lock()
 	selected := lb.get(lb.next)
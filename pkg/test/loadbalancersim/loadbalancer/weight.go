@@ -55,7 +55,7 @@ func (lb *weightedConnections) get(index int) *WeightedConnection {
 	return lb.conns[index]
 }
 
-func (lb *weightedConnections) doRequest(c *WeightedConnection, onDone func()) {
+func (lb *weightedConnections) doRequest(c *WeightedConnection, onDone func(err error)) {
 	lb.helper.Request(c.Request, onDone)
 }
 
@@ -75,6 +75,10 @@ func (lb *weightedConnections) Latency() *timeseries.Instance {
 	return lb.helper.Latency()
 }
 
+func (lb *weightedConnections) Errors() *timeseries.Instance {
+	return lb.helper.Errors()
+}
+
 type WeightedConnectionFactory func(src *mesh2.Client, n *mesh2.Node) *WeightedConnection
 
 func EquallyWeightedConnectionFactory() WeightedConnectionFactory {
@@ -53,7 +53,7 @@ func (c *Client) SendRequests(conn network.Connection, numRequests int, done fun
 
 			// Send a request
 			wg.Add(1)
-			conn.Request(wg.Done)
+			conn.Request(func(error) { wg.Done() })
 			numRequests--
 
 			if numRequests <= 0 {
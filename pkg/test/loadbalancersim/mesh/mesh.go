@@ -63,7 +63,7 @@ func (m *Instance) NewConnection(src *Client, dest *Node) network.Connection {
 
 	request := dest.Request
 	if networkLatency > time.Duration(0) {
-		request = func(onDone func()) {
+		request = func(onDone func(err error)) {
 			m.networkQ.Schedule(func() {
 				dest.Request(onDone)
 			}, time.Now().Add(networkLatency))
@@ -16,8 +16,11 @@ package mesh
 
 import (
 	"math"
+	"math/rand"
 	"time"
 
+	"go.uber.org/atomic"
+
 	"istio.io/istio/pkg/test/loadbalancersim/locality"
 	"istio.io/istio/pkg/test/loadbalancersim/network"
 	"istio.io/istio/pkg/test/loadbalancersim/timer"
@@ -36,6 +39,7 @@ type Node struct {
 	qLatencyEnabled bool
 	qLength         timeseries.Instance
 	qLatency        timeseries.Instance
+	errorRate       *atomic.Float64
 }
 
 func newNode(name string, serviceTime time.Duration, enableQueueLatency bool, l locality.Instance) *Node {
@@ -45,6 +49,7 @@ func newNode(name string, serviceTime time.Duration, enableQueueLatency bool, l
 		q:               timer.NewQueue(),
 		serviceTime:     serviceTime,
 		qLatencyEnabled: enableQueueLatency,
+		errorRate:       atomic.NewFloat64(0),
 	}
 }
 
@@ -100,12 +105,29 @@ func (n *Node) Latency() *timeseries.Instance {
 	return n.helper.Latency()
 }
 
-func (n *Node) Request(onDone func()) {
-	n.helper.Request(func(wrappedOnDone func()) {
+func (n *Node) Errors() *timeseries.Instance {
+	return n.helper.Errors()
+}
+
+// SetErrorRate sets the fraction of requests, between 0 and 1, that the node fails. Failed requests still
+// take the full request duration, like a server returning a 503 after timing out on a dependency.
+func (n *Node) SetErrorRate(rate float64) {
+	n.errorRate.Store(rate)
+}
+
+func (n *Node) Request(onDone func(err error)) {
+	n.helper.Request(func(wrappedOnDone func(err error)) {
 		deadline := time.Now().Add(n.calcRequestDuration())
 
+		var err error
+		if rate := n.errorRate.Load(); rate > 0 && rand.Float64() < rate {
+			err = network.ErrRequestFailed
+		}
+
 		// Schedule the done function to be called after the deadline.
-		n.q.Schedule(wrappedOnDone, deadline)
+		n.q.Schedule(func() {
+			wrappedOnDone(err)
+		}, deadline)
 	}, onDone)
 }
 
@@ -145,6 +167,21 @@ func (nodes Nodes) QueueLatency() *timeseries.Instance {
 	return &out
 }
 
+func (nodes Nodes) Errors() *timeseries.Instance {
+	var out timeseries.Instance
+	for _, n := range nodes {
+		out.AddAll(n.Errors())
+	}
+	return &out
+}
+
+// SetErrorRate sets the error rate of all nodes.
+func (nodes Nodes) SetErrorRate(rate float64) {
+	for _, n := range nodes {
+		n.SetErrorRate(rate)
+	}
+}
+
 func (nodes Nodes) TotalRequests() uint64 {
 	var out uint64
 	for _, n := range nodes {
@@ -15,18 +15,26 @@
 package network
 
 import (
+	"errors"
+
 	"istio.io/istio/pkg/test/loadbalancersim/timeseries"
 )
 
+// ErrRequestFailed is returned for requests that the server failed with a 5xx response.
+var ErrRequestFailed = errors.New("request failed")
+
 type Connection interface {
 	Name() string
-	Request(onDone func())
+	// Request sends a request, calling onDone with its error, if any, once it completes.
+	Request(onDone func(err error))
 	TotalRequests() uint64
 	ActiveRequests() uint64
 	Latency() *timeseries.Instance
+	// Errors has an observation of 1 for each failed request and 0 for each successful one.
+	Errors() *timeseries.Instance
 }
 
-func NewConnection(name string, request func(onDone func())) Connection {
+func NewConnection(name string, request func(onDone func(err error))) Connection {
 	return &connection{
 		request: request,
 		helper:  NewConnectionHelper(name),
@@ -34,7 +42,7 @@ func NewConnection(name string, request func(onDone func())) Connection {
 }
 
 type connection struct {
-	request func(onDone func())
+	request func(onDone func(err error))
 	helper  *ConnectionHelper
 }
 
@@ -54,6 +62,10 @@ func (c *connection) Latency() *timeseries.Instance {
 	return c.helper.Latency()
 }
 
-func (c *connection) Request(onDone func()) {
+func (c *connection) Errors() *timeseries.Instance {
+	return c.helper.Errors()
+}
+
+func (c *connection) Request(onDone func(err error)) {
 	c.helper.Request(c.request, onDone)
 }
@@ -25,6 +25,7 @@ import (
 type ConnectionHelper struct {
 	name   string
 	hist   timeseries.Instance
+	errs   timeseries.Instance
 	active *atomic.Uint64
 	total  *atomic.Uint64
 }
@@ -53,22 +54,32 @@ func (c *ConnectionHelper) Latency() *timeseries.Instance {
 	return &c.hist
 }
 
-func (c *ConnectionHelper) Request(request func(onDone func()), onDone func()) {
+func (c *ConnectionHelper) Errors() *timeseries.Instance {
+	return &c.errs
+}
+
+func (c *ConnectionHelper) Request(request func(onDone func(err error)), onDone func(err error)) {
 	start := time.Now()
 	c.total.Inc()
 	c.active.Inc()
 
-	wrappedDone := func() {
+	wrappedDone := func(err error) {
 		// Calculate the latency for this request.
-		latency := time.Since(start)
+		tnow := time.Now()
+		latency := tnow.Sub(start)
 
-		// Add the latency observation.
-		c.hist.AddObservation(latency.Seconds(), time.Now())
+		// Add the latency and error observations.
+		c.hist.AddObservation(latency.Seconds(), tnow)
+		failed := 0.0
+		if err != nil {
+			failed = 1
+		}
+		c.errs.AddObservation(failed, tnow)
 
 		c.active.Dec()
 
 		// Invoke the caller's handler.
-		onDone()
+		onDone(err)
 	}
 
 	request(wrappedDone)
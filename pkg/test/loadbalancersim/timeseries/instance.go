@@ -59,6 +59,25 @@ func (ts *Instance) SeriesAsDurationSinceEpoch(epoch time.Time) (Data, []time.Du
 	return ts.data.Copy(), ts.times.asDurationSinceEpoch(epoch)
 }
 
+// Windows splits the observations into consecutive windows of the given width, starting at epoch, and returns
+// the data of each window. Observations before epoch are dropped.
+func (ts *Instance) Windows(epoch time.Time, width time.Duration) []Data {
+	ts.mutex.Lock()
+	defer ts.mutex.Unlock()
+	var out []Data
+	for i, t := range ts.times {
+		if t.Before(epoch) {
+			continue
+		}
+		idx := int(t.Sub(epoch) / width)
+		for len(out) <= idx {
+			out = append(out, nil)
+		}
+		out[idx] = append(out[idx], ts.data[i])
+	}
+	return out
+}
+
 type times []time.Time
 
 func (t times) copy() times {
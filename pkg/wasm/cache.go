@@ -19,6 +19,7 @@ import (
 	"context"
 	"crypto/sha256"
 	"encoding/hex"
+	"errors"
 	"fmt"
 	"net/url"
 	"os"
@@ -30,7 +31,10 @@ import (
 
 	"github.com/google/go-containerregistry/pkg/name"
 
+	"istio.io/istio/pkg/file"
 	"istio.io/istio/pkg/log"
+	"istio.io/istio/pkg/maps"
+	"istio.io/istio/pkg/slices"
 	"istio.io/istio/pkg/util/sets"
 )
 
@@ -42,6 +46,13 @@ const (
 
 	// sha256 scheme prefix
 	sha256SchemePrefix = "sha256:"
+
+	// suffix of the lock file of a module in a shared directory. Lock files are never removed, as another agent
+	// may be waiting on one; removing it would let a later agent lock a new file and download the module concurrently.
+	lockSuffix = ".lock"
+
+	// suffix of the file holding the hex-encoded sha256 of a module in a shared directory
+	checksumSuffix = ".sha256"
 )
 
 // Cache models a Wasm module cache.
@@ -58,9 +69,16 @@ type LocalFileCache struct {
 	checksums map[string]*checksumEntry
 	// http fetcher fetches Wasm module with HTTP get.
 	httpFetcher *HTTPFetcher
+	// verifier checks module signatures, if signature verification is configured.
+	verifier *SignatureVerifier
+	// verifierErr is set if the verification keys could not be loaded; all modules are rejected then.
+	verifierErr error
 
 	// directory path used to store Wasm module.
 	dir string
+	// files indexes the module files in dir by path, so that adding a module does not list the directory.
+	// It is rebuilt on every purge, to pick up the modules added or removed by other agents sharing the directory.
+	files map[string]moduleFile
 
 	// mux is needed because stale Wasm module files will be purged periodically.
 	mux sync.Mutex
@@ -133,6 +151,9 @@ func (o cacheOptions) sanitize() cacheOptions {
 	if o.HTTPRequestMaxRetries != 0 {
 		ret.HTTPRequestMaxRetries = o.HTTPRequestMaxRetries
 	}
+	ret.VerificationKeysPath = o.VerificationKeysPath
+	ret.SharedDir = o.SharedDir
+	ret.MaxCacheSize = o.MaxCacheSize
 
 	return ret
 }
@@ -146,6 +167,9 @@ func NewLocalFileCache(dir string, options Options) *LocalFileCache {
 	wasmLog.Debugf("LocalFileCache is created with the option\n%#v", options)
 
 	cacheOptions := cacheOptions{Options: options}
+	if options.SharedDir != "" {
+		dir = options.SharedDir
+	}
 	cache := &LocalFileCache{
 		httpFetcher:  NewHTTPFetcher(options.HTTPRequestTimeout, options.HTTPRequestMaxRetries),
 		modules:      make(map[moduleKey]*cacheEntry),
@@ -154,6 +178,13 @@ func NewLocalFileCache(dir string, options Options) *LocalFileCache {
 		cacheOptions: cacheOptions.sanitize(),
 		stopChan:     make(chan struct{}),
 	}
+	cache.files = cache.moduleFiles()
+	if cache.VerificationKeysPath != "" {
+		cache.verifier, cache.verifierErr = NewSignatureVerifier(cache.VerificationKeysPath)
+		if cache.verifierErr != nil {
+			wasmLog.Errorf("failed to load Wasm verification keys, all Wasm modules will be rejected: %v", cache.verifierErr)
+		}
+	}
 
 	go func() {
 		cache.purge()
@@ -228,16 +259,43 @@ func (c *LocalFileCache) getOrFetch(key cacheKey, opts GetOptions) (*cacheEntry,
 		return ce, nil
 	}
 	key.checksum = checksum
+	if c.verifierErr != nil {
+		wasmRemoteFetchCount.With(resultTag.Value(signatureFailure)).Increment()
+		return nil, fmt.Errorf("%w: verification keys are not available: %v", ErrSignatureVerification, c.verifierErr)
+	}
 	// Fetch the image now as it is not available in cache.
 	var b []byte         // Byte array of Wasm binary.
 	var dChecksum string // Hex-Encoded sha256 checksum of binary.
 	var binaryFetcher func() ([]byte, error)
+	// verifyStored verifies an OCI module stored in the shared directory by another agent.
+	var verifyStored func([]byte) error
 	insecure := c.allowInsecure(u.Host)
 
 	ctx, cancel := context.WithTimeout(context.Background(), opts.RequestTimeout)
 	defer cancel()
+	// In a shared directory, the module lock is held until the module is stored, so that the agents on a node
+	// download each module only once.
+	unlock := func() {}
+	defer func() { unlock() }()
+	// sharedChecked is set once the module was looked up in the shared directory, with its lock held.
+	sharedChecked := false
 	switch u.Scheme {
 	case "http", "https":
+		if c.SharedDir != "" && key.checksum != "" {
+			unlock = c.lockModule(key.moduleKey)
+			ce, err := c.loadFromSharedDir(key, func(b []byte) error {
+				if sha := sha256.Sum256(b); hex.EncodeToString(sha[:]) != key.checksum {
+					return errChecksumMismatch
+				}
+				if c.verifier != nil {
+					return c.verifyHTTPModule(ctx, key.downloadURL, b, insecure)
+				}
+				return nil
+			})
+			if ce != nil || err != nil {
+				return ce, err
+			}
+		}
 		// Download the Wasm module with http fetcher.
 		b, err = c.httpFetcher.Fetch(ctx, key.downloadURL, insecure)
 		if err != nil {
@@ -251,17 +309,32 @@ func (c *LocalFileCache) getOrFetch(key cacheKey, opts GetOptions) (*cacheEntry,
 	case "oci":
 		imgFetcherOps := ImageFetcherOption{
 			Insecure: insecure,
+			Verifier: c.verifier,
 		}
 		if opts.PullSecret != nil {
 			imgFetcherOps.PullSecret = opts.PullSecret
 		}
-		wasmLog.Debugf("fetching oci image from %s with options: %v", key.downloadURL, imgFetcherOps)
 		fetcher := NewImageFetcher(ctx, imgFetcherOps)
+		if c.SharedDir != "" && key.checksum != "" {
+			// The digest is already known, so a module stored by another agent is used without resolving the image.
+			unlock = c.lockModule(key.moduleKey)
+			sharedChecked = true
+			ce, err := c.loadFromSharedDir(key, func([]byte) error {
+				return fetcher.verifyDigest(u.Host+u.Path, key.checksum)
+			})
+			if ce != nil || err != nil {
+				return ce, err
+			}
+		}
+		wasmLog.Debugf("fetching oci image from %s with options: %v", key.downloadURL, imgFetcherOps)
 		binaryFetcher, dChecksum, err = fetcher.PrepareFetch(u.Host + u.Path)
 		if err != nil {
 			wasmRemoteFetchCount.With(resultTag.Value(manifestFailure)).Increment()
 			return nil, fmt.Errorf("could not fetch Wasm OCI image: %v", err)
 		}
+		verifyStored = func([]byte) error {
+			return fetcher.verifySignature()
+		}
 	default:
 		return nil, fmt.Errorf("unsupported Wasm module downloading URL scheme: %v", u.Scheme)
 	}
@@ -278,11 +351,27 @@ func (c *LocalFileCache) getOrFetch(key cacheKey, opts GetOptions) (*cacheEntry,
 	}
 
 	if binaryFetcher != nil {
+		if c.SharedDir != "" && !sharedChecked {
+			unlock = c.lockModule(key.moduleKey)
+			ce, err := c.loadFromSharedDir(key, verifyStored)
+			if ce != nil || err != nil {
+				return ce, err
+			}
+		}
 		b, err = binaryFetcher()
+		if errors.Is(err, ErrSignatureVerification) {
+			wasmRemoteFetchCount.With(resultTag.Value(signatureFailure)).Increment()
+			return nil, fmt.Errorf("could not verify Wasm image %s: %w", key.downloadURL, err)
+		}
 		if err != nil {
 			wasmRemoteFetchCount.With(resultTag.Value(downloadFailure)).Increment()
 			return nil, fmt.Errorf("could not fetch Wasm binary: %v", err)
 		}
+	} else if c.verifier != nil {
+		if err := c.verifyHTTPModule(ctx, key.downloadURL, b, insecure); err != nil {
+			wasmRemoteFetchCount.With(resultTag.Value(signatureFailure)).Increment()
+			return nil, err
+		}
 	}
 
 	if !isValidWasmBinary(b) {
@@ -296,6 +385,71 @@ func (c *LocalFileCache) getOrFetch(key cacheKey, opts GetOptions) (*cacheEntry,
 	return c.addEntry(key, b)
 }
 
+var errChecksumMismatch = errors.New("checksum mismatch")
+
+// lockModule takes the lock of a module in the shared directory. Locking is best effort: modules are written
+// atomically, so without the lock agents may only download the same module more than once.
+func (c *LocalFileCache) lockModule(key moduleKey) func() {
+	modulePath, err := getModulePath(c.dir, key)
+	if err == nil {
+		var unlock func()
+		if unlock, err = lockFile(modulePath + lockSuffix); err == nil {
+			return unlock
+		}
+	}
+	wasmLog.Warnf("failed to lock Wasm module %s in %s: %v", key.name, c.dir, err)
+	return func() {}
+}
+
+// loadFromSharedDir adds a module stored in the shared directory by another agent to the cache, if present.
+// verify checks the stored binary before it is used; a signature verification error is returned, while other
+// errors cause the module to be downloaded again.
+func (c *LocalFileCache) loadFromSharedDir(key cacheKey, verify func([]byte) error) (*cacheEntry, error) {
+	modulePath, err := getModulePath(c.dir, key.moduleKey)
+	if err != nil {
+		return nil, nil
+	}
+	b, err := os.ReadFile(modulePath)
+	if err != nil {
+		return nil, nil
+	}
+	if !isValidWasmBinary(b) {
+		wasmLog.Warnf("ignoring invalid Wasm module %s in the shared cache", modulePath)
+		return nil, nil
+	}
+	// The name of an OCI module is the image digest, which does not cover the file contents, so they are checked
+	// against the checksum stored by the agent that downloaded the module.
+	stored, err := os.ReadFile(modulePath + checksumSuffix)
+	if sha := sha256.Sum256(b); err != nil || strings.TrimSpace(string(stored)) != hex.EncodeToString(sha[:]) {
+		wasmLog.Warnf("ignoring Wasm module %s in the shared cache: it does not match its stored checksum", modulePath)
+		return nil, nil
+	}
+	if verify != nil {
+		if err := verify(b); errors.Is(err, ErrSignatureVerification) {
+			wasmRemoteFetchCount.With(resultTag.Value(signatureFailure)).Increment()
+			return nil, fmt.Errorf("could not verify Wasm module %s: %w", key.downloadURL, err)
+		} else if err != nil {
+			wasmLog.Warnf("ignoring Wasm module %s in the shared cache: %v", modulePath, err)
+			return nil, nil
+		}
+	}
+	wasmLog.Debugf("using Wasm module %s stored in the shared cache", modulePath)
+	return c.addEntry(key, nil)
+}
+
+// verifyHTTPModule verifies a module downloaded with HTTP(S) against the detached signature served next to it.
+func (c *LocalFileCache) verifyHTTPModule(ctx context.Context, downloadURL string, module []byte, insecure bool) error {
+	sig, err := c.httpFetcher.Fetch(ctx, downloadURL+".sig", insecure)
+	if err != nil {
+		wasmSignatureVerificationCount.With(resultTag.Value(signatureMissing)).Increment()
+		return fmt.Errorf("could not verify Wasm module %s: %w: could not fetch signature: %v", downloadURL, ErrSignatureVerification, err)
+	}
+	if err := c.verifier.verifyBlob(module, sig); err != nil {
+		return fmt.Errorf("could not verify Wasm module %s: %w", downloadURL, err)
+	}
+	return nil
+}
+
 // Cleanup closes background Wasm module purge routine.
 func (c *LocalFileCache) Cleanup() {
 	close(c.stopChan)
@@ -338,9 +492,25 @@ func (c *LocalFileCache) addEntry(key cacheKey, wasmModule []byte) (*cacheEntry,
 	if err != nil {
 		return nil, err
 	}
-	// Materialize the Wasm module into a local file. Use checksum as name of the module.
-	if err := os.WriteFile(modulePath, wasmModule, 0o644); err != nil {
-		return nil, err
+	if wasmModule != nil {
+		// Materialize the Wasm module into a local file. Use checksum as name of the module.
+		// The file is written atomically, as other agents may read it if the directory is shared.
+		if err := file.AtomicWrite(modulePath, wasmModule, 0o644); err != nil {
+			return nil, err
+		}
+		if c.SharedDir != "" {
+			// Other agents check the module against its checksum before using it.
+			sha := sha256.Sum256(wasmModule)
+			if err := file.AtomicWrite(modulePath+checksumSuffix, []byte(hex.EncodeToString(sha[:])), 0o644); err != nil {
+				return nil, err
+			}
+		}
+	} else {
+		// The module was stored by another agent; mark it as recently used.
+		touch(modulePath)
+	}
+	if info, err := os.Stat(modulePath); err == nil {
+		c.files[modulePath] = moduleFile{path: modulePath, size: info.Size(), modTime: info.ModTime()}
 	}
 
 	ce := cacheEntry{
@@ -353,6 +523,7 @@ func (c *LocalFileCache) addEntry(key cacheKey, wasmModule []byte) (*cacheEntry,
 	}
 	c.modules[key.moduleKey] = &ce
 	wasmCacheEntries.Record(float64(len(c.modules)))
+	c.evict(modulePath)
 	return &ce, nil
 }
 
@@ -391,6 +562,18 @@ func (c *LocalFileCache) getEntry(key cacheKey, ignoreResourceVersion bool) (*ca
 	}
 
 	if ce, ok := c.modules[key.moduleKey]; ok {
+		if c.tracksFiles() {
+			if !touch(ce.modulePath) {
+				// The module was evicted from the directory, possibly by another agent sharing it.
+				delete(c.files, ce.modulePath)
+				c.removeEntry(key.moduleKey, ce)
+				return nil, key.checksum
+			}
+			if f, ok := c.files[ce.modulePath]; ok {
+				f.modTime = time.Now()
+				c.files[ce.modulePath] = f
+			}
+		}
 		// Update last touched time.
 		ce.last = time.Now()
 		cacheHit = true
@@ -412,18 +595,23 @@ func (c *LocalFileCache) purge() {
 				if !m.expired(c.ModuleExpiry) {
 					continue
 				}
+				if c.SharedDir != "" && !fileExpired(m.modulePath, c.ModuleExpiry) {
+					// The module is still used by other agents sharing the directory; only forget about it.
+					c.removeEntry(k, m)
+					continue
+				}
 				// The module has not be touched for expiry duration, delete it from the map as well as the local dir.
-				if err := os.Remove(m.modulePath); err != nil {
+				if err := os.Remove(m.modulePath); err != nil && !os.IsNotExist(err) {
 					wasmLog.Errorf("failed to purge Wasm module %v: %v", m.modulePath, err)
 				} else {
-					for downloadURL := range m.referencingURLs {
-						delete(c.checksums, downloadURL)
-					}
-					delete(c.modules, k)
+					_ = os.Remove(m.modulePath + checksumSuffix)
+					c.removeEntry(k, m)
 					wasmLog.Debugf("successfully removed stale Wasm module %v", m.modulePath)
 				}
 			}
 			wasmCacheEntries.Record(float64(len(c.modules)))
+			c.files = c.moduleFiles()
+			c.evict("")
 			c.mux.Unlock()
 		case <-c.stopChan:
 			// Currently this will only happen in test.
@@ -432,6 +620,109 @@ func (c *LocalFileCache) purge() {
 	}
 }
 
+// removeEntry removes a module from the cache map. It must be called with the lock held.
+func (c *LocalFileCache) removeEntry(k moduleKey, m *cacheEntry) {
+	for downloadURL := range m.referencingURLs {
+		delete(c.checksums, downloadURL)
+	}
+	delete(c.modules, k)
+	wasmCacheEntries.Record(float64(len(c.modules)))
+}
+
+// tracksFiles returns true if module files may be removed by eviction, so the file modification time
+// is used to track their usage.
+func (c *LocalFileCache) tracksFiles() bool {
+	return c.SharedDir != "" || c.MaxCacheSize > 0
+}
+
+type moduleFile struct {
+	path    string
+	size    int64
+	modTime time.Time
+}
+
+// moduleFiles lists the module files stored in the cache directory, indexed by path.
+func (c *LocalFileCache) moduleFiles() map[string]moduleFile {
+	files := map[string]moduleFile{}
+	dirs, err := os.ReadDir(c.dir)
+	if err != nil {
+		return files
+	}
+	for _, d := range dirs {
+		// Modules are stored in directories named after the sha256 of the module name.
+		if !d.IsDir() || len(d.Name()) != sha256.Size*2 {
+			continue
+		}
+		entries, err := os.ReadDir(filepath.Join(c.dir, d.Name()))
+		if err != nil {
+			continue
+		}
+		for _, e := range entries {
+			if !e.Type().IsRegular() || filepath.Ext(e.Name()) != ".wasm" {
+				continue
+			}
+			info, err := e.Info()
+			if err != nil {
+				continue
+			}
+			path := filepath.Join(c.dir, d.Name(), e.Name())
+			files[path] = moduleFile{path: path, size: info.Size(), modTime: info.ModTime()}
+		}
+	}
+	return files
+}
+
+// evict removes the least recently used module files until their total size is within MaxCacheSize,
+// and records the size of the cache. If the directory is shared, the modules of all agents count towards
+// the limit, as last seen by the periodic purge. The module at keep, which was just added, is never evicted.
+// It must be called with the lock held.
+func (c *LocalFileCache) evict(keep string) {
+	files := maps.Values(c.files)
+	var total int64
+	for _, f := range files {
+		total += f.size
+	}
+	if c.MaxCacheSize > 0 && total > c.MaxCacheSize {
+		slices.SortFunc(files, func(a, b moduleFile) int {
+			return a.modTime.Compare(b.modTime)
+		})
+		for _, f := range files {
+			if total <= c.MaxCacheSize {
+				break
+			}
+			if f.path == keep {
+				continue
+			}
+			if err := os.Remove(f.path); err != nil && !os.IsNotExist(err) {
+				wasmLog.Errorf("failed to evict Wasm module %v: %v", f.path, err)
+				continue
+			}
+			_ = os.Remove(f.path + checksumSuffix)
+			delete(c.files, f.path)
+			total -= f.size
+			for k, m := range c.modules {
+				if m.modulePath == f.path {
+					c.removeEntry(k, m)
+				}
+			}
+			wasmLog.Infof("evicted Wasm module %v to keep the cache within %d bytes", f.path, c.MaxCacheSize)
+		}
+	}
+	wasmCacheBytes.Record(float64(total))
+}
+
+// touch marks a module file as recently used. It returns false if the file does not exist.
+func touch(path string) bool {
+	now := time.Now()
+	return os.Chtimes(path, now, now) == nil
+}
+
+// fileExpired returns true if the module file has not been used by any agent for the expiry duration.
+func fileExpired(path string, expiry time.Duration) bool {
+	info, err := os.Stat(path)
+	return err != nil || time.Since(info.ModTime()) > expiry
+}
+
 // Expired returns true if the module has not been touched for Wasm module Expiry.
 func (ce *cacheEntry) expired(expiry time.Duration) bool {
 	now := time.Now()
@@ -15,6 +15,7 @@
 package wasm
 
 import (
+	"bytes"
 	"crypto/sha256"
 	"crypto/tls"
 	"encoding/hex"
@@ -957,3 +958,198 @@ func generateModulePath(t *testing.T, baseDir, resourceName, filename string) st
 	}
 	return filepath.Join(moduleDir, filename)
 }
+
+func TestWasmSharedCache(t *testing.T) {
+	sharedDir := t.TempDir()
+	options := defaultOptions()
+	options.SharedDir = sharedDir
+	// Two caches sharing a directory, as the agents of two pods on the same node do.
+	cache1 := NewLocalFileCache(t.TempDir(), options)
+	defer close(cache1.stopChan)
+	cache2 := NewLocalFileCache(t.TempDir(), options)
+	defer close(cache2.stopChan)
+
+	binary := append(wasmHeader, []byte("shared module")...)
+	checksum := fmt.Sprintf("%x", sha256.Sum256(binary))
+	var httpRequests atomic.Int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		httpRequests.Add(1)
+		_, _ = w.Write(binary)
+	}))
+	defer ts.Close()
+
+	var blobRequests, registryRequests atomic.Int32
+	reg := registry.New()
+	tos := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		registryRequests.Add(1)
+		if r.Method == http.MethodGet && strings.Contains(r.URL.Path, "/blobs/") {
+			blobRequests.Add(1)
+		}
+		reg.ServeHTTP(w, r)
+	}))
+	defer tos.Close()
+	ou, err := url.Parse(tos.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dockerImageDigest, _ := setupOCIRegistry(t, ou.Host)
+	ociURL := fmt.Sprintf("oci://%s/test/valid/docker:v0.1.0", ou.Host)
+
+	get := func(cache *LocalFileCache, downloadURL, checksum string) string {
+		t.Helper()
+		path, err := cache.Get(downloadURL, GetOptions{Checksum: checksum, RequestTimeout: 10 * time.Second})
+		if err != nil {
+			t.Fatalf("failed to get Wasm module: %v", err)
+		}
+		if !strings.HasPrefix(path, sharedDir) {
+			t.Fatalf("module %s is not stored in the shared directory %s", path, sharedDir)
+		}
+		return path
+	}
+
+	// The module downloaded by the first cache is reused by the second one.
+	path1 := get(cache1, ts.URL, checksum)
+	path2 := get(cache2, ts.URL, checksum)
+	if path1 != path2 {
+		t.Fatalf("got different module paths %s and %s", path1, path2)
+	}
+	if got := httpRequests.Load(); got != 1 {
+		t.Fatalf("got %d HTTP downloads, want 1", got)
+	}
+
+	// OCI images are resolved, but their layers are only downloaded once.
+	ociPath := get(cache1, ociURL, "")
+	downloads := blobRequests.Load()
+	if downloads == 0 {
+		t.Fatal("expected the image layers to be downloaded")
+	}
+	if got := get(cache2, ociURL, ""); filepath.Base(got) != dockerImageDigest+".wasm" {
+		t.Fatalf("got module path %s, want digest %s", got, dockerImageDigest)
+	}
+	if got := blobRequests.Load(); got != downloads {
+		t.Fatalf("got %d blob downloads, want %d", got, downloads)
+	}
+
+	// If the digest is known, a stored module is used without contacting the registry at all.
+	requests := registryRequests.Load()
+	cache4 := NewLocalFileCache(t.TempDir(), options)
+	defer close(cache4.stopChan)
+	get(cache4, ociURL, dockerImageDigest)
+	get(cache4, fmt.Sprintf("oci://%s/test/valid/docker@sha256:%s", ou.Host, dockerImageDigest), "")
+	if got := registryRequests.Load(); got != requests {
+		t.Fatalf("got %d registry requests, want %d", got, requests)
+	}
+
+	// A valid module planted in the shared directory does not match its stored checksum, so the image is pulled again.
+	if err := os.WriteFile(ociPath, append(wasmHeader, []byte("planted")...), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	downloads = blobRequests.Load()
+	cache5 := NewLocalFileCache(t.TempDir(), options)
+	defer close(cache5.stopChan)
+	get(cache5, ociURL, dockerImageDigest)
+	if got := blobRequests.Load(); got == downloads {
+		t.Fatal("expected the image layers to be downloaded again")
+	}
+	if b, _ := os.ReadFile(ociPath); bytes.Contains(b, []byte("planted")) {
+		t.Fatal("expected the planted module to be replaced")
+	}
+
+	// A corrupted module in the shared directory is downloaded again.
+	if err := os.WriteFile(path1, []byte("corrupted"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cache3 := NewLocalFileCache(t.TempDir(), options)
+	defer close(cache3.stopChan)
+	get(cache3, ts.URL, checksum)
+	if got := httpRequests.Load(); got != 2 {
+		t.Fatalf("got %d HTTP downloads, want 2", got)
+	}
+
+	// Modules are written atomically, without leftover temporary files.
+	_ = filepath.WalkDir(sharedDir, func(path string, d os.DirEntry, err error) error {
+		if strings.Contains(d.Name(), ".tmp") {
+			t.Errorf("unexpected temporary file %s", path)
+		}
+		return nil
+	})
+}
+
+func TestWasmCacheSizeEviction(t *testing.T) {
+	binaries := map[string][]byte{}
+	for _, name := range []string{"a", "b", "c"} {
+		binaries["/"+name] = append(wasmHeader, []byte(strings.Repeat(name, 100))...)
+	}
+	var requests atomic.Int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		_, _ = w.Write(binaries[r.URL.Path])
+	}))
+	defer ts.Close()
+
+	tmpDir := t.TempDir()
+	options := defaultOptions()
+	// Room for two of the modules.
+	options.MaxCacheSize = int64(2*len(binaries["/a"]) + 1)
+	cache := NewLocalFileCache(tmpDir, options)
+	defer close(cache.stopChan)
+
+	get := func(path string) string {
+		t.Helper()
+		got, err := cache.Get(ts.URL+path, GetOptions{RequestTimeout: 10 * time.Second})
+		if err != nil {
+			t.Fatalf("failed to get Wasm module: %v", err)
+		}
+		return got
+	}
+	exists := func(path string) bool {
+		_, err := os.Stat(path)
+		return err == nil
+	}
+	setModTime := func(path string, age time.Duration) {
+		t.Helper()
+		mtime := time.Now().Add(-age)
+		if err := os.Chtimes(path, mtime, mtime); err != nil {
+			t.Fatal(err)
+		}
+		cache.mux.Lock()
+		defer cache.mux.Unlock()
+		f := cache.files[path]
+		f.modTime = mtime
+		cache.files[path] = f
+	}
+
+	pathA := get("/a")
+	setModTime(pathA, 2*time.Minute)
+	pathB := get("/b")
+	setModTime(pathB, time.Minute)
+	// Using a module marks it as recently used, so b is now the least recently used module.
+	get("/a")
+	pathC := get("/c")
+	if !exists(pathA) || exists(pathB) || !exists(pathC) {
+		t.Fatalf("expected only %s to be evicted", pathB)
+	}
+	if got := len(cache.modules); got != 2 {
+		t.Fatalf("got %d cache entries, want 2", got)
+	}
+	if got := len(cache.files); got != 2 {
+		t.Fatalf("got %d indexed module files, want 2", got)
+	}
+
+	// An evicted module is downloaded again.
+	before := requests.Load()
+	if got := get("/b"); got != pathB || !exists(pathB) {
+		t.Fatalf("expected %s to be downloaded again", pathB)
+	}
+	if got := requests.Load(); got != before+1 {
+		t.Fatalf("got %d downloads, want %d", got, before+1)
+	}
+
+	// Another agent sharing the directory may remove a module; it is downloaded again as well.
+	if err := os.Remove(pathB); err != nil {
+		t.Fatal(err)
+	}
+	if got := get("/b"); got != pathB || !exists(pathB) {
+		t.Fatalf("expected %s to be downloaded again", pathB)
+	}
+}
@@ -15,6 +15,7 @@
 package wasm
 
 import (
+	"errors"
 	"fmt"
 	"strings"
 	"sync"
@@ -96,7 +97,9 @@ func MaybeConvertWasmExtensionConfig(resources []*anypb.Any, cache Cache) error
 			if wasmHTTPConfig != nil {
 				newExtensionConfig, err := convertHTTPWasmConfigFromRemoteToLocal(extConfig, wasmHTTPConfig, cache)
 				if err != nil {
-					if !wasmHTTPConfig.GetConfig().GetFailOpen() {
+					// A module rejected by signature verification is always NACKed, so that the policy
+					// violation is surfaced instead of silently skipping the plugin.
+					if !wasmHTTPConfig.GetConfig().GetFailOpen() || errors.Is(err, ErrSignatureVerification) {
 						convertErrs[i] = err
 						return
 					}
@@ -113,7 +116,9 @@ func MaybeConvertWasmExtensionConfig(resources []*anypb.Any, cache Cache) error
 			} else {
 				newExtensionConfig, err := convertNetworkWasmConfigFromRemoteToLocal(extConfig, wasmNetworkConfig, cache)
 				if err != nil {
-					if !wasmNetworkConfig.GetConfig().GetFailOpen() {
+					// A module rejected by signature verification is always NACKed, so that the policy
+					// violation is surfaced instead of silently skipping the plugin.
+					if !wasmNetworkConfig.GetConfig().GetFailOpen() || errors.Is(err, ErrSignatureVerification) {
 						convertErrs[i] = err
 						return
 					}
@@ -348,6 +353,9 @@ func rewriteVMConfig(resourceName string, vm *wasmextensions.VmConfig, status *s
 	})
 	if err != nil {
 		*status = fetchFailure
+		if errors.Is(err, ErrSignatureVerification) {
+			*status = signatureFailure
+		}
 		return fmt.Errorf("cannot fetch Wasm module %v: %w", remote.GetHttpUri().GetUri(), err)
 	}
 
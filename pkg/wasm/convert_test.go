@@ -50,7 +50,9 @@ func (c *mockCache) Get(downloadURL string, opts GetOptions) (string, error) {
 	module := query.Get("module")
 	errMsg := query.Get("error")
 	var err error
-	if errMsg != "" {
+	if errMsg == "signature-error" {
+		err = fmt.Errorf("%w: unsigned", ErrSignatureVerification)
+	} else if errMsg != "" {
 		err = errors.New(errMsg)
 	}
 	if c.wantSecret != nil && !reflect.DeepEqual(c.wantSecret, opts.PullSecret) {
@@ -256,6 +258,16 @@ func TestWasmConvert(t *testing.T) {
 			},
 			wantErr: false,
 		},
+		{
+			name: "signature verification failure ignores fail open",
+			input: []*core.TypedExtensionConfig{
+				extensionConfigMap["remote-load-unsigned-fail-open"],
+			},
+			wantOutput: []*core.TypedExtensionConfig{
+				extensionConfigMap["remote-load-unsigned-fail-open"],
+			},
+			wantErr: true,
+		},
 		{
 			name: "no typed struct",
 			input: []*core.TypedExtensionConfig{
@@ -478,6 +490,22 @@ var extensionConfigMap = map[string]*core.TypedExtensionConfig{
 			FailOpen: true,
 		},
 	}),
+	"remote-load-unsigned-fail-open": buildTypedStructExtensionConfig("remote-load-unsigned", &wasm.Wasm{
+		Config: &v3.PluginConfig{
+			Vm: &v3.PluginConfig_VmConfig{
+				VmConfig: &v3.VmConfig{
+					Code: &core.AsyncDataSource{Specifier: &core.AsyncDataSource_Remote{
+						Remote: &core.RemoteDataSource{
+							HttpUri: &core.HttpUri{
+								Uri: "http://test?module=test.wasm&error=signature-error",
+							},
+						},
+					}},
+				},
+			},
+			FailOpen: true,
+		},
+	}),
 	"remote-load-allow": buildAnyExtensionConfig("remote-load-fail", &rbac.RBAC{}),
 	"remote-load-secret": buildTypedStructExtensionConfig("remote-load-success", &wasm.Wasm{
 		Config: &v3.PluginConfig{
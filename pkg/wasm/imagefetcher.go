@@ -42,9 +42,10 @@ import (
 // Basically, this supports fetching and unpackaging three types of container images containing a Wasm binary.
 
 type ImageFetcherOption struct {
-	// TODO(mathetake) Add signature verification stuff.
 	PullSecret []byte
 	Insecure   bool
+	// Verifier, if set, requires the image to be signed by one of its trusted keys.
+	Verifier *SignatureVerifier
 }
 
 func (o *ImageFetcherOption) useDefaultKeyChain() bool {
@@ -53,13 +54,17 @@ func (o *ImageFetcherOption) useDefaultKeyChain() bool {
 
 func (o ImageFetcherOption) String() string {
 	if o.PullSecret == nil {
-		return fmt.Sprintf("{Insecure: %v}", o.Insecure)
+		return fmt.Sprintf("{Insecure: %v, VerifySignature: %v}", o.Insecure, o.Verifier != nil)
 	}
-	return fmt.Sprintf("{Insecure: %v, PullSecret: <redacted>}", o.Insecure)
+	return fmt.Sprintf("{Insecure: %v, VerifySignature: %v, PullSecret: <redacted>}", o.Insecure, o.Verifier != nil)
 }
 
 type ImageFetcher struct {
 	fetchOpts []remote.Option
+	verifier  *SignatureVerifier
+	// ref and digest of the image resolved by PrepareFetch.
+	ref    name.Reference
+	digest v1.Hash
 }
 
 func NewImageFetcher(ctx context.Context, opt ImageFetcherOption) *ImageFetcher {
@@ -85,6 +90,7 @@ func NewImageFetcher(ctx context.Context, opt ImageFetcherOption) *ImageFetcher
 
 	return &ImageFetcher{
 		fetchOpts: append(fetchOpts, remote.WithContext(ctx)),
+		verifier:  opt.Verifier,
 	}
 }
 
@@ -126,7 +132,12 @@ func (o *ImageFetcher) PrepareFetch(url string) (binaryFetcher func() ([]byte, e
 	// Check Manifest's digest if expManifestDigest is not empty.
 	d, _ := img.Digest()
 	actualDigest = d.Hex
+	o.ref, o.digest = ref, d
 	binaryFetcher = func() ([]byte, error) {
+		// Verify before extracting anything, so that an untrusted module is never handed out.
+		if err := o.verifySignature(); err != nil {
+			return nil, err
+		}
 		manifest, err := img.Manifest()
 		if err != nil {
 			return nil, fmt.Errorf("could not retrieve manifest: %v", err)
@@ -166,6 +177,28 @@ func (o *ImageFetcher) PrepareFetch(url string) (binaryFetcher func() ([]byte, e
 	return
 }
 
+// verifySignature verifies the signature of the image resolved by PrepareFetch, if signature
+// verification is configured.
+func (o *ImageFetcher) verifySignature() error {
+	if o.verifier == nil {
+		return nil
+	}
+	return o.verifier.verifyImage(o.ref, o.digest, o.fetchOpts)
+}
+
+// verifyDigest verifies the signature of the image at url with the given sha256 digest, if signature verification
+// is configured. Unlike verifySignature, it does not need the image to be resolved by PrepareFetch.
+func (o *ImageFetcher) verifyDigest(url string, digest string) error {
+	if o.verifier == nil {
+		return nil
+	}
+	ref, err := name.ParseReference(url)
+	if err != nil {
+		return fmt.Errorf("could not parse url in image reference: %v", err)
+	}
+	return o.verifier.verifyImage(ref, v1.Hash{Algorithm: "sha256", Hex: digest}, o.fetchOpts)
+}
+
 // extractDockerImage extracts the Wasm binary from the
 // *compat* variant Wasm image with the standard Docker media type: application/vnd.docker.image.rootfs.diff.tar.gzip.
 // https://github.com/solo-io/wasm/blob/master/spec/spec-compat.md#specification
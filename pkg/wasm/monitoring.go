@@ -23,6 +23,12 @@ const (
 	downloadFailure  = "download_failure"
 	manifestFailure  = "manifest_failure"
 	checksumMismatch = "checksum_mismatched"
+	signatureFailure = "signature_failure"
+
+	// For signature verification metric.
+	signatureVerified = "verified"
+	signatureMissing  = "missing"
+	signatureInvalid  = "invalid"
 
 	// For Wasm conversion metric.
 	conversionSuccess   = "success"
@@ -42,6 +48,11 @@ var (
 		"number of Wasm remote fetch cache entries.",
 	)
 
+	wasmCacheBytes = monitoring.NewGauge(
+		"wasm_cache_bytes",
+		"total size in bytes of the Wasm modules stored in the cache directory.",
+	)
+
 	wasmCacheLookupCount = monitoring.NewSum(
 		"wasm_cache_lookup_count",
 		"number of Wasm remote fetch cache lookups.",
@@ -52,6 +63,11 @@ var (
 		"number of Wasm remote fetches and results, including success, download failure, and checksum mismatch.",
 	)
 
+	wasmSignatureVerificationCount = monitoring.NewSum(
+		"wasm_signature_verification_count",
+		"number of Wasm module signature verifications and results, including verified, missing, and invalid signatures.",
+	)
+
 	wasmConfigConversionCount = monitoring.NewSum(
 		"wasm_config_conversion_count",
 		"number of Wasm config conversion count and results, including success, no remote load, marshal failure, remote fetch failure, miss remote fetch hint.",
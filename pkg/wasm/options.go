@@ -34,6 +34,16 @@ type Options struct {
 	InsecureRegistries    sets.String
 	HTTPRequestTimeout    time.Duration
 	HTTPRequestMaxRetries int
+	// VerificationKeysPath is a PEM file, or a directory of PEM files, with the public keys trusted to sign
+	// Wasm modules. If set, modules without a valid signature by one of these keys are rejected.
+	VerificationKeysPath string
+	// SharedDir, if set, is a directory shared by the agents on a node, for example a hostPath volume,
+	// in which modules are stored instead of the directory passed to NewLocalFileCache. Modules stored
+	// by one agent are reused by the others, and downloads of the same module are serialized with file locks.
+	SharedDir string
+	// MaxCacheSize is the maximum total size in bytes of the modules in the cache directory. When it is
+	// exceeded, the least recently used modules are evicted. Zero means no limit.
+	MaxCacheSize int64
 }
 
 func defaultOptions() Options {
@@ -0,0 +1,243 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package wasm
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/go-containerregistry/pkg/v1/remote/transport"
+)
+
+// This file implements offline verification of Wasm module signatures against locally configured public keys.
+// OCI images are verified using the signature layout of cosign: the signature is stored in the same repository,
+// tagged `sha256-<digest>.sig`, as an image whose layers are "simple signing" payloads referencing the signed
+// digest, with the base64 encoded signature of each payload in a layer annotation.
+// Modules downloaded with HTTP(S) are verified using a detached, base64 encoded signature of the module binary
+// served next to it with a `.sig` suffix, as produced by `cosign sign-blob`.
+
+// ErrSignatureVerification is returned when a Wasm module is not signed by any of the configured keys.
+var ErrSignatureVerification = errors.New("wasm module signature verification failed")
+
+const (
+	cosignSignatureAnnotation = "dev.cosignproject.cosign/signature"
+	// Limit the signature payload to 1mb; in reality it is a few hundred bytes.
+	maxSignaturePayloadSize = 1024 * 1024
+)
+
+// simpleSigningPayload is the subset of the "simple signing" payload signed by cosign that is needed
+// to bind a signature to an image.
+type simpleSigningPayload struct {
+	Critical struct {
+		Image struct {
+			DockerManifestDigest string `json:"docker-manifest-digest"`
+		} `json:"image"`
+	} `json:"critical"`
+}
+
+// SignatureVerifier verifies signatures of Wasm modules against a set of trusted public keys.
+type SignatureVerifier struct {
+	keys []crypto.PublicKey
+}
+
+// NewSignatureVerifier loads the PEM encoded public keys in path, which is either a file or
+// a directory of files. ECDSA, RSA and Ed25519 keys are supported.
+func NewSignatureVerifier(path string) (*SignatureVerifier, error) {
+	files := []string{path}
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if info.IsDir() {
+		entries, err := os.ReadDir(path)
+		if err != nil {
+			return nil, err
+		}
+		files = files[:0]
+		for _, e := range entries {
+			if e.Type().IsRegular() {
+				files = append(files, filepath.Join(path, e.Name()))
+			}
+		}
+	}
+
+	v := &SignatureVerifier{}
+	for _, f := range files {
+		b, err := os.ReadFile(f)
+		if err != nil {
+			return nil, err
+		}
+		keys, err := parsePublicKeys(b)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse public keys in %s: %v", f, err)
+		}
+		v.keys = append(v.keys, keys...)
+	}
+	if len(v.keys) == 0 {
+		return nil, fmt.Errorf("no public keys found in %s", path)
+	}
+	return v, nil
+}
+
+func parsePublicKeys(b []byte) ([]crypto.PublicKey, error) {
+	var keys []crypto.PublicKey
+	for {
+		var block *pem.Block
+		block, b = pem.Decode(b)
+		if block == nil {
+			return keys, nil
+		}
+		if block.Type != "PUBLIC KEY" {
+			continue
+		}
+		key, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		switch key.(type) {
+		case *ecdsa.PublicKey, *rsa.PublicKey, ed25519.PublicKey:
+		default:
+			return nil, fmt.Errorf("unsupported public key type %T", key)
+		}
+		keys = append(keys, key)
+	}
+}
+
+// verify checks that sig is a signature of payload by any of the trusted keys.
+func (v *SignatureVerifier) verify(payload, sig []byte) bool {
+	digest := sha256.Sum256(payload)
+	for _, key := range v.keys {
+		switch k := key.(type) {
+		case *ecdsa.PublicKey:
+			if ecdsa.VerifyASN1(k, digest[:], sig) {
+				return true
+			}
+		case *rsa.PublicKey:
+			if rsa.VerifyPKCS1v15(k, crypto.SHA256, digest[:], sig) == nil {
+				return true
+			}
+		case ed25519.PublicKey:
+			if ed25519.Verify(k, payload, sig) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// verifyBlob verifies a detached, base64 encoded signature of a Wasm binary.
+func (v *SignatureVerifier) verifyBlob(module, encodedSig []byte) error {
+	sig, err := base64.StdEncoding.DecodeString(string(bytes.TrimSpace(encodedSig)))
+	if err != nil {
+		wasmSignatureVerificationCount.With(resultTag.Value(signatureInvalid)).Increment()
+		return fmt.Errorf("%w: malformed signature: %v", ErrSignatureVerification, err)
+	}
+	if !v.verify(module, sig) {
+		wasmSignatureVerificationCount.With(resultTag.Value(signatureInvalid)).Increment()
+		return fmt.Errorf("%w: module is not signed by a trusted key", ErrSignatureVerification)
+	}
+	wasmSignatureVerificationCount.With(resultTag.Value(signatureVerified)).Increment()
+	return nil
+}
+
+// verifyImage verifies that the image with the given digest in the repository of ref has a signature
+// by any of the trusted keys.
+func (v *SignatureVerifier) verifyImage(ref name.Reference, digest v1.Hash, opts []remote.Option) error {
+	sigRef := ref.Context().Tag(fmt.Sprintf("%s-%s.sig", digest.Algorithm, digest.Hex))
+	img, err := remote.Image(sigRef, opts...)
+	if err != nil {
+		var terr *transport.Error
+		if errors.As(err, &terr) && terr.StatusCode == http.StatusNotFound {
+			wasmSignatureVerificationCount.With(resultTag.Value(signatureMissing)).Increment()
+			return fmt.Errorf("%w: no signature found for %s", ErrSignatureVerification, digest)
+		}
+		return fmt.Errorf("could not fetch signature %s: %v", sigRef, err)
+	}
+	manifest, err := img.Manifest()
+	if err != nil {
+		return fmt.Errorf("could not retrieve signature manifest: %v", err)
+	}
+
+	signatures := 0
+	for _, desc := range manifest.Layers {
+		encodedSig, ok := desc.Annotations[cosignSignatureAnnotation]
+		if !ok {
+			continue
+		}
+		signatures++
+		sig, err := base64.StdEncoding.DecodeString(encodedSig)
+		if err != nil {
+			wasmLog.Debugf("skipping malformed signature in %s: %v", sigRef, err)
+			continue
+		}
+		payload, err := readSignaturePayload(img, desc.Digest)
+		if err != nil {
+			return err
+		}
+		if !v.verify(payload, sig) {
+			continue
+		}
+		// The payload is trusted now; make sure it is for this image and not another one signed by the same key.
+		var p simpleSigningPayload
+		if err := json.Unmarshal(payload, &p); err != nil {
+			wasmLog.Debugf("skipping malformed signature payload in %s: %v", sigRef, err)
+			continue
+		}
+		if p.Critical.Image.DockerManifestDigest == digest.String() {
+			wasmSignatureVerificationCount.With(resultTag.Value(signatureVerified)).Increment()
+			return nil
+		}
+	}
+	if signatures == 0 {
+		wasmSignatureVerificationCount.With(resultTag.Value(signatureMissing)).Increment()
+		return fmt.Errorf("%w: no signature found for %s", ErrSignatureVerification, digest)
+	}
+	wasmSignatureVerificationCount.With(resultTag.Value(signatureInvalid)).Increment()
+	return fmt.Errorf("%w: %s is not signed by a trusted key", ErrSignatureVerification, digest)
+}
+
+func readSignaturePayload(img v1.Image, digest v1.Hash) ([]byte, error) {
+	layer, err := img.LayerByDigest(digest)
+	if err != nil {
+		return nil, fmt.Errorf("could not fetch signature payload: %v", err)
+	}
+	r, err := layer.Compressed()
+	if err != nil {
+		return nil, fmt.Errorf("could not fetch signature payload: %v", err)
+	}
+	defer r.Close()
+	payload, err := io.ReadAll(io.LimitReader(r, maxSignaturePayloadSize))
+	if err != nil {
+		return nil, fmt.Errorf("could not read signature payload: %v", err)
+	}
+	return payload, nil
+}
@@ -0,0 +1,298 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package wasm
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/go-containerregistry/pkg/crane"
+	"github.com/google/go-containerregistry/pkg/registry"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/static"
+	"github.com/google/go-containerregistry/pkg/v1/types"
+)
+
+// signer signs payloads the way cosign does for the supported key types.
+type signer struct {
+	key crypto.Signer
+}
+
+func newSigner(t *testing.T, kind string) signer {
+	t.Helper()
+	var key crypto.Signer
+	var err error
+	switch kind {
+	case "ecdsa":
+		key, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	case "rsa":
+		key, err = rsa.GenerateKey(rand.Reader, 2048)
+	case "ed25519":
+		_, key, err = ed25519.GenerateKey(rand.Reader)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signer{key: key}
+}
+
+func (s signer) sign(t *testing.T, payload []byte) string {
+	t.Helper()
+	var sig []byte
+	var err error
+	if _, ok := s.key.(ed25519.PrivateKey); ok {
+		sig, err = s.key.Sign(rand.Reader, payload, crypto.Hash(0))
+	} else {
+		digest := sha256.Sum256(payload)
+		sig, err = s.key.Sign(rand.Reader, digest[:], crypto.SHA256)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	return base64.StdEncoding.EncodeToString(sig)
+}
+
+func (s signer) publicKeyPEM(t *testing.T) []byte {
+	t.Helper()
+	der, err := x509.MarshalPKIXPublicKey(s.key.Public())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})
+}
+
+func writeKeys(t *testing.T, signers ...signer) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "keys.pem")
+	var b []byte
+	for _, s := range signers {
+		b = append(b, s.publicKeyPEM(t)...)
+	}
+	if err := os.WriteFile(path, b, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestNewSignatureVerifier(t *testing.T) {
+	ec, ed := newSigner(t, "ecdsa"), newSigner(t, "ed25519")
+	file := writeKeys(t, ec, ed)
+	v, err := NewSignatureVerifier(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(v.keys) != 2 {
+		t.Fatalf("expected 2 keys, got %d", len(v.keys))
+	}
+
+	// A directory loads the keys of all files in it.
+	dir := t.TempDir()
+	for i, s := range []signer{ec, ed, newSigner(t, "rsa")} {
+		if err := os.WriteFile(filepath.Join(dir, fmt.Sprintf("key-%d.pem", i)), s.publicKeyPEM(t), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	v, err = NewSignatureVerifier(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(v.keys) != 3 {
+		t.Fatalf("expected 3 keys, got %d", len(v.keys))
+	}
+
+	empty := filepath.Join(t.TempDir(), "empty.pem")
+	if err := os.WriteFile(empty, []byte("not a key"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewSignatureVerifier(empty); err == nil {
+		t.Fatal("expected error for a file without keys")
+	}
+	if _, err := NewSignatureVerifier(filepath.Join(dir, "missing")); err == nil {
+		t.Fatal("expected error for a missing path")
+	}
+}
+
+func TestVerifyBlob(t *testing.T) {
+	module := append(wasmHeader, []byte("signed module")...)
+	for _, kind := range []string{"ecdsa", "rsa", "ed25519"} {
+		t.Run(kind, func(t *testing.T) {
+			trusted, untrusted := newSigner(t, kind), newSigner(t, kind)
+			v, err := NewSignatureVerifier(writeKeys(t, trusted))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := v.verifyBlob(module, []byte(trusted.sign(t, module)+"\n")); err != nil {
+				t.Fatalf("expected valid signature, got %v", err)
+			}
+			if err := v.verifyBlob(module, []byte(untrusted.sign(t, module))); !errors.Is(err, ErrSignatureVerification) {
+				t.Fatalf("expected signature verification error, got %v", err)
+			}
+			if err := v.verifyBlob(append(module, 0), []byte(trusted.sign(t, module))); !errors.Is(err, ErrSignatureVerification) {
+				t.Fatalf("expected signature verification error for a modified module, got %v", err)
+			}
+			if err := v.verifyBlob(module, []byte("%%%")); !errors.Is(err, ErrSignatureVerification) {
+				t.Fatalf("expected signature verification error for a malformed signature, got %v", err)
+			}
+		})
+	}
+}
+
+// pushSignature pushes a cosign style signature of the image with the given digest to the repository of ref.
+func pushSignature(t *testing.T, ref, digest string, s signer) {
+	t.Helper()
+	pushSignaturePayload(t, ref, digest, digest, s)
+}
+
+// pushSignaturePayload pushes a signature stored for the image with the given digest, signing a payload
+// that references payloadDigest.
+func pushSignaturePayload(t *testing.T, ref, digest, payloadDigest string, s signer) {
+	t.Helper()
+	payload := []byte(fmt.Sprintf(`{"critical":{"identity":{"docker-reference":%q},`+
+		`"image":{"docker-manifest-digest":%q},"type":"cosign container image signature"},"optional":null}`, ref, payloadDigest))
+	layer := static.NewLayer(payload, "application/vnd.dev.cosign.simplesigning.v1+json")
+	img, err := mutate.Append(empty.Image, mutate.Addendum{
+		Layer:       layer,
+		Annotations: map[string]string{cosignSignatureAnnotation: s.sign(t, payload)},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sigTag := fmt.Sprintf("%s:sha256-%s.sig", ref, digest[len(sha256SchemePrefix):])
+	if err := crane.Push(img, sigTag); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// pushModule pushes a Wasm image to ref and returns its digest.
+func pushModule(t *testing.T, ref string, module []byte) string {
+	t.Helper()
+	l, err := newMockLayer(types.DockerLayer, map[string][]byte{"plugin.wasm": module})
+	if err != nil {
+		t.Fatal(err)
+	}
+	img, err := mutate.Append(empty.Image, mutate.Addendum{Layer: l})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := crane.Push(img, ref); err != nil {
+		t.Fatal(err)
+	}
+	d, err := img.Digest()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return d.String()
+}
+
+func TestWasmCacheSignatureVerification(t *testing.T) {
+	trusted, untrusted := newSigner(t, "ecdsa"), newSigner(t, "ecdsa")
+	module := append(wasmHeader, []byte("signed module")...)
+
+	reg := httptest.NewServer(registry.New())
+	defer reg.Close()
+	regURL, _ := url.Parse(reg.URL)
+	host := regURL.Host
+
+	signed := host + "/test/signed:v1"
+	signedDigest := pushModule(t, signed, module)
+	pushSignature(t, host+"/test/signed", signedDigest, trusted)
+
+	unsigned := host + "/test/unsigned:v1"
+	pushModule(t, unsigned, append(wasmHeader, []byte("unsigned module")...))
+
+	untrustedRef := host + "/test/untrusted:v1"
+	pushSignature(t, host+"/test/untrusted", pushModule(t, untrustedRef, append(wasmHeader, []byte("untrusted module")...)), untrusted)
+
+	// A valid signature of a different image in the same repository must not be accepted.
+	otherRef := host + "/test/signed:v2"
+	otherDigest := pushModule(t, otherRef, append(wasmHeader, []byte("other module")...))
+	pushSignaturePayload(t, host+"/test/signed", otherDigest, signedDigest, trusted)
+
+	httpModule := append(wasmHeader, []byte("http module")...)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/signed.wasm", "/unsigned.wasm", "/untrusted.wasm":
+			_, _ = w.Write(httpModule)
+		case "/signed.wasm.sig":
+			_, _ = w.Write([]byte(trusted.sign(t, httpModule)))
+		case "/untrusted.wasm.sig":
+			_, _ = w.Write([]byte(untrusted.sign(t, httpModule)))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+
+	cases := []struct {
+		name    string
+		url     string
+		wantErr bool
+	}{
+		{name: "signed image", url: "oci://" + signed},
+		{name: "signed image by digest", url: "oci://" + host + "/test/signed@" + signedDigest},
+		{name: "unsigned image", url: "oci://" + unsigned, wantErr: true},
+		{name: "image signed by untrusted key", url: "oci://" + untrustedRef, wantErr: true},
+		{name: "signature for another digest", url: "oci://" + otherRef, wantErr: true},
+		{name: "signed http module", url: ts.URL + "/signed.wasm"},
+		{name: "unsigned http module", url: ts.URL + "/unsigned.wasm", wantErr: true},
+		{name: "http module signed by untrusted key", url: ts.URL + "/untrusted.wasm", wantErr: true},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			cache := NewLocalFileCache(t.TempDir(), Options{
+				VerificationKeysPath:  writeKeys(t, trusted),
+				HTTPRequestMaxRetries: 1,
+			})
+			defer cache.Cleanup()
+			_, err := cache.Get(c.url, GetOptions{RequestTimeout: 10 * time.Second})
+			if c.wantErr {
+				if !errors.Is(err, ErrSignatureVerification) {
+					t.Fatalf("expected signature verification error, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		})
+	}
+
+	t.Run("invalid keys reject all modules", func(t *testing.T) {
+		cache := NewLocalFileCache(t.TempDir(), Options{VerificationKeysPath: filepath.Join(t.TempDir(), "missing")})
+		defer cache.Cleanup()
+		_, err := cache.Get("oci://"+signed, GetOptions{RequestTimeout: 10 * time.Second})
+		if !errors.Is(err, ErrSignatureVerification) {
+			t.Fatalf("expected signature verification error, got %v", err)
+		}
+	})
+}
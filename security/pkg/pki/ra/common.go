@@ -19,11 +19,13 @@ import (
 	"encoding/asn1"
 	"fmt"
 	"strings"
+	"sync"
 	"time"
 
 	clientset "k8s.io/client-go/kubernetes"
 
 	meshconfig "istio.io/api/mesh/v1alpha1"
+	istiogrpc "istio.io/istio/pilot/pkg/grpc"
 	"istio.io/istio/pkg/slices"
 	raerror "istio.io/istio/security/pkg/pki/error"
 	"istio.io/istio/security/pkg/pki/util"
@@ -52,6 +54,8 @@ type IstioRAOptions struct {
 	MaxCertTTL time.Duration
 	// CaCertFile : File containing PEM encoded CA root certificate of external CA
 	CaCertFile string
+	// CaCertPEM : PEM encoded CA root certificate of external CA, used by the gRPC RA if CaCertFile is not set
+	CaCertPEM []byte
 	// CaSigner : To indicate custom CA Signer name when using external K8s CA
 	CaSigner string
 	// VerifyAppendCA : Whether to use caCertFile containing CA root cert to verify and append to signed cert-chain
@@ -62,12 +66,21 @@ type IstioRAOptions struct {
 	TrustDomain string
 	// CertSignerDomain info
 	CertSignerDomain string
+	// ExternalCAAddr : Address of the external signer, when using ExtCAGRPC
+	ExternalCAAddr string
+	// ExternalCATLS : TLS settings for the connection to the external signer. If nil, plaintext is used.
+	ExternalCATLS *istiogrpc.TLSOptions
+	// ExternalCATimeout : Timeout for requests to the external signer
+	ExternalCATimeout time.Duration
 }
 
 const (
 	// ExtCAK8s : Integrate with external CA using k8s CSR API
 	ExtCAK8s CaExternalType = "ISTIOD_RA_KUBERNETES_API"
 
+	// ExtCAGRPC : Integrate with external CA by forwarding CSRs to a signer over gRPC
+	ExtCAGRPC CaExternalType = "ISTIOD_RA_GRPC"
+
 	// DefaultExtCACertDir : Location of external CA certificate
 	DefaultExtCACertDir string = "./etc/external-ca-cert"
 )
@@ -166,12 +179,19 @@ func compareCSRs(orgCSR, genCSR *x509.CertificateRequest) bool {
 // NewIstioRA is a factory method that returns an RA that implements the RegistrationAuthority functionality.
 // the caOptions defines the external provider
 func NewIstioRA(opts *IstioRAOptions) (RegistrationAuthority, error) {
-	if opts.ExternalCAType == ExtCAK8s {
+	switch opts.ExternalCAType {
+	case ExtCAK8s:
 		istioRA, err := NewKubernetesRA(opts)
 		if err != nil {
 			return nil, fmt.Errorf("failed to create an K8s CA: %v", err)
 		}
 		return istioRA, err
+	case ExtCAGRPC:
+		istioRA, err := NewGRPCRA(opts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create a gRPC CA: %v", err)
+		}
+		return istioRA, err
 	}
 	return nil, fmt.Errorf("invalid CA Name %s", opts.ExternalCAType)
 }
@@ -198,3 +218,52 @@ func preSign(raOpts *IstioRAOptions, csrPEM []byte, subjectIDs []string, request
 	}
 	return lifetime, nil
 }
+
+// meshConfigCACertificates holds the root certificates for each signer configured in mesh config. It implements the
+// mesh config methods of RegistrationAuthority, and is shared by the RA implementations.
+type meshConfigCACertificates struct {
+	caCertificatesFromMeshConfig map[string]string
+	// mutex protects the R/W to caCertificatesFromMeshConfig.
+	mutex sync.RWMutex
+}
+
+func newMeshConfigCACertificates() meshConfigCACertificates {
+	return meshConfigCACertificates{caCertificatesFromMeshConfig: make(map[string]string)}
+}
+
+func (r *meshConfigCACertificates) SetCACertificatesFromMeshConfig(caCertificates []*meshconfig.MeshConfig_CertificateData) {
+	r.mutex.Lock()
+	for _, pemCert := range caCertificates {
+		// TODO:  take care of spiffe bundle format as well
+		cert := pemCert.GetPem()
+		certSigners := pemCert.CertSigners
+		if len(certSigners) != 0 {
+			certSigner := strings.Join(certSigners, ",")
+			if cert != "" {
+				r.caCertificatesFromMeshConfig[certSigner] = cert
+			}
+		}
+	}
+	r.mutex.Unlock()
+}
+
+func (r *meshConfigCACertificates) GetRootCertFromMeshConfig(signerName string) ([]byte, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	caCertificates := r.caCertificatesFromMeshConfig
+	if len(caCertificates) == 0 {
+		return nil, fmt.Errorf("no caCertificates defined in mesh config")
+	}
+	for signers, caCertificate := range caCertificates {
+		signerList := strings.Split(signers, ",")
+		if len(signerList) == 0 {
+			continue
+		}
+		for _, signer := range signerList {
+			if signer == signerName {
+				return []byte(caCertificate), nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("failed to find root cert for signer: %v in mesh config", signerName)
+}
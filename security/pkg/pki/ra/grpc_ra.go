@@ -0,0 +1,184 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ra
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	pb "istio.io/api/security/v1alpha1"
+	istiogrpc "istio.io/istio/pilot/pkg/grpc"
+	"istio.io/istio/pkg/security"
+	"istio.io/istio/security/pkg/pki/ca"
+	raerror "istio.io/istio/security/pkg/pki/error"
+	"istio.io/istio/security/pkg/pki/util"
+)
+
+const (
+	// SubjectIDs is the request metadata key holding the identities the certificate is requested for, as
+	// authenticated by Istiod. The signer should only issue certificates for these identities.
+	SubjectIDs = "SubjectIDs"
+
+	defaultExternalCATimeout = 10 * time.Second
+)
+
+// GRPCRA integrates with an external CA by forwarding CSRs to an out-of-process signer over gRPC.
+//
+// The signer implements the IstioCertificateService API. Each request carries the CSR, the requested lifetime,
+// and the CertSigner and SubjectIDs metadata. The response cert chain must contain the signed leaf certificate,
+// followed by any intermediate certificates, with the root certificate bundle of the signer as the last entry.
+//
+// The root bundle returned by the signer is never trusted: every chain is verified against the root configured
+// for the RA, either with CaCertFile or CaCertPEM. Root rotation is done by updating that configuration.
+type GRPCRA struct {
+	conn          *grpc.ClientConn
+	client        pb.IstioCertificateServiceClient
+	raOpts        *IstioRAOptions
+	keyCertBundle *util.KeyCertBundle
+	meshConfigCACertificates
+}
+
+// NewGRPCRA creates a RA that forwards CSRs to the signer at raOpts.ExternalCAAddr.
+func NewGRPCRA(raOpts *IstioRAOptions) (*GRPCRA, error) {
+	if raOpts.ExternalCAAddr == "" {
+		return nil, raerror.NewError(raerror.CAInitFail, fmt.Errorf("address of the external signer is required"))
+	}
+	var keyCertBundle *util.KeyCertBundle
+	if raOpts.CaCertFile != "" {
+		var err error
+		keyCertBundle, err = util.NewKeyCertBundleWithRootCertFromFile(raOpts.CaCertFile)
+		if err != nil {
+			return nil, raerror.NewError(raerror.CAInitFail, fmt.Errorf("error processing Certificate Bundle for gRPC RA: %v", err))
+		}
+	} else {
+		keyCertBundle = util.NewKeyCertBundleFromPem(nil, nil, nil, raOpts.CaCertPEM)
+	}
+	if len(keyCertBundle.GetRootCertPem()) == 0 {
+		return nil, raerror.NewError(raerror.CAInitFail, fmt.Errorf("the root cert of the external signer is required"))
+	}
+	opts, err := istiogrpc.ClientOptions(nil, raOpts.ExternalCATLS)
+	if err != nil {
+		return nil, raerror.NewError(raerror.CAInitFail, err)
+	}
+	conn, err := grpc.NewClient(raOpts.ExternalCAAddr, opts...)
+	if err != nil {
+		return nil, raerror.NewError(raerror.CAInitFail, fmt.Errorf("failed to connect to external signer %s: %v",
+			raOpts.ExternalCAAddr, err))
+	}
+	return &GRPCRA{
+		conn:                     conn,
+		client:                   pb.NewIstioCertificateServiceClient(conn),
+		raOpts:                   raOpts,
+		keyCertBundle:            keyCertBundle,
+		meshConfigCACertificates: newMeshConfigCACertificates(),
+	}, nil
+}
+
+// Close closes the connection to the signer.
+func (r *GRPCRA) Close() error {
+	return r.conn.Close()
+}
+
+// signerSign sends the CSR to the signer, and returns the signed cert chain, without the root bundle of the signer.
+// The chain is verified against rootCert.
+func (r *GRPCRA) signerSign(csrPEM []byte, certOpts ca.CertOpts, rootCert []byte) ([]byte, error) {
+	lifetime, err := preSign(r.raOpts, csrPEM, certOpts.SubjectIDs, certOpts.TTL, certOpts.ForCA)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]any, 0, len(certOpts.SubjectIDs))
+	for _, id := range certOpts.SubjectIDs {
+		ids = append(ids, id)
+	}
+	md, err := structpb.NewStruct(map[string]any{
+		security.CertSigner: certOpts.CertSigner,
+		SubjectIDs:          ids,
+	})
+	if err != nil {
+		return nil, raerror.NewError(raerror.CertGenError, err)
+	}
+	timeout := r.raOpts.ExternalCATimeout
+	if timeout <= 0 {
+		timeout = defaultExternalCATimeout
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	resp, err := r.client.CreateCertificate(ctx, &pb.IstioCertificateRequest{
+		Csr:              string(csrPEM),
+		ValidityDuration: int64(lifetime.Seconds()),
+		Metadata:         md,
+	})
+	if err != nil {
+		return nil, raerror.NewError(raerror.CertGenError, fmt.Errorf("external signer %s failed to sign CSR: %v",
+			r.raOpts.ExternalCAAddr, err))
+	}
+	if len(resp.CertChain) < 2 {
+		return nil, raerror.NewError(raerror.CertGenError, fmt.Errorf(
+			"external signer returned %d certificates, expected the signed cert chain followed by the root bundle", len(resp.CertChain)))
+	}
+	var certChain []byte
+	for _, c := range resp.CertChain[:len(resp.CertChain)-1] {
+		certChain = append(certChain, withTrailingNewline(c)...)
+	}
+	if err := util.VerifyCertificate(nil, certChain, rootCert, nil); err != nil {
+		return nil, raerror.NewError(raerror.CertGenError, fmt.Errorf("cert chain returned by external signer "+
+			"cannot be verified with the configured root cert: %v", err))
+	}
+	return certChain, nil
+}
+
+// Sign takes a PEM-encoded CSR and cert opts, and returns the certificate and any intermediate certificates
+// signed by the external signer. The root cert is available from GetCAKeyCertBundle.
+func (r *GRPCRA) Sign(csrPEM []byte, certOpts ca.CertOpts) ([]byte, error) {
+	return r.signerSign(csrPEM, certOpts, r.keyCertBundle.GetRootCertPem())
+}
+
+// SignWithCertChain is similar to Sign but returns the leaf cert and the entire cert chain, and is used for
+// custom cert signers. The chain is verified against the root cert for the signer from mesh config if present,
+// which is then appended to the response, or else against the root cert of the RA.
+func (r *GRPCRA) SignWithCertChain(csrPEM []byte, certOpts ca.CertOpts) ([]string, error) {
+	rootCert := r.keyCertBundle.GetRootCertPem()
+	rootCertFromMeshConfig, err := r.GetRootCertFromMeshConfig(r.raOpts.CertSignerDomain + "/" + certOpts.CertSigner)
+	if err == nil {
+		rootCert = rootCertFromMeshConfig
+	}
+	certChain, err := r.signerSign(csrPEM, certOpts, rootCert)
+	if err != nil {
+		return nil, err
+	}
+	respCertChain := []string{string(certChain)}
+	if !bytes.Equal(rootCert, r.keyCertBundle.GetRootCertPem()) {
+		respCertChain = append(respCertChain, string(rootCert))
+	}
+	return respCertChain, nil
+}
+
+// GetCAKeyCertBundle returns the KeyCertBundle for the CA.
+func (r *GRPCRA) GetCAKeyCertBundle() *util.KeyCertBundle {
+	return r.keyCertBundle
+}
+
+func withTrailingNewline(pem string) []byte {
+	if !strings.HasSuffix(pem, "\n") {
+		pem += "\n"
+	}
+	return []byte(pem)
+}
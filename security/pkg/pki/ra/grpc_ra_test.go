@@ -0,0 +1,162 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ra
+
+import (
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	meshconfig "istio.io/api/mesh/v1alpha1"
+	"istio.io/istio/pkg/security"
+	"istio.io/istio/pkg/test/env"
+	"istio.io/istio/pkg/test/util/assert"
+	"istio.io/istio/security/pkg/pki/ca"
+	raerror "istio.io/istio/security/pkg/pki/error"
+	"istio.io/istio/security/pkg/pki/ra/mock"
+	pkiutil "istio.io/istio/security/pkg/pki/util"
+)
+
+func newFakeSigner(t *testing.T) *mock.FakeSigner {
+	t.Helper()
+	signer, err := mock.NewFakeSigner()
+	assert.NoError(t, err)
+	t.Cleanup(signer.Stop)
+	return signer
+}
+
+func createFakeGRPCRA(t *testing.T, signer *mock.FakeSigner, caCertFile string, caCertPEM []byte) *GRPCRA {
+	t.Helper()
+	r, err := NewIstioRA(&IstioRAOptions{
+		ExternalCAType:   ExtCAGRPC,
+		ExternalCAAddr:   signer.Addr,
+		DefaultCertTTL:   30 * time.Minute,
+		MaxCertTTL:       time.Hour,
+		CaCertFile:       caCertFile,
+		CaCertPEM:        caCertPEM,
+		VerifyAppendCA:   true,
+		CertSignerDomain: "example.com",
+	})
+	assert.NoError(t, err)
+	t.Cleanup(func() { _ = r.(*GRPCRA).Close() })
+	return r.(*GRPCRA)
+}
+
+func TestNewGRPCRARequiresRoot(t *testing.T) {
+	signer := newFakeSigner(t)
+	_, err := NewGRPCRA(&IstioRAOptions{ExternalCAType: ExtCAGRPC, ExternalCAAddr: signer.Addr})
+	assert.Error(t, err)
+}
+
+func TestGRPCSign(t *testing.T) {
+	signer := newFakeSigner(t)
+	root := signer.RootBundle()
+	r := createFakeGRPCRA(t, signer, "", root)
+	assert.Equal(t, r.GetCAKeyCertBundle().GetRootCertPem(), root)
+
+	certChain, err := r.Sign(createDefaultFakeCsr(t), ca.CertOpts{SubjectIDs: []string{testCsrHostName}, TTL: time.Minute})
+	assert.NoError(t, err)
+	certs, _, err := pkiutil.ParsePemEncodedCertificateChain(certChain)
+	assert.NoError(t, err)
+	// Leaf and intermediate; the root is exposed separately.
+	assert.Equal(t, len(certs), 2)
+	assert.NoError(t, pkiutil.VerifyCertificate(nil, certChain, root, nil))
+	ids, err := pkiutil.ExtractIDs(certs[0].Extensions)
+	assert.NoError(t, err)
+	assert.Equal(t, ids, []string{testCsrHostName})
+
+	requests := signer.Requests()
+	assert.Equal(t, len(requests), 1)
+	assert.Equal(t, requests[0].ValidityDuration, int64(60))
+	ids = nil
+	for _, id := range requests[0].Metadata.GetFields()[SubjectIDs].GetListValue().GetValues() {
+		ids = append(ids, id.GetStringValue())
+	}
+	assert.Equal(t, ids, []string{testCsrHostName})
+
+	// Roots returned by the signer are never picked up by the RA.
+	extraRoot, err := os.ReadFile(path.Join(env.IstioSrc, "samples/certs", "root-cert.pem"))
+	assert.NoError(t, err)
+	signer.AddRoot(extraRoot)
+	_, err = r.Sign(createDefaultFakeCsr(t), ca.CertOpts{SubjectIDs: []string{testCsrHostName}})
+	assert.NoError(t, err)
+	assert.Equal(t, r.GetCAKeyCertBundle().GetRootCertPem(), root)
+	// The default TTL is requested if none is set.
+	assert.Equal(t, signer.Requests()[1].ValidityDuration, int64(30*60))
+}
+
+func TestGRPCSignErrors(t *testing.T) {
+	signer := newFakeSigner(t)
+	r := createFakeGRPCRA(t, signer, "", signer.RootBundle())
+	csrPEM := createDefaultFakeCsr(t)
+
+	// Requests failing validation are not sent to the signer.
+	_, err := r.Sign(csrPEM, ca.CertOpts{SubjectIDs: []string{testCsrHostName}, TTL: 2 * time.Hour})
+	assert.Error(t, err)
+	_, err = r.Sign(csrPEM, ca.CertOpts{SubjectIDs: []string{"spiffe://cluster.local/ns/other/sa/other"}})
+	assert.Error(t, err)
+	assert.Equal(t, len(signer.Requests()), 0)
+
+	signer.SetError(status.Error(codes.PermissionDenied, "denied"))
+	_, err = r.Sign(csrPEM, ca.CertOpts{SubjectIDs: []string{testCsrHostName}})
+	assert.Error(t, err)
+	assert.Equal(t, err.(*raerror.Error).ErrorType(), "CERT_GEN_ERROR")
+}
+
+func TestGRPCSignWithCACertFile(t *testing.T) {
+	// The chain returned by the signer cannot be verified with an unrelated root, even though the signer
+	// returns the root it was signed with.
+	signer := newFakeSigner(t)
+	r := createFakeGRPCRA(t, signer, TestCACertFile, nil)
+	rootCert, err := os.ReadFile(TestCACertFile)
+	assert.NoError(t, err)
+	assert.Equal(t, r.GetCAKeyCertBundle().GetRootCertPem(), rootCert)
+	_, err = r.Sign(createDefaultFakeCsr(t), ca.CertOpts{SubjectIDs: []string{testCsrHostName}})
+	assert.Error(t, err)
+	assert.Equal(t, r.GetCAKeyCertBundle().GetRootCertPem(), rootCert)
+
+	rootFile := path.Join(t.TempDir(), "root-cert.pem")
+	assert.NoError(t, os.WriteFile(rootFile, signer.RootBundle(), 0o644))
+	r = createFakeGRPCRA(t, signer, rootFile, nil)
+	_, err = r.Sign(createDefaultFakeCsr(t), ca.CertOpts{SubjectIDs: []string{testCsrHostName}})
+	assert.NoError(t, err)
+}
+
+func TestGRPCSignWithCertChain(t *testing.T) {
+	signer := newFakeSigner(t)
+	r := createFakeGRPCRA(t, signer, "", signer.RootBundle())
+	certOpts := ca.CertOpts{SubjectIDs: []string{testCsrHostName}, CertSigner: "custom"}
+
+	// Without a root for the signer in mesh config, the chain is verified with the root of the RA, which is
+	// added to the response by the CA server.
+	chain, err := r.SignWithCertChain(createDefaultFakeCsr(t), certOpts)
+	assert.NoError(t, err)
+	assert.Equal(t, len(chain), 1)
+	assert.Equal(t, signer.Requests()[0].Metadata.GetFields()[security.CertSigner].GetStringValue(), "custom")
+
+	// The root from mesh config is preferred, and must verify the chain.
+	otherRoot, err := os.ReadFile(TestCACertFile)
+	assert.NoError(t, err)
+	r.SetCACertificatesFromMeshConfig([]*meshconfig.MeshConfig_CertificateData{{
+		CertificateData: &meshconfig.MeshConfig_CertificateData_Pem{Pem: string(otherRoot)},
+		CertSigners:     []string{"example.com/custom"},
+	}})
+	_, err = r.SignWithCertChain(createDefaultFakeCsr(t), certOpts)
+	assert.Error(t, err)
+}
@@ -17,14 +17,11 @@ package ra
 import (
 	"bytes"
 	"fmt"
-	"strings"
-	"sync"
 	"time"
 
 	cert "k8s.io/api/certificates/v1"
 	clientset "k8s.io/client-go/kubernetes"
 
-	meshconfig "istio.io/api/mesh/v1alpha1"
 	"istio.io/istio/pkg/log"
 	"istio.io/istio/security/pkg/k8s/chiron"
 	"istio.io/istio/security/pkg/pki/ca"
@@ -34,13 +31,11 @@ import (
 
 // KubernetesRA integrated with an external CA using Kubernetes CSR API
 type KubernetesRA struct {
-	csrInterface                 clientset.Interface
-	keyCertBundle                *util.KeyCertBundle
-	raOpts                       *IstioRAOptions
-	caCertificatesFromMeshConfig map[string]string
-	certSignerDomain             string
-	// mutex protects the R/W to caCertificatesFromMeshConfig.
-	mutex sync.RWMutex
+	csrInterface     clientset.Interface
+	keyCertBundle    *util.KeyCertBundle
+	raOpts           *IstioRAOptions
+	certSignerDomain string
+	meshConfigCACertificates
 }
 
 var pkiRaLog = log.RegisterScope("pkira", "Istiod RA log")
@@ -52,11 +47,11 @@ func NewKubernetesRA(raOpts *IstioRAOptions) (*KubernetesRA, error) {
 		return nil, raerror.NewError(raerror.CAInitFail, fmt.Errorf("error processing Certificate Bundle for Kubernetes RA"))
 	}
 	istioRA := &KubernetesRA{
-		csrInterface:                 raOpts.K8sClient,
-		raOpts:                       raOpts,
-		keyCertBundle:                keyCertBundle,
-		certSignerDomain:             raOpts.CertSignerDomain,
-		caCertificatesFromMeshConfig: make(map[string]string),
+		csrInterface:             raOpts.K8sClient,
+		raOpts:                   raOpts,
+		keyCertBundle:            keyCertBundle,
+		certSignerDomain:         raOpts.CertSignerDomain,
+		meshConfigCACertificates: newMeshConfigCACertificates(),
 	}
 	return istioRA, nil
 }
@@ -155,40 +150,3 @@ func (r *KubernetesRA) SignWithCertChain(csrPEM []byte, certOpts ca.CertOpts) ([
 func (r *KubernetesRA) GetCAKeyCertBundle() *util.KeyCertBundle {
 	return r.keyCertBundle
 }
-
-func (r *KubernetesRA) SetCACertificatesFromMeshConfig(caCertificates []*meshconfig.MeshConfig_CertificateData) {
-	r.mutex.Lock()
-	for _, pemCert := range caCertificates {
-		// TODO:  take care of spiffe bundle format as well
-		cert := pemCert.GetPem()
-		certSigners := pemCert.CertSigners
-		if len(certSigners) != 0 {
-			certSigner := strings.Join(certSigners, ",")
-			if cert != "" {
-				r.caCertificatesFromMeshConfig[certSigner] = cert
-			}
-		}
-	}
-	r.mutex.Unlock()
-}
-
-func (r *KubernetesRA) GetRootCertFromMeshConfig(signerName string) ([]byte, error) {
-	r.mutex.RLock()
-	defer r.mutex.RUnlock()
-	caCertificates := r.caCertificatesFromMeshConfig
-	if len(caCertificates) == 0 {
-		return nil, fmt.Errorf("no caCertificates defined in mesh config")
-	}
-	for signers, caCertificate := range caCertificates {
-		signerList := strings.Split(signers, ",")
-		if len(signerList) == 0 {
-			continue
-		}
-		for _, signer := range signerList {
-			if signer == signerName {
-				return []byte(caCertificate), nil
-			}
-		}
-	}
-	return nil, fmt.Errorf("failed to find root cert for signer: %v in mesh config", signerName)
-}
@@ -0,0 +1,175 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mock
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/pem"
+	"net"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+
+	pb "istio.io/api/security/v1alpha1"
+	"istio.io/istio/security/pkg/pki/util"
+)
+
+// FakeSigner is a local external signer for the gRPC RA. It issues certificates from an intermediate CA, and
+// responds with the leaf and intermediate certificates followed by the root bundle.
+type FakeSigner struct {
+	pb.UnimplementedIstioCertificateServiceServer
+	// Addr is the address the signer is listening on.
+	Addr string
+
+	server           *grpc.Server
+	intermediateCert *x509.Certificate
+	intermediateKey  any
+	intermediatePem  []byte
+
+	mu         sync.Mutex
+	rootBundle []byte
+	err        error
+	requests   []*pb.IstioCertificateRequest
+}
+
+// NewFakeSigner creates a signer with a new root and intermediate CA, and starts serving on a local port.
+func NewFakeSigner() (*FakeSigner, error) {
+	rootPem, rootKeyPem, err := util.GenCertKeyFromOptions(util.CertOptions{
+		Org:          "fake-signer-root",
+		TTL:          24 * time.Hour,
+		IsCA:         true,
+		IsSelfSigned: true,
+		ECSigAlg:     util.EcdsaSigAlg,
+	})
+	if err != nil {
+		return nil, err
+	}
+	rootCert, err := util.ParsePemEncodedCertificate(rootPem)
+	if err != nil {
+		return nil, err
+	}
+	rootKey, err := util.ParsePemEncodedKey(rootKeyPem)
+	if err != nil {
+		return nil, err
+	}
+	intermediatePem, intermediateKeyPem, err := util.GenCertKeyFromOptions(util.CertOptions{
+		Org:        "fake-signer-intermediate",
+		TTL:        24 * time.Hour,
+		IsCA:       true,
+		SignerCert: rootCert,
+		SignerPriv: rootKey,
+		ECSigAlg:   util.EcdsaSigAlg,
+	})
+	if err != nil {
+		return nil, err
+	}
+	intermediateCert, err := util.ParsePemEncodedCertificate(intermediatePem)
+	if err != nil {
+		return nil, err
+	}
+	intermediateKey, err := util.ParsePemEncodedKey(intermediateKeyPem)
+	if err != nil {
+		return nil, err
+	}
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+	s := &FakeSigner{
+		Addr:             listener.Addr().String(),
+		server:           grpc.NewServer(),
+		intermediateCert: intermediateCert,
+		intermediateKey:  intermediateKey,
+		intermediatePem:  intermediatePem,
+		rootBundle:       rootPem,
+	}
+	pb.RegisterIstioCertificateServiceServer(s.server, s)
+	go func() {
+		_ = s.server.Serve(listener)
+	}()
+	return s, nil
+}
+
+// Stop stops the signer.
+func (s *FakeSigner) Stop() {
+	s.server.Stop()
+}
+
+// RootBundle returns the root bundle returned by the signer.
+func (s *FakeSigner) RootBundle() []byte {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.rootBundle
+}
+
+// AddRoot appends a root certificate to the root bundle returned by the signer, as is done during root rotation.
+func (s *FakeSigner) AddRoot(rootPem []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.rootBundle = append(append([]byte{}, s.rootBundle...), rootPem...)
+}
+
+// SetError makes the signer fail all requests with err, or succeed if err is nil.
+func (s *FakeSigner) SetError(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.err = err
+}
+
+// Requests returns the requests received by the signer.
+func (s *FakeSigner) Requests() []*pb.IstioCertificateRequest {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make([]*pb.IstioCertificateRequest, 0, len(s.requests))
+	for _, r := range s.requests {
+		out = append(out, proto.Clone(r).(*pb.IstioCertificateRequest))
+	}
+	return out
+}
+
+// CreateCertificate signs the CSR for the SubjectIDs in the request metadata.
+func (s *FakeSigner) CreateCertificate(_ context.Context, request *pb.IstioCertificateRequest) (*pb.IstioCertificateResponse, error) {
+	s.mu.Lock()
+	s.requests = append(s.requests, proto.Clone(request).(*pb.IstioCertificateRequest))
+	err, rootBundle := s.err, s.rootBundle
+	s.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	csr, err := util.ParsePemEncodedCSR([]byte(request.Csr))
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid CSR: %v", err)
+	}
+	var ids []string
+	for _, id := range request.Metadata.GetFields()["SubjectIDs"].GetListValue().GetValues() {
+		ids = append(ids, id.GetStringValue())
+	}
+	ttl := time.Duration(request.ValidityDuration) * time.Second
+	certBytes, err := util.GenCertFromCSR(csr, s.intermediateCert, csr.PublicKey, s.intermediateKey, ids, ttl, false)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to sign CSR: %v", err)
+	}
+	cert := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certBytes})
+	return &pb.IstioCertificateResponse{
+		CertChain: []string{string(cert), string(s.intermediatePem), string(rootBundle)},
+	}, nil
+}
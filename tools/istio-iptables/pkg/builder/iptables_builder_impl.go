@@ -25,6 +25,7 @@ import (
 	"istio.io/istio/tools/istio-iptables/pkg/config"
 	"istio.io/istio/tools/istio-iptables/pkg/constants"
 	iptableslog "istio.io/istio/tools/istio-iptables/pkg/log"
+	"istio.io/istio/tools/istio-iptables/pkg/nftables"
 )
 
 // Rule represents iptables rule - chain, table and options
@@ -410,6 +411,28 @@ func (rb *IptablesRuleBuilder) BuildV6Restore() string {
 	return rb.buildRestore(rb.rules.rulesv6)
 }
 
+// BuildNftables translates the rules into an nftables ruleset, with tables for IPv6 if it is enabled.
+func (rb *IptablesRuleBuilder) BuildNftables() (*nftables.Ruleset, error) {
+	rs := &nftables.Ruleset{Families: []nftables.Family{nftables.IPv4}}
+	if rb.cfg.EnableIPv6 {
+		rs.Families = append(rs.Families, nftables.IPv6)
+	}
+	for _, family := range rs.Families {
+		rules := rb.rules.rulesv4
+		if family == nftables.IPv6 {
+			rules = rb.rules.rulesv6
+		}
+		tables, err := nftables.Translate(family, slices.Map(rules, func(r Rule) nftables.IptablesRule {
+			return nftables.IptablesRule{Table: r.table, Chain: r.chain, Params: r.params}
+		}))
+		if err != nil {
+			return nil, fmt.Errorf("failed to translate %s rules to nftables: %v", family, err)
+		}
+		rs.Tables = append(rs.Tables, tables...)
+	}
+	return rs, nil
+}
+
 // getStateFromSave function takes a string in iptables-restore format and returns a map of the tables, chains, and rules.
 // Note that if this function is used to parse iptables-save output, the rules may have changed since they were first applied
 // as rules do not necessarily undergo a round-trip through the kernel in the same form.
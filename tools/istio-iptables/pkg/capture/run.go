@@ -277,23 +277,28 @@ func configureIPv6Addresses(cfg *config.Config) error {
 }
 
 func (cfg *IptablesConfigurator) Run() error {
-	iptVer, err := cfg.ext.DetectIptablesVersion(false)
-	if err != nil {
-		return err
-	}
-
-	ipt6Ver, err := cfg.ext.DetectIptablesVersion(true)
-	if err != nil {
-		return err
-	}
+	var iptVer, ipt6Ver dep.IptablesVersion
+	// The iptables binaries are not used at all with native nftables.
+	if !cfg.cfg.NativeNftables {
+		var err error
+		iptVer, err = cfg.ext.DetectIptablesVersion(false)
+		if err != nil {
+			return err
+		}
 
-	defer func() {
-		// Best effort since we don't know if the commands exist
-		_ = cfg.ext.Run(constants.IPTablesSave, &iptVer, nil)
-		if cfg.cfg.EnableIPv6 {
-			_ = cfg.ext.Run(constants.IPTablesSave, &ipt6Ver, nil)
+		ipt6Ver, err = cfg.ext.DetectIptablesVersion(true)
+		if err != nil {
+			return err
 		}
-	}()
+
+		defer func() {
+			// Best effort since we don't know if the commands exist
+			_ = cfg.ext.Run(constants.IPTablesSave, &iptVer, nil)
+			if cfg.cfg.EnableIPv6 {
+				_ = cfg.ext.Run(constants.IPTablesSave, &ipt6Ver, nil)
+			}
+		}()
+	}
 
 	// Since OUTBOUND_IP_RANGES_EXCLUDE could carry ipv4 and ipv6 ranges
 	// need to split them in different arrays one for ipv4 and one for ipv6
@@ -554,6 +559,9 @@ func (cfg *IptablesConfigurator) Run() error {
 		cfg.ruleBuilder.InsertRule(iptableslog.UndefinedCommand, constants.ISTIOINBOUND, constants.MANGLE, 3,
 			"-p", constants.TCP, "-i", "lo", "-m", "mark", "!", "--mark", outboundMark, "-j", constants.RETURN)
 	}
+	if cfg.cfg.NativeNftables {
+		return cfg.executeNftables()
+	}
 	return cfg.executeCommands(&iptVer, &ipt6Ver)
 }
 
@@ -910,3 +918,24 @@ func (cfg *IptablesConfigurator) executeCommands(iptVer, ipt6Ver *dep.IptablesVe
 
 	return nil
 }
+
+// executeNftables applies the rules as nftables tables. Since the Istio tables are replaced atomically,
+// there is no previous state to verify or reconcile, unlike with iptables.
+func (cfg *IptablesConfigurator) executeNftables() error {
+	if cfg.cfg.CleanupOnly {
+		log.Info("Performing cleanup of existing nftables tables")
+		// An empty ruleset owns the same address families, without depending on the rules being translatable.
+		rs, err := builder.NewIptablesRuleBuilder(cfg.cfg).BuildNftables()
+		if err != nil {
+			return err
+		}
+		return cfg.ext.DeleteNftables(rs)
+	}
+
+	rs, err := cfg.ruleBuilder.BuildNftables()
+	if err != nil {
+		return err
+	}
+	log.Info("Applying nftables tables")
+	return cfg.ext.ApplyNftables(rs)
+}
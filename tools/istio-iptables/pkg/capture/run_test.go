@@ -302,6 +302,33 @@ func TestIptables(t *testing.T) {
 	}
 }
 
+func TestNftables(t *testing.T) {
+	for _, tt := range getCommonTestCases() {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := constructTestConfig()
+			tt.config(cfg)
+			cfg.NativeNftables = true
+
+			ext := &dep.DependenciesStub{}
+			iptConfigurator := NewIptablesConfigurator(cfg, ext)
+			assert.NoError(t, iptConfigurator.Run())
+			compareToGolden(t, filepath.Join("nft", tt.name), ext.ExecutedAll)
+		})
+	}
+}
+
+func TestNftablesCleanup(t *testing.T) {
+	cfg := constructTestConfig()
+	cfg.NativeNftables = true
+	cfg.CleanupOnly = true
+	cfg.EnableIPv6 = true
+
+	ext := &dep.DependenciesStub{}
+	iptConfigurator := NewIptablesConfigurator(cfg, ext)
+	assert.NoError(t, iptConfigurator.Run())
+	compareToGolden(t, filepath.Join("nft", "cleanup"), ext.ExecutedAll)
+}
+
 func TestSeparateV4V6(t *testing.T) {
 	mkIPList := func(ips ...string) []netip.Prefix {
 		ret := []netip.Prefix{}
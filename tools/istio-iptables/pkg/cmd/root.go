@@ -148,6 +148,10 @@ func bindCmdlineFlags(cfg *config.Config, cmd *cobra.Command) {
 	// Consider removing it after several releases with no reported issues.
 	flag.BindEnv(fs, constants.ForceApply, "", "Apply iptables changes even if they appear to already be in place.",
 		&cfg.ForceApply)
+
+	flag.BindEnv(fs, constants.NativeNftables, "",
+		"Program the rules as nftables tables over netlink, without the iptables binaries. The Istio tables are replaced atomically.",
+		&cfg.NativeNftables)
 }
 
 func GetCommand(logOpts *log.Options) *cobra.Command {
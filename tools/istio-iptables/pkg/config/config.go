@@ -93,6 +93,8 @@ type Config struct {
 	Reconcile                bool       `json:"RECONCILE"`
 	CleanupOnly              bool       `json:"CLEANUP_ONLY"`
 	ForceApply               bool       `json:"FORCE_APPLY"`
+	// NativeNftables programs the rules as nftables tables over netlink, instead of running the iptables binaries.
+	NativeNftables bool `json:"NATIVE_NFTABLES"`
 }
 
 func (c *Config) String() string {
@@ -139,6 +141,7 @@ func (c *Config) Print() {
 	b.WriteString(fmt.Sprintf("RECONCILE=%t\n", c.Reconcile))
 	b.WriteString(fmt.Sprintf("CLEANUP_ONLY=%t\n", c.CleanupOnly))
 	b.WriteString(fmt.Sprintf("FORCE_APPLY=%t\n", c.ForceApply))
+	b.WriteString(fmt.Sprintf("NATIVE_NFTABLES=%t\n", c.NativeNftables))
 	log.Infof("Istio iptables variables:\n%s", b.String())
 }
 
@@ -109,6 +109,7 @@ const (
 	Reconcile                 = "reconcile"
 	CleanupOnly               = "cleanup-only"
 	ForceApply                = "force-apply"
+	NativeNftables            = "native-nftables"
 )
 
 // Environment variables that deliberately have no equivalent command-line flags.
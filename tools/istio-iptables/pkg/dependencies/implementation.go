@@ -26,6 +26,7 @@ import (
 
 	"istio.io/istio/pkg/log"
 	"istio.io/istio/tools/istio-iptables/pkg/constants"
+	"istio.io/istio/tools/istio-iptables/pkg/nftables"
 )
 
 // XTablesExittype is the exit type of xtables commands.
@@ -230,3 +231,15 @@ func (r *RealDependencies) RunWithOutput(cmd constants.IptablesCmd, iptVer *Ipta
 func (r *RealDependencies) RunQuietlyAndIgnore(cmd constants.IptablesCmd, iptVer *IptablesVersion, stdin io.ReadSeeker, args ...string) {
 	_ = r.executeXTables(cmd, iptVer, true, stdin, args...)
 }
+
+// ApplyNftables applies the ruleset in the current network namespace
+func (r *RealDependencies) ApplyNftables(rs *nftables.Ruleset) error {
+	log.Infof("Applying nftables ruleset:\n%v", strings.TrimSpace(rs.String()))
+	return r.applyNftables(rs)
+}
+
+// DeleteNftables deletes the Istio tables in the current network namespace
+func (r *RealDependencies) DeleteNftables(rs *nftables.Ruleset) error {
+	log.Infof("Deleting nftables tables of families %v", rs.Families)
+	return r.deleteNftables(rs)
+}
@@ -29,6 +29,7 @@ import (
 
 	"istio.io/istio/pkg/log"
 	"istio.io/istio/tools/istio-iptables/pkg/constants"
+	"istio.io/istio/tools/istio-iptables/pkg/nftables"
 )
 
 // TODO the entire `istio-iptables` package is linux-specific, I'm not sure we really need
@@ -283,3 +284,13 @@ func (r *RealDependencies) executeXTables(cmd constants.IptablesCmd, iptVer *Ipt
 	_, err := r.executeXTablesWithOutput(cmd, iptVer, ignoreErrors, stdin, args...)
 	return err
 }
+
+// applyNftables does not need the sandbox used for iptables: the rules are sent over netlink, in the network namespace
+// of the calling thread, so there is no lock to take and no user lookup to avoid.
+func (r *RealDependencies) applyNftables(rs *nftables.Ruleset) error {
+	return nftables.Apply(rs)
+}
+
+func (r *RealDependencies) deleteNftables(rs *nftables.Ruleset) error {
+	return nftables.Delete(rs)
+}
@@ -23,6 +23,7 @@ import (
 	"io"
 
 	"istio.io/istio/tools/istio-iptables/pkg/constants"
+	"istio.io/istio/tools/istio-iptables/pkg/nftables"
 )
 
 // ErrNotImplemented is returned when a requested feature is not implemented.
@@ -46,3 +47,11 @@ func (r *RealDependencies) executeXTablesWithOutput(cmd constants.IptablesCmd, i
 func shouldUseBinaryForCurrentContext(iptablesBin string) (IptablesVersion, error) {
 	return IptablesVersion{}, ErrNotImplemented
 }
+
+func (r *RealDependencies) applyNftables(rs *nftables.Ruleset) error {
+	return ErrNotImplemented
+}
+
+func (r *RealDependencies) deleteNftables(rs *nftables.Ruleset) error {
+	return ErrNotImplemented
+}
@@ -19,6 +19,7 @@ import (
 	"io"
 
 	"istio.io/istio/tools/istio-iptables/pkg/constants"
+	"istio.io/istio/tools/istio-iptables/pkg/nftables"
 )
 
 // Dependencies is used as abstraction for the commands used from the operating system
@@ -35,4 +36,10 @@ type Dependencies interface {
 	// DetectIptablesVersion consults the available binaries and in-use tables to determine
 	// which iptables variant (legacy, nft, v6, v4) we should use in the current context.
 	DetectIptablesVersion(ipV6 bool) (IptablesVersion, error)
+
+	// ApplyNftables atomically replaces the Istio nftables tables with the given ruleset, over netlink.
+	ApplyNftables(rs *nftables.Ruleset) error
+
+	// DeleteNftables removes the Istio nftables tables of the address families of the given ruleset.
+	DeleteNftables(rs *nftables.Ruleset) error
 }
@@ -24,6 +24,7 @@ import (
 
 	"istio.io/istio/pkg/env"
 	"istio.io/istio/tools/istio-iptables/pkg/constants"
+	"istio.io/istio/tools/istio-iptables/pkg/nftables"
 )
 
 var DryRunFilePath = env.Register("DRY_RUN_FILE_PATH", "", "If provided, StdoutStubDependencies will write the input from stdin to the given file.")
@@ -68,6 +69,18 @@ func (s *DependenciesStub) DetectIptablesVersion(ipV6 bool) (IptablesVersion, er
 	}, nil
 }
 
+func (s *DependenciesStub) ApplyNftables(rs *nftables.Ruleset) error {
+	s.ExecutedAll = append(s.ExecutedAll, rs.Lines()...)
+	_ = s.writeAllToDryRunPath()
+	return nil
+}
+
+func (s *DependenciesStub) DeleteNftables(rs *nftables.Ruleset) error {
+	s.ExecutedAll = append(s.ExecutedAll, rs.DeleteLines()...)
+	_ = s.writeAllToDryRunPath()
+	return nil
+}
+
 func (s *DependenciesStub) execute(quietly bool, cmd constants.IptablesCmd, iptVer *IptablesVersion, stdin io.ReadSeeker, args ...string) {
 	// We are either getting iptables rules as a `stdin` blob in `iptables-save` format (if this is a restore)
 	if stdin != nil {
@@ -0,0 +1,403 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package nftables
+
+import (
+	"fmt"
+	"os/user"
+	"strconv"
+	"strings"
+
+	"github.com/google/nftables"
+	"github.com/google/nftables/binaryutil"
+	"github.com/google/nftables/expr"
+	"golang.org/x/sys/unix"
+)
+
+// Apply replaces the Istio tables of the families of the ruleset with the tables of the ruleset, in a single
+// transaction. It is run in the network namespace of the calling thread.
+func Apply(rs *Ruleset) error {
+	return run(rs, true)
+}
+
+// Delete removes the Istio tables of the families of the ruleset, in a single transaction.
+// It is run in the network namespace of the calling thread.
+func Delete(rs *Ruleset) error {
+	return run(rs, false)
+}
+
+func run(rs *Ruleset, apply bool) error {
+	conn, err := nftables.New()
+	if err != nil {
+		return fmt.Errorf("failed to open netlink connection: %v", err)
+	}
+	for _, family := range rs.Families {
+		for _, name := range TableNames {
+			// Adding the table first ensures the deletion does not fail if the table does not exist.
+			t := &nftables.Table{Family: tableFamily(family), Name: name}
+			conn.AddTable(t)
+			conn.DelTable(t)
+		}
+	}
+	if apply {
+		for _, t := range rs.Tables {
+			if err := addTable(conn, t); err != nil {
+				return fmt.Errorf("table %s %s: %v", t.Family, t.Name, err)
+			}
+		}
+	}
+	if err := conn.Flush(); err != nil {
+		return fmt.Errorf("failed to apply nftables ruleset: %v", err)
+	}
+	return nil
+}
+
+func tableFamily(f Family) nftables.TableFamily {
+	if f == IPv6 {
+		return nftables.TableFamilyIPv6
+	}
+	return nftables.TableFamilyIPv4
+}
+
+var hookNums = map[string]*nftables.ChainHook{
+	"prerouting":  nftables.ChainHookPrerouting,
+	"input":       nftables.ChainHookInput,
+	"forward":     nftables.ChainHookForward,
+	"output":      nftables.ChainHookOutput,
+	"postrouting": nftables.ChainHookPostrouting,
+}
+
+// tableWriter is the part of *nftables.Conn used to add a table, which is faked in tests.
+type tableWriter interface {
+	AddTable(t *nftables.Table) *nftables.Table
+	AddChain(c *nftables.Chain) *nftables.Chain
+	AddRule(r *nftables.Rule) *nftables.Rule
+	AddSet(s *nftables.Set, vals []nftables.SetElement) error
+}
+
+func addTable(conn tableWriter, t *Table) error {
+	table := conn.AddTable(&nftables.Table{Family: tableFamily(t.Family), Name: t.Name})
+	sets := make(map[string]*nftables.Set, len(t.Sets))
+	for _, name := range t.Sets {
+		set := &nftables.Set{Table: table, Name: name, KeyType: SetKeyType(t.Family)}
+		if err := conn.AddSet(set, nil); err != nil {
+			return fmt.Errorf("set %s: %v", name, err)
+		}
+		sets[name] = set
+	}
+	// All the chains must exist before the rules jumping to them are added.
+	chains := make([]*nftables.Chain, 0, len(t.Chains))
+	for _, c := range t.Chains {
+		chain := &nftables.Chain{Name: c.Name, Table: table}
+		if c.Hook != nil {
+			chain.Type = nftables.ChainType(c.Hook.Type)
+			chain.Hooknum = hookNums[c.Hook.Hook]
+			chain.Priority = nftables.ChainPriorityRef(nftables.ChainPriority(c.Hook.Priority))
+			policy := nftables.ChainPolicyAccept
+			chain.Policy = &policy
+		}
+		chains = append(chains, conn.AddChain(chain))
+	}
+	for i, c := range t.Chains {
+		for _, r := range c.Rules {
+			exprs, err := ruleExprs(conn, table, sets, r)
+			if err != nil {
+				return fmt.Errorf("chain %s: %q: %v", c.Name, r.String(), err)
+			}
+			conn.AddRule(&nftables.Rule{Table: table, Chain: chains[i], Exprs: exprs})
+		}
+	}
+	return nil
+}
+
+const (
+	// ifNameSize is the size of interface names in the kernel, including the terminating null byte.
+	ifNameSize = unix.IFNAMSIZ
+	allBits    = ^uint32(0)
+)
+
+// SetKeyType returns the type of the elements of the address sets of a family.
+func SetKeyType(f Family) nftables.SetDatatype {
+	if f == IPv6 {
+		return nftables.TypeIP6Addr
+	}
+	return nftables.TypeIPAddr
+}
+
+// ruleExprs converts a rule into netlink expressions, looking up named sets in sets. All the expressions use
+// the first register.
+func ruleExprs(conn tableWriter, table *nftables.Table, sets map[string]*nftables.Set, r *Rule) ([]expr.Any, error) {
+	var exprs []expr.Any
+	cmp := func(neg bool, data []byte) *expr.Cmp {
+		op := expr.CmpOpEq
+		if neg {
+			op = expr.CmpOpNeq
+		}
+		return &expr.Cmp{Op: op, Register: 1, Data: data}
+	}
+	for _, e := range r.Exprs {
+		switch e := e.(type) {
+		case *L4Proto:
+			proto := byte(unix.IPPROTO_TCP)
+			if e.Proto == "udp" {
+				proto = unix.IPPROTO_UDP
+			}
+			exprs = append(exprs, &expr.Meta{Key: expr.MetaKeyL4PROTO, Register: 1}, cmp(e.Neg, []byte{proto}))
+		case *Addr:
+			addr := e.Prefix.Addr().AsSlice()
+			exprs = append(exprs, addrPayload(e.Source, e.Prefix.Addr().Is6()))
+			if !e.Prefix.IsSingleIP() {
+				mask := make([]byte, len(addr))
+				for i := 0; i < e.Prefix.Bits(); i++ {
+					mask[i/8] |= 0x80 >> (i % 8)
+				}
+				exprs = append(exprs, &expr.Bitwise{
+					SourceRegister: 1,
+					DestRegister:   1,
+					Len:            uint32(len(addr)),
+					Mask:           mask,
+					Xor:            make([]byte, len(addr)),
+				})
+			}
+			exprs = append(exprs, cmp(e.Neg, addr))
+		case *AddrSet:
+			set, ok := sets[e.Set]
+			if !ok {
+				return nil, fmt.Errorf("unknown set %q", e.Set)
+			}
+			exprs = append(exprs, addrPayload(e.Source, e.Family == IPv6), &expr.Lookup{
+				SourceRegister: 1,
+				SetID:          set.ID,
+				SetName:        set.Name,
+				Invert:         e.Neg,
+			})
+		case *Iface:
+			key := expr.MetaKeyOIFNAME
+			if e.Input {
+				key = expr.MetaKeyIIFNAME
+			}
+			var name []byte
+			if prefix, ok := strings.CutSuffix(e.Name, "+"); ok {
+				// Comparing without the null terminator matches any interface with the prefix.
+				name = []byte(prefix)
+			} else {
+				name = make([]byte, ifNameSize)
+				copy(name, e.Name)
+			}
+			exprs = append(exprs, &expr.Meta{Key: key, Register: 1}, cmp(e.Neg, name))
+		case *Port:
+			exprs = append(exprs, portPayload(e.Source))
+			if e.Min == e.Max {
+				exprs = append(exprs, cmp(e.Neg, binaryutil.BigEndian.PutUint16(e.Min)))
+			} else {
+				op := expr.CmpOpEq
+				if e.Neg {
+					op = expr.CmpOpNeq
+				}
+				exprs = append(exprs, &expr.Range{
+					Op:       op,
+					Register: 1,
+					FromData: binaryutil.BigEndian.PutUint16(e.Min),
+					ToData:   binaryutil.BigEndian.PutUint16(e.Max),
+				})
+			}
+		case *PortSet:
+			set := &nftables.Set{
+				Table:     table,
+				Anonymous: true,
+				Constant:  true,
+				KeyType:   nftables.TypeInetService,
+			}
+			elements := make([]nftables.SetElement, 0, len(e.Ports))
+			for _, p := range e.Ports {
+				elements = append(elements, nftables.SetElement{Key: binaryutil.BigEndian.PutUint16(p)})
+			}
+			if err := conn.AddSet(set, elements); err != nil {
+				return nil, err
+			}
+			exprs = append(exprs, portPayload(e.Source), &expr.Lookup{
+				SourceRegister: 1,
+				SetID:          set.ID,
+				SetName:        set.Name,
+				Invert:         e.Neg,
+			})
+		case *Mark:
+			exprs = append(exprs, markLoad(e.Conntrack))
+			if e.Mask != allBits {
+				exprs = append(exprs, &expr.Bitwise{
+					SourceRegister: 1,
+					DestRegister:   1,
+					Len:            4,
+					Mask:           binaryutil.NativeEndian.PutUint32(e.Mask),
+					Xor:            binaryutil.NativeEndian.PutUint32(0),
+				})
+			}
+			exprs = append(exprs, cmp(e.Neg, binaryutil.NativeEndian.PutUint32(e.Value&e.Mask)))
+		case *Owner:
+			key := expr.MetaKeySKUID
+			if e.Group {
+				key = expr.MetaKeySKGID
+			}
+			id, err := lookupID(e)
+			if err != nil {
+				return nil, err
+			}
+			exprs = append(exprs, &expr.Meta{Key: key, Register: 1}, cmp(e.Neg, binaryutil.NativeEndian.PutUint32(id)))
+		case *SocketExists:
+			// Loading the owner ends the evaluation of the rule for packets without a socket, so any owner matches.
+			exprs = append(exprs,
+				&expr.Meta{Key: expr.MetaKeySKUID, Register: 1},
+				&expr.Cmp{Op: expr.CmpOpGte, Register: 1, Data: binaryutil.NativeEndian.PutUint32(0)})
+		case *CtState:
+			exprs = append(exprs,
+				&expr.Ct{Key: expr.CtKeySTATE, Register: 1},
+				&expr.Bitwise{
+					SourceRegister: 1,
+					DestRegister:   1,
+					Len:            4,
+					Mask:           binaryutil.NativeEndian.PutUint32(e.States),
+					Xor:            binaryutil.NativeEndian.PutUint32(0),
+				},
+				cmp(true, binaryutil.NativeEndian.PutUint32(0)))
+		case *SetMark:
+			if e.Mask == allBits {
+				exprs = append(exprs, &expr.Immediate{Register: 1, Data: binaryutil.NativeEndian.PutUint32(e.Value)})
+			} else {
+				exprs = append(exprs, markLoad(e.Conntrack), &expr.Bitwise{
+					SourceRegister: 1,
+					DestRegister:   1,
+					Len:            4,
+					Mask:           binaryutil.NativeEndian.PutUint32(^e.Mask),
+					Xor:            binaryutil.NativeEndian.PutUint32(e.Value),
+				})
+			}
+			exprs = append(exprs, markStore(e.Conntrack))
+		case *CopyMark:
+			exprs = append(exprs, markLoad(e.ToPacket), markStore(!e.ToPacket))
+		case *CtZone:
+			exprs = append(exprs,
+				&expr.Immediate{Register: 1, Data: binaryutil.NativeEndian.PutUint16(e.Zone)},
+				&expr.Ct{Key: expr.CtKeyZONE, Register: 1, SourceRegister: true})
+		case *Log:
+			exprs = append(exprs, &expr.Log{
+				Key:     1<<unix.NFTA_LOG_PREFIX | 1<<unix.NFTA_LOG_GROUP | 1<<unix.NFTA_LOG_SNAPLEN,
+				Data:    []byte(e.Prefix),
+				Group:   e.Group,
+				Snaplen: e.Snaplen,
+			})
+		case *Redirect:
+			exprs = append(exprs,
+				&expr.Immediate{Register: 1, Data: binaryutil.BigEndian.PutUint16(e.Port)},
+				&expr.Redir{RegisterProtoMin: 1})
+		case *SNAT:
+			family := uint32(unix.NFPROTO_IPV4)
+			if e.Addr.Is6() {
+				family = unix.NFPROTO_IPV6
+			}
+			exprs = append(exprs,
+				&expr.Immediate{Register: 1, Data: e.Addr.AsSlice()},
+				&expr.NAT{Type: expr.NATTypeSourceNAT, Family: family, RegAddrMin: 1})
+		case *TProxy:
+			family := byte(unix.NFPROTO_IPV4)
+			if table.Family == nftables.TableFamilyIPv6 {
+				family = unix.NFPROTO_IPV6
+			}
+			exprs = append(exprs,
+				&expr.Immediate{Register: 1, Data: binaryutil.BigEndian.PutUint16(e.Port)},
+				&expr.TProxy{Family: family, TableFamily: family, RegPort: 1})
+		case *Verdict:
+			v := &expr.Verdict{}
+			switch e.Kind {
+			case Accept:
+				v.Kind = expr.VerdictAccept
+			case Drop:
+				v.Kind = expr.VerdictDrop
+			case Return:
+				v.Kind = expr.VerdictReturn
+			case Jump:
+				v.Kind = expr.VerdictJump
+				v.Chain = e.Chain
+			default:
+				return nil, fmt.Errorf("unsupported verdict %q", e.Kind)
+			}
+			exprs = append(exprs, v)
+		default:
+			return nil, fmt.Errorf("unsupported expression %T", e)
+		}
+	}
+	return exprs, nil
+}
+
+// lookupID returns the numeric id of the owner. Only names need a lookup, which may use NSS.
+func lookupID(o *Owner) (uint32, error) {
+	if id, err := strconv.ParseUint(o.ID, 10, 32); err == nil {
+		return uint32(id), nil
+	}
+	var id string
+	if o.Group {
+		g, err := user.LookupGroup(o.ID)
+		if err != nil {
+			return 0, err
+		}
+		id = g.Gid
+	} else {
+		u, err := user.Lookup(o.ID)
+		if err != nil {
+			return 0, err
+		}
+		id = u.Uid
+	}
+	v, err := strconv.ParseUint(id, 10, 32)
+	if err != nil {
+		return 0, fmt.Errorf("invalid id %q for %q: %v", id, o.ID, err)
+	}
+	return uint32(v), nil
+}
+
+func addrPayload(source, v6 bool) *expr.Payload {
+	offset, size := uint32(16), uint32(4)
+	if source {
+		offset = 12
+	}
+	if v6 {
+		offset, size = 24, 16
+		if source {
+			offset = 8
+		}
+	}
+	return &expr.Payload{DestRegister: 1, Base: expr.PayloadBaseNetworkHeader, Offset: offset, Len: size}
+}
+
+func portPayload(source bool) *expr.Payload {
+	offset := uint32(2)
+	if source {
+		offset = 0
+	}
+	return &expr.Payload{DestRegister: 1, Base: expr.PayloadBaseTransportHeader, Offset: offset, Len: 2}
+}
+
+func markLoad(conntrack bool) expr.Any {
+	if conntrack {
+		return &expr.Ct{Key: expr.CtKeyMARK, Register: 1}
+	}
+	return &expr.Meta{Key: expr.MetaKeyMARK, Register: 1}
+}
+
+func markStore(conntrack bool) expr.Any {
+	if conntrack {
+		return &expr.Ct{Key: expr.CtKeyMARK, Register: 1, SourceRegister: true}
+	}
+	return &expr.Meta{Key: expr.MetaKeyMARK, Register: 1, SourceRegister: true}
+}
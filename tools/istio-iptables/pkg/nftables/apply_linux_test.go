@@ -0,0 +1,449 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package nftables
+
+import (
+	"fmt"
+	"net/netip"
+	"strings"
+	"testing"
+
+	"github.com/google/nftables"
+	"github.com/google/nftables/binaryutil"
+	"github.com/google/nftables/expr"
+	"golang.org/x/sys/unix"
+
+	"istio.io/istio/pkg/test/util/assert"
+)
+
+// fakeTableWriter records what is added instead of sending netlink messages.
+type fakeTableWriter struct {
+	// ops records the order of the additions, as "table <name>", "set <name>", "chain <name>" and "rule <chain>".
+	ops    []string
+	chains []*nftables.Chain
+	rules  []*nftables.Rule
+	sets   map[string][]nftables.SetElement
+}
+
+func (f *fakeTableWriter) AddTable(t *nftables.Table) *nftables.Table {
+	f.ops = append(f.ops, "table "+t.Name)
+	return t
+}
+
+func (f *fakeTableWriter) AddChain(c *nftables.Chain) *nftables.Chain {
+	f.ops = append(f.ops, "chain "+c.Name)
+	f.chains = append(f.chains, c)
+	return c
+}
+
+func (f *fakeTableWriter) AddRule(r *nftables.Rule) *nftables.Rule {
+	f.ops = append(f.ops, "rule "+r.Chain.Name)
+	f.rules = append(f.rules, r)
+	return r
+}
+
+func (f *fakeTableWriter) AddSet(s *nftables.Set, vals []nftables.SetElement) error {
+	if f.sets == nil {
+		f.sets = map[string][]nftables.SetElement{}
+	}
+	s.ID = uint32(len(f.sets) + 1)
+	if s.Anonymous {
+		s.Name = fmt.Sprintf("__set%d", len(f.sets))
+	} else {
+		f.ops = append(f.ops, "set "+s.Name)
+	}
+	f.sets[s.Name] = vals
+	return nil
+}
+
+func TestRuleExprs(t *testing.T) {
+	u32 := binaryutil.NativeEndian.PutUint32
+	port := binaryutil.BigEndian.PutUint16
+	ifname := func(name string) []byte {
+		b := make([]byte, ifNameSize)
+		copy(b, name)
+		return b
+	}
+	eq := func(data []byte) *expr.Cmp {
+		return &expr.Cmp{Op: expr.CmpOpEq, Register: 1, Data: data}
+	}
+	neq := func(data []byte) *expr.Cmp {
+		return &expr.Cmp{Op: expr.CmpOpNeq, Register: 1, Data: data}
+	}
+	cases := []struct {
+		name   string
+		family nftables.TableFamily
+		expr   Expr
+		want   []expr.Any
+		sets   map[string][]nftables.SetElement
+	}{
+		{
+			name: "protocol",
+			expr: &L4Proto{Proto: "udp", Neg: true},
+			want: []expr.Any{&expr.Meta{Key: expr.MetaKeyL4PROTO, Register: 1}, neq([]byte{unix.IPPROTO_UDP})},
+		},
+		{
+			name: "ipv4 destination address",
+			expr: &Addr{Prefix: netip.MustParsePrefix("127.0.0.6/32")},
+			want: []expr.Any{
+				&expr.Payload{DestRegister: 1, Base: expr.PayloadBaseNetworkHeader, Offset: 16, Len: 4},
+				eq([]byte{127, 0, 0, 6}),
+			},
+		},
+		{
+			name: "ipv4 source prefix",
+			expr: &Addr{Source: true, Prefix: netip.MustParsePrefix("10.0.0.0/12"), Neg: true},
+			want: []expr.Any{
+				&expr.Payload{DestRegister: 1, Base: expr.PayloadBaseNetworkHeader, Offset: 12, Len: 4},
+				&expr.Bitwise{SourceRegister: 1, DestRegister: 1, Len: 4, Mask: []byte{0xff, 0xf0, 0, 0}, Xor: []byte{0, 0, 0, 0}},
+				neq([]byte{10, 0, 0, 0}),
+			},
+		},
+		{
+			name:   "ipv6 source address",
+			family: nftables.TableFamilyIPv6,
+			expr:   &Addr{Source: true, Prefix: netip.MustParsePrefix("::6/128")},
+			want: []expr.Any{
+				&expr.Payload{DestRegister: 1, Base: expr.PayloadBaseNetworkHeader, Offset: 8, Len: 16},
+				eq(netip.MustParseAddr("::6").AsSlice()),
+			},
+		},
+		{
+			name:   "ipv6 destination prefix",
+			family: nftables.TableFamilyIPv6,
+			expr:   &Addr{Prefix: netip.MustParsePrefix("fd00::/9")},
+			want: []expr.Any{
+				&expr.Payload{DestRegister: 1, Base: expr.PayloadBaseNetworkHeader, Offset: 24, Len: 16},
+				&expr.Bitwise{
+					SourceRegister: 1, DestRegister: 1, Len: 16,
+					Mask: append([]byte{0xff, 0x80}, make([]byte, 14)...),
+					Xor:  make([]byte, 16),
+				},
+				eq(netip.MustParseAddr("fd00::").AsSlice()),
+			},
+		},
+		{
+			name: "output interface",
+			expr: &Iface{Name: "lo"},
+			want: []expr.Any{&expr.Meta{Key: expr.MetaKeyOIFNAME, Register: 1}, eq(ifname("lo"))},
+		},
+		{
+			name: "input interface prefix",
+			expr: &Iface{Input: true, Name: "veth+", Neg: true},
+			want: []expr.Any{&expr.Meta{Key: expr.MetaKeyIIFNAME, Register: 1}, neq([]byte("veth"))},
+		},
+		{
+			name: "source port",
+			expr: &Port{Proto: "tcp", Source: true, Min: 15008, Max: 15008},
+			want: []expr.Any{
+				&expr.Payload{DestRegister: 1, Base: expr.PayloadBaseTransportHeader, Offset: 0, Len: 2},
+				eq(port(15008)),
+			},
+		},
+		{
+			name: "negated destination port range",
+			expr: &Port{Proto: "tcp", Min: 15000, Max: 15090, Neg: true},
+			want: []expr.Any{
+				&expr.Payload{DestRegister: 1, Base: expr.PayloadBaseTransportHeader, Offset: 2, Len: 2},
+				&expr.Range{Op: expr.CmpOpNeq, Register: 1, FromData: port(15000), ToData: port(15090)},
+			},
+		},
+		{
+			name: "port set",
+			expr: &PortSet{Proto: "tcp", Ports: []uint16{80, 443}, Neg: true},
+			want: []expr.Any{
+				&expr.Payload{DestRegister: 1, Base: expr.PayloadBaseTransportHeader, Offset: 2, Len: 2},
+				&expr.Lookup{SourceRegister: 1, SetID: 1, SetName: "__set0", Invert: true},
+			},
+			sets: map[string][]nftables.SetElement{"__set0": {{Key: port(80)}, {Key: port(443)}}},
+		},
+		{
+			name: "mark",
+			expr: &Mark{Value: 0x539, Mask: allBits},
+			want: []expr.Any{&expr.Meta{Key: expr.MetaKeyMARK, Register: 1}, eq(u32(0x539))},
+		},
+		{
+			name: "masked conntrack mark",
+			expr: &Mark{Conntrack: true, Value: 0x11, Mask: 0xf0, Neg: true},
+			want: []expr.Any{
+				&expr.Ct{Key: expr.CtKeyMARK, Register: 1},
+				&expr.Bitwise{SourceRegister: 1, DestRegister: 1, Len: 4, Mask: u32(0xf0), Xor: u32(0)},
+				neq(u32(0x10)),
+			},
+		},
+		{
+			name: "group owner",
+			expr: &Owner{Group: true, ID: "1337"},
+			want: []expr.Any{&expr.Meta{Key: expr.MetaKeySKGID, Register: 1}, eq(u32(1337))},
+		},
+		{
+			name: "user owner by name",
+			expr: &Owner{ID: "root", Neg: true},
+			want: []expr.Any{&expr.Meta{Key: expr.MetaKeySKUID, Register: 1}, neq(u32(0))},
+		},
+		{
+			name: "conntrack state",
+			expr: &CtState{States: CtStateEstablished | CtStateRelated},
+			want: []expr.Any{
+				&expr.Ct{Key: expr.CtKeySTATE, Register: 1},
+				&expr.Bitwise{SourceRegister: 1, DestRegister: 1, Len: 4, Mask: u32(6), Xor: u32(0)},
+				neq(u32(0)),
+			},
+		},
+		{
+			name: "set mark",
+			expr: &SetMark{Value: 0x539, Mask: allBits},
+			want: []expr.Any{
+				&expr.Immediate{Register: 1, Data: u32(0x539)},
+				&expr.Meta{Key: expr.MetaKeyMARK, Register: 1, SourceRegister: true},
+			},
+		},
+		{
+			name: "set masked conntrack mark",
+			expr: &SetMark{Conntrack: true, Value: 0x1, Mask: 0x1},
+			want: []expr.Any{
+				&expr.Ct{Key: expr.CtKeyMARK, Register: 1},
+				&expr.Bitwise{SourceRegister: 1, DestRegister: 1, Len: 4, Mask: u32(^uint32(1)), Xor: u32(1)},
+				&expr.Ct{Key: expr.CtKeyMARK, Register: 1, SourceRegister: true},
+			},
+		},
+		{
+			name: "save mark",
+			expr: &CopyMark{},
+			want: []expr.Any{
+				&expr.Meta{Key: expr.MetaKeyMARK, Register: 1},
+				&expr.Ct{Key: expr.CtKeyMARK, Register: 1, SourceRegister: true},
+			},
+		},
+		{
+			name: "restore mark",
+			expr: &CopyMark{ToPacket: true},
+			want: []expr.Any{
+				&expr.Ct{Key: expr.CtKeyMARK, Register: 1},
+				&expr.Meta{Key: expr.MetaKeyMARK, Register: 1, SourceRegister: true},
+			},
+		},
+		{
+			name: "conntrack zone",
+			expr: &CtZone{Zone: 3},
+			want: []expr.Any{
+				&expr.Immediate{Register: 1, Data: binaryutil.NativeEndian.PutUint16(3)},
+				&expr.Ct{Key: expr.CtKeyZONE, Register: 1, SourceRegister: true},
+			},
+		},
+		{
+			name: "log",
+			expr: &Log{Prefix: "istio", Group: 1337, Snaplen: 20},
+			want: []expr.Any{&expr.Log{
+				Key:     1<<unix.NFTA_LOG_PREFIX | 1<<unix.NFTA_LOG_GROUP | 1<<unix.NFTA_LOG_SNAPLEN,
+				Data:    []byte("istio"),
+				Group:   1337,
+				Snaplen: 20,
+			}},
+		},
+		{
+			name: "redirect",
+			expr: &Redirect{Port: 15001},
+			want: []expr.Any{&expr.Immediate{Register: 1, Data: port(15001)}, &expr.Redir{RegisterProtoMin: 1}},
+		},
+		{
+			name: "ipv4 tproxy",
+			expr: &TProxy{Port: 15006},
+			want: []expr.Any{
+				&expr.Immediate{Register: 1, Data: port(15006)},
+				&expr.TProxy{Family: unix.NFPROTO_IPV4, TableFamily: unix.NFPROTO_IPV4, RegPort: 1},
+			},
+		},
+		{
+			name:   "ipv6 tproxy",
+			family: nftables.TableFamilyIPv6,
+			expr:   &TProxy{Port: 15006},
+			want: []expr.Any{
+				&expr.Immediate{Register: 1, Data: port(15006)},
+				&expr.TProxy{Family: unix.NFPROTO_IPV6, TableFamily: unix.NFPROTO_IPV6, RegPort: 1},
+			},
+		},
+		{
+			name: "ipv4 destination address set",
+			expr: &AddrSet{Family: IPv4, Set: "probes"},
+			want: []expr.Any{
+				&expr.Payload{DestRegister: 1, Base: expr.PayloadBaseNetworkHeader, Offset: 16, Len: 4},
+				&expr.Lookup{SourceRegister: 1, SetID: 7, SetName: "probes"},
+			},
+		},
+		{
+			name:   "ipv6 source address not in set",
+			family: nftables.TableFamilyIPv6,
+			expr:   &AddrSet{Family: IPv6, Source: true, Set: "probes", Neg: true},
+			want: []expr.Any{
+				&expr.Payload{DestRegister: 1, Base: expr.PayloadBaseNetworkHeader, Offset: 8, Len: 16},
+				&expr.Lookup{SourceRegister: 1, SetID: 7, SetName: "probes", Invert: true},
+			},
+		},
+		{
+			name: "socket exists",
+			expr: &SocketExists{},
+			want: []expr.Any{
+				&expr.Meta{Key: expr.MetaKeySKUID, Register: 1},
+				&expr.Cmp{Op: expr.CmpOpGte, Register: 1, Data: u32(0)},
+			},
+		},
+		{
+			name: "ipv4 snat",
+			expr: &SNAT{Addr: netip.MustParseAddr("169.254.7.127")},
+			want: []expr.Any{
+				&expr.Immediate{Register: 1, Data: []byte{169, 254, 7, 127}},
+				&expr.NAT{Type: expr.NATTypeSourceNAT, Family: unix.NFPROTO_IPV4, RegAddrMin: 1},
+			},
+		},
+		{
+			name:   "ipv6 snat",
+			family: nftables.TableFamilyIPv6,
+			expr:   &SNAT{Addr: netip.MustParseAddr("fd16:9254:7127:1337:ffff:ffff:ffff:ffff")},
+			want: []expr.Any{
+				&expr.Immediate{Register: 1, Data: netip.MustParseAddr("fd16:9254:7127:1337:ffff:ffff:ffff:ffff").AsSlice()},
+				&expr.NAT{Type: expr.NATTypeSourceNAT, Family: unix.NFPROTO_IPV6, RegAddrMin: 1},
+			},
+		},
+		{
+			name: "jump",
+			expr: &Verdict{Kind: Jump, Chain: "ISTIO_OUTPUT"},
+			want: []expr.Any{&expr.Verdict{Kind: expr.VerdictJump, Chain: "ISTIO_OUTPUT"}},
+		},
+		{
+			name: "accept",
+			expr: &Verdict{Kind: Accept},
+			want: []expr.Any{&expr.Verdict{Kind: expr.VerdictAccept}},
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			family := tt.family
+			if family == 0 {
+				family = nftables.TableFamilyIPv4
+			}
+			conn := &fakeTableWriter{}
+			sets := map[string]*nftables.Set{"probes": {Name: "probes", ID: 7}}
+			got, err := ruleExprs(conn, &nftables.Table{Family: family, Name: "istio-nat"}, sets, &Rule{Exprs: []Expr{tt.expr}})
+			assert.NoError(t, err)
+			assert.Equal(t, got, tt.want)
+			assert.Equal(t, conn.sets, tt.sets)
+		})
+	}
+}
+
+type unknownExpr struct{}
+
+func (unknownExpr) String() string { return "unknown" }
+
+func TestRuleExprsErrors(t *testing.T) {
+	cases := []struct {
+		name string
+		expr Expr
+		err  string
+	}{
+		{name: "unknown verdict", expr: &Verdict{Kind: "goto"}, err: `unsupported verdict "goto"`},
+		{name: "unknown user", expr: &Owner{ID: "no-such-user-istio"}, err: "no-such-user-istio"},
+		{name: "unknown group", expr: &Owner{Group: true, ID: "no-such-group-istio"}, err: "no-such-group-istio"},
+		{name: "unknown expression", expr: unknownExpr{}, err: "unsupported expression"},
+		{name: "unknown set", expr: &AddrSet{Family: IPv4, Set: "probes"}, err: `unknown set "probes"`},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			table := &nftables.Table{Family: nftables.TableFamilyIPv4, Name: "istio-nat"}
+			_, err := ruleExprs(&fakeTableWriter{}, table, nil, &Rule{Exprs: []Expr{tt.expr}})
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Fatalf("expected error containing %q, got %v", tt.err, err)
+			}
+		})
+	}
+}
+
+func TestAddTable(t *testing.T) {
+	conn := &fakeTableWriter{}
+	err := addTable(conn, &Table{
+		Family: IPv6,
+		Name:   "istio-nat",
+		Chains: []*Chain{
+			{
+				Name:  "OUTPUT",
+				Hook:  &Hook{Type: "nat", Hook: "output", Priority: -100},
+				Rules: []*Rule{{Exprs: []Expr{&Verdict{Kind: Jump, Chain: "ISTIO_OUTPUT"}}}},
+			},
+			{
+				Name: "ISTIO_OUTPUT",
+				Rules: []*Rule{
+					{Exprs: []Expr{&Iface{Name: "lo"}, &Verdict{Kind: Return}}},
+					{Exprs: []Expr{&Redirect{Port: 15001}}},
+				},
+			},
+		},
+	})
+	assert.NoError(t, err)
+	// Chains are all added before the rules that jump to them.
+	assert.Equal(t, conn.ops, []string{"table istio-nat", "chain OUTPUT", "chain ISTIO_OUTPUT", "rule OUTPUT", "rule ISTIO_OUTPUT", "rule ISTIO_OUTPUT"})
+
+	table := &nftables.Table{Family: nftables.TableFamilyIPv6, Name: "istio-nat"}
+	accept := nftables.ChainPolicyAccept
+	assert.Equal(t, conn.chains, []*nftables.Chain{
+		{
+			Name:     "OUTPUT",
+			Table:    table,
+			Type:     nftables.ChainTypeNAT,
+			Hooknum:  nftables.ChainHookOutput,
+			Priority: nftables.ChainPriorityRef(-100),
+			Policy:   &accept,
+		},
+		{Name: "ISTIO_OUTPUT", Table: table},
+	})
+	assert.Equal(t, conn.rules[0].Chain, conn.chains[0])
+	assert.Equal(t, conn.rules[0].Exprs, []expr.Any{&expr.Verdict{Kind: expr.VerdictJump, Chain: "ISTIO_OUTPUT"}})
+	assert.Equal(t, conn.rules[2].Chain, conn.chains[1])
+	assert.Equal(t, conn.rules[2].Exprs, []expr.Any{
+		&expr.Immediate{Register: 1, Data: binaryutil.BigEndian.PutUint16(15001)},
+		&expr.Redir{RegisterProtoMin: 1},
+	})
+}
+
+func TestAddTableWithSet(t *testing.T) {
+	conn := &fakeTableWriter{}
+	err := addTable(conn, &Table{
+		Family: IPv4,
+		Name:   "istio-nat",
+		Sets:   []string{"istio-inpod-probes-v4"},
+		Chains: []*Chain{
+			{
+				Name:  "ISTIO_POSTRT",
+				Rules: []*Rule{{Exprs: []Expr{&AddrSet{Family: IPv4, Set: "istio-inpod-probes-v4"}, &Verdict{Kind: Accept}}}},
+			},
+		},
+	})
+	assert.NoError(t, err)
+	// Sets are added before the rules that look them up.
+	assert.Equal(t, conn.ops, []string{"table istio-nat", "set istio-inpod-probes-v4", "chain ISTIO_POSTRT", "rule ISTIO_POSTRT"})
+	assert.Equal(t, conn.sets, map[string][]nftables.SetElement{"istio-inpod-probes-v4": nil})
+	assert.Equal(t, conn.rules[0].Exprs[1], expr.Any(&expr.Lookup{SourceRegister: 1, SetID: 1, SetName: "istio-inpod-probes-v4"}))
+}
+
+func TestAddTableError(t *testing.T) {
+	err := addTable(&fakeTableWriter{}, &Table{
+		Family: IPv4,
+		Name:   "istio-filter",
+		Chains: []*Chain{{Name: "ISTIO_DROP", Rules: []*Rule{{Exprs: []Expr{&Verdict{Kind: "goto"}}}}}},
+	})
+	if err == nil || !strings.Contains(err.Error(), "chain ISTIO_DROP") {
+		t.Fatalf("expected error naming the chain, got %v", err)
+	}
+}
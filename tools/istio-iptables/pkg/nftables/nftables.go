@@ -0,0 +1,439 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package nftables translates the rules generated by the iptables rule builder into an equivalent nftables
+// ruleset, which can be applied directly over netlink without any iptables or nft binary.
+//
+// Each iptables table is mapped to its own nftables table per address family (for example `ip istio-nat`),
+// with a base chain for every built-in chain in use and a regular chain for every ISTIO_* chain.
+// Since Istio owns these tables, applying a ruleset replaces them atomically, and cleaning up deletes them.
+package nftables
+
+import (
+	"fmt"
+	"net/netip"
+	"strconv"
+	"strings"
+)
+
+// Family is the address family of an nftables table.
+type Family string
+
+const (
+	IPv4 Family = "ip"
+	IPv6 Family = "ip6"
+)
+
+// TablePrefix is prepended to the iptables table name to get the name of the nftables table.
+const TablePrefix = "istio-"
+
+// TableNames are the names of all the tables that may be owned by Istio, in each family.
+var TableNames = []string{TablePrefix + "raw", TablePrefix + "mangle", TablePrefix + "nat", TablePrefix + "filter"}
+
+// Ruleset is a set of tables that is applied atomically.
+type Ruleset struct {
+	// Families are the address families owned by the ruleset. Applying the ruleset replaces all the Istio
+	// tables of these families, and deleting it removes them, even if the ruleset has no table for them.
+	Families []Family
+	Tables   []*Table
+}
+
+type Table struct {
+	Family Family
+	Name   string
+	// Sets are the names of the address sets of the table. Only the sets are part of the ruleset: their elements
+	// are managed separately, and are lost when the ruleset is applied.
+	Sets   []string
+	Chains []*Chain
+}
+
+type Chain struct {
+	Name string
+	// Hook is set for base chains, which are attached to a netfilter hook. Regular chains are only reached by jumps.
+	Hook  *Hook
+	Rules []*Rule
+}
+
+// Hook attaches a base chain to a netfilter hook, in place of the built-in chain of an iptables table.
+type Hook struct {
+	Type     string
+	Hook     string
+	Priority int
+}
+
+// Rule is a list of expressions, evaluated in order until one does not match.
+type Rule struct {
+	Exprs []Expr
+}
+
+// Expr is a match or a statement of a rule.
+type Expr interface {
+	String() string
+}
+
+// L4Proto matches the transport protocol.
+type L4Proto struct {
+	Proto string
+	Neg   bool
+}
+
+// Addr matches the source or destination address of the packet.
+type Addr struct {
+	Source bool
+	Prefix netip.Prefix
+	Neg    bool
+}
+
+// AddrSet matches the source or destination address of the packet against a set of the table.
+type AddrSet struct {
+	Family Family
+	Source bool
+	Set    string
+	Neg    bool
+}
+
+// Iface matches the input or output interface. A trailing '+' in the name matches any interface with the prefix.
+type Iface struct {
+	Input bool
+	Name  string
+	Neg   bool
+}
+
+// Port matches the source or destination port of the transport protocol. Min and Max are equal for a single port.
+type Port struct {
+	Proto  string
+	Source bool
+	Min    uint16
+	Max    uint16
+	Neg    bool
+}
+
+// PortSet matches a list of source or destination ports of the transport protocol.
+type PortSet struct {
+	Proto  string
+	Source bool
+	Ports  []uint16
+	Neg    bool
+}
+
+// Mark matches the bits of the packet mark, or of the conntrack mark if Conntrack is set, selected by Mask.
+type Mark struct {
+	Conntrack bool
+	Value     uint32
+	Mask      uint32
+	Neg       bool
+}
+
+// Owner matches the user or group of the socket of the packet. The user or group is a numeric id or a name,
+// which is only resolved when the ruleset is applied.
+type Owner struct {
+	Group bool
+	ID    string
+	Neg   bool
+}
+
+// SocketExists matches packets with a local socket. Like the owner of the socket, which it matches on, it is
+// unknown for packets without a socket.
+type SocketExists struct{}
+
+// Conntrack state bits, as used by the kernel.
+const (
+	CtStateInvalid     uint32 = 1
+	CtStateEstablished uint32 = 2
+	CtStateRelated     uint32 = 4
+	CtStateNew         uint32 = 8
+	CtStateUntracked   uint32 = 64
+)
+
+var ctStateNames = []struct {
+	bit  uint32
+	name string
+}{
+	{CtStateInvalid, "invalid"},
+	{CtStateEstablished, "established"},
+	{CtStateRelated, "related"},
+	{CtStateNew, "new"},
+	{CtStateUntracked, "untracked"},
+}
+
+// CtState matches any of the conntrack states.
+type CtState struct {
+	States uint32
+}
+
+// SetMark sets the packet mark, or the conntrack mark if Conntrack is set, to (mark & ^Mask) ^ Value.
+type SetMark struct {
+	Conntrack bool
+	Value     uint32
+	Mask      uint32
+}
+
+// CopyMark copies the packet mark to the conntrack mark, or the other way around if ToPacket is set.
+type CopyMark struct {
+	ToPacket bool
+}
+
+// CtZone sets the conntrack zone of the packet.
+type CtZone struct {
+	Zone uint16
+}
+
+// Log sends the packet to the nflog group.
+type Log struct {
+	Prefix  string
+	Group   uint16
+	Snaplen uint32
+}
+
+// Redirect redirects the packet to the port on the local host. It is terminal.
+type Redirect struct {
+	Port uint16
+}
+
+// SNAT rewrites the source address of the packet. It is terminal.
+type SNAT struct {
+	Addr netip.Addr
+}
+
+// TProxy assigns the packet to the transparent socket listening on the port. It is terminal when the socket exists.
+type TProxy struct {
+	Port uint16
+}
+
+// Verdict kinds.
+const (
+	Accept = "accept"
+	Drop   = "drop"
+	Return = "return"
+	Jump   = "jump"
+)
+
+// Verdict ends the evaluation of the chain.
+type Verdict struct {
+	Kind string
+	// Chain is the target of a jump.
+	Chain string
+}
+
+func neq(neg bool) string {
+	if neg {
+		return "!= "
+	}
+	return ""
+}
+
+func (e *L4Proto) String() string {
+	return "meta l4proto " + neq(e.Neg) + e.Proto
+}
+
+func (e *Addr) String() string {
+	field := "daddr"
+	if e.Source {
+		field = "saddr"
+	}
+	family := IPv4
+	if e.Prefix.Addr().Is6() {
+		family = IPv6
+	}
+	value := e.Prefix.String()
+	if e.Prefix.IsSingleIP() {
+		value = e.Prefix.Addr().String()
+	}
+	return fmt.Sprintf("%s %s %s%s", family, field, neq(e.Neg), value)
+}
+
+func (e *AddrSet) String() string {
+	field := "daddr"
+	if e.Source {
+		field = "saddr"
+	}
+	return fmt.Sprintf("%s %s %s@%s", e.Family, field, neq(e.Neg), e.Set)
+}
+
+func (e *Iface) String() string {
+	field := "oifname"
+	if e.Input {
+		field = "iifname"
+	}
+	return fmt.Sprintf("%s %s%q", field, neq(e.Neg), ifaceName(e.Name))
+}
+
+// ifaceName converts an iptables interface wildcard to the nftables one.
+func ifaceName(name string) string {
+	if prefix, ok := strings.CutSuffix(name, "+"); ok {
+		return prefix + "*"
+	}
+	return name
+}
+
+func portField(proto string, source bool) string {
+	if source {
+		return proto + " sport "
+	}
+	return proto + " dport "
+}
+
+func (e *Port) String() string {
+	value := strconv.Itoa(int(e.Min))
+	if e.Max != e.Min {
+		value = fmt.Sprintf("%d-%d", e.Min, e.Max)
+	}
+	return portField(e.Proto, e.Source) + neq(e.Neg) + value
+}
+
+func (e *PortSet) String() string {
+	ports := make([]string, 0, len(e.Ports))
+	for _, p := range e.Ports {
+		ports = append(ports, strconv.Itoa(int(p)))
+	}
+	return portField(e.Proto, e.Source) + neq(e.Neg) + "{ " + strings.Join(ports, ", ") + " }"
+}
+
+func markKey(conntrack bool) string {
+	if conntrack {
+		return "ct mark"
+	}
+	return "meta mark"
+}
+
+func (e *Mark) String() string {
+	if e.Mask == ^uint32(0) {
+		return fmt.Sprintf("%s %s0x%08x", markKey(e.Conntrack), neq(e.Neg), e.Value)
+	}
+	op := "=="
+	if e.Neg {
+		op = "!="
+	}
+	return fmt.Sprintf("%s & 0x%08x %s 0x%08x", markKey(e.Conntrack), e.Mask, op, e.Value)
+}
+
+func (e *Owner) String() string {
+	key := "meta skuid "
+	if e.Group {
+		key = "meta skgid "
+	}
+	return key + neq(e.Neg) + e.ID
+}
+
+func (e *SocketExists) String() string {
+	return "meta skuid >= 0"
+}
+
+func (e *CtState) String() string {
+	var states []string
+	for _, s := range ctStateNames {
+		if e.States&s.bit != 0 {
+			states = append(states, s.name)
+		}
+	}
+	return "ct state " + strings.Join(states, ",")
+}
+
+func (e *SetMark) String() string {
+	key := markKey(e.Conntrack)
+	if e.Mask == ^uint32(0) {
+		return fmt.Sprintf("%s set 0x%08x", key, e.Value)
+	}
+	return fmt.Sprintf("%s set %s and 0x%08x xor 0x%08x", key, key, ^e.Mask, e.Value)
+}
+
+func (e *CopyMark) String() string {
+	if e.ToPacket {
+		return "meta mark set ct mark"
+	}
+	return "ct mark set meta mark"
+}
+
+func (e *CtZone) String() string {
+	return fmt.Sprintf("ct zone set %d", e.Zone)
+}
+
+func (e *Log) String() string {
+	return fmt.Sprintf("log prefix %q group %d snaplen %d", e.Prefix, e.Group, e.Snaplen)
+}
+
+func (e *Redirect) String() string {
+	return fmt.Sprintf("redirect to :%d", e.Port)
+}
+
+func (e *SNAT) String() string {
+	return "snat to " + e.Addr.String()
+}
+
+func (e *TProxy) String() string {
+	return fmt.Sprintf("tproxy to :%d", e.Port)
+}
+
+func (e *Verdict) String() string {
+	if e.Kind == Jump {
+		return Jump + " " + e.Chain
+	}
+	return e.Kind
+}
+
+func (r *Rule) String() string {
+	exprs := make([]string, 0, len(r.Exprs))
+	for _, e := range r.Exprs {
+		exprs = append(exprs, e.String())
+	}
+	return strings.Join(exprs, " ")
+}
+
+// Lines renders the ruleset in the format read by `nft -f`, including the commands that replace the existing
+// Istio tables. Applying these lines with nft is equivalent to applying the ruleset.
+func (rs *Ruleset) Lines() []string {
+	lines := rs.DeleteLines()
+	for _, t := range rs.Tables {
+		lines = append(lines, fmt.Sprintf("table %s %s {", t.Family, t.Name))
+		for _, set := range t.Sets {
+			lines = append(lines, fmt.Sprintf("\tset %s {", set), "\t\ttype "+setType(t.Family), "\t}")
+		}
+		for _, c := range t.Chains {
+			lines = append(lines, fmt.Sprintf("\tchain %s {", c.Name))
+			if c.Hook != nil {
+				lines = append(lines, fmt.Sprintf("\t\ttype %s hook %s priority %d; policy accept;", c.Hook.Type, c.Hook.Hook, c.Hook.Priority))
+			}
+			for _, r := range c.Rules {
+				lines = append(lines, "\t\t"+r.String())
+			}
+			lines = append(lines, "\t}")
+		}
+		lines = append(lines, "}")
+	}
+	return lines
+}
+
+// setType returns the type of the elements of the address sets of a family.
+func setType(f Family) string {
+	if f == IPv6 {
+		return "ipv6_addr"
+	}
+	return "ipv4_addr"
+}
+
+func (rs *Ruleset) String() string {
+	return strings.Join(rs.Lines(), "\n") + "\n"
+}
+
+// DeleteLines renders the commands deleting all the Istio tables of the families of the ruleset.
+func (rs *Ruleset) DeleteLines() []string {
+	var lines []string
+	for _, family := range rs.Families {
+		for _, name := range TableNames {
+			// Adding the table first ensures the deletion does not fail if the table does not exist.
+			lines = append(lines, fmt.Sprintf("add table %s %s", family, name), fmt.Sprintf("delete table %s %s", family, name))
+		}
+	}
+	return lines
+}
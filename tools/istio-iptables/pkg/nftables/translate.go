@@ -0,0 +1,483 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package nftables
+
+import (
+	"fmt"
+	"net/netip"
+	"strconv"
+	"strings"
+
+	"istio.io/istio/pkg/maps"
+	"istio.io/istio/pkg/slices"
+	"istio.io/istio/tools/istio-iptables/pkg/constants"
+)
+
+// IptablesRule is a rule generated by the iptables rule builder.
+type IptablesRule struct {
+	Table string
+	Chain string
+	// Params are the iptables parameters of the rule, starting with `-A <chain>` or `-I <chain> <position>`.
+	Params []string
+}
+
+// hooks are the base chains replacing the built-in chains of each iptables table, with the same priorities.
+var hooks = map[string]map[string]Hook{
+	constants.RAW: {
+		constants.PREROUTING: {Type: "filter", Hook: "prerouting", Priority: -300},
+		constants.OUTPUT:     {Type: "filter", Hook: "output", Priority: -300},
+	},
+	constants.MANGLE: {
+		constants.PREROUTING:  {Type: "filter", Hook: "prerouting", Priority: -150},
+		constants.INPUT:       {Type: "filter", Hook: "input", Priority: -150},
+		constants.FORWARD:     {Type: "filter", Hook: "forward", Priority: -150},
+		constants.OUTPUT:      {Type: "route", Hook: "output", Priority: -150},
+		constants.POSTROUTING: {Type: "filter", Hook: "postrouting", Priority: -150},
+	},
+	constants.NAT: {
+		constants.PREROUTING:  {Type: "nat", Hook: "prerouting", Priority: -100},
+		constants.INPUT:       {Type: "nat", Hook: "input", Priority: 100},
+		constants.OUTPUT:      {Type: "nat", Hook: "output", Priority: -100},
+		constants.POSTROUTING: {Type: "nat", Hook: "postrouting", Priority: 100},
+	},
+	constants.FILTER: {
+		constants.INPUT:   {Type: "filter", Hook: "input", Priority: 0},
+		constants.FORWARD: {Type: "filter", Hook: "forward", Priority: 0},
+		constants.OUTPUT:  {Type: "filter", Hook: "output", Priority: 0},
+	},
+}
+
+// Translate converts the rules of an address family into nftables tables, in the order the tables are first used.
+func Translate(family Family, rules []IptablesRule) ([]*Table, error) {
+	var tables []*Table
+	tablesByName := map[string]*Table{}
+	chainsByName := map[string]*Chain{}
+	for _, r := range rules {
+		tableHooks, ok := hooks[r.Table]
+		if !ok {
+			return nil, fmt.Errorf("unsupported table %q", r.Table)
+		}
+		t, ok := tablesByName[r.Table]
+		if !ok {
+			t = &Table{Family: family, Name: TablePrefix + r.Table}
+			tablesByName[r.Table] = t
+			tables = append(tables, t)
+		}
+		c, ok := chainsByName[r.Table+":"+r.Chain]
+		if !ok {
+			c = &Chain{Name: r.Chain}
+			if _, builtin := constants.BuiltInChainsMap[r.Chain]; builtin {
+				hook, ok := tableHooks[r.Chain]
+				if !ok {
+					return nil, fmt.Errorf("unsupported chain %q in table %q", r.Chain, r.Table)
+				}
+				c.Hook = &hook
+			}
+			chainsByName[r.Table+":"+r.Chain] = c
+			t.Chains = append(t.Chains, c)
+		}
+
+		position, params, err := splitOperation(r.Params)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", strings.Join(r.Params, " "), err)
+		}
+		rule, err := translateRule(family, params)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", strings.Join(r.Params, " "), err)
+		}
+		for _, e := range rule.Exprs {
+			if set, ok := e.(*AddrSet); ok && !slices.Contains(t.Sets, set.Set) {
+				t.Sets = append(t.Sets, set.Set)
+			}
+		}
+		if position < 0 || position >= len(c.Rules) {
+			c.Rules = append(c.Rules, rule)
+		} else {
+			c.Rules = slices.Insert(c.Rules, position, rule)
+		}
+	}
+	return tables, nil
+}
+
+// splitOperation returns the index at which the rule is inserted, or -1 if it is appended, and the remaining parameters.
+func splitOperation(params []string) (int, []string, error) {
+	if len(params) < 2 {
+		return 0, nil, fmt.Errorf("missing operation")
+	}
+	switch params[0] {
+	case "-A", "--append":
+		return -1, params[2:], nil
+	case "-I", "--insert":
+		if len(params) > 2 {
+			if n, err := strconv.Atoi(params[2]); err == nil {
+				if n < 1 {
+					return 0, nil, fmt.Errorf("invalid rule position %d", n)
+				}
+				return n - 1, params[3:], nil
+			}
+		}
+		return 0, params[2:], nil
+	default:
+		return 0, nil, fmt.Errorf("unsupported operation %q", params[0])
+	}
+}
+
+// translateRule converts the matches and the target of an iptables rule. Matches are kept in their order,
+// followed by the statements of the target.
+func translateRule(family Family, params []string) (*Rule, error) {
+	rule := &Rule{}
+	var proto, module string
+	neg := false
+	for i := 0; i < len(params); i++ {
+		flag := params[i]
+		if flag == "!" {
+			neg = true
+			continue
+		}
+		if flag == "-j" || flag == "--jump" {
+			if neg {
+				return nil, fmt.Errorf("unexpected '!' before %s", flag)
+			}
+			exprs, err := translateTarget(family, params[i+1:])
+			if err != nil {
+				return nil, err
+			}
+			rule.Exprs = append(rule.Exprs, exprs...)
+			return rule, nil
+		}
+		if flag == "--socket-exists" {
+			if module != "owner" || neg {
+				return nil, fmt.Errorf("%s requires the owner match and cannot be negated", flag)
+			}
+			rule.Exprs = append(rule.Exprs, &SocketExists{})
+			continue
+		}
+		if i+1 >= len(params) {
+			return nil, fmt.Errorf("missing value for %s", flag)
+		}
+		i++
+		value := params[i]
+
+		var e Expr
+		switch flag {
+		case "-p", "--protocol":
+			if value != constants.TCP && value != constants.UDP {
+				return nil, fmt.Errorf("unsupported protocol %q", value)
+			}
+			proto = value
+			e = &L4Proto{Proto: value, Neg: neg}
+		case "-s", "--source", "-d", "--destination":
+			prefix, err := parsePrefix(value)
+			if err != nil {
+				return nil, err
+			}
+			if prefix.Addr().Is4() != (family == IPv4) {
+				return nil, fmt.Errorf("address %q does not match family %s", value, family)
+			}
+			e = &Addr{Source: flag == "-s" || flag == "--source", Prefix: prefix, Neg: neg}
+		case "-i", "--in-interface", "-o", "--out-interface":
+			e = &Iface{Input: flag == "-i" || flag == "--in-interface", Name: value, Neg: neg}
+		case "-m", "--match":
+			if neg {
+				return nil, fmt.Errorf("unexpected '!' before %s", flag)
+			}
+			switch value {
+			case "tcp", "udp", "multiport", "owner", "mark", "connmark", "conntrack", "set":
+				module = value
+			default:
+				return nil, fmt.Errorf("unsupported match %q", value)
+			}
+			continue
+		case "--dport", "--destination-port", "--sport", "--source-port":
+			if proto == "" {
+				return nil, fmt.Errorf("%s requires a protocol", flag)
+			}
+			minPort, maxPort, err := parsePortRange(value)
+			if err != nil {
+				return nil, err
+			}
+			e = &Port{Proto: proto, Source: flag == "--sport" || flag == "--source-port", Min: minPort, Max: maxPort, Neg: neg}
+		case "--dports", "--destination-ports", "--sports", "--source-ports":
+			if proto == "" || module != "multiport" {
+				return nil, fmt.Errorf("%s requires a protocol and the multiport match", flag)
+			}
+			var ports []uint16
+			for _, p := range strings.Split(value, ",") {
+				port, err := parsePort(p)
+				if err != nil {
+					return nil, err
+				}
+				ports = append(ports, port)
+			}
+			e = &PortSet{Proto: proto, Source: flag == "--sports" || flag == "--source-ports", Ports: ports, Neg: neg}
+		case "--match-set":
+			if module != "set" || i+1 >= len(params) {
+				return nil, fmt.Errorf("%s requires the set match and the address to match", flag)
+			}
+			i++
+			// Only sets of addresses are supported, which are matched on a single address.
+			dir := params[i]
+			if dir != "src" && dir != "dst" {
+				return nil, fmt.Errorf("unsupported %s flags %q", flag, dir)
+			}
+			e = &AddrSet{Family: family, Source: dir == "src", Set: value, Neg: neg}
+		case "--uid-owner", "--gid-owner":
+			e = &Owner{Group: flag == "--gid-owner", ID: value, Neg: neg}
+		case "--mark":
+			if module != "mark" && module != "connmark" {
+				return nil, fmt.Errorf("%s requires the mark or connmark match", flag)
+			}
+			v, mask, err := parseMark(value)
+			if err != nil {
+				return nil, err
+			}
+			e = &Mark{Conntrack: module == "connmark", Value: v, Mask: mask, Neg: neg}
+		case "--ctstate":
+			if neg {
+				return nil, fmt.Errorf("negated %s is not supported", flag)
+			}
+			states, err := parseCtState(value)
+			if err != nil {
+				return nil, err
+			}
+			e = &CtState{States: states}
+		default:
+			return nil, fmt.Errorf("unsupported parameter %q", flag)
+		}
+		rule.Exprs = append(rule.Exprs, e)
+		neg = false
+	}
+	return nil, fmt.Errorf("missing target")
+}
+
+// translateTarget converts an iptables target and its options into nftables statements.
+func translateTarget(family Family, params []string) ([]Expr, error) {
+	if len(params) == 0 {
+		return nil, fmt.Errorf("missing target")
+	}
+	target := params[0]
+	opts := map[string]string{}
+	for i := 1; i < len(params); i++ {
+		if !strings.HasPrefix(params[i], "--") {
+			return nil, fmt.Errorf("unexpected target option %q", params[i])
+		}
+		if i+1 < len(params) && !strings.HasPrefix(params[i+1], "--") {
+			opts[params[i]] = params[i+1]
+			i++
+		} else {
+			opts[params[i]] = ""
+		}
+	}
+	option := func(names ...string) (string, bool) {
+		for _, n := range names {
+			if v, ok := opts[n]; ok {
+				delete(opts, n)
+				return v, true
+			}
+		}
+		return "", false
+	}
+
+	var exprs []Expr
+	switch target {
+	case constants.ACCEPT:
+		exprs = []Expr{&Verdict{Kind: Accept}}
+	case "DROP":
+		exprs = []Expr{&Verdict{Kind: Drop}}
+	case constants.RETURN:
+		exprs = []Expr{&Verdict{Kind: Return}}
+	case constants.REDIRECT:
+		v, _ := option("--to-ports", "--to-port")
+		port, err := parsePort(v)
+		if err != nil {
+			return nil, err
+		}
+		exprs = []Expr{&Redirect{Port: port}}
+	case constants.TPROXY:
+		v, _ := option("--on-port")
+		port, err := parsePort(v)
+		if err != nil {
+			return nil, err
+		}
+		exprs = []Expr{&TProxy{Port: port}}
+		if v, ok := option("--tproxy-mark"); ok {
+			mark, mask, err := parseMark(v)
+			if err != nil {
+				return nil, err
+			}
+			exprs = append(exprs, &SetMark{Value: mark, Mask: mask})
+		}
+		// Like the TPROXY target, accept the packet once it is assigned to the socket.
+		exprs = append(exprs, &Verdict{Kind: Accept})
+	case "SNAT":
+		v, _ := option("--to-source")
+		addr, err := netip.ParseAddr(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid source %q", v)
+		}
+		if addr.Is4() != (family == IPv4) {
+			return nil, fmt.Errorf("address %q does not match family %s", v, family)
+		}
+		exprs = []Expr{&SNAT{Addr: addr}}
+	case constants.MARK, "CONNMARK":
+		conntrack := target == "CONNMARK"
+		if v, ok := option("--set-xmark"); ok {
+			mark, mask, err := parseMark(v)
+			if err != nil {
+				return nil, err
+			}
+			exprs = []Expr{&SetMark{Conntrack: conntrack, Value: mark, Mask: mask}}
+		} else if v, ok := option("--set-mark"); ok {
+			mark, mask, err := parseMark(v)
+			if err != nil {
+				return nil, err
+			}
+			// Unlike --set-xmark, the value is ORed into the mark: the bits of the value are cleared first.
+			exprs = []Expr{&SetMark{Conntrack: conntrack, Value: mark, Mask: mask | mark}}
+		} else if _, ok := option("--save-mark"); ok && conntrack {
+			exprs = []Expr{&CopyMark{}}
+		} else if _, ok := option("--restore-mark"); ok && conntrack {
+			exprs = []Expr{&CopyMark{ToPacket: true}}
+		} else {
+			return nil, fmt.Errorf("unsupported %s options", target)
+		}
+		// Only masks that keep the whole mark are supported when copying marks.
+		for _, name := range []string{"--nfmask", "--ctmask", "--mask"} {
+			if v, ok := option(name); ok {
+				if mask, err := parseUint32(v); err != nil || mask != ^uint32(0) {
+					return nil, fmt.Errorf("unsupported %s %q", name, v)
+				}
+			}
+		}
+	case constants.CT:
+		v, _ := option("--zone")
+		zone, err := strconv.ParseUint(v, 0, 16)
+		if err != nil {
+			return nil, fmt.Errorf("invalid zone %q", v)
+		}
+		exprs = []Expr{&CtZone{Zone: uint16(zone)}}
+	case "NFLOG":
+		l := &Log{}
+		if v, ok := option("--nflog-prefix"); ok {
+			if unquoted, err := strconv.Unquote(v); err == nil {
+				v = unquoted
+			}
+			l.Prefix = v
+		}
+		if v, ok := option("--nflog-group"); ok {
+			group, err := strconv.ParseUint(v, 0, 16)
+			if err != nil {
+				return nil, fmt.Errorf("invalid nflog group %q", v)
+			}
+			l.Group = uint16(group)
+		}
+		if v, ok := option("--nflog-size", "--nflog-range"); ok {
+			size, err := parseUint32(v)
+			if err != nil {
+				return nil, fmt.Errorf("invalid nflog size %q", v)
+			}
+			l.Snaplen = size
+		}
+		exprs = []Expr{l}
+	default:
+		if strings.HasPrefix(target, "ISTIO_") {
+			exprs = []Expr{&Verdict{Kind: Jump, Chain: target}}
+		} else {
+			return nil, fmt.Errorf("unsupported target %q", target)
+		}
+	}
+	if len(opts) > 0 {
+		return nil, fmt.Errorf("unsupported %s options %v", target, slices.Sort(maps.Keys(opts)))
+	}
+	return exprs, nil
+}
+
+func parsePrefix(s string) (netip.Prefix, error) {
+	if strings.Contains(s, "/") {
+		p, err := netip.ParsePrefix(s)
+		if err != nil {
+			return netip.Prefix{}, err
+		}
+		return p.Masked(), nil
+	}
+	a, err := netip.ParseAddr(s)
+	if err != nil {
+		return netip.Prefix{}, err
+	}
+	return netip.PrefixFrom(a, a.BitLen()), nil
+}
+
+func parsePort(s string) (uint16, error) {
+	p, err := strconv.ParseUint(s, 10, 16)
+	if err != nil {
+		return 0, fmt.Errorf("invalid port %q", s)
+	}
+	return uint16(p), nil
+}
+
+func parsePortRange(s string) (uint16, uint16, error) {
+	from, to, isRange := strings.Cut(s, ":")
+	minPort, err := parsePort(from)
+	if err != nil {
+		return 0, 0, err
+	}
+	if !isRange {
+		return minPort, minPort, nil
+	}
+	maxPort, err := parsePort(to)
+	if err != nil {
+		return 0, 0, err
+	}
+	return minPort, maxPort, nil
+}
+
+func parseUint32(s string) (uint32, error) {
+	v, err := strconv.ParseUint(s, 0, 32)
+	return uint32(v), err
+}
+
+// parseMark parses a mark in the iptables `value[/mask]` format. The mask defaults to all bits.
+func parseMark(s string) (uint32, uint32, error) {
+	value, mask, hasMask := strings.Cut(s, "/")
+	v, err := parseUint32(value)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid mark %q", s)
+	}
+	if !hasMask {
+		return v, ^uint32(0), nil
+	}
+	m, err := parseUint32(mask)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid mark %q", s)
+	}
+	return v, m, nil
+}
+
+func parseCtState(s string) (uint32, error) {
+	var states uint32
+	for _, state := range strings.Split(s, ",") {
+		found := false
+		for _, n := range ctStateNames {
+			if strings.EqualFold(n.name, state) {
+				states |= n.bit
+				found = true
+			}
+		}
+		if !found {
+			return 0, fmt.Errorf("unsupported conntrack state %q", state)
+		}
+	}
+	return states, nil
+}
@@ -0,0 +1,242 @@
+// Copyright Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package nftables
+
+import (
+	"net/netip"
+	"strings"
+	"testing"
+
+	"istio.io/istio/pkg/slices"
+	"istio.io/istio/pkg/test/util/assert"
+)
+
+func rule(table, chain string, params ...string) IptablesRule {
+	return IptablesRule{Table: table, Chain: chain, Params: params}
+}
+
+func TestTranslateErrors(t *testing.T) {
+	cases := []struct {
+		name  string
+		rule  IptablesRule
+		ipv6  bool
+		error string
+	}{
+		{"unsupported table", rule("security", "OUTPUT", "-A", "OUTPUT", "-j", "ACCEPT"), false, `unsupported table "security"`},
+		{"unsupported chain", rule("raw", "INPUT", "-A", "INPUT", "-j", "ACCEPT"), false, `unsupported chain "INPUT" in table "raw"`},
+		{"missing operation", rule("nat", "ISTIO_OUTPUT", "-A"), false, "missing operation"},
+		{"unsupported operation", rule("nat", "ISTIO_OUTPUT", "-D", "ISTIO_OUTPUT", "-j", "RETURN"), false, `unsupported operation "-D"`},
+		{"invalid position", rule("nat", "ISTIO_OUTPUT", "-I", "ISTIO_OUTPUT", "0", "-j", "RETURN"), false, "invalid rule position 0"},
+		{"negated target", rule("nat", "ISTIO_OUTPUT", "-A", "ISTIO_OUTPUT", "!", "-j", "RETURN"), false, "unexpected '!' before -j"},
+		{"missing value", rule("nat", "ISTIO_OUTPUT", "-A", "ISTIO_OUTPUT", "-p"), false, "missing value for -p"},
+		{"unsupported protocol", rule("nat", "ISTIO_OUTPUT", "-A", "ISTIO_OUTPUT", "-p", "icmp", "-j", "RETURN"), false, `unsupported protocol "icmp"`},
+		{"invalid address", rule("nat", "ISTIO_OUTPUT", "-A", "ISTIO_OUTPUT", "-d", "localhost", "-j", "RETURN"), false, "localhost"},
+		{"ipv6 address in ipv4", rule("nat", "ISTIO_OUTPUT", "-A", "ISTIO_OUTPUT", "-d", "::1/128", "-j", "RETURN"), false, `address "::1/128" does not match family ip`},
+		{"ipv4 address in ipv6", rule("nat", "ISTIO_OUTPUT", "-A", "ISTIO_OUTPUT", "-s", "127.0.0.6", "-j", "RETURN"), true, `address "127.0.0.6" does not match family ip6`},
+		{"negated match", rule("nat", "ISTIO_OUTPUT", "-A", "ISTIO_OUTPUT", "!", "-m", "tcp", "-j", "RETURN"), false, "unexpected '!' before -m"},
+		{"unsupported match", rule("nat", "ISTIO_OUTPUT", "-A", "ISTIO_OUTPUT", "-m", "comment", "-j", "RETURN"), false, `unsupported match "comment"`},
+		{"port without protocol", rule("nat", "ISTIO_OUTPUT", "-A", "ISTIO_OUTPUT", "--dport", "80", "-j", "RETURN"), false, "--dport requires a protocol"},
+		{"invalid port", rule("nat", "ISTIO_OUTPUT", "-A", "ISTIO_OUTPUT", "-p", "tcp", "--dport", "70000", "-j", "RETURN"), false, `invalid port "70000"`},
+		{"invalid port range", rule("nat", "ISTIO_OUTPUT", "-A", "ISTIO_OUTPUT", "-p", "tcp", "--dport", "80:x", "-j", "RETURN"), false, `invalid port "x"`},
+		{
+			"ports without multiport",
+			rule("nat", "ISTIO_OUTPUT", "-A", "ISTIO_OUTPUT", "-p", "tcp", "-m", "tcp", "--dports", "80,443", "-j", "RETURN"), false,
+			"--dports requires a protocol and the multiport match",
+		},
+		{
+			"invalid port in list",
+			rule("nat", "ISTIO_OUTPUT", "-A", "ISTIO_OUTPUT", "-p", "tcp", "-m", "multiport", "--dports", "80,", "-j", "RETURN"), false,
+			`invalid port ""`,
+		},
+		{"mark without match", rule("mangle", "ISTIO_DIVERT", "-A", "ISTIO_DIVERT", "--mark", "1", "-j", "RETURN"), false, "--mark requires the mark or connmark match"},
+		{"invalid mark", rule("mangle", "ISTIO_DIVERT", "-A", "ISTIO_DIVERT", "-m", "mark", "--mark", "1/x", "-j", "RETURN"), false, `invalid mark "1/x"`},
+		{
+			"negated ctstate",
+			rule("filter", "ISTIO_INPUT", "-A", "ISTIO_INPUT", "-m", "conntrack", "!", "--ctstate", "NEW", "-j", "RETURN"), false,
+			"negated --ctstate is not supported",
+		},
+		{
+			"unsupported ctstate",
+			rule("filter", "ISTIO_INPUT", "-A", "ISTIO_INPUT", "-m", "conntrack", "--ctstate", "SNAT", "-j", "RETURN"), false,
+			`unsupported conntrack state "SNAT"`,
+		},
+		{"unsupported parameter", rule("nat", "ISTIO_OUTPUT", "-A", "ISTIO_OUTPUT", "--fragment", "x", "-j", "RETURN"), false, `unsupported parameter "--fragment"`},
+		{"no target", rule("nat", "ISTIO_OUTPUT", "-A", "ISTIO_OUTPUT", "-p", "tcp"), false, "missing target"},
+		{"empty target", rule("nat", "ISTIO_OUTPUT", "-A", "ISTIO_OUTPUT", "-j"), false, "missing target"},
+		{"unsupported target", rule("nat", "POSTROUTING", "-A", "POSTROUTING", "-j", "MASQUERADE"), false, `unsupported target "MASQUERADE"`},
+		{"invalid snat source", rule("nat", "POSTROUTING", "-A", "POSTROUTING", "-j", "SNAT", "--to-source", "1.1.1.1:80"), false, `invalid source "1.1.1.1:80"`},
+		{"ipv6 snat source in ipv4", rule("nat", "POSTROUTING", "-A", "POSTROUTING", "-j", "SNAT", "--to-source", "::1"), false, `address "::1" does not match family ip`},
+		{"socket exists without match", rule("nat", "ISTIO_POSTRT", "-A", "ISTIO_POSTRT", "--socket-exists", "-j", "RETURN"), false, "--socket-exists requires the owner match"},
+		{"set without match", rule("nat", "ISTIO_POSTRT", "-A", "ISTIO_POSTRT", "--match-set", "probes", "dst", "-j", "RETURN"), false, "--match-set requires the set match"},
+		{
+			"unsupported set flags",
+			rule("nat", "ISTIO_POSTRT", "-A", "ISTIO_POSTRT", "-m", "set", "--match-set", "probes", "dst,dst", "-j", "RETURN"), false,
+			`unsupported --match-set flags "dst,dst"`,
+		},
+		{"unexpected target option", rule("nat", "ISTIO_OUTPUT", "-A", "ISTIO_OUTPUT", "-j", "REDIRECT", "15001"), false, `unexpected target option "15001"`},
+		{"unsupported target options", rule("nat", "ISTIO_OUTPUT", "-A", "ISTIO_OUTPUT", "-j", "ACCEPT", "--foo", "--bar", "1"), false, "unsupported ACCEPT options [--bar --foo]"},
+		{"redirect without port", rule("nat", "ISTIO_OUTPUT", "-A", "ISTIO_OUTPUT", "-j", "REDIRECT"), false, `invalid port ""`},
+		{"invalid tproxy mark", rule("mangle", "ISTIO_TPROXY", "-A", "ISTIO_TPROXY", "-j", "TPROXY", "--on-port", "15006", "--tproxy-mark", "x"), false, `invalid mark "x"`},
+		{"mark without options", rule("mangle", "ISTIO_DIVERT", "-A", "ISTIO_DIVERT", "-j", "MARK"), false, "unsupported MARK options"},
+		{"save mark to packet", rule("mangle", "ISTIO_DIVERT", "-A", "ISTIO_DIVERT", "-j", "MARK", "--save-mark"), false, "unsupported MARK options"},
+		{
+			"partial mark mask",
+			rule("mangle", "ISTIO_DIVERT", "-A", "ISTIO_DIVERT", "-j", "CONNMARK", "--save-mark", "--nfmask", "0xff"), false,
+			`unsupported --nfmask "0xff"`,
+		},
+		{"invalid zone", rule("raw", "OUTPUT", "-A", "OUTPUT", "-j", "CT", "--zone", "x"), false, `invalid zone "x"`},
+		{"invalid nflog group", rule("raw", "OUTPUT", "-A", "OUTPUT", "-j", "NFLOG", "--nflog-group", "x"), false, `invalid nflog group "x"`},
+		{"invalid nflog size", rule("raw", "OUTPUT", "-A", "OUTPUT", "-j", "NFLOG", "--nflog-size", "-1"), false, `invalid nflog size "-1"`},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			family := IPv4
+			if tt.ipv6 {
+				family = IPv6
+			}
+			_, err := Translate(family, []IptablesRule{tt.rule})
+			if err == nil || !strings.Contains(err.Error(), tt.error) {
+				t.Fatalf("expected error containing %q, got %v", tt.error, err)
+			}
+		})
+	}
+}
+
+func TestTranslateRules(t *testing.T) {
+	cases := []struct {
+		name   string
+		params []string
+		want   []Expr
+	}{
+		{
+			name:   "negation applies to the next match only",
+			params: []string{"-A", "C", "!", "-o", "lo", "-p", "tcp", "-j", "RETURN"},
+			want:   []Expr{&Iface{Name: "lo", Neg: true}, &L4Proto{Proto: "tcp"}, &Verdict{Kind: Return}},
+		},
+		{
+			name:   "address is masked",
+			params: []string{"-A", "C", "-d", "10.1.2.3/8", "-j", "RETURN"},
+			want:   []Expr{&Addr{Prefix: netip.MustParsePrefix("10.0.0.0/8")}, &Verdict{Kind: Return}},
+		},
+		{
+			name:   "set mark ors the value into the mark",
+			params: []string{"-A", "C", "-j", "MARK", "--set-mark", "0x1/0x10"},
+			want:   []Expr{&SetMark{Value: 1, Mask: 0x11}},
+		},
+		{
+			name:   "connmark with full masks",
+			params: []string{"-A", "C", "-j", "CONNMARK", "--restore-mark", "--nfmask", "0xffffffff", "--ctmask", "0xffffffff"},
+			want:   []Expr{&CopyMark{ToPacket: true}},
+		},
+		{
+			name:   "tproxy without mark",
+			params: []string{"-A", "C", "-j", "TPROXY", "--on-port", "15006"},
+			want:   []Expr{&TProxy{Port: 15006}, &Verdict{Kind: Accept}},
+		},
+		{
+			name:   "quoted nflog prefix",
+			params: []string{"-A", "C", "-j", "NFLOG", "--nflog-prefix", `"istio"`, "--nflog-range", "20"},
+			want:   []Expr{&Log{Prefix: "istio", Snaplen: 20}},
+		},
+		{
+			name:   "set of destination addresses",
+			params: []string{"-A", "C", "-m", "set", "!", "--match-set", "probes", "dst", "-j", "RETURN"},
+			want:   []Expr{&AddrSet{Family: IPv4, Set: "probes", Neg: true}, &Verdict{Kind: Return}},
+		},
+		{
+			name:   "snat local sockets",
+			params: []string{"-A", "C", "-m", "owner", "--socket-exists", "-j", "SNAT", "--to-source", "169.254.7.127"},
+			want:   []Expr{&SocketExists{}, &SNAT{Addr: netip.MustParseAddr("169.254.7.127")}},
+		},
+		{
+			name:   "ctstate names are case insensitive",
+			params: []string{"-A", "C", "-m", "conntrack", "--ctstate", "related,ESTABLISHED", "-j", "ACCEPT"},
+			want:   []Expr{&CtState{States: CtStateRelated | CtStateEstablished}, &Verdict{Kind: Accept}},
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			tables, err := Translate(IPv4, []IptablesRule{rule("mangle", "C", tt.params...)})
+			assert.NoError(t, err)
+			// netip.Prefix cannot be compared field by field, so compare the rendered rules.
+			assert.Equal(t, slices.Map(tables[0].Chains[0].Rules, (*Rule).String), []string{(&Rule{Exprs: tt.want}).String()})
+		})
+	}
+}
+
+func TestTranslateOrdering(t *testing.T) {
+	ret := func(chain string) []string {
+		return []string{"-A", chain, "-j", "RETURN"}
+	}
+	tables, err := Translate(IPv4, []IptablesRule{
+		rule("nat", "ISTIO_OUTPUT", ret("ISTIO_OUTPUT")...),
+		rule("mangle", "PREROUTING", "-A", "PREROUTING", "-j", "ISTIO_INBOUND"),
+		rule("nat", "ISTIO_OUTPUT", "-I", "ISTIO_OUTPUT", "-j", "ACCEPT"),
+		rule("nat", "ISTIO_OUTPUT", "-I", "ISTIO_OUTPUT", "2", "-j", "DROP"),
+		// Positions past the end of the chain append.
+		rule("nat", "ISTIO_OUTPUT", "-I", "ISTIO_OUTPUT", "10", "-j", "ISTIO_REDIRECT"),
+		rule("nat", "OUTPUT", "--append", "OUTPUT", "-j", "ISTIO_OUTPUT"),
+	})
+	assert.NoError(t, err)
+	// Tables and chains are kept in the order they are first used.
+	assert.Equal(t, tables, []*Table{
+		{
+			Family: IPv4,
+			Name:   "istio-nat",
+			Chains: []*Chain{
+				{
+					Name: "ISTIO_OUTPUT",
+					Rules: []*Rule{
+						{Exprs: []Expr{&Verdict{Kind: Accept}}},
+						{Exprs: []Expr{&Verdict{Kind: Drop}}},
+						{Exprs: []Expr{&Verdict{Kind: Return}}},
+						{Exprs: []Expr{&Verdict{Kind: Jump, Chain: "ISTIO_REDIRECT"}}},
+					},
+				},
+				{
+					Name:  "OUTPUT",
+					Hook:  &Hook{Type: "nat", Hook: "output", Priority: -100},
+					Rules: []*Rule{{Exprs: []Expr{&Verdict{Kind: Jump, Chain: "ISTIO_OUTPUT"}}}},
+				},
+			},
+		},
+		{
+			Family: IPv4,
+			Name:   "istio-mangle",
+			Chains: []*Chain{{
+				Name:  "PREROUTING",
+				Hook:  &Hook{Type: "filter", Hook: "prerouting", Priority: -150},
+				Rules: []*Rule{{Exprs: []Expr{&Verdict{Kind: Jump, Chain: "ISTIO_INBOUND"}}}},
+			}},
+		},
+	})
+}
+
+func TestTranslateSets(t *testing.T) {
+	match := func(set string) IptablesRule {
+		return rule("nat", "ISTIO_POSTRT", "-A", "ISTIO_POSTRT", "-m", "set", "--match-set", set, "src", "-j", "RETURN")
+	}
+	tables, err := Translate(IPv6, []IptablesRule{match("b"), match("a"), match("b")})
+	assert.NoError(t, err)
+	// Each set used by the rules of a table is declared once, in the order it is first used.
+	assert.Equal(t, tables[0].Sets, []string{"b", "a"})
+	assert.Equal(t, (&Ruleset{Tables: tables}).Lines()[:4], []string{"table ip6 istio-nat {", "\tset b {", "\t\ttype ipv6_addr", "\t}"})
+}
+
+func TestTranslateEmpty(t *testing.T) {
+	tables, err := Translate(IPv6, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, len(tables), 0)
+}